@@ -4,6 +4,7 @@ if (( $# < 1 )) ; then
     cat <<EOF
 usage:
    $0 <go-program> [program-options]
+   $0 --attach host:port
 
 Runs Go SSA debugger
 EOF
@@ -21,7 +22,7 @@ if (( $? != 0 )); then
     }
 fi
 
-TEMP=$(getopt -o hi:g: --long gub:,interp:,highlight:,help -- "$@")
+TEMP=$(getopt -o hi:g: --long gub:,interp:,highlight:,listen:,attach:,interpreter:,batch,command:,annotate:,help -- "$@")
 
 if [ $? != 0 ] ; then echo "Terminating..." >&2 ; exit 1 ; fi
 
@@ -30,12 +31,23 @@ eval set -- "$TEMP"
 
 typeset gub_opt=''
 typeset highlight_opt=''
+typeset listen_opt=''
+typeset interpreter_opt=''
+typeset batch_opt=''
+typeset command_opt=''
+typeset annotate_opt=''
 interp_opt='S'
 while true ; do
 	case "$1" in
 	    --gub) gub_opt="$2" ; shift ;;
 	    --interp) interp_opt="S$2" ; shift ;;
 	    --highlight) highlight_opt="-highlight=$2" ; shift ;;
+	    --listen) listen_opt="-listen=$2" ; shift ;;
+	    --interpreter) interpreter_opt="-interpreter=$2" ; shift ;;
+	    --batch) batch_opt="-batch" ;;
+	    --annotate) annotate_opt="-annotate=$2" ; shift ;;
+	    --command) command_opt="-command=$2" ; shift ;;
+	    --attach) exec $tortoise -attach="$2" ;;
 	    --help|h) cat <<EOF
 Usage: $0 *gub-opts* [--] *go-program* [*program options]
 
@@ -46,7 +58,22 @@ opts are:
   --gub='...'                 options to gub
   --interp="options to tortoise interpeter"
   --highlight={true,false}    gub option -highlight
+  --listen=host:port          wait for a debugger to attach from
+                              another machine with --attach; more
+                              can attach later on
+  --attach=host:port          debug a program started elsewhere
+                              with --listen
+  --interpreter=mi            emit gdb/MI records for front ends
+  --batch --command=file      run the commands in file at each stop
+                              without asking for commands
+  --annotate=level            emit position annotations for Emacs
+                              realgud; 2 annotates prompts as well
   --help|-h                   this help
+
+The exit status is that of the program, or 2 if it panicked. With
+--batch it is 3 if the program's was 0 but a command failed, and a
+last line "gub: status=... program-status=... reason=... errors=..."
+sums up how the run went.
 EOF
 		exit 100 ;;
 	    --) shift;  break ;;
@@ -62,6 +89,13 @@ if [[ -n $highlight_opt ]] ; then
 	gub_opt+=",$highlight_opt"
     fi
 fi
+for opt in $listen_opt $interpreter_opt $batch_opt $command_opt $annotate_opt ; do
+    if [[ -z $gub_opt ]] ; then
+	gub_opt="$opt"
+    else
+	gub_opt+=" $opt"
+    fi
+done
 cmd="$tortoise -run -gub="$gub_opt" -interp="S$interp_opt" -- $@"
 # Not used anymore, but we may as well save it.
 export GUB_RESTART_CMD="$cmd"
@@ -13,6 +13,8 @@ import (
 	"os"
 	"runtime"
 	"runtime/pprof"
+	"strconv"
+	"strings"
 
 	"github.com/rocky/go-loader"
 	"github.com/rocky/ssa-interp"
@@ -42,6 +44,11 @@ var runFlag = flag.Bool("run", false, "Invokes the SSA interpreter on the progra
 var interpFlag = flag.String("interp", "", `Options controlling the interpreter.
 The value is a sequence of zero or more more of these letters:
 R	disable [R]ecover() from panic; show interpreter crash instead.
+D	[D]eterministic scheduling: run goroutines one at a time, in turn.
+X	run goroutines one at a time, picked at random (seed from -seed).
+C	[C]heck for data races and report them on standard error.
+O	run goroutines on [O]ne OS thread rather than in parallel.
+B	[B]reak into the debugger rather than stopping at -maxinsns or -timeout.
 T	[T]race execution of the program.  Best for single-threaded programs!
 I	trace [I]int() functions before main.main()
 S	[S]atement tracing
@@ -50,6 +57,83 @@ S	[S]atement tracing
 var gubFlag = flag.String("gub", "", `Options passed to the gub debugger.
 `)
 
+var seedFlag = flag.Int64("seed", 1, `Seed for the random goroutine scheduling of -interp=X.
+`)
+
+var selectFlag = flag.String("select", "random", `How a select statement picks among ready
+cases: random, as Go does, ordered, the first in source order, or an integer
+seed for reproducible pseudo-random choices.
+`)
+
+var randSeedFlag = flag.String("randseed", "go", `How math/rand's default source is seeded: go, as Go
+does, or an integer seed, also used for the program's calls of rand.Seed, so
+that every run gives the same numbers.
+`)
+
+var netFlag = flag.String("net", "deny", `What network the program's net.Dial and net.Listen reach:
+deny, none at all, loopback, loopback addresses only, or mock, an in-memory
+network in which the program's dials reach its own listeners.
+`)
+
+// envFlag holds the name=value settings given with -env.
+type envFlag []string
+
+func (e *envFlag) String() string { return strings.Join(*e, ",") }
+
+func (e *envFlag) Set(s string) error {
+	if strings.Index(s, "=") <= 0 {
+		return fmt.Errorf("expecting name=value; got %s", s)
+	}
+	*e = append(*e, s)
+	return nil
+}
+
+var envSettings envFlag
+
+func init() {
+	flag.Var(&envSettings, "env", `Sets a variable, given as name=value, in the program's environment;
+may be given more than once. The program has an environment of its own, so
+what it sets isn't seen by us.
+`)
+}
+
+var envFileFlag = flag.String("envfile", "", `File of name=value lines setting variables in the program's
+environment, after -clearenv and before -env.
+`)
+
+var clearEnvFlag = flag.Bool("clearenv", false, `Start the program with an empty environment rather than a copy of ours.
+`)
+
+var heapStatsFlag = flag.Bool("heapstats", false, `Count the memory the program allocates from the start, for
+runtime.ReadMemStats and gub's "info memory".
+`)
+
+var maxHeapFlag = flag.String("maxheap", "", `Most memory the program may have in use, e.g. 64M;
+allocating more panics with a runtime error. A number of bytes optionally
+followed by K, M or G.
+`)
+
+var maxInsnsFlag = flag.Uint64("maxinsns", 0, `Most instructions the program may run, over all its
+goroutines, before it is stopped; 0 for no limit.
+`)
+
+var timeoutFlag = flag.Duration("timeout", 0, `Longest the program may run, e.g. 10s, before it is
+stopped; 0 for no limit.
+`)
+
+var nativeFlag = flag.String("native", "", `Comma-separated list of packages, e.g.
+strings,strconv, whose functions run as compiled code rather than being
+interpreted, where we have it. They can't be stepped into.
+`)
+
+var attachFlag = flag.String("attach", "", `Attach to a gub debugger started with
+-gub=-listen=host:port at TCP address host:port, instead of running a program.
+`)
+
+var tokenFlag = flag.String("token", "", `Token to give the debugger -attach attaches to, as it
+showed or was given with -listen-token. It is asked for if not given.
+`)
+
 const usage = `SSA builder and interpreter.
 Usage: tortoise [<flag> ...] [<file.go> ...] [<arg> ...]
        tortoise [<flag> ...] <import/path>   [<arg> ...]
@@ -94,6 +178,10 @@ func doMain() error {
 	flag.Parse()
 	args := flag.Args()
 
+	if *attachFlag != "" {
+		return gub.Attach(*attachFlag, *tokenFlag)
+	}
+
 	conf := loader.Config{
 		Build:         &build.Default,
 		SourceImports: true,
@@ -135,6 +223,71 @@ func doMain() error {
 		}
 	}
 
+	if *nativeFlag != "" {
+		for _, path := range strings.Split(*nativeFlag, ",") {
+			if err := interp.RegisterNativePackage(path); err != nil {
+				return err
+			}
+		}
+	}
+
+	interp.InstructionLimit = *maxInsnsFlag
+	interp.TimeLimit = *timeoutFlag
+
+	if *heapStatsFlag {
+		interp.SetHeapAccounting(true)
+	}
+	if *maxHeapFlag != "" {
+		limit, err := interp.ParseSize(*maxHeapFlag)
+		if err != nil {
+			return fmt.Errorf("-maxheap: %s", err)
+		}
+		interp.SetHeapLimit(limit)
+	}
+
+	switch *selectFlag {
+	case "random":
+	case "ordered":
+		interp.SetSelectOrdered()
+	default:
+		seed, err := strconv.ParseInt(*selectFlag, 10, 64)
+		if err != nil {
+			return fmt.Errorf("-select: expecting random, ordered or an integer seed; got %s", *selectFlag)
+		}
+		interp.SetSelectSeed(seed)
+	}
+
+	if *randSeedFlag != "go" {
+		seed, err := strconv.ParseInt(*randSeedFlag, 10, 64)
+		if err != nil {
+			return fmt.Errorf("-randseed: expecting go or an integer seed; got %s", *randSeedFlag)
+		}
+		interp.SetRandSeed(seed)
+	}
+
+	if *clearEnvFlag {
+		interp.ClearProgramEnv()
+	}
+	if *envFileFlag != "" {
+		if err := interp.ReadProgramEnv(*envFileFlag); err != nil {
+			return fmt.Errorf("-envfile: %s", err)
+		}
+	}
+	for _, setting := range envSettings {
+		eq := strings.Index(setting, "=")
+		interp.SetProgramEnv(setting[:eq], setting[eq+1:])
+	}
+
+	switch *netFlag {
+	case "deny":
+	case "loopback":
+		interp.SetNetTransport(interp.NetLoopback)
+	case "mock":
+		interp.SetNetTransport(interp.NewNetMock())
+	default:
+		return fmt.Errorf("-net: expecting deny, loopback or mock; got %s", *netFlag)
+	}
+
 	var interpMode interp.Mode
 	var interpTraceMode interp.TraceMode
 	for _, c := range *interpFlag {
@@ -143,6 +296,17 @@ func doMain() error {
 			interpTraceMode |= interp.EnableInitTracing
 		case 'R':
 			interpMode |= interp.DisableRecover
+		case 'D':
+			interpMode |= interp.RoundRobinSchedule
+		case 'X':
+			interpMode |= interp.RandomSchedule
+			interp.SchedSeed = *seedFlag
+		case 'C':
+			interpMode |= interp.RaceDetect
+		case 'O':
+			interpMode |= interp.SerialExecution
+		case 'B':
+			interpMode |= interp.BreakOnLimit
 		case 'S':
 			interpTraceMode |= interp.EnableStmtTracing
 			mode |= ssa2.GlobalDebug
@@ -248,7 +412,15 @@ func doMain() error {
 				build.Default.GOARCH, runtime.GOARCH)
 		}
 
-		interp.Interpret(main, interpMode, interpTraceMode, conf.TypeChecker.Sizes, main.Object.Path(), prog_args)
+		exitCode := interp.Interpret(main, interpMode, interpTraceMode, conf.TypeChecker.Sizes, main.Object.Path(), prog_args)
+		if interpTraceMode & interp.EnableStmtTracing != 0 {
+			// gub's status also says whether the program panicked
+			// or a debugger command failed.
+			exitCode = gub.ExitStatus()
+		}
+		if exitCode != 0 {
+			os.Exit(exitCode)
+		}
 	}  else {
 		fmt.Println(`Built ok, but not running because "-run" option not given`)
 	}
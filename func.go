@@ -321,6 +321,7 @@ func (f *Function) finishBody() {
 	}
 
 	// Remove from f.Locals any Allocs that escape to the heap.
+	old := append([]*Alloc(nil), f.Locals...)
 	j := 0
 	for _, l := range f.Locals {
 		if !l.Heap {
@@ -333,6 +334,7 @@ func (f *Function) finishBody() {
 		f.Locals[i] = nil
 	}
 	f.Locals = f.Locals[:j]
+	f.renumberLocals(old)
 
 	optimizeBlocks(f)
 
@@ -413,6 +415,26 @@ func (f *Function) addNamedLocal(obj types.Object) *Alloc {
 	return l
 }
 
+// renumberLocals fixes up f.LocalsByName after some of the Allocs in
+// old, which was f.Locals, have been removed from f.Locals. Names of
+// removed locals are dropped.
+func (f *Function) renumberLocals(old []*Alloc) {
+	index := make(map[*Alloc]uint, len(f.Locals))
+	for i, l := range f.Locals {
+		index[l] = uint(i + 1)
+	}
+	for nameScope, i := range f.LocalsByName {
+		if i == 0 || int(i) > len(old) {
+			continue
+		}
+		if j, ok := index[old[i-1]]; ok {
+			f.LocalsByName[nameScope] = j
+		} else {
+			delete(f.LocalsByName, nameScope)
+		}
+	}
+}
+
 func (f *Function) addLocalForIdent(id *ast.Ident) *Alloc {
 	return f.addNamedLocal(f.Pkg.info.Defs[id])
 }
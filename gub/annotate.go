@@ -0,0 +1,62 @@
+// Copyright 2015 Rocky Bernstein.
+// Annotations for Emacs and other front ends that track the source
+
+package gub
+
+import (
+	"fmt"
+	"go/token"
+	"io"
+	"path/filepath"
+)
+
+// annotateMarker starts each annotation, as in gdb.
+const annotateMarker = "\032\032"
+
+// Annotate returns the annotation level given with -annotate. At
+// level 1 or more, each time we stop or a frame is selected we emit
+// a line
+//
+//    \032\032/full/path/file.go:line:column:beg:0
+//
+// like gdb does, so that realgud and Emacs's gud can show the source
+// in a buffer. At level 2 or more, prompts and where the program
+// stops and starts are annotated too: "pre-prompt", "prompt",
+// "post-prompt", "stopped" and "starting".
+func Annotate() int { return *annotate }
+
+// annotateWrite writes annotation name on a line by itself.
+func annotateWrite(name string) {
+	io.WriteString(output, "\n" + annotateMarker + name + "\n")
+}
+
+// annotateSource emits the source annotation for position pos.
+func annotateSource(pos token.Position) {
+	if Annotate() < 1 || !pos.IsValid() {
+		return
+	}
+	filename := pos.Filename
+	if abs, err := filepath.Abs(filename); err == nil {
+		filename = abs
+	}
+	io.WriteString(output, fmt.Sprintf("%s%s:%d:%d:beg:0\n", annotateMarker,
+		filename, pos.Line, pos.Column))
+}
+
+// annotatePrompt returns prompt with the prompt annotations around it
+// at level 2 and above.
+func annotatePrompt(prompt string) string {
+	if Annotate() < 2 {
+		return prompt
+	}
+	return "\n" + annotateMarker + "pre-prompt\n" + prompt +
+		"\n" + annotateMarker + "prompt\n"
+}
+
+// annotateEvent emits annotation name, like "stopped", at level 2
+// and above.
+func annotateEvent(name string) {
+	if Annotate() >= 2 {
+		annotateWrite(name)
+	}
+}
@@ -0,0 +1,225 @@
+// Copyright 2015 Rocky Bernstein.
+// Assigning to variables of the debugged program
+
+package gub
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+
+	"github.com/rocky/go-types"
+	"github.com/rocky/ssa-interp"
+	"github.com/rocky/ssa-interp/interp"
+)
+
+// A storeFunc stores a value into the variable, field, element or map
+// entry an lvalue refers to.
+type storeFunc func(v interp.Value) error
+
+// addrStore returns a storeFunc for the variable at addr.
+func addrStore(addr *interp.Value) storeFunc {
+	return func(v interp.Value) error {
+		*addr = v
+		return nil
+	}
+}
+
+// SplitAssign splits s, which looks like lhs = rhs, at its "=". An
+// "=" inside of a string or character literal, or one that is part of
+// an operator like "==", doesn't count.
+func SplitAssign(s string) (lhs, rhs string, ok bool) {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote != '`' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'' || c == '`':
+			quote = c
+		case c == '=':
+			if i+1 < len(s) && s[i+1] == '=' {
+				i++
+				continue
+			}
+			if i > 0 {
+				switch s[i-1] {
+				case '!', '<', '>', ':':
+					continue
+				}
+			}
+			return s[:i], s[i+1:], true
+		}
+	}
+	return "", "", false
+}
+
+// evalLvalue evaluates node, which must be something that can be
+// assigned to, in frame fr. It returns how to store into it and its
+// type.
+func evalLvalue(fr *interp.Frame, scope *ssa2.Scope, node ast.Expr) (storeFunc, types.Type, error) {
+	switch e := node.(type) {
+	case *ast.ParenExpr:
+		return evalLvalue(fr, scope, e.X)
+	case *ast.Ident:
+		nameVal, interpVal, err := EnvLookupOK(fr, e.Name, scope)
+		if err != nil {
+			return globalLvalue(fr, fr.Fn().Pkg, e.Name)
+		}
+		switch nameVal.(type) {
+		case *ssa2.Alloc, *ssa2.FreeVar:
+			// These hold the address of the variable.
+			addr, _ := interpVal.(*interp.Value)
+			if addr == nil {
+				return nil, nil, fmt.Errorf("%s doesn't have a value yet", e.Name)
+			}
+			return addrStore(addr), deref(nameVal.Type()), nil
+		}
+		env := fr.Env()
+		return func(v interp.Value) error {
+			env[nameVal] = v
+			return nil
+		}, nameVal.Type(), nil
+	case *ast.SelectorExpr:
+		if id, ok := e.X.(*ast.Ident); ok {
+			if pkg := PkgLookup(id.Name); pkg != nil {
+				if _, _, err := EnvLookupOK(fr, id.Name, scope); err != nil {
+					return globalLvalue(fr, pkg, e.Sel.Name)
+				}
+			}
+		}
+		x, err := evalNode(fr, scope, e.X)
+		if err != nil {
+			return nil, nil, err
+		}
+		if p, ok := x.t.Underlying().(*types.Pointer); ok {
+			addr, _ := x.v.(*interp.Value)
+			if addr == nil {
+				return nil, nil, fmt.Errorf("nil pointer dereference")
+			}
+			x = exprVal{*addr, p.Elem()}
+		}
+		st, ok := x.t.Underlying().(*types.Struct)
+		if !ok {
+			return nil, nil, fmt.Errorf("%s is not a struct", x.t)
+		}
+		for i := 0; i < st.NumFields(); i++ {
+			if st.Field(i).Name() == e.Sel.Name {
+				addr, err := interp.FieldAddr(x.v, i)
+				if err != nil {
+					return nil, nil, err
+				}
+				return addrStore(addr), st.Field(i).Type(), nil
+			}
+		}
+		return nil, nil, fmt.Errorf("%s has no field %s", x.t, e.Sel.Name)
+	case *ast.IndexExpr:
+		x, err := evalNode(fr, scope, e.X)
+		if err != nil {
+			return nil, nil, err
+		}
+		idx, err := evalNode(fr, scope, e.Index)
+		if err != nil {
+			return nil, nil, err
+		}
+		if p, ok := x.t.Underlying().(*types.Pointer); ok {
+			// A pointer to an array.
+			addr, _ := x.v.(*interp.Value)
+			if addr == nil {
+				return nil, nil, fmt.Errorf("nil pointer dereference")
+			}
+			x = exprVal{*addr, p.Elem()}
+		}
+		var elemType types.Type
+		switch t := x.t.Underlying().(type) {
+		case *types.Map:
+			key := convertArg(idx, t.Key())
+			return func(v interp.Value) error {
+				return interp.MapUpdate(x.v, key.v, v)
+			}, t.Elem(), nil
+		case *types.Slice:
+			elemType = t.Elem()
+		case *types.Array:
+			elemType = t.Elem()
+		default:
+			return nil, nil, fmt.Errorf("can't assign to an element of %s", x.t)
+		}
+		i, ok := asInt(convertUntyped(idx, types.Typ[types.Int]).v)
+		if !ok {
+			return nil, nil, fmt.Errorf("index must be an integer")
+		}
+		addr, err := interp.ElemAddr(x.v, i)
+		if err != nil {
+			return nil, nil, err
+		}
+		return addrStore(addr), elemType, nil
+	case *ast.StarExpr:
+		x, err := evalNode(fr, scope, e.X)
+		if err != nil {
+			return nil, nil, err
+		}
+		ptr, ok := x.t.Underlying().(*types.Pointer)
+		if !ok {
+			return nil, nil, fmt.Errorf("can't dereference non-pointer type %s", x.t)
+		}
+		addr, _ := x.v.(*interp.Value)
+		if addr == nil {
+			return nil, nil, fmt.Errorf("nil pointer dereference")
+		}
+		return addrStore(addr), ptr.Elem(), nil
+	}
+	return nil, nil, fmt.Errorf("can't assign to %s", types.ExprString(node))
+}
+
+// globalLvalue returns how to store into package variable name of
+// pkg.
+func globalLvalue(fr *interp.Frame, pkg *ssa2.Package, name string) (storeFunc, types.Type, error) {
+	if g := pkg.Var(name); g != nil {
+		if addr, ok := fr.I().Global(name, pkg); ok && addr != nil {
+			return addrStore(addr), deref(g.Type()), nil
+		}
+	}
+	if pkg.Const(name) != nil {
+		return nil, nil, fmt.Errorf("can't assign to constant %s", name)
+	}
+	return nil, nil, fmt.Errorf("can't find %s", name)
+}
+
+// AssignExpr evaluates rhs in frame fr and stores it into lhs, a
+// variable, parameter, struct field, array or slice element, map entry
+// or pointer indirection. The value is converted to the type of lhs
+// the way an assignment in Go does. It returns the new value of lhs
+// and its type.
+func AssignExpr(fr *interp.Frame, scope *ssa2.Scope, lhs, rhs string) (v interp.Value, t types.Type, err error) {
+	lnode, err := parser.ParseExpr(dollarExpr(lhs))
+	if err != nil {
+		return nil, nil, err
+	}
+	x, err := evalString(fr, scope, rhs)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Evaluating can panic; see EvalExpr.
+	defer func() {
+		if x := recover(); x != nil {
+			err = fmt.Errorf("assigning to %s: %v", lhs, x)
+		}
+	}()
+	store, t, err := evalLvalue(fr, scope, lnode)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !assignable(x, t) {
+		return nil, nil, fmt.Errorf("can't assign %s to %s of type %s", x.t, lhs, t)
+	}
+	x = convertArg(x, t)
+	v = interp.CopyVal(x.v)
+	if err := store(v); err != nil {
+		return nil, nil, err
+	}
+	return v, t, nil
+}
@@ -0,0 +1,58 @@
+// Copyright 2015 Rocky Bernstein.
+// Running a script of debugger commands at each stop, unattended
+
+package gub
+
+import (
+	"bufio"
+	"os"
+
+	"github.com/rocky/ssa-interp"
+)
+
+// batchFile is the file of commands given with -command, and
+// batchLines its commands.
+var batchFile string
+var batchLines []string
+
+// BatchMode is true when we were started with -batch. The commands
+// of the -command file are then run each time the program stops and,
+// unless one of them resumes the program, it is continued. When the
+// program finishes we show the summary line and exit with the status
+// given by ExitStatus. Nothing is read from the terminal, and
+// questions get their default answer.
+func BatchMode() bool { return *batch }
+
+// batchSetup reads the -command file for -batch.
+func batchSetup(filename string) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		batchLines = append(batchLines, scanner.Text())
+	}
+	batchFile = filename
+	return scanner.Err()
+}
+
+// runBatch runs the -command file for a stop on event and then, if
+// the program wasn't resumed, continues it. When the program has
+// finished, we exit.
+func runBatch(event ssa2.TraceEvent) {
+	// Commands after one that resumed the program were meant for
+	// the last stop, not this one.
+	sources = sources[:0]
+	lines := make([]string, len(batchLines))
+	copy(lines, batchLines)
+	sources = append(sources, &cmdSource{batchFile, lines, false, true})
+	RunSourceLines()
+	if event == ssa2.PROGRAM_TERMINATION {
+		os.Exit(ExitStatus())
+	}
+	if InCmdLoop {
+		RunLine("continue")
+	}
+}
@@ -2,22 +2,44 @@ package gub
 
 import (
 	"go/token"
+	"strconv"
+	"strings"
 	"github.com/rocky/ssa-interp"
+	"github.com/rocky/ssa-interp/interp"
 	"fmt"
 )
 
 type Breakpoint struct {
 	// condition
-	Hits    int       // How many times hit (with a true condition)
+	Hits    int       // How many times hit (with a true condition),
+	                  // whether or not HitCond let us stop
 	Id      int      // Id of breakpoint. Is position inside of Breakpoints
 	Deleted bool      // Set when breakpoint is deleted
 	Temp    bool      // Set when one-time breakpoint
+	Once    bool      // Set when breakpoint is disabled after its next stop
 	Enabled bool      // Set when breakpoint is enabled
 	Pos     token.Pos // Position of breakpoint
 	EndP    token.Pos // End Position of breakpoint
 	Ignore  int       // Number of times to ignore before triggering
 	Kind    string    // 'Function' if function breakpoint. 'Stmt'
-	                  // if at a statement boundary
+	                  // if at a statement boundary. 'Watchpoint' if
+	                  // a data watchpoint. 'Catchpoint' if a catchpoint
+	Expr    string           // Variable name text of a watchpoint, or
+	                         // what a catchpoint catches, e.g. "panic"
+	Addr    *interp.Value    // Address watched by a watchpoint
+	Entry   *interp.MapEntry // Map element watched by a watchpoint
+	Watch   interp.WatchType // Accesses that trigger a watchpoint
+	Chan    chan interp.Value // Channel caught by a channel catchpoint
+	GoOnly  bool      // Set when breakpoint only fires in goroutine GoNum
+	GoNum   int       // Goroutine number when GoOnly is set
+	HitCond *HitCond  // If not nil, stop only when the hit count satisfies it
+	Condition string  // If not empty, expression that must be true to stop
+	Label   string    // If not empty, group name for bulk operations
+	Format  string    // If not empty, a dprintf breakpoint's format;
+	                  // we print rather than stop
+	FmtArgs []string  // Expressions whose values are printed by Format
+	Trace   bool      // Set when a tracepoint; we record hits rather than stop
+	Collect []string  // Expressions whose values a tracepoint records
 }
 
 var Breakpoints []*Breakpoint
@@ -38,6 +60,7 @@ var BrkptsDeleted = 0
 func BreakpointAdd(bp *Breakpoint) int {
 	Breakpoints = append(Breakpoints, bp)
 	BrkptLocs = append(BrkptLocs, toknum{pos: bp.Pos, bpnum: bp.Id})
+	miBreakpointCreated(bp)
 	return len(Breakpoints)-1
 }
 
@@ -50,7 +73,30 @@ func BreakpointExists(bpnum int) bool {
 
 func BreakpointDelete(bpnum int) bool {
 	if BreakpointExists(bpnum) {
-		Breakpoints[bpnum].Deleted = true
+		bp := Breakpoints[bpnum]
+		bp.Deleted = true
+		miBreakpointDeleted(bpnum)
+		if bp.Entry != nil {
+			if len(BreakpointFindByEntry(bp.Entry)) == 0 {
+				interp.UnwatchMapEntry(bp.Entry)
+			}
+		} else if bp.Kind == "Watchpoint" {
+			// Only stop watching those kinds of accesses that no
+			// other watchpoint on this address needs.
+			var still interp.WatchType
+			for _, other := range BreakpointFindByAddr(bp.Addr) {
+				still |= Breakpoints[other].Watch
+			}
+			interp.ClearWatch(bp.Addr, bp.Watch &^ still)
+		} else if bp.Chan != nil && len(CatchpointFindByChan(bp.Chan)) == 0 {
+			interp.UncatchChan(bp.Chan)
+		} else if bp.Kind == "Catchpoint" && bp.Expr == "defer" &&
+			len(CatchpointFind("defer")) == 0 {
+			interp.ClearTraceEvent(ssa2.DEFER_ENTER)
+		} else if bp.Kind == "Catchpoint" && strings.HasPrefix(bp.Expr, "call ") &&
+			len(CatchpointFind(bp.Expr)) == 0 {
+			interp.ClearExternalBreakpoint(strings.TrimPrefix(bp.Expr, "call "))
+		}
 		return true
 	}
 	return false
@@ -72,6 +118,22 @@ func BreakpointEnable(bpnum int) bool {
 	return false
 }
 
+// BreakpointStopped is called after we have stopped for breakpoint
+// bpnum. A breakpoint enabled with "enable once" is disabled; a
+// temporary breakpoint is deleted.
+func BreakpointStopped(bpnum int) {
+	if !BreakpointExists(bpnum) {
+		return
+	}
+	bp := Breakpoints[bpnum]
+	if bp.Temp {
+		BreakpointDelete(bpnum)
+	} else if bp.Once {
+		bp.Enabled = false
+		bp.Once = false
+	}
+}
+
 func BreakpointFindByPos(pos token.Pos) []int {
 	results := make([]int, 0)
 	for _, v := range BrkptLocs {
@@ -89,6 +151,18 @@ func BreakpointFindById(bpNum int) *Breakpoint {
 	return nil
 }
 
+// BreakpointFindByLabel returns the numbers of the undeleted
+// breakpoints labeled label.
+func BreakpointFindByLabel(label string) []int {
+	results := make([]int, 0)
+	for _, bp := range Breakpoints {
+		if bp.Label == label && !bp.Deleted {
+			results = append(results, bp.Id)
+		}
+	}
+	return results
+}
+
 func BreakpointIsEnabled(bpnum int) bool {
 	if BreakpointExists(bpnum) {
 		return Breakpoints[bpnum].Enabled
@@ -110,14 +184,45 @@ func Bpprint(bp Breakpoint) {
 	disp := "keep "
 	if bp.Temp {
 		disp  = "del  "
+	} else if bp.Once {
+		disp  = "dis  "
 	}
 	enabled := "n "
 	if bp.Enabled { enabled = "y " }
 
-	loc  := ssa2.FmtRange(curFrame.Fn(), bp.Pos, bp.EndP)
-    mess := fmt.Sprintf("%3d breakpoint    %s  %sat %s",
-		bp.Id, disp, enabled, loc)
-	Msg(mess)
+	switch bp.Kind {
+	case "Catchpoint":
+		Msg("%3d catchpoint    %s  %s%s", bp.Id, disp, enabled, bp.Expr)
+	case "Watchpoint":
+		loc  := ssa2.FmtRange(curFrame.Fn(), bp.Pos, bp.EndP)
+		kind := "watchpoint"
+		if bp.Watch == interp.WATCH_READ {
+			kind = "rwatchpoint"
+		}
+		Msg("%3d %-13s %s  %s%s at %s", bp.Id, kind, disp, enabled,
+			bp.Expr, loc)
+	default:
+		loc  := ssa2.FmtRange(curFrame.Fn(), bp.Pos, bp.EndP)
+		kind := "breakpoint"
+		if bp.Format != "" {
+			kind = "dprintf"
+		} else if bp.Trace {
+			kind = "tracepoint"
+		}
+		mess := fmt.Sprintf("%3d %-13s %s  %sat %s",
+			bp.Id, kind, disp, enabled, loc)
+		Msg(mess)
+		if bp.Format != "" {
+			args := strconv.Quote(bp.Format)
+			if len(bp.FmtArgs) > 0 {
+				args += ", " + strings.Join(bp.FmtArgs, ", ")
+			}
+			Msg("\tprintf %s", args)
+		}
+		if len(bp.Collect) > 0 {
+			Msg("\tcollect %s", strings.Join(bp.Collect, ", "))
+		}
+	}
 
     // line_loc = '%s:%d' %
     //   [iseq.source_container.join(' '),
@@ -136,6 +241,18 @@ func Bpprint(bp Breakpoint) {
     //   Msg("\tstop %s %s" %
     //       [bp.negate ? "unless" : "only if", bp.condition])
     // end
+	if bp.Label != "" {
+		Msg("\tlabel %s", bp.Label)
+	}
+	if bp.GoOnly {
+		Msg("\tonly in goroutine %d", bp.GoNum)
+	}
+	if bp.Condition != "" {
+		Msg("\tstop only if %s", bp.Condition)
+	}
+	if bp.HitCond != nil {
+		Msg("\tstop only if %s", bp.HitCond)
+	}
     if bp.Ignore > 0 {
 		Msg("\tignore next %d hits", bp.Ignore)
 	}
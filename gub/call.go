@@ -0,0 +1,233 @@
+// Copyright 2015 Rocky Bernstein.
+// Calling functions in the debugged program from the debugger
+
+package gub
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+
+	"github.com/rocky/go-types"
+	"github.com/rocky/ssa-interp"
+	"github.com/rocky/ssa-interp/interp"
+)
+
+// callee finds the function that fun, the function part of a call
+// expression, refers to. For a method the receiver is returned as
+// well.
+func callee(fr *interp.Frame, scope *ssa2.Scope, fun ast.Expr) (fn interp.Value,
+	sig *types.Signature, recv *exprVal, err error) {
+	switch e := fun.(type) {
+	case *ast.ParenExpr:
+		return callee(fr, scope, e.X)
+	case *ast.Ident:
+		if _, _, err := EnvLookupOK(fr, e.Name, scope); err != nil {
+			if f := fr.Fn().Pkg.Func(e.Name); f != nil {
+				return f, f.Signature, nil, nil
+			}
+		}
+	case *ast.SelectorExpr:
+		if id, ok := e.X.(*ast.Ident); ok {
+			if pkg := PkgLookup(id.Name); pkg != nil {
+				if _, _, err := EnvLookupOK(fr, id.Name, scope); err != nil {
+					if f := pkg.Func(e.Sel.Name); f != nil {
+						return f, f.Signature, nil, nil
+					}
+					return nil, nil, nil,
+						fmt.Errorf("%s is not a function in %s", e.Sel.Name, id.Name)
+				}
+			}
+		}
+		x, err := evalNode(fr, scope, e.X)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if f, x, ok := method(fr, x, e.Sel.Name); ok {
+			return f, f.Signature, &x, nil
+		}
+		if _, err := evalField(x, e.Sel.Name); err != nil {
+			return nil, nil, nil,
+				fmt.Errorf("%s has no field or method %s", x.t, e.Sel.Name)
+		}
+	}
+	// Something that evaluates to a function value, like a
+	// variable holding a closure.
+	f, err := evalNode(fr, scope, fun)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	sig, ok := f.t.Underlying().(*types.Signature)
+	if !ok {
+		return nil, nil, nil, fmt.Errorf("can't call non-function of type %s", f.t)
+	}
+	if f.v == nil {
+		return nil, nil, nil, fmt.Errorf("call of nil function")
+	}
+	return f.v, sig, nil, nil
+}
+
+// method looks up method name of x. It returns the method's function
+// and the receiver to pass to it.
+func method(fr *interp.Frame, x exprVal, name string) (*ssa2.Function, exprVal, bool) {
+	if dt, dv, ok := interp.IfaceValue(x.v); ok {
+		if _, isIface := x.t.Underlying().(*types.Interface); isIface {
+			// Dispatch on the dynamic type.
+			x = exprVal{dv, dt}
+		}
+	}
+	prog := fr.Fn().Prog
+	var pkg *types.Package
+	if fr.Fn().Pkg != nil {
+		pkg = fr.Fn().Pkg.Object
+	}
+	if sel := prog.MethodSets.MethodSet(x.t).Lookup(pkg, name); sel != nil {
+		if f := prog.Method(sel); f != nil {
+			return f, x, true
+		}
+	}
+	if _, ok := x.t.Underlying().(*types.Pointer); !ok {
+		// A method with a pointer receiver. We don't have the
+		// variable's address, so the method gets a pointer to a
+		// copy of it.
+		ptr := types.NewPointer(x.t)
+		if sel := prog.MethodSets.MethodSet(ptr).Lookup(pkg, name); sel != nil {
+			if f := prog.Method(sel); f != nil {
+				v := x.v
+				return f, exprVal{&v, ptr}, true
+			}
+		}
+	}
+	return nil, x, false
+}
+
+// CallExpr evaluates expr, a call of a function or method in the
+// debugged program, in frame fr and runs the call. It returns the
+// results along with their types.
+func CallExpr(fr *interp.Frame, scope *ssa2.Scope, expr string) ([]interp.Value, *types.Tuple, error) {
+	node, err := parser.ParseExpr(expr)
+	if err != nil {
+		return nil, nil, err
+	}
+	call, ok := node.(*ast.CallExpr)
+	if !ok {
+		return nil, nil, fmt.Errorf("%s is not a function call", expr)
+	}
+	if call.Ellipsis.IsValid() {
+		return nil, nil, fmt.Errorf("can't handle ... in calls yet")
+	}
+	var args []interp.Value
+	fn, sig, err := func() (fn interp.Value, sig *types.Signature, err error) {
+		// Evaluating operands can panic; see EvalExpr.
+		defer func() {
+			if x := recover(); x != nil {
+				err = fmt.Errorf("evaluating %s: %v", expr, x)
+			}
+		}()
+		var recv *exprVal
+		fn, sig, recv, err = callee(fr, scope, call.Fun)
+		if err != nil {
+			return
+		}
+		if recv != nil {
+			args = append(args, recv.v)
+		}
+		params := sig.Params()
+		n := len(call.Args)
+		if sig.Variadic() {
+			if n < params.Len()-1 {
+				err = fmt.Errorf("not enough arguments in call to %s", call.Fun)
+				return
+			}
+		} else if n != params.Len() {
+			err = fmt.Errorf("%s takes %d arguments, got %d", call.Fun, params.Len(), n)
+			return
+		}
+		var variadic []interp.Value
+		for i, arg := range call.Args {
+			var x exprVal
+			x, err = evalNode(fr, scope, arg)
+			if err != nil {
+				return
+			}
+			var t types.Type
+			if sig.Variadic() && i >= params.Len()-1 {
+				t = params.At(params.Len()-1).Type().(*types.Slice).Elem()
+			} else {
+				t = params.At(i).Type()
+			}
+			if !assignable(x, t) {
+				err = fmt.Errorf("can't pass %s as %s in call to %s", x.t, t, call.Fun)
+				return
+			}
+			if sig.Variadic() && i >= params.Len()-1 {
+				variadic = append(variadic, convertArg(x, t).v)
+				continue
+			}
+			args = append(args, convertArg(x, t).v)
+		}
+		if sig.Variadic() {
+			args = append(args, variadic)
+		}
+		return
+	}()
+	if err != nil {
+		return nil, nil, err
+	}
+	results, err := interp.CallFunction(fr, fn, args)
+	if err != nil {
+		return nil, nil, err
+	}
+	if sig.Results().Len() == 0 {
+		results = nil
+	}
+	return results, sig.Results(), nil
+}
+
+// assignable returns true if x can be passed as or assigned to a value
+// of type t. An untyped constant only has to be of the right kind.
+func assignable(x exprVal, t types.Type) bool {
+	if !isUntyped(x.t) {
+		return types.AssignableTo(x.t, t)
+	}
+	if _, ok := t.Underlying().(*types.Interface); ok {
+		return true
+	}
+	kind := x.t.(*types.Basic).Kind()
+	if kind == types.UntypedNil {
+		switch t.Underlying().(type) {
+		case *types.Pointer, *types.Slice, *types.Map, *types.Chan, *types.Signature:
+			return true
+		}
+		return false
+	}
+	b, ok := t.Underlying().(*types.Basic)
+	if !ok {
+		return false
+	}
+	switch kind {
+	case types.UntypedBool:
+		return b.Info()&types.IsBoolean != 0
+	case types.UntypedString:
+		return b.Info()&types.IsString != 0
+	}
+	return b.Info()&types.IsNumeric != 0
+}
+
+// convertArg converts x to the type t of the parameter it is passed
+// as.
+func convertArg(x exprVal, t types.Type) exprVal {
+	if _, ok := t.Underlying().(*types.Interface); !ok {
+		return convertUntyped(x, t)
+	}
+	if isUntyped(x.t) {
+		if x.t.(*types.Basic).Kind() == types.UntypedNil {
+			return convertUntyped(x, t)
+		}
+		x = convertUntyped(x, defaultType(x.t))
+	}
+	if _, ok := x.t.Underlying().(*types.Interface); !ok {
+		return exprVal{interp.MakeInterface(x.t, x.v), t}
+	}
+	return x
+}
@@ -0,0 +1,93 @@
+// Copyright 2015 Rocky Bernstein.
+// Things dealing with catchpoints
+
+package gub
+
+import (
+	"fmt"
+
+	"github.com/rocky/ssa-interp"
+	"github.com/rocky/ssa-interp/interp"
+)
+
+// CatchpointAdd creates a catchpoint for events of kind what, e.g.
+// "panic", and returns its breakpoint number. Like watchpoints,
+// catchpoints aren't tied to a stopping location so they aren't
+// added to BrkptLocs.
+func CatchpointAdd(what string) int {
+	bp := &Breakpoint {
+		Hits: 0,
+		Id: BreakpointNext(),
+		Ignore: 0,
+		Kind: "Catchpoint",
+		Temp: false,
+		Enabled: true,
+		Expr: what,
+	}
+	Breakpoints = append(Breakpoints, bp)
+	miBreakpointCreated(bp)
+	return bp.Id
+}
+
+// CatchpointFind returns the numbers of the undeleted catchpoints
+// for events of kind what.
+func CatchpointFind(what string) []int {
+	results := make([]int, 0)
+	for _, bp := range Breakpoints {
+		if bp.Kind == "Catchpoint" && bp.Expr == what && !bp.Deleted {
+			results = append(results, bp.Id)
+		}
+	}
+	return results
+}
+
+// ChanCatchpointAdd creates a catchpoint for operations on channel
+// ch, which was given by expression expr, and returns its breakpoint
+// number.
+func ChanCatchpointAdd(expr string, ch chan interp.Value) int {
+	bpnum := CatchpointAdd("chan " + expr)
+	Breakpoints[bpnum].Chan = ch
+	interp.CatchChan(ch)
+	return bpnum
+}
+
+// CallCatchpointAdd creates a catchpoint for calls to the external
+// function name and returns its breakpoint number, or -1 if name
+// isn't an external function.
+func CallCatchpointAdd(name string) int {
+	if !interp.SetExternalBreakpoint(name) {
+		return -1
+	}
+	return CatchpointAdd("call " + name)
+}
+
+// CatchpointFindByChan returns the numbers of the undeleted
+// catchpoints on channel ch.
+func CatchpointFindByChan(ch chan interp.Value) []int {
+	results := make([]int, 0)
+	for _, bp := range Breakpoints {
+		if ch != nil && bp.Chan == ch && !bp.Deleted {
+			results = append(results, bp.Id)
+		}
+	}
+	return results
+}
+
+// ChanLookup returns the channel value of the variable name as seen
+// from frame fr in scope.
+func ChanLookup(fr *interp.Frame, name string,
+	scope *ssa2.Scope) (chan interp.Value, error) {
+	var val interp.Value
+	if addr, _, err := VarAddrLookup(fr, name, scope); err == nil {
+		val = *addr
+	} else if nameVal, interpVal, _ := EnvLookup(fr, name, scope); nameVal != nil {
+		val = DerefValue(interpVal)
+	} else {
+		return nil, err
+	}
+	ch, ok := val.(chan interp.Value)
+	if !ok || ch == nil {
+		return nil, fmt.Errorf("%s is not a non-nil channel", name)
+	}
+	return ch, nil
+}
@@ -0,0 +1,61 @@
+// Copyright 2015 Rocky Bernstein.
+
+// alias command
+
+package gubcmd
+
+import (
+	"strings"
+
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	name := "alias"
+	gub.Cmds[name] = &gub.CmdInfo{
+		Fn: AliasAddCommand,
+		Help: `alias *new* *existing* [*args*...]
+
+Add *new* as another name for command *existing*, which can itself be
+an alias. If *args* are given, they are put in front of any arguments
+given when *new* is used. Aliases that come with the debugger can't be
+redefined, but your own can.
+
+Without arguments, or with just one, this is the same as "aliases".
+
+To have aliases in every session, put alias commands in ~/.gubrc.
+
+Examples:
+
+   alias bt backtrace
+   alias bm breakpoint main.main
+   alias ev eval
+
+See also "aliases" and "unalias".
+`,
+		Min_args: 0,
+		Max_args: -1,
+	}
+	gub.AddToCategory("support", name)
+}
+
+// AliasAddCommand implements the debugger command:
+//    alias *new* *existing* [*args*...]
+// which adds a user-defined alias.
+func AliasAddCommand(args []string) {
+	if len(args) <= 2 {
+		AliasCommand(args)
+		return
+	}
+	alias, existing := args[1], args[2]
+	if i := strings.Index(existing, "/"); i > 0 {
+		gub.Errmsg("Can't give a format in an alias; use %s", existing[:i])
+		return
+	}
+	// Don't use args, but gub.CmdArgstr which preserves blanks inside quotes
+	aliasArgs := strings.TrimLeft(gub.CmdArgstr[len(alias):], " ")
+	aliasArgs = strings.TrimLeft(aliasArgs[len(existing):], " ")
+	if err := gub.AddUserAlias(alias, existing, aliasArgs); err != nil {
+		gub.Errmsg(err.Error())
+	}
+}
@@ -13,20 +13,21 @@ func init() {
 	name := "aliases"
 	gub.Cmds[name] = &gub.CmdInfo{
 		Fn: AliasCommand,
-		Help: `aliases
+		Help: `aliases [*name*]
 
 Without argument, print the list of debugger command aliases.
 
 When an argument is given, if it is command name, the aliases for
 that command are shown. if the argument is an alias name, we'll
 show the command that this is an alias for.
+
+See also "alias" and "unalias".
 `,
 
 		Min_args: 0,
 		Max_args: 1,
 	}
 	gub.AddToCategory("support", name)
-	gub.AddAlias("alias", name)
 }
 
 func AliasCommand(args []string) {
@@ -53,6 +54,9 @@ func AliasCommand(args []string) {
 				gub.Msg("No aliases for %s", cmd)
 			}
 		} else if realCmd := gub.Aliases[cmd]; realCmd != "" {
+			if aliasArgs := gub.AliasArgs[cmd]; aliasArgs != "" {
+				realCmd += " " + aliasArgs
+			}
 			gub.Msg("Alias %s is an alias for command %s", cmd, realCmd)
 
 		} else {
@@ -8,14 +8,17 @@ func init() {
 	name := "backtrace"
 	gub.Cmds[name] = &gub.CmdInfo{
 		Fn: BacktraceCommand,
-		Help: `backtrace [*count*]
+		Help: `backtrace [full] [*count*]
 
 Print a stack trace, with the most recent frame at the top.
 
-With a positive number, print at most many entries.`,
+With a positive number, print at most many entries.
+
+With "full", the parameters and local variables of each frame are
+shown as well.`,
 
 		Min_args: 0,
-		Max_args: 1,
+		Max_args: 2,
 	}
 	gub.AddToCategory("stack", name)
 	gub.AddAlias("where", name)
@@ -24,13 +27,24 @@ With a positive number, print at most many entries.`,
 	gub.AddAlias("bt", name)
 }
 
+// BacktraceCommand implements the debugger command:
+//    backtrace [full] [*count*]
+// which shows the call stack.
 func BacktraceCommand(args []string) {
 	count := gub.MAXSTACKSHOW
+	full := len(args) > 1 && args[1] == "full"
+	if full {
+		args = args[1:]
+	}
 	var err error
 	if len(args) > 1 {
 		count, err = gub.GetInt(args[1], "maximum count",
 			0, gub.MAXSTACKSHOW)
 		if err != nil { return }
 	}
-	gub.PrintStack(gub.TopFrame(), count)
+	if full {
+		gub.PrintStackFull(gub.TopFrame(), count)
+	} else {
+		gub.PrintStack(gub.TopFrame(), count)
+	}
 }
@@ -4,7 +4,9 @@
 package gubcmd
 
 import (
+	"fmt"
 	"strconv"
+	"strings"
 	"github.com/rocky/ssa-interp"
 	"github.com/rocky/ssa-interp/interp"
 	"github.com/rocky/ssa-interp/gub"
@@ -14,18 +16,41 @@ func init() {
 	name := "breakpoint"
 	gub.Cmds[name] = &gub.CmdInfo{
 		Fn: BreakpointCommand,
-		Help: `breakpoint [*fn* | line [column]]
+		Help: `breakpoint [*fn* | *iface*.*method* | init *pkg* | [*file*:]*line* [*column*]] [hitcount *cond*] [goroutine *n*] [label *name*]
 
 Set a breakpoint. The target can either be a function name as fn pkg.fn
 or a line and and optional column number. Specifying a column number
 may be useful if there is more than one statement on a line or if you
 want to distinguish parts of a compound statement.
 
+A line number can be prefixed with a file name and a colon to set a
+breakpoint in a file other than the current one. The file can be in
+any package loaded in the program and need only match the end of
+the file's full name, e.g. fmt/print.go:123 or mypkg/util.go:40.
+
+If the target is a method of an interface type, given as I.Method or
+pkg.I.Method, a breakpoint is set in the method of every concrete type
+that implements the interface, so you stop in whichever method a call
+through the interface dispatches to.
+
+"init *pkg*" sets a breakpoint at the start of the initialization
+function of package *pkg*, which runs before main. *pkg* is a package
+name or import path. Use this along with init tracing to stop before
+main.main().
+
+If "goroutine *n*" is added at the end, the breakpoint only stops
+when it is hit by goroutine *n*. See "goroutines" for goroutine
+numbers.
+
+If "label *name*" is added, the breakpoint is tagged with *name* so
+that a group of breakpoints can be enabled, disabled, or deleted
+together, e.g. "disable label io-path".
+
 See also "info break", "enable", and "disable".
 `,
 
 		Min_args: 0,
-		Max_args: 2,
+		Max_args: 11,
 	}
 	gub.AddToCategory("breakpoints", name)
 	gub.AddAlias("break", name)
@@ -33,13 +58,19 @@ See also "info break", "enable", and "disable".
 }
 
 // BreakpointCommand implements the debugger command:
-//    breakpoint [*fn* | line [column]
+//    breakpoint [*fn* | *iface*.*method* | init *pkg* | [*file*:]*line* [*column*]] [hitcount *cond*] [goroutine *n*] [label *name*]
 // which sets a breakpoint.
 //
 // The target can either be a function name as fn pkg.fn
 // or a line and and optional column number. Specifying a column number
 // may be useful if there is more than one statement on a line or if you
-// want to distinguish parts of a compound statement.
+// want to distinguish parts of a compound statement. A line may be
+// prefixed with a file name of any package loaded, e.g. fmt/print.go:123.
+// With "goroutine *n*", the breakpoint only stops in goroutine *n*.
+// With "hitcount *cond*", it stops only when its hit count satisfies
+// *cond*, e.g. "hitcount % 100 == 0". With "label *name*", the
+// breakpoint is tagged with *name* for "enable", "disable", and
+// "delete".
 //
 // See also "info break", "enable", and "disable" and "delete".
 func BreakpointCommand(args []string) {
@@ -47,7 +78,105 @@ func BreakpointCommand(args []string) {
 		InfoBreakpointSubcmd(args)
 		return
 	}
+	goOnly := false
+	goNum  := 0
+	var hitCond *gub.HitCond
+	label := ""
+	// Peel off trailing "goroutine", "hitcount" and "label" clauses, last
+	// one first, so each clause runs to the end of what remains.
+	for j := len(args)-1; j >= 2; j-- {
+		switch args[j] {
+		case "goroutine":
+			if j+2 != len(args) {
+				gub.Errmsg("Expecting a single goroutine number after \"goroutine\"")
+				return
+			}
+			n, err := gub.GetInt(args[j+1], "goroutine number", 0, 0)
+			if err != nil { return }
+			goOnly, goNum = true, n
+			args = args[:j]
+		case "hitcount":
+			c, err := gub.ParseHitCond(args[j+1:])
+			if err != nil {
+				gub.Errmsg(err.Error())
+				return
+			}
+			hitCond = c
+			args = args[:j]
+		case "label":
+			if j+2 != len(args) {
+				gub.Errmsg("Expecting a single name after \"label\"")
+				return
+			}
+			label = args[j+1]
+			args = args[:j]
+		}
+	}
+	if len(args) > 3 {
+		gub.Errmsg("Expecting \"goroutine\", \"hitcount\" or \"label\" after the breakpoint location")
+		return
+	}
+	goSuffix := ""
+	if goOnly { goSuffix = fmt.Sprintf(" in goroutine %d", goNum) }
+
 	name := args[1]
+	if name == "init" && len(args) == 3 {
+		pkg := gub.PkgLookup(args[2])
+		if pkg == nil {
+			pkg = gub.Program().PackagesByPath[args[2]]
+		}
+		if pkg == nil {
+			gub.Errmsg("Can't find package %s", args[2])
+			return
+		}
+		fn := pkg.InitFunc()
+		interp.SetFnBreakpoint(fn)
+		bp := &gub.Breakpoint {
+			Hits: 0,
+			Id: gub.BreakpointNext(),
+			Pos: fn.Pos(),
+			EndP: fn.EndP(),
+			Ignore: 0,
+			Kind: "Function",
+			Temp: false,
+			Enabled: true,
+			GoOnly: goOnly,
+			GoNum: goNum,
+			HitCond: hitCond,
+			Label: label,
+		}
+		bpnum := gub.BreakpointAdd(bp)
+		gub.Msg(" Breakpoint %d set in initialization of package %s%s", bpnum,
+			pkg.Object.Path(), goSuffix)
+		return
+	}
+	if fns := gub.GetInterfaceMethods(name); fns != nil {
+		if len(fns) == 0 {
+			gub.Errmsg("No concrete methods found for interface method %s", name)
+			return
+		}
+		for _, fn := range fns {
+			interp.SetFnBreakpoint(fn)
+			bp := &gub.Breakpoint {
+				Hits: 0,
+				Id: gub.BreakpointNext(),
+				Pos: fn.Pos(),
+				EndP: fn.EndP(),
+				Ignore: 0,
+				Kind: "Function",
+				Temp: false,
+				Enabled: true,
+				GoOnly: goOnly,
+				GoNum: goNum,
+				HitCond: hitCond,
+				Label: label,
+			}
+			bpnum := gub.BreakpointAdd(bp)
+			gub.Msg(" Breakpoint %d set in method %s at %s%s", bpnum, fn,
+				ssa2.FmtRange(fn, fn.Pos(), fn.EndP()), goSuffix)
+		}
+		return
+	}
 	fn := gub.GetFunction(name)
 	if fn != nil {
 		if ext := interp.Externals()[name]; ext != nil {
@@ -64,13 +193,23 @@ func BreakpointCommand(args []string) {
 			Kind: "Function",
 			Temp: false,
 			Enabled: true,
+			GoOnly: goOnly,
+			GoNum: goNum,
+			HitCond: hitCond,
+			Label: label,
 		}
 		bpnum := gub.BreakpointAdd(bp)
-		gub.Msg(" Breakpoint %d set in function %s at %s", bpnum, name,
-			ssa2.FmtRange(fn, fn.Pos(), fn.EndP()))
+		gub.Msg(" Breakpoint %d set in function %s at %s%s", bpnum, name,
+			ssa2.FmtRange(fn, fn.Pos(), fn.EndP()), goSuffix)
 		return
 	}
-	line, ok := strconv.Atoi(args[1])
+	filename := ""
+	lineStr  := args[1]
+	if colon := strings.LastIndex(lineStr, ":"); colon != -1 {
+		filename = lineStr[:colon]
+		lineStr  = lineStr[colon+1:]
+	}
+	line, ok := strconv.Atoi(lineStr)
 	if ok != nil {
 		gub.Errmsg("Don't know yet how to deal with a break that doesn't start with a function or integer")
 		return
@@ -86,43 +225,135 @@ func BreakpointCommand(args []string) {
 		column = foo
 	}
 
-	fn = gub.CurFrame().Fn()
-	fset := gub.CurFrame().Fset()
-	position := gub.CurFrame().Position()
-	if position.IsValid() {
-		filename := position.Filename
-		for _, l := range fn.Pkg.Locs() {
-			try := fset.Position(l.Pos())
-			if try.Filename == filename && line == try.Line {
-				if column == -1 || column == try.Column {
-					bp := &gub.Breakpoint {
-						Hits: 0,
-						Id: gub.BreakpointNext(),
-						Pos: l.Pos(),
-						EndP: l.Pos(),
-						Ignore: 0,
-						Kind: "Statement",
-						Temp: false,
-						Enabled: true,
-					}
-					bpnum := gub.BreakpointAdd(bp)
-					if l.Trace != nil {
-						l.Trace.Breakpoint = true
-					} else if l.Fn != nil {
-						l.Fn.Breakpoint = true
-						bp.Kind = "Function"
-					} else {
-						gub.Errmsg("Internal error setting in file %s line %d, column %d",
-							bpnum, filename, line, try.Column)
-						return
-					}
-					gub.Msg("Breakpoint %d set in file %s line %d, column %d", bpnum, filename, line, try.Column)
-					return
-				}
-			}
+	if filename == "" {
+		position := gub.CurFrame().Position()
+		if !position.IsValid() {
+			gub.Errmsg("Can't figure out the current file; give a file name")
+			return
 		}
+		filename = position.Filename
+	}
+
+	fset := gub.CurFrame().Fset()
+	locs := gub.CurFrame().Fn().Prog.FileLineLocs(filename, line, column)
+	if len(locs) == 0 {
 		suffix := ""
 		if column != -1 { suffix = ", column " + args[2] }
 		gub.Errmsg("Can't find statement in file %s at line %d%s", filename, line, suffix)
+		return
+	}
+	l   := locs[0]
+	try := fset.Position(l.Pos())
+	bp := &gub.Breakpoint {
+		Hits: 0,
+		Id: gub.BreakpointNext(),
+		Pos: l.Pos(),
+		EndP: l.Pos(),
+		Ignore: 0,
+		Kind: "Statement",
+		Temp: false,
+		Enabled: true,
+		GoOnly: goOnly,
+		GoNum: goNum,
+		HitCond: hitCond,
+		Label: label,
+	}
+	bpnum := gub.BreakpointAdd(bp)
+	if l.Trace != nil {
+		l.Trace.Breakpoint = true
+	} else if l.Fn != nil {
+		l.Fn.Breakpoint = true
+		bp.Kind = "Function"
+	} else {
+		gub.Errmsg("Internal error setting in file %s line %d, column %d",
+			bpnum, try.Filename, line, try.Column)
+		return
+	}
+	gub.Msg("Breakpoint %d set in file %s line %d, column %d%s", bpnum,
+		try.Filename, line, try.Column, goSuffix)
+}
+
+// breakpointArgs returns the breakpoint numbers given in args
+// starting at index start. These are either a list of numbers or
+// "label *name*" for all of the breakpoints labeled *name*. Numbers
+// that aren't valid are reported and dropped.
+func breakpointArgs(args []string, start int) []int {
+	bpnums := make([]int, 0)
+	if len(args) > start && args[start] == "label" {
+		if len(args) != start+2 {
+			gub.Errmsg("Expecting a single name after \"label\"")
+			return bpnums
+		}
+		bpnums = gub.BreakpointFindByLabel(args[start+1])
+		if len(bpnums) == 0 {
+			gub.Errmsg("No breakpoints labeled %s", args[start+1])
+		}
+		return bpnums
+	}
+	for i:=start; i<len(args); i++ {
+		msg := fmt.Sprintf("breakpoint number for argument %d", i)
+		bpnum, err := gub.GetInt(args[i], msg, 0, len(gub.Breakpoints)-1)
+		if err != nil { continue }
+		bpnums = append(bpnums, bpnum)
+	}
+	return bpnums
+}
+
+// setLocBreakpoint fills in the position and kind of bp from loc,
+// which is a function name or a [*file*:]*line*, arranges for the
+// interpreter to stop there, and adds bp. It returns the breakpoint
+// number and a description of where it was set, or -1 if loc can't be
+// found.
+func setLocBreakpoint(loc string, bp *gub.Breakpoint) (int, string) {
+	bp.Id = gub.BreakpointNext()
+	if fn := gub.GetFunction(loc); fn != nil {
+		if ext := interp.Externals()[loc]; ext != nil {
+			gub.Errmsg("Sorry, %s is a built-in external function.", loc)
+			return -1, ""
+		}
+		interp.SetFnBreakpoint(fn)
+		bp.Pos, bp.EndP = fn.Pos(), fn.EndP()
+		bp.Kind = "Function"
+		return gub.BreakpointAdd(bp), fmt.Sprintf("function %s at %s", loc,
+			ssa2.FmtRange(fn, fn.Pos(), fn.EndP()))
+	}
+	filename := ""
+	lineStr  := loc
+	if colon := strings.LastIndex(lineStr, ":"); colon != -1 {
+		filename = lineStr[:colon]
+		lineStr  = lineStr[colon+1:]
+	}
+	line, err := strconv.Atoi(lineStr)
+	if err != nil {
+		gub.Errmsg("Expecting a function name or [file:]line; got %s", loc)
+		return -1, ""
+	}
+	if filename == "" {
+		position := gub.CurFrame().Position()
+		if !position.IsValid() {
+			gub.Errmsg("Can't figure out the current file; give a file name")
+			return -1, ""
+		}
+		filename = position.Filename
+	}
+	locs := gub.Program().FileLineLocs(filename, line, -1)
+	if len(locs) == 0 {
+		gub.Errmsg("Can't find statement in file %s at line %d", filename, line)
+		return -1, ""
+	}
+	l := locs[0]
+	if l.Trace != nil {
+		l.Trace.Breakpoint = true
+		bp.Kind = "Statement"
+	} else if l.Fn != nil {
+		l.Fn.Breakpoint = true
+		bp.Kind = "Function"
+	} else {
+		gub.Errmsg("Internal error setting in file %s line %d", filename, line)
+		return -1, ""
 	}
+	bp.Pos, bp.EndP = l.Pos(), l.Pos()
+	try := gub.CurFrame().Fset().Position(l.Pos())
+	return gub.BreakpointAdd(bp), fmt.Sprintf("file %s line %d, column %d",
+		try.Filename, line, try.Column)
 }
@@ -0,0 +1,58 @@
+// Copyright 2015 Rocky Bernstein.
+// Debugger call command
+
+package gubcmd
+
+import "github.com/rocky/ssa-interp/gub"
+
+func init() {
+	name := "call"
+	gub.Cmds[name] = &gub.CmdInfo{
+		Fn: CallCommand,
+		Help: `call *fn*(*arg1*, *arg2*, ...)
+
+Call function or method *fn* in the program being debugged and show
+the values it returns. The arguments are evaluated as in "eval" in the
+environment of the current frame.
+
+Breakpoints and stepping do not stop inside the call. If *fn* panics,
+the panic is reported and the program continues to be stopped where
+it was.
+
+Examples:
+
+   call gcd(10, 4)
+   call p.String()
+   call strings.Repeat("ab", n)
+
+See also "eval".
+`,
+		Min_args: 1,
+		Max_args: -1,
+	}
+	gub.AddToCategory("inspecting", name)
+}
+
+// CallCommand implements the debugger command:
+//    call *fn*(*arg1*, *arg2*, ...)
+// which runs a function of the program being debugged and shows its
+// results.
+//
+// See also "eval".
+func CallCommand(args []string) {
+	// Don't use args, but gub.CmdArgstr which preserves blanks inside quotes
+	fr := gub.CurFrame()
+	results, tuple, err := gub.CallExpr(fr, gub.CurScope(), gub.CmdArgstr)
+	if err != nil {
+		gub.Errmsg("%s", err)
+		return
+	}
+	if len(results) == 0 {
+		gub.Msg("No value returned")
+		return
+	}
+	for i, v := range results {
+		t := tuple.At(i).Type()
+		gub.Msg("(%s) %s", t, gub.FormatValue(v, t))
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright 2015 Rocky Bernstein.
+// Debugger catch command
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp"
+	"github.com/rocky/ssa-interp/gub"
+	"github.com/rocky/ssa-interp/interp"
+)
+
+func init() {
+	name := "catch"
+	gub.Cmds[name] = &gub.CmdInfo{
+		Fn: CatchCommand,
+		Help: `catch panic | defer | chan *name* | call *fn*
+
+Set a catchpoint. With "panic", execution stops in the frame where a
+panic is raised, before any deferred functions are run and before the
+stack is unwound. The panic value is shown and the frame can be
+inspected.
+
+The debugger stops on panics even without a catchpoint. However once a
+panic catchpoint is set, disabling it keeps the debugger from stopping
+on panics.
+
+With "defer", execution stops just before each deferred function is
+called, whether on a normal return or while a panic unwinds the
+stack. The stopping frame is the one that deferred the call.
+
+With "chan", execution stops whenever a value is sent on or received
+from the channel held in variable *name*, or when that channel is
+closed. Sends and closes stop before the operation; receives stop
+after it, so the value received is shown.
+
+With "call", execution stops just before the interpreter calls *fn*,
+which must be one of the functions it runs natively rather than
+interprets, e.g. syscall.Open or syscall.Write. The arguments of the
+call are shown, and the stopping frame is the caller. To stop in a
+function that is interpreted, use "breakpoint".
+
+See also "info break", "enable", "disable", and "delete".
+`,
+
+		Min_args: 1,
+		Max_args: 2,
+	}
+	gub.AddToCategory("breakpoints", name)
+}
+
+// CatchCommand implements the debugger command:
+//    catch panic | defer | chan *name* | call *fn*
+// which sets a catchpoint.
+//
+// See also "info break", "enable", "disable", and "delete".
+func CatchCommand(args []string) {
+	switch args[1] {
+	case "panic":
+		bpnum := gub.CatchpointAdd("panic")
+		gub.Msg("Catchpoint %d (panic)", bpnum)
+	case "defer":
+		bpnum := gub.CatchpointAdd("defer")
+		interp.SetTraceEvent(ssa2.DEFER_ENTER)
+		gub.Msg("Catchpoint %d (defer)", bpnum)
+	case "chan":
+		if len(args) != 3 {
+			gub.Errmsg("catch chan needs a channel variable name")
+			return
+		}
+		ch, err := gub.ChanLookup(gub.CurFrame(), args[2], gub.CurScope())
+		if err != nil {
+			gub.Errmsg(err.Error())
+			return
+		}
+		bpnum := gub.ChanCatchpointAdd(args[2], ch)
+		gub.Msg("Catchpoint %d (chan %s)", bpnum, args[2])
+	case "call":
+		if len(args) != 3 {
+			gub.Errmsg("catch call needs a function name")
+			return
+		}
+		bpnum := gub.CallCatchpointAdd(args[2])
+		if bpnum < 0 {
+			gub.Errmsg("%s is not an external function; try \"breakpoint\"", args[2])
+			return
+		}
+		gub.Msg("Catchpoint %d (call %s)", bpnum, args[2])
+	default:
+		gub.Errmsg("Don't know how to catch %s; try \"panic\", \"defer\", \"chan\", or \"call\"", args[1])
+	}
+}
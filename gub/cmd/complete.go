@@ -0,0 +1,46 @@
+// Copyright 2015 Rocky Bernstein.
+
+// complete command
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	name := "complete"
+	gub.Cmds[name] = &gub.CmdInfo{
+		Fn: CompleteCommand,
+		Help: `complete *prefix*
+
+Show the ways the command line *prefix* can be completed, one per
+line. Command names and aliases, subcommand names, function names for
+location commands like "break" and "disassemble", and names of
+variables in scope for expression commands like "eval" and "whatis"
+are completed.
+
+Examples:
+
+   complete inf           # info
+   complete info b        # info block, info breakpoint
+   complete break ma      # main, main.main ...
+   complete eval ex       # variables starting with ex
+
+This is what typing TAB at the gub prompt completes with, and what a
+front end such as an editor can use to complete commands.`,
+		Min_args: 0,
+		Max_args: -1,
+	}
+	gub.AddToCategory("support", name)
+}
+
+// CompleteCommand implements the debugger command:
+//    complete *prefix*
+// which shows the ways the command line *prefix* can be completed.
+func CompleteCommand(args []string) {
+	// Don't use args, but gub.CmdArgstr which preserves blanks
+	for _, line := range gub.Complete(gub.CmdArgstr) {
+		gub.Msg(line)
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright 2015 Rocky Bernstein.
+// Debugger condition command
+
+package gubcmd
+
+import (
+	"go/parser"
+	"strings"
+
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	name := "condition"
+	gub.Cmds[name] = &gub.CmdInfo{
+		Fn: ConditionCommand,
+		Help: `condition *bpnum* [*expr*]
+
+Set the condition of breakpoint *bpnum* to Go expression *expr*. The
+breakpoint then stops only when *expr* evaluates to true in the frame
+it is hit in. If *expr* is omitted, any condition is removed and the
+breakpoint becomes unconditional.
+
+Examples:
+
+   condition 1 i == 5
+   condition 2 name == "foo" && count > 10
+   condition 1           # remove the condition on breakpoint 1
+
+See also "info break", "ignore", "enable", and "disable".
+`,
+
+		Min_args: 1,
+		Max_args: -1,
+	}
+	gub.AddToCategory("breakpoints", name)
+	gub.AddAlias("cond", name)
+}
+
+// ConditionCommand implements the debugger command:
+//    condition *bpnum* [*expr*]
+// which sets or removes the stopping condition of a breakpoint.
+//
+// See also "info break", "ignore", "enable", and "disable".
+func ConditionCommand(args []string) {
+	bpnum, err := gub.GetInt(args[1], "breakpoint number", 0, len(gub.Breakpoints)-1)
+	if err != nil { return }
+	if !gub.BreakpointExists(bpnum) {
+		gub.Errmsg("Breakpoint %d doesn't exist", bpnum)
+		return
+	}
+	bp := gub.Breakpoints[bpnum]
+	if len(args) == 2 {
+		if bp.Condition == "" {
+			gub.Msg("Breakpoint %d is already unconditional.", bpnum)
+		} else {
+			bp.Condition = ""
+			gub.Msg("Breakpoint %d now unconditional.", bpnum)
+		}
+		return
+	}
+	expr := strings.TrimSpace(strings.TrimPrefix(gub.CmdArgstr, args[1]))
+	if _, err := parser.ParseExpr(expr); err != nil {
+		gub.Errmsg("Bad condition %s: %s", expr, err)
+		return
+	}
+	bp.Condition = expr
+	gub.Msg("Breakpoint %d stops only if %s", bpnum, expr)
+}
@@ -11,20 +11,45 @@ func init() {
 	name := "continue"
 	gub.Cmds[name] = &gub.CmdInfo{
 		Fn: ContinueCommand,
-		Help: `continue
+		Help: `continue [*location*]
 
 Leave the debugger loop and continue execution. Subsequent entry to
 the debugger however may occur via breakpoints or explicit calls, or
 exceptions.
+
+If *location* is given, a temporary breakpoint is set there first, so
+execution stops when it is reached unless something else stops it
+sooner. *location* is a function name or [*file*:]*line*, as in
+"breakpoint". For example:
+
+   continue 25
+   c gcd.go:12
 `,
 		Min_args: 0,
-		Max_args: 0,
+		Max_args: 1,
 	}
 	gub.AddAlias("c", name)
 	gub.AddToCategory("running", name)
 }
 
+// ContinueCommand implements the debugger command:
+//    continue [*location*]
+// which continues execution, after setting a temporary breakpoint
+// at *location* if it is given.
+//
+// See also "breakpoint", "step", "next", and "finish".
 func ContinueCommand(args []string) {
+	if len(args) == 2 {
+		bp := &gub.Breakpoint {
+			Hits: 0,
+			Ignore: 0,
+			Temp: true,
+			Enabled: true,
+		}
+		bpnum, where := setLocBreakpoint(args[1], bp)
+		if bpnum < 0 { return }
+		gub.Msg("Temporary breakpoint %d set in %s", bpnum, where)
+	}
 	for fr := gub.TopFrame(); fr != nil; fr = fr.Caller(0) {
 		interp.SetStepOff(fr)
 	}
@@ -0,0 +1,85 @@
+// Copyright 2015 Rocky Bernstein.
+
+// define command
+
+package gubcmd
+
+import (
+	"io"
+	"strings"
+
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	name := "define"
+	gub.Cmds[name] = &gub.CmdInfo{
+		Fn: DefineCommand,
+		Help: `define *name*
+
+Define a command *name* made up of other debugger commands. The
+commands are given on the lines that follow, ending with a line
+containing just "end". A command already defined this way is
+replaced.
+
+When *name* is run, $arg0, $arg1, ... in its commands are replaced by
+the arguments given to it, and $argc by the number of arguments. If
+one of its commands lets the program run, like "next" or "continue",
+the commands after it are run when the program next stops.
+
+Example:
+
+   define pv
+   eval $arg0
+   whatis $arg0
+   end
+
+after which "pv x" shows the value and the type of x. Commands can be
+defined in ~/.gubrc so that they are there in every session.
+
+Some names are hooks, run without being asked for: "hook-stop" is run
+each time the program stops, before we ask for commands, and
+"hook-*command*" and "hookpost-*command*" are run before and after
+each *command*. For example, to always see the source and the value
+of x where we stop:
+
+   define hook-stop
+   list
+   eval x
+   end
+
+See also "alias".
+`,
+		Min_args: 1,
+		Max_args: 1,
+	}
+	gub.AddToCategory("support", name)
+}
+
+// DefineCommand implements the debugger command:
+//    define *name*
+// which defines a command made up of the debugger commands on the
+// lines that follow, up to "end".
+func DefineCommand(args []string) {
+	name := args[1]
+	gub.Msg("Type commands for definition of \"%s\".", name)
+	gub.Msg("End with a line saying just \"end\".")
+	var body []string
+	for {
+		line, err := gub.ReadCommandLine(">")
+		if err != nil && (err != io.EOF || line == "") {
+			gub.Errmsg("Definition of %s not finished; ignored", name)
+			return
+		}
+		line = strings.TrimSpace(line)
+		if line == "end" {
+			break
+		}
+		if line != "" {
+			body = append(body, line)
+		}
+	}
+	if err := gub.DefineCommand(name, body); err != nil {
+		gub.Errmsg(err.Error())
+	}
+}
@@ -4,7 +4,6 @@
 package gubcmd
 
 import (
-	"fmt"
  	"github.com/rocky/ssa-interp/gub"
 )
 
@@ -12,9 +11,10 @@ func init() {
 	name := "delete"
 	gub.Cmds[name] = &gub.CmdInfo{
 		Fn: DeleteCommand,
-		Help: `Delete [bpnum1 ...]
+		Help: `Delete [bpnum1 ... | label *name*]
 
-Delete a breakpoint by breakpoint number.
+Delete a breakpoint by breakpoint number. With "label *name*", delete
+all of the breakpoints labeled *name*.
 `,
 
 		Min_args: 0,
@@ -26,15 +26,12 @@ Delete a breakpoint by breakpoint number.
 }
 
 // DeleteCommand implements the debugger command:
-//    delete [bpnum1 ... ]
-// which deletes some breakpoints by breakpoint number
+//    delete [bpnum1 ... | label *name*]
+// which deletes some breakpoints by breakpoint number or label
 //
 // See also "breakpoint", "info break", "enable", and "disable".
 func DeleteCommand(args []string) {
-	for i:=1; i<len(args); i++ {
-		msg := fmt.Sprintf("breakpoint number for argument %d", i)
-		bpnum, err := gub.GetInt(args[i], msg, 0, len(gub.Breakpoints)-1)
-		if err != nil { continue }
+	for _, bpnum := range breakpointArgs(args, 1) {
 		if gub.BreakpointExists(bpnum) {
 			if gub.BreakpointDelete(bpnum) {
 				gub.Msg(" Deleted breakpoint %d", bpnum)
@@ -2,7 +2,6 @@
 
 package gubcmd
 import (
-	"fmt"
 	"github.com/rocky/ssa-interp/gub"
 )
 
@@ -10,9 +9,10 @@ func init() {
 	name := "disable"
 	gub.Cmds[name] = &gub.CmdInfo{
 		Fn: DisableCommand,
-		Help: `Disable [bpnum1 ...]
+		Help: `Disable [bpnum1 ... | label *name*]
 
-Disable a breakpoint by its breakpoint number.
+Disable a breakpoint by its breakpoint number. With "label *name*",
+disable all of the breakpoints labeled *name*.
 
 See also "enable", "delete", and "info break"`,
 		Min_args: 0,
@@ -24,15 +24,13 @@ See also "enable", "delete", and "info break"`,
 // FIXME: DRY with Enable and Delete?
 
 // DisableCommand implements the debugger command:
-//    disable [bpnum1 ...]
-// which disables a breakpoint by its breakpoint number.
+//    disable [bpnum1 ... | label *name*]
+// which disables a breakpoint by its breakpoint number, or the
+// breakpoints labeled *name*.
 //
 // See also "enable", "delete", and "info break".
 func DisableCommand(args []string) {
-	for i:=1; i<len(args); i++ {
-		msg := fmt.Sprintf("breakpoint number for argument %d", i)
-		bpnum, err := gub.GetInt(args[i], msg, 0, len(gub.Breakpoints)-1)
-		if err != nil { continue }
+	for _, bpnum := range breakpointArgs(args, 1) {
 		if gub.BreakpointExists(bpnum) {
 			if !gub.BreakpointIsEnabled(bpnum) {
 				gub.Msg("Breakpoint %d is already disabled", bpnum)
@@ -4,7 +4,10 @@
 package gubcmd
 
 import (
-	"os"
+	"strconv"
+	"strings"
+
+	"github.com/rocky/ssa-interp"
 	"github.com/rocky/ssa-interp/gub"
 )
 
@@ -12,64 +15,132 @@ func init() {
 	name := "disassemble"
 	gub.Cmds[name] = &gub.CmdInfo{
 		Fn: DisassembleCommand,
-		Help: `disassemble [*fn* | *int* | . | + ]
+		Help: `disassemble [*fn*] [*int* | *line*-*line*]
+disassemble [. | + ]
 
 disassemble SSA instructions. Without any parameters we disassemble the
 current instruction. If a function name is given, that is disassembled.
 If a number is given that is the block number of the current frame.
 If "." is given we disassemble the current block only. If "+" we disassemble
 the current function.
+
+After a function name, a number picks out just that block of the
+function, and a range of source lines like 10-20 picks out the
+instructions that come from those lines. Without a function name, a
+range of lines applies to the current function.
+
+The instruction we are stopped at is marked with "=>". Each block
+lists its predecessor and successor blocks, and the source line an
+instruction comes from is shown as a comment before the first
+instruction of that line.
+
+Examples:
+
+   disassemble             # current instruction
+   disassemble .           # current block
+   disassemble 3           # block 3 of the current function
+   disassemble 3 5         # instruction 5 of block 3
+   disassemble main.gcd    # all of function main.gcd
+   disassemble gcd 2       # block 2 of gcd
+   disassemble gcd 10-12   # instructions of gcd from lines 10 to 12
 `,
 		Min_args: 0,
-		Max_args: 1,
+		Max_args: 2,
 	}
 	gub.AddToCategory("inspecting", name)
 	gub.AddAlias("disasm", name)
 }
 
+// parseLineRange parses a range of lines like 10-20.
+func parseLineRange(arg string) (first, last int, ok bool) {
+	dash := strings.Index(arg, "-")
+	if dash <= 0 {
+		return 0, 0, false
+	}
+	first, err := strconv.Atoi(arg[:dash])
+	if err != nil {
+		return 0, 0, false
+	}
+	last, err = strconv.Atoi(arg[dash+1:])
+	if err != nil {
+		return 0, 0, false
+	}
+	return first, last, true
+}
+
+// disasmBlockOrLines disassembles the part of function fn that arg, a
+// block number or a range of lines, picks out.
+func disasmBlockOrLines(fn *ssa2.Function, arg string) {
+	if first, last, ok := parseLineRange(arg); ok {
+		if first > last {
+			gub.Errmsg("First line %d is after last line %d", first, last)
+			return
+		}
+		gub.DisasmLines(fn, first, last)
+		return
+	}
+	if len(fn.Blocks) == 0 {
+		gub.Errmsg("%s has no blocks", fn)
+		return
+	}
+	bnum, err := gub.GetInt(arg, "block number or line range", 0, len(fn.Blocks)-1)
+	if err == nil {
+		gub.DisasmBlock(fn, bnum, -1)
+	}
+}
 
+// DisassembleCommand implements the debugger command:
+//    disassemble [*fn*] [*int* | *line*-*line*]
+// which disassembles SSA instructions.
 func DisassembleCommand(args []string) {
 	fr := gub.CurFrame()
 	myfn := fr.Fn()
-	if len(args) > 1 {
-		what := args[1]
-		if what == "." {
-			if block := gub.CurBlock(); block != nil {
-				gub.DisasmBlock(myfn, block.Index, fr.PC())
-			} else {
-				gub.Errmsg("Can't get block info here")
-			}
+	if len(args) == 1 {
+		gub.DisasmCurrentInst()
+		return
+	}
+	what := args[1]
+	switch what {
+	case ".":
+		if block := gub.CurBlock(); block != nil {
+			gub.DisasmBlock(myfn, block.Index, fr.PC())
+		} else {
+			gub.Errmsg("Can't get block info here")
+		}
+		return
+	case "+":
+		gub.DisasmFunction(myfn)
+		return
+	}
+	if fn, err := gub.FuncLookup(what); err == nil && fn != nil {
+		if len(args) == 3 {
+			disasmBlockOrLines(fn, args[2])
+		} else {
+			gub.DisasmFunction(fn)
+		}
+		return
+	}
+	if _, _, ok := parseLineRange(what); ok {
+		if len(args) == 3 {
+			gub.Errmsg("Unexpected argument after a range of lines: %s", args[2])
 			return
-		} else if what != "+" {
-			if fn, err := gub.FuncLookup(what); err == nil && fn != nil {
-				myfn = fn
-			} else {
-				bnum, err := gub.GetInt(args[1],
-					"block number of function name", 0, len(myfn.Blocks)-1)
-				if err == nil {
-					lastBlock := len(myfn.Blocks) - 1
-					if bnum <= lastBlock {
-						b := myfn.Blocks[bnum]
-						if len(args) == 3 {
-							ic, err := gub.GetUInt(args[2],
-								"instruction number", 0, uint64(len(b.Instrs)-1))
-							if err == nil {
-								gub.DisasmInst(myfn, bnum, ic)
-							}
-						} else {
-							gub.DisasmBlock(myfn, bnum, -1)
-						}
-					} else {
-						gub.Errmsg("Block number should be between 0 and %d; got %d",
-							lastBlock, bnum)
-					}
-				}
-				return
-			}
 		}
-	} else {
-		gub.DisasmCurrentInst()
+		disasmBlockOrLines(myfn, what)
+		return
+	}
+	bnum, err := gub.GetInt(what,
+		"block number of function name", 0, len(myfn.Blocks)-1)
+	if err != nil {
 		return
 	}
-	myfn.WriteTo(os.Stderr)
+	if len(args) == 3 {
+		b := myfn.Blocks[bnum]
+		ic, err := gub.GetUInt(args[2],
+			"instruction number", 0, uint64(len(b.Instrs)-1))
+		if err == nil {
+			gub.DisasmInst(myfn, bnum, ic)
+		}
+	} else {
+		gub.DisasmBlock(myfn, bnum, -1)
+	}
 }
@@ -0,0 +1,60 @@
+// Copyright 2015 Rocky Bernstein.
+// Debugger display command
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	name := "display"
+	gub.Cmds[name] = &gub.CmdInfo{
+		Fn: DisplayCommand,
+		Help: `display[/*fmt*] [*expr*]
+
+Add go expression *expr* to the list of expressions that are evaluated
+and shown each time the program stops. Each expression gets a number
+which "undisplay" uses to remove it.
+
+A format letter like those of "eval" can be given after a slash. It
+applies only to this expression.
+
+Without an expression, show the current value of all of the display
+expressions.
+
+Examples:
+
+   display i
+   display/x flags
+   display len(stack)
+
+See also "undisplay", "info display", and "eval".
+`,
+		Min_args: 0,
+		Max_args: -1,
+	}
+	gub.AddToCategory("inspecting", name)
+}
+
+// DisplayCommand implements the debugger command:
+//    display[/*fmt*] [*expr*]
+// which adds an expression to be shown each time we stop.
+//
+// See also "undisplay", "info display", and "eval".
+func DisplayCommand(args []string) {
+	if len(args) == 1 {
+		gub.PrintDisplays()
+		return
+	}
+	format := ""
+	if gub.CmdFormat != "" {
+		format = gub.CmdFormat[1:]
+	}
+	// Don't use args, but gub.CmdArgstr which preserves blanks inside quotes
+	n := gub.DisplayAdd(gub.CmdArgstr, format)
+	if n < 0 {
+		return
+	}
+	gub.PrintDisplay(gub.Displays[n])
+}
@@ -11,7 +11,8 @@ func init() {
 		Help: `down [*count*]
 
 Move the current frame down in the stack trace (to a newer frame). 0
-is the most recent frame. If no count is given, move down 1.
+is the most recent frame. If no count is given, move down 1. A count
+that goes past the newest frame stops there.
 
 See also 'up' and 'frame'.
 `,
@@ -0,0 +1,64 @@
+// Copyright 2015 Rocky Bernstein.
+// Debugger dprintf command
+
+package gubcmd
+
+import (
+	"strings"
+
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	name := "dprintf"
+	gub.Cmds[name] = &gub.CmdInfo{
+		Fn: DprintfCommand,
+		Help: `dprintf *location* "*format*", *expr1*, ...
+
+Set a dynamic printf at *location*, which is a function name or a
+[*file*:]*line* as in "breakpoint". Whenever execution reaches there,
+the expressions are evaluated in that frame and printed using
+*format*, as fmt.Printf would, but execution doesn't stop. For
+example:
+
+   dprintf 24 "i=%d name=%s\n", i, name
+
+dprintfs are listed by "info break" and can be disabled, enabled,
+or deleted like breakpoints.
+
+See also "breakpoint", "condition", and "info break".
+`,
+
+		Min_args: 2,
+		Max_args: -1,
+	}
+	gub.AddToCategory("breakpoints", name)
+}
+
+// DprintfCommand implements the debugger command:
+//    dprintf *location* "*format*", *expr1*, ...
+// which sets a breakpoint that prints the values of the expressions
+// using *format* whenever it is reached, without stopping.
+//
+// See also "breakpoint", "condition", and "info break".
+func DprintfCommand(args []string) {
+	argstr := strings.TrimSpace(gub.CmdArgstr)
+	loc := args[1]
+	rest := strings.TrimSpace(argstr[len(loc):])
+	format, fmtArgs, err := gub.ParseDprintf(rest)
+	if err != nil {
+		gub.Errmsg("Bad dprintf arguments %s: %s", rest, err)
+		return
+	}
+	bp := &gub.Breakpoint {
+		Hits: 0,
+		Ignore: 0,
+		Temp: false,
+		Enabled: true,
+		Format: format,
+		FmtArgs: fmtArgs,
+	}
+	bpnum, where := setLocBreakpoint(loc, bp)
+	if bpnum < 0 { return }
+	gub.Msg("Dprintf %d set in %s", bpnum, where)
+}
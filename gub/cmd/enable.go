@@ -2,7 +2,6 @@
 
 package gubcmd
 import (
-	"fmt"
 	"github.com/rocky/ssa-interp/gub"
 )
 
@@ -10,9 +9,14 @@ func init() {
 	name := "enable"
 	gub.Cmds[name] = &gub.CmdInfo{
 		Fn: EnableCommand,
-		Help: `enable [bpnum1 ...]
+		Help: `enable [once | delete] [bpnum1 ... | label *name*]
 
-Enable a breakpoint by the number assigned to it.`,
+Enable a breakpoint by the number assigned to it. With "label *name*",
+enable all of the breakpoints labeled *name*.
+
+With "once", the breakpoint is disabled again after the next time we
+stop at it. With "delete", the breakpoint is deleted after the next
+time we stop at it.`,
 
 		Min_args: 0,
 		Max_args: -1,
@@ -23,18 +27,42 @@ Enable a breakpoint by the number assigned to it.`,
 // FIXME: DRY with Disable and Delete?
 
 // EnableCommand implements the debugger command:
-//    enable [bpnum1 ...]
-// which enables a breakpoint by its breakpoint number.
+//    enable [once | delete] [bpnum1 ... | label *name*]
+// which enables a breakpoint by its breakpoint number. "once" disables
+// the breakpoint after its next stop and "delete" deletes it then.
 //
 // See also "disable", "delete", and "info break".
 func EnableCommand(args []string) {
-	for i:=1; i<len(args); i++ {
-		msg := fmt.Sprintf("breakpoint number for argument %d", i)
-		bpnum, err := gub.GetInt(args[i], msg, 0, len(gub.Breakpoints)-1)
-		if err != nil { continue }
+	once, temp := false, false
+	start := 1
+	if len(args) > 1 {
+		switch args[1] {
+		case "once":
+			once = true
+			start = 2
+		case "delete":
+			temp = true
+			start = 2
+		}
+	}
+	for _, bpnum := range breakpointArgs(args, start) {
 		if gub.BreakpointExists(bpnum) {
+			bp := gub.Breakpoints[bpnum]
+			if once {
+				bp.Once = true
+				bp.Temp = false
+			} else if temp {
+				bp.Temp = true
+				bp.Once = false
+			}
 			if gub.BreakpointIsEnabled(bpnum) {
-				gub.Msg("Breakpoint %d is already enabled", bpnum)
+				if once {
+					gub.Msg("Breakpoint %d will be disabled after its next stop", bpnum)
+				} else if temp {
+					gub.Msg("Breakpoint %d will be deleted after its next stop", bpnum)
+				} else {
+					gub.Msg("Breakpoint %d is already enabled", bpnum)
+				}
 				continue
 			}
 			if gub.BreakpointEnable(bpnum) {
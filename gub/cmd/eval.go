@@ -0,0 +1,83 @@
+// Copyright 2013-2015 Rocky Bernstein.
+
+package gubcmd
+
+import (
+	"strings"
+
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	name := "eval"
+	gub.Cmds[name] = &gub.CmdInfo{
+		Fn: EvalCommand,
+		Help: `eval[/*fmt*] [-frame *n*] *expr*
+
+Evaluate go expression *expr* in the current frame and show its type
+and value. Names are looked up in the frame selected by "frame", "up"
+or "down". With -frame *n*, *expr* is evaluated in frame *n* instead,
+without selecting it.
+
+*expr* can use local and package variables and constants, field
+selection, indexing, pointer indirection, arithmetic, comparisons,
+type conversions like int64(x) or []byte(s), and the builtins len and
+cap. The concrete value in an interface value x can be gotten with a
+type assertion, x.(T), or as (T)(x). Interface values are shown with
+the type of the value they hold.
+
+An output format can be given after a slash, as in gdb: eval/x shows
+integers, and arrays and slices of them, in hexadecimal, /o in octal,
+/b in binary, /d in decimal, and /c as the integer followed by the
+character it is. The format stays in effect for later eval commands
+until another one is given; "eval/" goes back to the normal format.
+
+Examples:
+
+   eval x
+   eval -frame 2 n
+   print/x flags
+   p/c s[0]
+   eval p.name
+   eval a[i+1] * 2
+   eval len(s) > 0 && s[0] == 'a'
+   eval float64(n) / 3
+
+See also "whatis", "locals", and "globals".
+`,
+		Min_args: 1,
+		Max_args: -1,
+	}
+	gub.AddToCategory("inspecting", name)
+	gub.AddAlias("print", name)
+	gub.AddAlias("p", name)
+}
+
+// EvalCommand implements the debugger command:
+//    eval[/*fmt*] [-frame *n*] *expr*
+// which evaluates go expression *expr*.
+//
+// See also "whatis", "locals", and "globals".
+func EvalCommand(args []string) {
+	if !gub.SetPrintFormat() {
+		return
+	}
+	// Don't use args, but gub.CmdArgstr which preserves blanks inside quotes
+	expr := gub.CmdArgstr
+	if len(args) > 1 && args[1] == "-frame" {
+		if len(args) < 4 {
+			gub.Errmsg("Expecting: eval -frame *n* *expr*")
+			return
+		}
+		frameNum, err := gub.GetInt(args[2],
+			"frame number", -gub.MAXSTACKSHOW, gub.MAXSTACKSHOW)
+		if err != nil {
+			return
+		}
+		expr = strings.TrimLeft(expr[len("-frame"):], " ")
+		expr = strings.TrimLeft(expr[len(args[2]):], " ")
+		gub.PrintExprInFrame(frameNum, expr)
+		return
+	}
+	gub.PrintExpr(expr)
+}
@@ -0,0 +1,42 @@
+// Copyright 2015 Rocky Bernstein.
+
+// examine command
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	name := "examine"
+	gub.Cmds[name] = &gub.CmdInfo{
+		Fn: ExamineCommand,
+		Help: `examine *register*
+
+Show SSA register *register* of the selected frame: the instruction
+that defines it, its type, the source variable it holds if any, the
+instructions that use it, and its value. Parameters and free
+variables of the function can be given as well.
+
+Examples:
+
+   examine t12
+   x t3
+
+See also "disassemble" and "eval".`,
+		Min_args: 1,
+		Max_args: 1,
+	}
+	gub.AddToCategory("inspecting", name)
+	gub.AddAlias("x", name)
+}
+
+// ExamineCommand implements the debugger command:
+//    examine *register*
+// which shows SSA register *register* of the selected frame.
+func ExamineCommand(args []string) {
+	if !gub.ExamineRegister(gub.CurFrame(), args[1]) {
+		gub.Errmsg("No SSA register %s in %s", args[1], gub.CurFrame().Fn())
+	}
+}
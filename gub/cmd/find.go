@@ -0,0 +1,63 @@
+// Copyright 2015 Rocky Bernstein.
+
+// find command
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	name := "find"
+	gub.Cmds[name] = &gub.CmdInfo{
+		Fn: FindCommand,
+		Help: `find *expr*
+
+Evaluate Go expression *expr* and search the values reachable from the
+program's variables for places holding a value equal to it. The search
+starts at package variables and at the parameters, local variables and
+free variables of every frame of every goroutine, and follows pointers
+and looks inside structs, arrays, slices, maps, interface values and
+closures.
+
+Each place found is shown as a path from where the search started,
+for example "goroutine 0 #1 main: (*p).next.name". This answers
+questions like "who still holds this pointer?". At most 100 places are
+shown.
+
+Examples:
+
+   find p            # where is the pointer in p held?
+   find "hello"      # which variables hold this string?
+   find 42
+
+See also "globals" and "eval".
+`,
+		Min_args: 1,
+		Max_args: -1,
+	}
+	gub.AddToCategory("inspecting", name)
+}
+
+// FindCommand implements the debugger command:
+//    find *expr*
+// which shows the places holding the value of *expr*.
+func FindCommand(args []string) {
+	// Don't use args, but gub.CmdArgstr which preserves blanks inside quotes
+	found, err := gub.FindExpr(gub.CmdArgstr)
+	if err != nil {
+		gub.Errmsg(err.Error())
+		return
+	}
+	if len(found) == 0 {
+		gub.Msg("Not found")
+		return
+	}
+	for _, path := range found {
+		gub.Msg(path)
+	}
+	if len(found) >= gub.MaxFindResults {
+		gub.Msg("Stopped after %d places", gub.MaxFindResults)
+	}
+}
@@ -20,6 +20,9 @@ Continue execution until the program is about to:
   yielded to.
 
 Sometimes this is called 'step out'.
+
+Execution stops at the next statement of the caller, and the values
+the function returned are shown.
 `,
 		Min_args: 0,
 		Max_args: 0,
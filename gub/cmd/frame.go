@@ -2,15 +2,21 @@
 
 package gubcmd
 
-import "github.com/rocky/ssa-interp/gub"
+import (
+	"strconv"
+
+	"github.com/rocky/ssa-interp/gub"
+)
 
 func init() {
 	name := "frame"
 	gub.Cmds[name] = &gub.CmdInfo{
 		Fn: FrameCommand,
-		Help: `frame *num*
+		Help: `frame *num* | *function*
 
-Change the current frame to frame *num*
+Change the current frame to frame *num*, or to the newest frame
+running *function*, given as in "main.gcd" or just "gcd". The
+frame's function with its arguments and its source line are shown.
 
 See also 'up' and 'down'.
 `,
@@ -21,6 +27,15 @@ See also 'up' and 'down'.
 }
 
 func FrameCommand(args []string) {
+	if _, err := strconv.Atoi(args[1]); err != nil {
+		i, ok := gub.FrameByFunction(args[1])
+		if !ok {
+			gub.Errmsg("No frame is running function %s", args[1])
+			return
+		}
+		gub.AdjustFrame(i, true)
+		return
+	}
 	i, err := gub.GetInt(args[1],
 		"frame number", -gub.MAXSTACKSHOW, gub.MAXSTACKSHOW)
 	if err != nil { return }
@@ -3,30 +3,38 @@
 package gubcmd
 
 import (
-	"fmt"
+	"regexp"
+	"sort"
 
-	"go/token"
-	"github.com/rocky/go-exact"
 	"github.com/rocky/go-types"
 
 	"github.com/rocky/ssa-interp"
 	"github.com/rocky/ssa-interp/gub"
-	"github.com/rocky/ssa-interp/interp"
 )
 
 func init() {
 	name := "globals"
 	gub.Cmds[name] = &gub.CmdInfo{
 		Fn: GlobalsCommand,
-		Help: `globals [*name*]
+		Help: `globals [*pkg-regexp* [*name-regexp*]]
 
-show global variable information. If *name* is not given list
-all global variables.
+Show package-level variables and their current values. With
+*pkg-regexp*, only the variables of packages whose import path or name
+matches *pkg-regexp* are shown. With *name-regexp* as well, only the
+variables whose names match it are shown. Variables are listed by
+package, sorted by name.
 
-See also "locals", "whatis", and "eval".
+Examples:
+
+   globals                # everything
+   globals main           # variables of package main
+   globals ^main$ ^debug  # variables of main starting with debug
+   globals . count        # variables with count in their name
+
+See also "info variables", "locals", "whatis", and "eval".
 `,
 		Min_args: 0,
-		Max_args: 1,
+		Max_args: 2,
 	}
 	gub.AddToCategory("inspecting", name)
 	// Down the line we'll have abbrevs
@@ -34,51 +42,67 @@ See also "locals", "whatis", and "eval".
 	gub.AddAlias("gl", name)
 }
 
+// byPkgPath sorts packages by import path.
+type byPkgPath []*ssa2.Package
+
+func (p byPkgPath) Len() int           { return len(p) }
+func (p byPkgPath) Less(i, j int) bool { return p[i].Object.Path() < p[j].Object.Path() }
+func (p byPkgPath) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+
 // GlobalsCommand implements the debugger command:
-//    globals [*name*]
-// which shows global variable info.
+//    globals [*pkg-regexp* [*name-regexp*]]
+// which shows package-level variables and their values.
 //
 // See also "locals", "whatis", and "eval".
 func GlobalsCommand(args []string) {
-	argc := len(args) - 1
-	if argc == 0 {
-		for k, v := range gub.CurFrame().I().Globals() {
-			if v == nil {
-				fmt.Printf("%s: nil\n")
-			} else {
-				// FIXME: figure out why reflect.lookupCache causes
-				// an panic on a nil pointer or invalid address
-				if fmt.Sprintf("%s", k) == "reflect.lookupCache" {
-					continue
-				}
-				gub.Msg("%s: %s", k, interp.ToInspect(*v, &k))
-			}
+	res := make([]*regexp.Regexp, 2)
+	for i := range res {
+		pattern := ""
+		if len(args) > i+1 {
+			pattern = args[i+1]
+		}
+		var err error
+		if res[i], err = regexp.Compile(pattern); err != nil {
+			gub.Errmsg("Bad regular expression %s: %s", pattern, err)
+			return
 		}
-	} else {
-		// This doesn't work and I don't know how to fix it.
-		for i:=1; i<=argc; i++ {
-			vv := ssa2.NewConst(exact.MakeString(args[i]),
-				types.Typ[types.String], token.NoPos, token.NoPos)
-			// fmt.Println(vv, "vs", interp.ToInspect(vv))
-			v, ok := gub.CurFrame().I().Globals()[vv]
-			if ok {
-				gub.Msg("%s: %s", vv, interp.ToInspect(*v, nil))
+	}
+	pkgRe, nameRe := res[0], res[1]
+	pkgs := gub.Program().AllPackages()
+	sort.Sort(byPkgPath(pkgs))
+	globals := gub.CurFrame().I().Globals()
+	found := false
+	for _, pkg := range pkgs {
+		if !pkgRe.MatchString(pkg.Object.Path()) &&
+			!pkgRe.MatchString(pkg.Object.Name()) {
+			continue
+		}
+		var names []string
+		for name, mem := range pkg.Members {
+			if _, ok := mem.(*ssa2.Global); ok && nameRe.MatchString(name) {
+				names = append(names, name)
 			}
 		}
-
-		// This is ugly, but I don't know how to turn a string into
-		// a ssa2.Value.
-		globals := make(map[string]*interp.Value)
-		for k, v := range gub.CurFrame().I().Globals() {
-			globals[fmt.Sprintf("%s", k)] = v
+		if len(names) == 0 {
+			continue
 		}
-
-		for i:=1; i<=argc; i++ {
-			vv := args[i]
-			v, ok := globals[vv]
-			if ok {
-				gub.Msg("%s: %s", vv, interp.ToInspect(*v, nil))
+		found = true
+		sort.Strings(names)
+		gub.Section("package %s", pkg.Object.Path())
+		for _, name := range names {
+			g := pkg.Var(name)
+			t := g.Type().(*types.Pointer).Elem()
+			v := globals[g]
+			// FIXME: figure out why reflect.lookupCache causes
+			// an panic on a nil pointer or invalid address
+			if v == nil || g.String() == "reflect.lookupCache" {
+				gub.Msg("\t%s %s: <no value>", name, t)
+				continue
 			}
+			gub.Msg("\t%s %s = %s", name, t, gub.FormatValue(*v, t))
 		}
 	}
+	if !found {
+		gub.Msg("No package variables found")
+	}
 }
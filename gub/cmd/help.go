@@ -23,6 +23,10 @@ name. For example 'help up' gives help on the 'up' debugger command.
 
 If a category name is given, a list of commands in that category is
 shown. For a list of categories, enter "help categories".
+
+Commands can be abbreviated to any unique prefix of their name, for
+example "disa" for "disassemble" or "cond" for "condition", except
+that a word naming a variable in scope shows the variable.
 `,
 
 		Min_args: 0,
@@ -30,7 +34,6 @@ shown. For a list of categories, enter "help categories".
 	}
 	gub.AddToCategory("support", name)
 	gub.AddAlias("?", name)
-	// Down the line we'll have abbrevs
 	gub.AddAlias("h", name)
 }
 
@@ -59,6 +62,9 @@ func HelpCommand(args []string) {
 				gub.Msg("\t %s", k)
 			}
 		} else if info := gub.Cmds[cmd]; info != nil {
+			if aliasArgs := gub.AliasArgs[what]; aliasArgs != "" {
+				gub.Msg("%s is an alias for: %s %s\n", what, cmd, aliasArgs)
+			}
 			if len(args) > 2 {
 				if info.SubcmdMgr != nil {
 					gub.HelpSubCommand(info.SubcmdMgr, args)
@@ -78,6 +84,9 @@ func HelpCommand(args []string) {
 			mems := strings.TrimRight(columnize.Columnize(cmds, opts),
 				"\n")
 			gub.Msg(mems)
+		} else if candidates := gub.CmdCandidates(what); len(candidates) > 1 {
+			gub.Errmsg("Ambiguous command \"%s\": %s.", what,
+				strings.Join(candidates, ", "))
 		} else {
 			gub.Errmsg("Can't find help for %s", what)
 		}
@@ -0,0 +1,69 @@
+// Copyright 2015 Rocky Bernstein.
+
+// history command
+
+package gubcmd
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	name := "history"
+	gub.Cmds[name] = &gub.CmdInfo{
+		Fn: HistoryCommand,
+		Help: `history [*count*|*string*]
+
+Show the last *count* commands entered, 10 by default, oldest first,
+each with its number in the history. If a string is given instead,
+show the commands containing it.
+
+The history is kept between runs of gub in the file .gub_history in
+your home directory. At the prompt, Ctrl-R searches backward through
+it as you type; press Ctrl-R again for an older match, RET to run the
+command found, or Ctrl-G to give up.
+
+Examples:
+   history       # the last 10 commands
+   history 30    # the last 30 commands
+   history break # the commands containing "break"
+`,
+		Min_args: 0,
+		Max_args: -1,
+	}
+	gub.AddToCategory("support", name)
+}
+
+// HistoryCommand implements the debugger command:
+//    history [count|string]
+// which shows recently entered commands.
+func HistoryCommand(args []string) {
+	count := 10
+	var found []int
+	if len(args) > 1 {
+		if _, err := strconv.Atoi(args[1]); err == nil && len(args) == 2 {
+			count, err = gub.GetInt(args[1], "count", 1, 0)
+			if err != nil { return }
+		} else {
+			s := strings.TrimSpace(gub.CmdArgstr)
+			found = gub.HistorySearch(s)
+			if len(found) == 0 {
+				gub.Errmsg("No command in the history contains \"%s\"", s)
+				return
+			}
+		}
+	}
+	if found == nil {
+		for i := len(gub.History) - count; i < len(gub.History); i++ {
+			if i >= 0 {
+				found = append(found, i+1)
+			}
+		}
+	}
+	for _, n := range found {
+		gub.Msg("%5d  %s", n, strings.TrimSpace(gub.History[n-1]))
+	}
+}
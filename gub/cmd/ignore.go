@@ -0,0 +1,54 @@
+// Copyright 2015 Rocky Bernstein.
+// Debugger ignore command
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	name := "ignore"
+	gub.Cmds[name] = &gub.CmdInfo{
+		Fn: IgnoreCommand,
+		Help: `ignore *bpnum* *count*
+
+Set the ignore count of breakpoint *bpnum* to *count*. The next
+*count* times the breakpoint is hit, execution doesn't stop. Each such
+hit still counts as a hit and decrements the ignore count. A *count*
+of 0 makes the breakpoint stop the next time it is hit.
+
+See also "info break", "condition", "enable", and "disable".
+`,
+
+		Min_args: 2,
+		Max_args: 2,
+	}
+	gub.AddToCategory("breakpoints", name)
+}
+
+// IgnoreCommand implements the debugger command:
+//    ignore *bpnum* *count*
+// which sets the number of times breakpoint *bpnum* is passed over
+// before it stops.
+//
+// See also "info break", "condition", "enable", and "disable".
+func IgnoreCommand(args []string) {
+	bpnum, err := gub.GetInt(args[1], "breakpoint number", 0, len(gub.Breakpoints)-1)
+	if err != nil { return }
+	if !gub.BreakpointExists(bpnum) {
+		gub.Errmsg("Breakpoint %d doesn't exist", bpnum)
+		return
+	}
+	count, err := gub.GetInt(args[2], "ignore count", 0, 0)
+	if err != nil { return }
+	gub.Breakpoints[bpnum].Ignore = count
+	switch count {
+	case 0:
+		gub.Msg("Will stop next time breakpoint %d is reached.", bpnum)
+	case 1:
+		gub.Msg("Will ignore next crossing of breakpoint %d.", bpnum)
+	default:
+		gub.Msg("Will ignore next %d crossings of breakpoint %d.", count, bpnum)
+	}
+}
@@ -44,6 +44,11 @@ Status of user-settable breakpoints.
 // The "Where" column indicates where the breakpoint is located.
 // Status of user-settable breakpoints.
 func InfoBreakpointSubcmd(args [] string) {
+	if gub.JSONOutput() {
+		// With none, the list is empty.
+		infoBreakpointJSON(args)
+		return
+	}
 	if gub.IsBreakpointEmpty() {
 		gub.Msg("No breakpoints set")
 		return
@@ -51,6 +56,7 @@ func InfoBreakpointSubcmd(args [] string) {
 	bpLen := len(gub.Breakpoints)
 	if bpLen - gub.BrkptsDeleted == 0 {
 		gub.Msg("No breakpoints.")
+		return
 	}
 	if len(args) > 2 {
 		headerShown := false
@@ -76,3 +82,29 @@ func InfoBreakpointSubcmd(args [] string) {
 		}
 	}
 }
+
+// infoBreakpointJSON is "info breakpoint" for "set output json".
+func infoBreakpointJSON(args []string) {
+	var bps []*gub.Breakpoint
+	if len(args) > 2 {
+		for _, num := range args[2:] {
+			bpNum, err := gub.GetInt(num, "breakpoint number", 0,
+				len(gub.Breakpoints)-1)
+			if err != nil {
+				continue
+			}
+			if bp := gub.BreakpointFindById(bpNum); bp != nil {
+				bps = append(bps, bp)
+			} else {
+				gub.Errmsg("Breakpoint %d not found.", bpNum)
+			}
+		}
+	} else {
+		for _, bp := range gub.Breakpoints {
+			if !bp.Deleted {
+				bps = append(bps, bp)
+			}
+		}
+	}
+	gub.BreakpointsJSON(bps)
+}
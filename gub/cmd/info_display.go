@@ -0,0 +1,49 @@
+// Copyright 2015 Rocky Bernstein.
+// Debugger info display command
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	parent := "info"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: InfoDisplaySubcmd,
+		Help: `info display
+
+List the expressions shown each time the program stops.
+
+See also "display" and "undisplay".
+`,
+		Min_args: 0,
+		Max_args: 0,
+		Short_help: "Expressions to show when the program stops",
+		Name: "display",
+	})
+}
+
+// InfoDisplaySubcmd implements the debugger command:
+//   info display
+// which lists the expressions added by "display".
+//
+// See also "display" and "undisplay".
+func InfoDisplaySubcmd(args []string) {
+	shown := 0
+	for _, d := range gub.Displays {
+		if d.Deleted { continue }
+		if shown == 0 {
+			gub.Section("Num Fmt Expression")
+		}
+		format := "   "
+		if d.Format != "" {
+			format = "/" + d.Format + " "
+		}
+		gub.Msg("%3d %s %s", d.Id, format, d.Expr)
+		shown ++
+	}
+	if shown == 0 {
+		gub.Msg("There are no auto-display expressions now.")
+	}
+}
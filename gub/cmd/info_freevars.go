@@ -0,0 +1,39 @@
+// Copyright 2015 Rocky Bernstein.
+// Debugger info freevars command
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	parent := "info"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: InfoFreevarsSubcmd,
+		Help: `info freevars
+
+Show the free variables of the closure the selected frame is running,
+that is the variables of enclosing functions that it uses, along with
+their current values.
+
+See also "locals" and "environment".
+`,
+		Min_args: 0,
+		Max_args: 0,
+		Short_help: "Show free variables of the current closure",
+		Name: "freevars",
+	})
+}
+
+// InfoFreevarsSubcmd implements the debugger command:
+//   info freevars
+// which shows the free variables of the current closure.
+//
+// See also "locals" and "environment".
+func InfoFreevarsSubcmd(args []string) {
+	fr := gub.CurFrame()
+	if !gub.PrintFreeVars(fr) {
+		gub.Msg("Function %s has no free variables", fr.Fn().Name())
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright 2015 Rocky Bernstein.
+// Debugger info functions command
+
+package gubcmd
+
+import (
+	"go/token"
+	"regexp"
+	"sort"
+
+	"github.com/rocky/ssa-interp"
+	"github.com/rocky/ssa-interp/gub"
+	"github.com/rocky/ssa-interp/ssautil"
+)
+
+func init() {
+	parent := "info"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: InfoFunctionsSubcmd,
+		Help: `info functions [*regexp* [*package*]]
+
+List the functions and methods of the program along with where they
+are defined. With *regexp*, only those whose fully-qualified name
+matches *regexp* are listed. With *package*, only those in that package
+are listed; *package* is a package name like "main" or an import path.
+
+See also "info types", "info variables", and "rbreak".
+`,
+		Min_args: 0,
+		Max_args: 2,
+		Short_help: "List functions, optionally matching a regexp",
+		Name: "functions",
+	})
+}
+
+// A member is a named thing of the program that "info functions",
+// "info types", or "info variables" lists.
+type member struct {
+	name string
+	pos  token.Pos
+}
+
+type byMemberName []member
+
+func (s byMemberName) Len() int           { return len(s) }
+func (s byMemberName) Less(i, j int) bool { return s[i].name < s[j].name }
+func (s byMemberName) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+// memberFilter returns a function which tests whether a member named
+// name of package pkg is selected by the regexp and package given in
+// args.
+func memberFilter(args []string) func(name string, pkg *ssa2.Package) bool {
+	re := regexp.MustCompile("")
+	if len(args) > 2 {
+		var err error
+		if re, err = regexp.Compile(args[2]); err != nil {
+			gub.Errmsg("Bad regular expression %s: %s", args[2], err)
+			return nil
+		}
+	}
+	pkgName := ""
+	if len(args) > 3 {
+		pkgName = args[3]
+	}
+	return func(name string, pkg *ssa2.Package) bool {
+		if pkgName != "" {
+			if pkg == nil ||
+				(pkg.Object.Name() != pkgName && pkg.Object.Path() != pkgName) {
+				return false
+			}
+		}
+		return re.MatchString(name)
+	}
+}
+
+// printMembers lists members, sorted by name, with their positions.
+func printMembers(what string, members []member) {
+	if len(members) == 0 {
+		gub.Msg("No %s found", what)
+		return
+	}
+	sort.Sort(byMemberName(members))
+	fset := gub.Program().Fset
+	for _, m := range members {
+		gub.Msg("%s at %s", m.name, ssa2.FmtPos(fset, m.pos))
+	}
+}
+
+// InfoFunctionsSubcmd implements the debugger command:
+//   info functions [*regexp* [*package*]]
+// which lists functions and methods.
+//
+// See also "info types" and "info variables".
+func InfoFunctionsSubcmd(args []string) {
+	match := memberFilter(args)
+	if match == nil {
+		return
+	}
+	members := make([]member, 0)
+	for fn := range ssautil.AllFunctions(gub.Program()) {
+		if fn.Synthetic != "" {
+			continue
+		}
+		name := fn.String()
+		if match(name, fn.Pkg) {
+			members = append(members, member{name, fn.Pos()})
+		}
+	}
+	printMembers("functions", members)
+}
@@ -0,0 +1,57 @@
+// Copyright 2015 Rocky Bernstein.
+// Debugger info goroutines command
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+	"github.com/rocky/ssa-interp/interp"
+)
+
+func init() {
+	parent := "info"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: InfoGoroutinesSubcmd,
+		Help: `info goroutines
+
+List each goroutine of the program along with what it is doing:
+running, blocked in a channel send or receive, in a select or on a
+semaphore (a locked sync.Mutex, WaitGroup.Wait and so on), sleeping,
+waiting for a process to finish, or finished. The function each
+goroutine is in and its position are shown too. The goroutine of the selected frame is marked
+with "*".
+
+See also "goroutines" which shows goroutine stacks.
+`,
+		Min_args: 0,
+		Max_args: 0,
+		Short_help: "List goroutines and their states",
+		Name: "goroutines",
+	})
+}
+
+// InfoGoroutinesSubcmd implements the debugger command:
+//   info goroutines
+// which lists the goroutines with their states and positions.
+//
+// See also "goroutines".
+func InfoGoroutinesSubcmd(args []string) {
+	goTops := interp.GetInterpreter().GoTops()
+	curGoNum := gub.CurFrame().GoNum()
+	for goNum, goTop := range goTops {
+		mark := " "
+		if goNum == curGoNum {
+			mark = "*"
+		}
+		state := goTop.State()
+		fr := goTop.Fr
+		if fr == nil {
+			gub.Msg("%s %3d %-12s", mark, goNum, state)
+		} else if state == interp.GoFinished {
+			gub.Msg("%s %3d %-12s %s", mark, goNum, state, fr.Fn().Name())
+		} else {
+			gub.Msg("%s %3d %-12s %s at %s", mark, goNum, state,
+				fr.Fn().Name(), fr.PositionRange())
+		}
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2015 Rocky Bernstein.
+
+// info line
+//
+// Shows which SSA instructions a source line turned into
+
+package gubcmd
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	parent := "info"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: InfoLineSubcmd,
+		Help: `info line [*location*]
+
+Shows what a source line turned into: the places on it the debugger
+can stop at, each with its trace event and the function, block and
+instruction of it, and the ranges of SSA instructions that come from
+the line. A stopping location in a block that can't be reached from
+the start of its function is pointed out, since a breakpoint there is
+never hit.
+
+*location* is [*file*:]*line* or a function name. Without one, the
+line we are stopped at is used.
+
+Examples:
+   info line
+   info line 12
+   info line gcd.go:12
+   info line main.gcd
+
+See also "disassemble" and "breakpoint".
+`,
+		Min_args: 0,
+		Max_args: 1,
+		Short_help: "SSA instructions of a source line",
+		Name: "line",
+	})
+}
+
+func InfoLineSubcmd(args []string) {
+	position := gub.CurFrame().Position()
+	filename, line := position.Filename, position.Line
+	if len(args) == 3 {
+		loc := args[2]
+		if fn := gub.GetFunction(loc); fn != nil {
+			if !fn.Pos().IsValid() {
+				gub.Errmsg("Function %s has no source position", loc)
+				return
+			}
+			position = gub.Program().Fset.Position(fn.Pos())
+			filename, line = position.Filename, position.Line
+		} else {
+			lineStr := loc
+			if colon := strings.LastIndex(loc, ":"); colon != -1 {
+				filename, lineStr = loc[:colon], loc[colon+1:]
+			}
+			var err error
+			if line, err = strconv.Atoi(lineStr); err != nil {
+				gub.Errmsg("Expecting a function name or [file:]line; got %s", loc)
+				return
+			}
+		}
+	}
+	if filename == "" {
+		gub.Errmsg("Can't figure out the current file; give a file name")
+		return
+	}
+	gub.LineInfo(filename, line)
+}
@@ -0,0 +1,57 @@
+// Copyright 2015 Rocky Bernstein.
+// Debugger info memory command
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+	"github.com/rocky/ssa-interp/interp"
+)
+
+func init() {
+	parent := "info"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: InfoMemorySubcmd,
+		Help: `info memory
+
+Show the memory the program has allocated: the bytes and objects
+still in use, those allocated in all, the objects reclaimed, and the
+number of garbage collections. Sizes are those a compiled program
+would use. Objects are reclaimed when the garbage collector runs and
+finds them unreachable. Maps and channels count toward what was
+allocated but not toward what is in use; the entries added to a map
+count as in use until they are deleted. The heap limit, if one is
+set, is shown too.
+
+Memory isn't counted until it is asked for, as counting slows the
+program down. Unless a heap limit is set or tortoise was given
+-heapstats, the first "info memory" starts the counting, and what
+was allocated before then isn't included.
+
+See also "set heap-limit".
+`,
+		Min_args: 0,
+		Max_args: 0,
+		Short_help: "Show the program's memory use",
+		Name: "memory",
+	})
+}
+
+// InfoMemorySubcmd implements the debugger command:
+//   info memory
+// which shows how much memory the program has allocated and how much
+// of it is still in use.
+func InfoMemorySubcmd(args []string) {
+	if !interp.HeapAccounting() {
+		interp.SetHeapAccounting(true)
+		gub.Msg("Memory wasn't being counted; counting from now on.")
+	}
+	s := interp.ReadHeapStats()
+	gub.Msg("In use:      %d bytes in %d objects", s.HeapAlloc, s.HeapObjects)
+	gub.Msg("Allocated:   %d bytes in %d objects", s.TotalAlloc, s.Mallocs)
+	gub.Msg("Reclaimed:   %d objects", s.Frees)
+	gub.Msg("Collections: %d", s.NumGC)
+	if limit := interp.HeapLimit(); limit != 0 {
+		gub.Msg("Limit:       %d bytes", limit)
+	}
+}
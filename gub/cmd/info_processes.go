@@ -0,0 +1,50 @@
+// Copyright 2015 Rocky Bernstein.
+// Debugger info processes command
+
+package gubcmd
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/rocky/ssa-interp/gub"
+	"github.com/rocky/ssa-interp/interp"
+)
+
+func init() {
+	parent := "info"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: InfoProcessesSubcmd,
+		Help: `info processes
+
+List the processes the program has started, say with os/exec, in
+the order they were started: the process ID, whether the process is
+still running or the exit status it finished with, and the command
+it runs. A process shows as running until the program has waited for
+it.
+`,
+		Min_args: 0,
+		Max_args: 0,
+		Short_help: "List processes the program has started",
+		Name: "processes",
+	})
+}
+
+// InfoProcessesSubcmd implements the debugger command:
+//   info processes
+// which lists the processes the program has started.
+func InfoProcessesSubcmd(args []string) {
+	procs := interp.Processes()
+	if len(procs) == 0 {
+		gub.Msg("The program hasn't started any processes")
+		return
+	}
+	gub.Section("  PID  State     Command")
+	for _, p := range procs {
+		state := "running"
+		if p.Exited {
+			state = "exit " + strconv.Itoa(p.Status)
+		}
+		gub.Msg("%5d  %-8s  %s", p.Pid, state, strings.Join(p.Args, " "))
+	}
+}
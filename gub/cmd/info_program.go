@@ -9,6 +9,7 @@ package gubcmd
 import (
 	"github.com/rocky/ssa-interp"
 	"github.com/rocky/ssa-interp/gub"
+	"github.com/rocky/ssa-interp/interp"
 )
 
 func init() {
@@ -24,6 +25,9 @@ Prints information about the program including:
 *  stop event
 *  source-code position
 
+Once the program has finished, or is stopped in a call of os.Exit,
+its exit status and how it finished are given too.
+
 See "info pc" for information concerning negative PC values.
 `,
 		Min_args: 0,
@@ -43,8 +47,13 @@ See "info pc" for information concerning negative PC values.
 //    source-code position
 func InfoProgramSubcmd(args []string) {
 	if gub.TraceEvent == ssa2.PROGRAM_TERMINATION {
-		gub.Msg("program stop event: %s", ssa2.Event2Name[gub.TraceEvent])
-		return
+		status, reason := interp.ExitStatus()
+		gub.Msg("exit status: %d (%s)", status, reason)
+		if !interp.ExitTrapped() {
+			gub.Msg("program stop event: %s", ssa2.Event2Name[gub.TraceEvent])
+			return
+		}
+		gub.Msg("stopped in a call of os.Exit")
 	}
 
 	fr := gub.CurFrame()
@@ -0,0 +1,47 @@
+// Copyright 2015 Rocky Bernstein.
+// Debugger info program-output command
+
+package gubcmd
+
+import (
+	"strings"
+
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	parent := "info"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: InfoProgramOutputSubcmd,
+		Help: `info program-output [clear]
+
+Show the output of the program kept in the buffer, as set by
+"set program-output buffer". With "clear" the buffer is emptied
+afterwards.
+`,
+		Min_args: 0,
+		Max_args: 1,
+		Short_help: "Show the program's buffered output",
+		Name: "program-output",
+	})
+}
+
+func InfoProgramOutputSubcmd(args []string) {
+	clear := false
+	if len(args) == 3 {
+		if args[2] != "clear" {
+			gub.Errmsg("Expecting clear; got %s", args[2])
+			return
+		}
+		clear = true
+	}
+	out := gub.ProgramBuffer(clear)
+	if out == "" {
+		gub.Msg("No program output is buffered.")
+		return
+	}
+	gub.MsgNoCr("%s", out)
+	if !strings.HasSuffix(out, "\n") {
+		gub.Msg("")
+	}
+}
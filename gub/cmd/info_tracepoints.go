@@ -0,0 +1,65 @@
+// Copyright 2015 Rocky Bernstein.
+// Debugger info tracepoints command
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	parent := "info"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: InfoTracepointsSubcmd,
+		Help: `info tracepoints [num...]
+
+Show what has been collected by tracepoints, oldest hit first. If
+tracepoint numbers are given, only hits of those tracepoints are
+shown.
+
+Only the most recent hits are kept.
+
+See also "trace".
+`,
+		Min_args: 0,
+		Max_args: -1,
+		Short_help: "Data collected by tracepoints",
+		Name: "tracepoints",
+	})
+}
+
+// InfoTracepointsSubcmd implements the debugger command:
+//   info tracepoints [num...]
+// which shows the hits recorded by tracepoints.
+//
+// See also "trace".
+func InfoTracepointsSubcmd(args []string) {
+	show := make(map[int]bool)
+	for _, num := range args[2:] {
+		bpnum, err := gub.GetInt(num, "tracepoint number", 0,
+			len(gub.Breakpoints)-1)
+		if err != nil { return }
+		if !gub.Breakpoints[bpnum].Trace {
+			gub.Errmsg("Breakpoint %d is not a tracepoint", bpnum)
+			return
+		}
+		show[bpnum] = true
+	}
+	if gub.TraceHitsDropped > 0 {
+		gub.Msg("(%d earlier hits dropped)", gub.TraceHitsDropped)
+	}
+	shown := 0
+	for _, hit := range gub.TraceHits {
+		if len(show) > 0 && !show[hit.Bpnum] { continue }
+		gub.Msg("%3d %s goroutine %d at %s", hit.Bpnum, hit.Fn, hit.GoNum,
+			hit.Loc)
+		exprs := gub.Breakpoints[hit.Bpnum].Collect
+		for i, v := range hit.Values {
+			gub.Msg("\t%s = %s", exprs[i], v)
+		}
+		shown ++
+	}
+	if shown == 0 {
+		gub.Msg("No tracepoint hits recorded")
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright 2015 Rocky Bernstein.
+// Debugger info types command
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp"
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	parent := "info"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: InfoTypesSubcmd,
+		Help: `info types [*regexp* [*package*]]
+
+List the named types of the program along with where they are
+defined. With *regexp*, only those whose fully-qualified name matches
+*regexp* are listed. With *package*, only those in that package are
+listed; *package* is a package name like "main" or an import path.
+
+See also "info functions" and "info variables".
+`,
+		Min_args: 0,
+		Max_args: 2,
+		Short_help: "List named types, optionally matching a regexp",
+		Name: "types",
+	})
+}
+
+// InfoTypesSubcmd implements the debugger command:
+//   info types [*regexp* [*package*]]
+// which lists package-level named types.
+//
+// See also "info functions" and "info variables".
+func InfoTypesSubcmd(args []string) {
+	match := memberFilter(args)
+	if match == nil {
+		return
+	}
+	members := make([]member, 0)
+	for _, pkg := range gub.Program().AllPackages() {
+		for _, mem := range pkg.Members {
+			if t, ok := mem.(*ssa2.Type); ok {
+				name := t.Type().String()
+				if match(name, pkg) {
+					members = append(members, member{name, t.Pos()})
+				}
+			}
+		}
+	}
+	printMembers("types", members)
+}
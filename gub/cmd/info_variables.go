@@ -0,0 +1,53 @@
+// Copyright 2015 Rocky Bernstein.
+// Debugger info variables command
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp"
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	parent := "info"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: InfoVariablesSubcmd,
+		Help: `info variables [*regexp* [*package*]]
+
+List the package-level variables of the program along with where they
+are defined. With *regexp*, only those whose fully-qualified name
+matches *regexp* are listed. With *package*, only those in that package
+are listed; *package* is a package name like "main" or an import path.
+
+See also "info functions", "info types", and "globals".
+`,
+		Min_args: 0,
+		Max_args: 2,
+		Short_help: "List package variables, optionally matching a regexp",
+		Name: "variables",
+	})
+}
+
+// InfoVariablesSubcmd implements the debugger command:
+//   info variables [*regexp* [*package*]]
+// which lists package-level variables.
+//
+// See also "info functions" and "info types".
+func InfoVariablesSubcmd(args []string) {
+	match := memberFilter(args)
+	if match == nil {
+		return
+	}
+	members := make([]member, 0)
+	for _, pkg := range gub.Program().AllPackages() {
+		for _, mem := range pkg.Members {
+			if g, ok := mem.(*ssa2.Global); ok {
+				name := g.String()
+				if match(name, pkg) {
+					members = append(members, member{name, g.Pos()})
+				}
+			}
+		}
+	}
+	printMembers("variables", members)
+}
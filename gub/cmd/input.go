@@ -0,0 +1,69 @@
+// Copyright 2015 Rocky Bernstein.
+
+// input command
+
+package gubcmd
+
+import (
+	"io"
+	"strings"
+
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	name := "input"
+	gub.Cmds[name] = &gub.CmdInfo{
+		Fn: InputCommand,
+		Help: `input *text*
+input <<*word*
+
+Give the program a line of input, *text*, to read from its standard
+input once it comes from the buffer; see "set program-input". With
+<<*word*, the lines that follow, up to one saying just *word*, are
+given instead, as with a shell here-document. This is handy in a file
+of commands run in batch mode.
+
+Examples:
+
+   input 42
+   input <<EOF
+   first line
+   second line
+   EOF
+`,
+		Min_args: 1,
+		Max_args: -1,
+	}
+	gub.AddToCategory("running", name)
+}
+
+// InputCommand implements the debugger command:
+//    input *text*
+//    input <<*word*
+// which adds to the program's buffered input.
+func InputCommand(args []string) {
+	text := gub.CmdArgstr + "\n"
+	if len(args) == 2 && strings.HasPrefix(args[1], "<<") && len(args[1]) > 2 {
+		word := args[1][2:]
+		text = ""
+		for {
+			line, err := gub.ReadCommandLine(">")
+			if err != nil && (err != io.EOF || line == "") {
+				gub.Errmsg("No line saying %s; input ignored", word)
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			if strings.TrimSpace(line) == word {
+				break
+			}
+			text += line + "\n"
+		}
+	}
+	n := gub.AddProgramInput(text)
+	if gub.ProgramInputSource() != "buffer" {
+		gub.Msg("%d bytes of input waiting; the program reads them after \"set program-input buffer\".", n)
+	} else {
+		gub.Msg("%d bytes of input waiting.", n)
+	}
+}
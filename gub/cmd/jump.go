@@ -3,29 +3,94 @@
 
 package gubcmd
 
-import "github.com/rocky/ssa-interp/gub"
+import (
+	"strconv"
+	"strings"
+
+	"github.com/rocky/ssa-interp"
+	"github.com/rocky/ssa-interp/gub"
+	"github.com/rocky/ssa-interp/interp"
+)
 
 func init() {
 	name := "jump"
 	gub.Cmds[name] = &gub.CmdInfo{
 		Fn: JumpCommand,
-		Help: `jump *num*
+		Help: `jump [*file*:]*line* | instruction *num*
+
+Jumps to the statement at *line* in the current function and stops
+there, without running the statements in between. This can be used to
+run a statement again or to skip over code. The statements jumped over
+don't run, so variables they would have set keep their current values.
+Since that can leave things inconsistent, you are asked to confirm.
+A jump into a block that merges values from the blocks before it, such
+as the head of a loop, is refused unless coming from one of those
+blocks.
+
+"jump instruction *num*" jumps to instruction *num* inside the current
+basic block.
 
-Jumps to instruction *num* inside the current basic block.
+Jumping is done only in the most recent frame.
 `,
 		Min_args: 1,
-		Max_args: 1,
+		Max_args: 2,
 	}
 	gub.AddToCategory("running", name)
 }
 
+// JumpCommand implements the debugger command:
+//    jump [*file*:]*line* | instruction *num*
+// which moves where execution resumes in the current function to the
+// statement at *line*, or to instruction *num* of the current block.
 func JumpCommand(args []string) {
 	fr := gub.CurFrame()
-	b := gub.CurBlock()
-	ic, err := gub.GetInt(args[1],
-		"instruction number", 0, len(b.Instrs)-1)
+	if fr != gub.TopFrame() {
+		gub.Errmsg("Can only jump in the most recent frame; use \"frame 0\" first")
+		return
+	}
+	if args[1] == "instruction" {
+		if len(args) != 3 {
+			gub.Errmsg("Expecting an instruction number")
+			return
+		}
+		b := gub.CurBlock()
+		ic, err := gub.GetInt(args[2],
+			"instruction number", 0, len(b.Instrs)-1)
+		if err != nil { return }
+		// compensate for interpreter loop which does ic++ at end of loop body
+		fr.SetPC(ic-1)
+		gub.InCmdLoop = false
+		return
+	}
+	filename := fr.Position().Filename
+	lineStr  := args[1]
+	if colon := strings.LastIndex(lineStr, ":"); colon != -1 {
+		filename = lineStr[:colon]
+		lineStr  = lineStr[colon+1:]
+	}
+	line, err := gub.GetInt(lineStr, "line number", 1, 0)
 	if err != nil { return }
-	// compensate for interpreter loop which does ic++ at end of loop body
-	fr.SetPC(ic-1)
+	fn := fr.Fn()
+	b, ic, ok := gub.StmtLookup(fn, filename, line)
+	if !ok {
+		gub.Errmsg("No statement of function %s at %s:%d", fn, filename, line)
+		return
+	}
+	pos := fr.Fset().Position(b.Instrs[ic].(*ssa2.Trace).Start)
+	if pos.Filename != fr.Position().Filename {
+		gub.Errmsg("Can only jump within function %s", fn)
+		return
+	}
+	if err := gub.CanResumeAt(fr, b, ic); err != nil {
+		gub.Errmsg("Can't jump to line %d: %s", line, err)
+		return
+	}
+	if !gub.Confirm("Jump to line " + strconv.Itoa(line) + "?", true) {
+		gub.Msg("Jump not confirmed")
+		return
+	}
+	// Resume at the statement's trace instruction so we stop there.
+	gub.SetResumePoint(fr, b, ic)
+	interp.SetStepIn(fr)
 	gub.InCmdLoop = false
 }
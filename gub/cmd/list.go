@@ -0,0 +1,99 @@
+// Copyright 2015 Rocky Bernstein.
+// Debugger list command
+
+package gubcmd
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	name := "list"
+	gub.Cmds[name] = &gub.CmdInfo{
+		Fn: ListCommand,
+		Help: `list [*function* | [*file*:]*line* | . | -]
+
+Show lines of source text. With no argument, show the lines after
+those last listed, or, if nothing has been listed since the program
+stopped, the lines around where it is stopped. "list -" shows the lines
+before those last listed, and "list ." the lines around the selected
+frame's position.
+
+With a function or line number, show the lines around that function or
+line. The line the program is stopped at is marked with "=>".
+
+Examples:
+
+   list
+   list -
+   list gcd
+   list 20
+   list gcd.go:20
+
+See also "format" which shows the source code for a function from its
+syntax tree.
+`,
+		Min_args: 0,
+		Max_args: 1,
+	}
+	gub.AddToCategory("files", name)
+	gub.AddAlias("l", name)
+}
+
+// ListCommand implements the debugger command:
+//    list [*function* | [*file*:]*line* | . | -]
+// which shows lines of source text.
+//
+// See also "format".
+func ListCommand(args []string) {
+	if len(args) == 1 {
+		gub.ListNext()
+		return
+	}
+	loc := args[1]
+	switch loc {
+	case "-":
+		gub.ListPrev()
+		return
+	case ".":
+		gub.ListHere()
+		return
+	}
+	if fn := gub.GetFunction(loc); fn != nil {
+		position := fn.Prog.Fset.Position(fn.Pos())
+		if !position.IsValid() {
+			gub.Errmsg("No source position for function %s", loc)
+			return
+		}
+		gub.ListCentered(position.Filename, position.Line)
+		return
+	}
+	filename := ""
+	lineStr  := loc
+	if colon := strings.LastIndex(lineStr, ":"); colon != -1 {
+		filename = lineStr[:colon]
+		lineStr  = lineStr[colon+1:]
+	}
+	line, err := strconv.Atoi(lineStr)
+	if err != nil {
+		gub.Errmsg("Expecting a function name or [file:]line; got %s", loc)
+		return
+	}
+	if filename == "" {
+		position := gub.CurFrame().Position()
+		if !position.IsValid() {
+			gub.Errmsg("Can't figure out the current file; give a file name")
+			return
+		}
+		filename = position.Filename
+	} else if fullname, ok := gub.FileLookup(filename); ok {
+		filename = fullname
+	} else {
+		gub.Errmsg("No source file named %s", filename)
+		return
+	}
+	gub.ListCentered(filename, line)
+}
@@ -14,7 +14,7 @@ func init() {
 		Help: `locals [*name*]
 
 show local variable information. If *name* is not given list
-all local variables.
+all local variables, and the free variables of a closure.
 
 See also "globals", "whatis", and "eval".
 `,
@@ -35,13 +35,19 @@ See also "globals", "whatis", and "eval".
 func LocalsCommand(args []string) {
 	argc := len(args) - 1
 	fr := gub.CurFrame()
-	if argc == 0 {
+	if argc == 0 && gub.JSONOutput() {
+		gub.LocalsJSON(fr)
+	} else if argc == 0 {
 		for i, _ := range fr.Locals() {
 			gub.PrintLocal(fr, uint(i), false)
 		}
 		for reg, v := range fr.Reg2Var {
 			gub.Msg("reg %s, var %s", reg, v)
 		}
+		if len(fr.Fn().FreeVars) > 0 {
+			gub.Section("Free variables:")
+			gub.PrintFreeVars(fr)
+		}
 	} else {
 		varname := args[1]
 		if gub.PrintIfLocal(fr, varname, false) {
@@ -16,6 +16,12 @@ func init() {
 Step one statement ignoring steps into function calls at this level.
 
 Sometimes this is called 'step over'.
+
+With "set step-mode line", all of the statements on the current line
+are stepped over.
+
+See "set step-defers" and "set step-goroutines" for stopping in
+deferred calls and new goroutines.
 `,
 		Min_args: 0,
 		Max_args: 0,
@@ -27,6 +33,9 @@ Sometimes this is called 'step over'.
 
 func NextCommand(args []string) {
 	interp.SetStepOver(gub.TopFrame())
+	if gub.StepMode == "line" {
+		gub.LineStepStart(gub.TopFrame())
+	}
 	gub.Msg("Step over...")
 	gub.LastCommand = "next " + gub.CmdArgstr
 	gub.InCmdLoop = false
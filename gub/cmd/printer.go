@@ -0,0 +1,104 @@
+// Copyright 2015 Rocky Bernstein.
+// Debugger printer command
+
+package gubcmd
+
+import (
+	"strings"
+
+	"github.com/rocky/ssa-interp/gub"
+	"github.com/rocky/ssa-interp/interp"
+)
+
+func init() {
+	name := "printer"
+	gub.Cmds[name] = &gub.CmdInfo{
+		Fn: PrinterCommand,
+		Help: `printer [*type* *method* | *type* "*format*", *field*... | delete *type*]
+
+Set how values of named type *type* are shown wherever values are
+shown, such as in "eval", "locals", and "display". *type* is given as
+*pkg*.*Type*, or just *Type* for a type of the current package.
+
+With a *method*, values are shown by calling that method, which must
+take no arguments and return one value. With a *format*, values are
+shown using fmt-style *format* on the values of the given fields of
+the value. A field can be a path like a.b to select a field of a
+field.
+
+"printer delete *type*" goes back to showing values of *type* in the
+normal way. Without arguments, list the printers that have been set.
+
+Examples:
+
+   printer time.Time String
+   printer main.Point "(%d, %d)", X, Y
+   printer delete main.Point
+
+See also "eval".
+`,
+		Min_args: 0,
+		Max_args: -1,
+	}
+	gub.AddToCategory("inspecting", name)
+}
+
+// PrinterCommand implements the debugger command:
+//    printer [*type* *method* | *type* "*format*", *field*... | delete *type*]
+// which sets how values of a named type are shown.
+//
+// See also "eval".
+func PrinterCommand(args []string) {
+	if len(args) == 1 {
+		names := interp.Printers()
+		if len(names) == 0 {
+			gub.Msg("No printers set")
+			return
+		}
+		for _, name := range names {
+			gub.Msg("%s: %s", name, gub.PrinterDescs[name])
+		}
+		return
+	}
+	if args[1] == "delete" {
+		if len(args) != 3 {
+			gub.Errmsg("Expecting a type name to delete the printer of")
+			return
+		}
+		t, err := gub.TypeLookup(args[2])
+		if err != nil {
+			gub.Errmsg("%s", err)
+			return
+		}
+		if !gub.PrinterDelete(t) {
+			gub.Errmsg("There is no printer for %s", t)
+		}
+		return
+	}
+	if len(args) < 3 {
+		gub.Errmsg("Expecting a method or format after the type name")
+		return
+	}
+	t, err := gub.TypeLookup(args[1])
+	if err != nil {
+		gub.Errmsg("%s", err)
+		return
+	}
+	// Use gub.CmdArgstr which preserves blanks inside quotes
+	rest := strings.TrimSpace(gub.CmdArgstr[len(args[1]):])
+	if strings.HasPrefix(rest, `"`) {
+		var format string
+		var fields []string
+		if format, fields, err = gub.ParseDprintf(rest); err == nil {
+			err = gub.PrinterFormatAdd(t, format, fields)
+		}
+	} else if len(args) == 3 {
+		err = gub.PrinterMethodAdd(t, args[2])
+	} else {
+		gub.Errmsg("Expecting a single method name, got %s", rest)
+		return
+	}
+	if err != nil {
+		gub.Errmsg("%s", err)
+	}
+}
@@ -6,7 +6,6 @@ package gubcmd
 import (
 	"os"
 	"strconv"
-	"code.google.com/p/go-gnureadline"
 	"github.com/rocky/ssa-interp/gub"
 )
 
@@ -46,9 +45,10 @@ func QuitCommand(args []string) {
 		}
 	}
 	gub.Msg("gub: That's all folks...")
+	gub.QuitSummary(rc)
 
-	// FIXME: determine under which conditions we've used term
-	gnureadline.Rl_reset_terminal(gub.Term)
+	// Save command history and reset the terminal.
+	gub.GnuReadLineTermination()
 
 	os.Exit(rc)
 
@@ -0,0 +1,91 @@
+// Copyright 2015 Rocky Bernstein.
+// Debugger rbreak command
+
+package gubcmd
+
+import (
+	"regexp"
+	"sort"
+
+	"github.com/rocky/ssa-interp"
+	"github.com/rocky/ssa-interp/gub"
+	"github.com/rocky/ssa-interp/interp"
+	"github.com/rocky/ssa-interp/ssautil"
+)
+
+func init() {
+	name := "rbreak"
+	gub.Cmds[name] = &gub.CmdInfo{
+		Fn: RbreakCommand,
+		Help: `rbreak *regexp*
+
+Set a breakpoint at the entry of every function whose fully-qualified
+name matches regular expression *regexp*. For example:
+
+   rbreak ^main\.     # all functions in package main
+   rbreak Print       # all functions with "Print" in their name
+
+Built-in external functions and functions without a body are skipped.
+
+See also "breakpoint", "info break", "enable", "disable", and "delete".
+`,
+
+		Min_args: 1,
+		Max_args: 1,
+	}
+	gub.AddToCategory("breakpoints", name)
+}
+
+// RbreakCommand implements the debugger command:
+//    rbreak *regexp*
+// which sets a breakpoint on every function whose fully-qualified
+// name matches *regexp*.
+//
+// See also "breakpoint", "info break", "enable", "disable", and "delete".
+func RbreakCommand(args []string) {
+	re, err := regexp.Compile(args[1])
+	if err != nil {
+		gub.Errmsg("Bad regular expression %s: %s", args[1], err)
+		return
+	}
+	fns := make([]*ssa2.Function, 0)
+	for fn := range ssautil.AllFunctions(gub.Program()) {
+		name := fn.String()
+		if len(fn.Blocks) == 0 || !re.MatchString(name) {
+			continue
+		}
+		if ext := interp.Externals()[name]; ext != nil {
+			continue
+		}
+		fns = append(fns, fn)
+	}
+	if len(fns) == 0 {
+		gub.Errmsg("No functions match %s", args[1])
+		return
+	}
+	sort.Sort(byName(fns))
+	for _, fn := range fns {
+		interp.SetFnBreakpoint(fn)
+		bp := &gub.Breakpoint {
+			Hits: 0,
+			Id: gub.BreakpointNext(),
+			Pos: fn.Pos(),
+			EndP: fn.EndP(),
+			Ignore: 0,
+			Kind: "Function",
+			Temp: false,
+			Enabled: true,
+		}
+		bpnum := gub.BreakpointAdd(bp)
+		gub.Msg(" Breakpoint %d set in function %s at %s", bpnum, fn,
+			ssa2.FmtRange(fn, fn.Pos(), fn.EndP()))
+	}
+	gub.Msg("%d breakpoints set", len(fns))
+}
+
+// byName sorts functions by their fully-qualified name.
+type byName []*ssa2.Function
+
+func (s byName) Len() int           { return len(s) }
+func (s byName) Less(i, j int) bool { return s[i].String() < s[j].String() }
+func (s byName) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
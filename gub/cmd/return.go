@@ -0,0 +1,75 @@
+// Copyright 2015 Rocky Bernstein.
+
+// return command
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+	"github.com/rocky/ssa-interp/interp"
+)
+
+func init() {
+	name := "return"
+	gub.Cmds[name] = &gub.CmdInfo{
+		Fn: ReturnCommand,
+		Help: `return [*expr* [, *expr* ...]]
+
+Make the current function return right away with the values of the
+given Go expressions, converted to its result types. Without
+expressions, the current values of named results are returned, or zero
+values for unnamed results.
+
+The rest of the function, including its deferred calls, doesn't run.
+Since that can leave things inconsistent, you are asked to confirm.
+Execution stops in the caller, as with "finish", and the values
+returned are shown.
+
+Returning is done only from the most recent frame.
+
+Examples:
+
+   return
+   return 0, nil
+   return n * 2, fmt.Errorf("bad")
+
+See also "finish".
+`,
+		Min_args: 0,
+		Max_args: -1,
+	}
+	gub.AddToCategory("running", name)
+}
+
+// ReturnCommand implements the debugger command:
+//    return [*expr* [, *expr* ...]]
+// which makes the current function return the values of the
+// expressions.
+func ReturnCommand(args []string) {
+	fr := gub.CurFrame()
+	if fr != gub.TopFrame() {
+		gub.Errmsg("Can only return from the most recent frame; use \"frame 0\" first")
+		return
+	}
+	var exprs []string
+	if len(args) > 1 {
+		var err error
+		// Don't use args, but gub.CmdArgstr which preserves blanks inside quotes
+		if exprs, err = gub.ParseExprList(gub.CmdArgstr); err != nil {
+			gub.Errmsg(err.Error())
+			return
+		}
+	}
+	values, err := gub.ReturnValues(fr, exprs)
+	if err != nil {
+		gub.Errmsg(err.Error())
+		return
+	}
+	if !gub.Confirm("Make "+fr.Fn().String()+" return now?", true) {
+		gub.Msg("Return not confirmed")
+		return
+	}
+	interp.ForceReturn(fr, values)
+	interp.SetStepOut(fr)
+	gub.InCmdLoop = false
+}
@@ -0,0 +1,46 @@
+// Copyright 2015 Rocky Bernstein.
+// Debugger reverse-continue command
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+	"github.com/rocky/ssa-interp/interp"
+)
+
+func init() {
+	name := "reverse-continue"
+	gub.Cmds[name] = &gub.CmdInfo{
+		Fn: ReverseContinueCommand,
+		Help: `reverse-continue
+
+Move back in the execution record of this goroutine to the most recent
+statement at which there is an enabled breakpoint. The location and
+the values parameters and local variables had then are shown.
+
+This needs execution recording turned on with "set record on". See
+"help reverse-step" for what moving back does and doesn't do.
+
+See also "reverse-step", "reverse-next", and "set record".
+`,
+		Min_args: 0,
+		Max_args: 0,
+	}
+	gub.AddToCategory("running", name)
+	gub.AddAlias("rc", name)
+}
+
+// ReverseContinueCommand implements the debugger command:
+//    reverse-continue
+// which moves back in the execution record to the last breakpoint
+// hit.
+//
+// See also "reverse-step", "reverse-next", and "set record".
+func ReverseContinueCommand(args []string) {
+	if !historyCheck() { return }
+	if !gub.HistoryBack(func(r *interp.ExecRecord, depth int) bool {
+		return gub.HistoryAtBreakpoint(r)
+	}) {
+		gub.Errmsg("No earlier breakpoint hit recorded")
+	}
+}
@@ -0,0 +1,47 @@
+// Copyright 2015 Rocky Bernstein.
+// Debugger reverse-next command
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+	"github.com/rocky/ssa-interp/interp"
+)
+
+func init() {
+	name := "reverse-next"
+	gub.Cmds[name] = &gub.CmdInfo{
+		Fn: ReverseNextCommand,
+		Help: `reverse-next
+
+Move back to the statement recorded before the current one in this
+goroutine, stepping back over function calls that were made. The
+location and the values parameters and local variables had then are
+shown.
+
+This needs execution recording turned on with "set record on". See
+"help reverse-step" for what moving back does and doesn't do.
+
+See also "reverse-step", "reverse-continue", and "set record".
+`,
+		Min_args: 0,
+		Max_args: 0,
+	}
+	gub.AddToCategory("running", name)
+	gub.AddAlias("rn", name)
+}
+
+// ReverseNextCommand implements the debugger command:
+//    reverse-next
+// which moves back one statement in the execution record, skipping
+// over the statements of calls.
+//
+// See also "reverse-step", "reverse-continue", and "set record".
+func ReverseNextCommand(args []string) {
+	if !historyCheck() { return }
+	if !gub.HistoryBack(func(r *interp.ExecRecord, depth int) bool {
+		return r.Depth <= depth
+	}) {
+		gub.Errmsg("No earlier statement recorded")
+	}
+}
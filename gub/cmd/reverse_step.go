@@ -0,0 +1,67 @@
+// Copyright 2015 Rocky Bernstein.
+// Debugger reverse-step command
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+	"github.com/rocky/ssa-interp/interp"
+)
+
+func init() {
+	name := "reverse-step"
+	gub.Cmds[name] = &gub.CmdInfo{
+		Fn: ReverseStepCommand,
+		Help: `reverse-step
+
+Move back to the statement recorded before the current one in this
+goroutine, entering function calls that were made. The location and
+the values parameters and local variables had then are shown.
+
+This needs execution recording turned on with "set record on" before
+running to here. When the statement was recorded by the function we
+are stopped in, that function's parameters, local variables and
+position are put back the way they were, and the program stops at the
+start of the statement again; going on runs it from there. Globals,
+and what is reached through pointers, slices, maps and channels, keep
+their present values. A statement recorded in a call that has since
+returned can only be looked at: its values are shown, while other
+commands still see the present state, and stepping or continuing
+resumes from the present.
+
+See also "reverse-next", "reverse-continue", and "set record".
+`,
+		Min_args: 0,
+		Max_args: 0,
+	}
+	gub.AddToCategory("running", name)
+	gub.AddAlias("rs", name)
+}
+
+// ReverseStepCommand implements the debugger command:
+//    reverse-step
+// which moves back one statement in the execution record.
+//
+// See also "reverse-next", "reverse-continue", and "set record".
+func ReverseStepCommand(args []string) {
+	if !historyCheck() { return }
+	if !gub.HistoryBack(func(r *interp.ExecRecord, depth int) bool {
+		return true
+	}) {
+		gub.Errmsg("No earlier statement recorded")
+	}
+}
+
+// historyCheck returns true if there is an execution record to move
+// back in, and reports why not otherwise.
+func historyCheck() bool {
+	if len(interp.Records()) == 0 {
+		if interp.Recording() {
+			gub.Errmsg("Nothing has been recorded yet")
+		} else {
+			gub.Errmsg("Execution recording is off; use \"set record on\"")
+		}
+		return false
+	}
+	return true
+}
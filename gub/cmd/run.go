@@ -14,22 +14,50 @@ func init() {
 	name := "run"
 	gub.Cmds[name] = &gub.CmdInfo{
 		Fn: RunCommand,
-		Help: `run
+		Help: `run [exec]
 
-Terminates program. If an exit code is given, that is the exit code
-for the program. Zero (normal termination) is used if no
-termintation code.
+Restarts the program from the beginning within this debugger session.
+Global variables are initialized again and main.main() is rerun.
+Breakpoints, catchpoints, skips, and settings are kept. Watchpoints
+refer to variables of the previous run, so set them again.
+
+This can only be done when stopped in the main goroutine, goroutine
+0; elsewhere use "run exec". Other goroutines can't be stopped, so any
+still running from the previous run keep going.
+
+With "exec", the debugger itself is restarted with the arguments it
+was originally given, so everything starts afresh. See "show args".
 `,
 		Min_args: 0,
-		Max_args: 0,
+		Max_args: 1,
 	}
 	gub.AddToCategory("running", name)
 	gub.AddAlias("R", name)
 	gub.AddAlias("restart", name)
 }
 
+// RunCommand implements the debugger command:
+//    run [exec]
+// which restarts the program.
 func RunCommand(args []string) {
-	ShowArgsSubcmd(args)
-	gub.Msg("gub: restarting...")
-	syscall.Exec(gub.RESTART_ARGS[0], gub.RESTART_ARGS[0:], os.Environ());
+	if len(args) == 2 {
+		if args[1] != "exec" {
+			gub.Errmsg("Expecting \"exec\"; got %s", args[1])
+			return
+		}
+		ShowArgsSubcmd(args)
+		gub.Msg("gub: restarting...")
+		syscall.Exec(gub.RESTART_ARGS[0], gub.RESTART_ARGS[0:], os.Environ());
+		return
+	}
+	// The restart unwinds the goroutine we are stopped in, which
+	// has to be the one that started the program.
+	if fr := gub.TopFrame(); fr != nil && fr.GoNum() != 0 {
+		gub.Errmsg("Can only restart when stopped in goroutine 0, not %d; use \"run exec\"",
+			fr.GoNum())
+		return
+	}
+	gub.Msg("gub: restarting program...")
+	gub.RestartRequested = true
+	gub.InCmdLoop = false
 }
@@ -0,0 +1,74 @@
+// Copyright 2015 Rocky Bernstein.
+// Debugger rwatch command
+
+package gubcmd
+
+import (
+	"strings"
+
+	"github.com/rocky/ssa-interp"
+	"github.com/rocky/ssa-interp/gub"
+	"github.com/rocky/ssa-interp/interp"
+)
+
+func init() {
+	name := "rwatch"
+	gub.Cmds[name] = &gub.CmdInfo{
+		Fn: RwatchCommand,
+		Help: `rwatch *name* | *name*[*index*]
+
+Set a read watchpoint on variable *name*. Execution stops whenever the
+value of the variable is loaded, and the value read is shown. *name*
+can be a local variable, a global variable of the current package, or
+a package-qualified global like pkg.var. An element of a slice or
+array can be watched by giving an index expression like s[3].
+
+A read watchpoint on a local variable is tied to the activation of the
+function it was set in.
+
+See also "watch", "info break", "enable", "disable", and "delete".
+`,
+
+		Min_args: 1,
+		Max_args: -1,
+	}
+	gub.AddToCategory("breakpoints", name)
+}
+
+// RwatchCommand implements the debugger command:
+//    rwatch *name* | *name*[*index*]
+// which sets a read watchpoint on variable *name* or on an element
+// of it.
+//
+// See also "watch", "info break", "enable", "disable", and "delete".
+func RwatchCommand(args []string) {
+	name := strings.TrimSpace(gub.CmdArgstr)
+	fr := gub.CurFrame()
+	if strings.Contains(name, "[") {
+		addr, entry, err := gub.WatchExprLookup(fr, name, gub.CurScope())
+		if err != nil {
+			gub.Errmsg(err.Error())
+			return
+		}
+		if entry != nil {
+			if len(gub.BreakpointFindByEntry(entry)) == 0 {
+				interp.UnwatchMapEntry(entry)
+			}
+			gub.Errmsg("Sorry, reads of map elements can't be watched")
+			return
+		}
+		bpnum := gub.WatchpointAdd(name, addr, fr.StartP(), fr.EndP(),
+			interp.WATCH_READ)
+		gub.Msg("Read watchpoint %d set on %s", bpnum, name)
+		return
+	}
+	addr, nameVal, err := gub.VarAddrLookup(fr, name, gub.CurScope())
+	if err != nil {
+		gub.Errmsg(err.Error())
+		return
+	}
+	bpnum := gub.WatchpointAdd(name, addr, nameVal.Pos(), nameVal.Pos(),
+		interp.WATCH_READ)
+	gub.Msg("Read watchpoint %d set on %s at %s", bpnum, name,
+		ssa2.FmtPos(fr.Fset(), nameVal.Pos()))
+}
@@ -5,6 +5,8 @@
 package gubcmd
 
 import (
+	"strings"
+
 	"github.com/rocky/ssa-interp/gub"
 )
 
@@ -18,9 +20,20 @@ func init() {
 		Fn: SetCommand,
 		Help: `Modifies parts of the debugger environment.
 
-Type "set" for a list of "set" subcommands and what they do.`,
+Type "set" for a list of "set" subcommands and what they do.
+
+"set $*name* = *expr*" sets convenience variable $*name* to the value
+of Go expression *expr*. Convenience variables belong to the debugger,
+not the program, and keep their values across stops. They can be used
+in the expressions of "eval", "display" and breakpoint conditions, for
+example:
+
+   set $start = i
+   condition 1 i > $start + 10
+
+See also "show convenience".`,
 		Min_args: 0,
-		Max_args: 3,
+		Max_args: -1,
 	}
 	gub.AddToCategory("support", name)
 }
@@ -62,7 +75,32 @@ Type "help set *" for just the list of "set" subcommands.`,
 
 // setCommand implements the debugger command:
 //    set [*subcommand*]
-// which modifies parts of the debugger environment.
+// which modifies parts of the debugger environment, or
+//    set $*name* = *expr*
+// which sets a convenience variable.
 func SetCommand(args []string) {
+	if len(args) > 1 && strings.HasPrefix(args[1], "$") {
+		setConvVar()
+		return
+	}
 	gub.SubcmdMgrCommand(args)
 }
+
+func setConvVar() {
+	// Use gub.CmdArgstr which preserves blanks inside quotes
+	argstr := gub.CmdArgstr
+	eq := strings.Index(argstr, "=")
+	if eq < 0 {
+		gub.Errmsg("Expecting: set $name = expression")
+		return
+	}
+	name := strings.TrimSpace(argstr[1:eq])
+	expr := strings.TrimSpace(argstr[eq+1:])
+	if expr == "" {
+		gub.Errmsg("Expecting an expression after =")
+		return
+	}
+	if err := gub.SetConvVar(name, expr); err != nil {
+		gub.Errmsg(err.Error())
+	}
+}
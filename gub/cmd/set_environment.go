@@ -0,0 +1,78 @@
+// Copyright 2015 Rocky Bernstein.
+
+// set environment - set or remove variables of the program's environment
+
+package gubcmd
+
+import (
+	"strings"
+
+	"github.com/rocky/ssa-interp/gub"
+	"github.com/rocky/ssa-interp/interp"
+)
+
+func init() {
+	parent := "set"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: SetEnvironmentSubcmd,
+		Help: `set environment *name*=*value*|*name*|clear|inherit|file *path*
+
+Changes the environment the program starts with. The program has an
+environment of its own, a copy of ours to begin with, so what it sets
+or removes with os.Setenv or os.Unsetenv isn't seen by us, nor by its
+next run.
+
+   *name*=*value*  sets variable *name* to *value*
+   *name*          removes variable *name*
+   clear           removes all variables
+   inherit         goes back to a copy of our environment
+   file *path*     sets the variables given in file *path*, one
+                   *name*=*value* per line
+
+Changes take effect when the program is next started, for example
+with "run".
+
+Examples:
+   set environment GOTRACEBACK=all
+   set environment HOME
+   set environment file test.env
+
+See also "show environment" and tortoise's -env, -envfile and -clearenv.`,
+		Min_args: 1,
+		Max_args: 2,
+		Short_help: "the program's environment variables",
+		Name: "environment",
+	})
+}
+
+func SetEnvironmentSubcmd(args []string) {
+	arg := args[2]
+	if len(args) == 4 {
+		if arg != "file" {
+			gub.Errmsg("Expecting file *path*; got %s", strings.Join(args[2:], " "))
+			return
+		}
+		if err := interp.ReadProgramEnv(args[3]); err != nil {
+			gub.Errmsg("%s", err)
+			return
+		}
+		gub.Msg("Program environment variables set from %s", args[3])
+		return
+	}
+	switch eq := strings.Index(arg, "="); {
+	case arg == "clear":
+		interp.ClearProgramEnv()
+		gub.Msg("The program starts with an empty environment")
+	case arg == "inherit":
+		interp.InheritProgramEnv()
+		gub.Msg("The program starts with a copy of our environment")
+	case eq == 0:
+		gub.Errmsg("Expecting *name*=*value*; got %s", arg)
+	case eq > 0:
+		interp.SetProgramEnv(arg[:eq], arg[eq+1:])
+		ShowEnvironmentSubcmd([]string{"show", "environment", arg[:eq]})
+	default:
+		interp.UnsetProgramEnv(arg)
+		gub.Msg("Environment variable %s removed", arg)
+	}
+}
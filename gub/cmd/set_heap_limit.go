@@ -0,0 +1,48 @@
+// Copyright 2015 Rocky Bernstein.
+
+// set heap-limit - the most memory the program may have in use
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+	"github.com/rocky/ssa-interp/interp"
+)
+
+func init() {
+	parent := "set"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: SetHeapLimitSubcmd,
+		Help: `set heap-limit *size*|none
+
+Sets the most memory the program may have in use, as "info memory"
+counts it. An allocation that would go over the limit panics with a
+runtime error giving the position of the allocation, which the
+program can recover from. *size* is a number of bytes optionally
+followed by K, M or G; "none" removes the limit.
+
+Examples:
+   set heap-limit 64M
+   set heap-limit none
+
+See also "show heap-limit", "info memory" and tortoise's -maxheap.`,
+		Min_args: 1,
+		Max_args: 1,
+		Short_help: "most memory the program may use",
+		Name: "heap-limit",
+	})
+}
+
+func SetHeapLimitSubcmd(args []string) {
+	if args[2] == "none" {
+		interp.SetHeapLimit(0)
+	} else {
+		limit, err := interp.ParseSize(args[2])
+		if err != nil {
+			gub.Errmsg(err.Error())
+			return
+		}
+		interp.SetHeapLimit(limit)
+	}
+	ShowHeapLimitSubcmd(args)
+}
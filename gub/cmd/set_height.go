@@ -0,0 +1,38 @@
+// Copyright 2015 Rocky Bernstein.
+
+// set height - number of lines before output pauses
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	parent := "set"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: SetHeightSubcmd,
+		Help: `set height *lines*
+
+Sets the number of lines on the terminal. Output of commands like
+"help", "disasm", "backtrace full" and "globals" that is longer than
+this stops every screenful with a "--More--" prompt. Press return to
+see the next screenful or "q" to skip the rest.
+
+0 turns paging off. The initial value comes from the LINES
+environment variable, or is 24 if that isn't set.`,
+		Min_args: 1,
+		Max_args: 1,
+		Short_help: "number of lines before output pauses",
+		Name: "height",
+	})
+}
+
+func SetHeightSubcmd(args []string) {
+	height, err := gub.GetInt(args[2], "height", 0, 100000)
+	if err != nil {
+		return
+	}
+	gub.Height = height
+	ShowHeightSubcmd(args)
+}
@@ -14,7 +14,11 @@ func init() {
 		Fn: SetHighlightSubcmd,
 		Help: `set highlight [on|off]
 
-Sets whether terminal highlighting is to be used`,
+Sets whether terminal highlighting is to be used. When on, "list"
+colors Go keywords, strings and comments, shows the line we are
+stopped at in bold and breakpoint markers in red, and "disasm" shows
+SSA opcode mnemonics in colors by the kind of instruction: control
+flow, calls, memory access and so on.`,
 		Min_args: 0,
 		Max_args: 1,
 		Short_help: "use terminal highlight",
@@ -0,0 +1,71 @@
+// Copyright 2015 Rocky Bernstein.
+
+// set location-format - how positions in the program are shown
+
+package gubcmd
+
+import (
+	"strings"
+
+	"github.com/rocky/ssa-interp"
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	parent := "set"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: SetLocationFormatSubcmd,
+		Help: `set location-format {absolute|relative|basename|[no]column|[no]function}...
+
+Sets how positions in the program, like those shown when the program
+stops and in backtraces, are shown. Several of the following can be
+given at once:
+
+   absolute    show file names as given, usually as absolute paths
+               (the default)
+   relative    show file names relative to the current directory
+   basename    show just the last component of file names
+   column      show column numbers as well as lines (the default)
+   nocolumn    show just line numbers
+   function    start positions of stack frames with the function name
+   nofunction  don't show the function name (the default)
+
+Examples:
+   set location-format basename nocolumn
+   set location-format relative function
+
+See also "show location-format".`,
+		Min_args: 1,
+		Max_args: 3,
+		Short_help: "how file positions are shown",
+		Name: "location-format",
+	})
+}
+
+func SetLocationFormatSubcmd(args []string) {
+	path, column, function := ssa2.LocationPath, ssa2.LocationColumn,
+		ssa2.LocationFunction
+	for _, word := range args[2:] {
+		switch word {
+		case "column", "nocolumn":
+			column = word == "column"
+		case "function", "nofunction":
+			function = word == "function"
+		default:
+			found := false
+			for _, p := range ssa2.LocationPaths {
+				if p == word {
+					path, found = word, true
+				}
+			}
+			if !found {
+				gub.Errmsg("Expecting one of %s, column, nocolumn, function or nofunction; got %s",
+					strings.Join(ssa2.LocationPaths, ", "), word)
+				return
+			}
+		}
+	}
+	ssa2.LocationPath, ssa2.LocationColumn, ssa2.LocationFunction =
+		path, column, function
+	ShowLocationFormatSubcmd(args)
+}
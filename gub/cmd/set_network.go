@@ -0,0 +1,56 @@
+// Copyright 2015 Rocky Bernstein.
+
+// set network - what network the program can reach
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+	"github.com/rocky/ssa-interp/interp"
+)
+
+func init() {
+	parent := "set"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: SetNetworkSubcmd,
+		Help: `set network deny|loopback|mock
+
+Sets what network the program's net.Dial, net.DialTimeout, Dialer.Dial
+and net.Listen reach:
+
+   deny      none at all; every dial and listen fails. The default
+   loopback  loopback addresses only, over the real network
+   mock      an in-memory network, in which the program's dials reach
+             the listeners it has itself. Nothing leaves the process,
+             so each run sees the same network
+
+A new in-memory network is started each time "set network mock" is
+given. Other ways into the network, such as host lookups, aren't
+intercepted.
+
+Examples:
+   set network mock
+   set network deny
+
+See also "show network" and tortoise's -net.`,
+		Min_args: 1,
+		Max_args: 1,
+		Short_help: "what network the program can reach",
+		Name: "network",
+	})
+}
+
+func SetNetworkSubcmd(args []string) {
+	switch args[2] {
+	case "deny":
+		interp.SetNetTransport(interp.NetDeny)
+	case "loopback":
+		interp.SetNetTransport(interp.NetLoopback)
+	case "mock":
+		interp.SetNetTransport(interp.NewNetMock())
+	default:
+		gub.Errmsg("Expecting deny, loopback or mock; got %s", args[2])
+		return
+	}
+	ShowNetworkSubcmd(args)
+}
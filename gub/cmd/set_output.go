@@ -0,0 +1,42 @@
+// Copyright 2015 Rocky Bernstein.
+
+// set output - show output for people or as JSON for tools
+
+package gubcmd
+
+import (
+	"strings"
+
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	parent := "set"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: SetOutputSubcmd,
+		Help: `set output text|json
+
+Sets how breakpoint lists ("info breakpoint"), backtraces, "locals"
+and the notice of where the program stopped are shown. "text", the
+default, is meant for people. With "json" each is shown as a single
+line holding a JSON object, for tools to read. Other output isn't
+changed.`,
+		Min_args: 1,
+		Max_args: 1,
+		Short_help: "show output as text or JSON",
+		Name: "output",
+	})
+}
+
+func SetOutputSubcmd(args []string) {
+	format := args[2]
+	for _, f := range gub.OutputFormats {
+		if f == format {
+			gub.OutputFormat = format
+			gub.Msg("Output format is %s", format)
+			return
+		}
+	}
+	gub.Errmsg("Expecting one of %s; got %s", strings.Join(gub.OutputFormats, ", "),
+		format)
+}
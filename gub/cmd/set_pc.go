@@ -0,0 +1,80 @@
+// Copyright 2015 Rocky Bernstein.
+
+// set pc - where to resume execution
+
+package gubcmd
+
+import (
+	"strings"
+
+	"github.com/rocky/ssa-interp"
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	parent := "set"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: SetPCSubcmd,
+		Help: `set pc [*file*:]*line* | .
+
+Sets where execution resumes in the current function to the statement
+at *line*, without resuming. The next "step", "next", or "continue"
+starts from there.
+
+"set pc ." goes back to the start of the statement we are stopped in,
+so it runs again. This is handy after changing a variable.
+
+Unlike "jump", this doesn't run anything and doesn't ask for
+confirmation. Statements skipped over don't run, and statements
+moved back to run again. Only the most recent frame can be changed.`,
+		Min_args: 1,
+		Max_args: 1,
+		Short_help: "set where execution resumes",
+		Name: "pc",
+	})
+}
+
+func SetPCSubcmd(args []string) {
+	fr := gub.CurFrame()
+	if fr != gub.TopFrame() {
+		gub.Errmsg("Can only set the pc of the most recent frame; use \"frame 0\" first")
+		return
+	}
+	fn := fr.Fn()
+	filename := fr.Position().Filename
+	if args[2] == "." {
+		ic := gub.CurStmtIndex(fr)
+		if ic < 0 {
+			gub.Errmsg("Can't find the start of the current statement")
+			return
+		}
+		gub.SetResumePoint(fr, fr.Block(), ic)
+		gub.Msg("Execution resumes at the current statement, %s",
+			ssa2.FmtPos(fr.Fset(), fr.Block().Instrs[ic].(*ssa2.Trace).Start))
+		return
+	}
+	lineStr  := args[2]
+	if colon := strings.LastIndex(lineStr, ":"); colon != -1 {
+		filename = lineStr[:colon]
+		lineStr  = lineStr[colon+1:]
+	}
+	line, err := gub.GetInt(lineStr, "line number", 1, 0)
+	if err != nil { return }
+	b, ic, ok := gub.StmtLookup(fn, filename, line)
+	if !ok {
+		gub.Errmsg("No statement of function %s at %s:%d", fn, filename, line)
+		return
+	}
+	pos := fr.Fset().Position(b.Instrs[ic].(*ssa2.Trace).Start)
+	if pos.Filename != fr.Position().Filename {
+		gub.Errmsg("Can only set the pc within function %s", fn)
+		return
+	}
+	if err := gub.CanResumeAt(fr, b, ic); err != nil {
+		gub.Errmsg("Can't resume at line %d: %s", line, err)
+		return
+	}
+	gub.SetResumePoint(fr, b, ic)
+	gub.Msg("Execution resumes at %s",
+		ssa2.FmtPos(fr.Fset(), b.Instrs[ic].(*ssa2.Trace).Start))
+}
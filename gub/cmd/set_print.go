@@ -0,0 +1,63 @@
+// Copyright 2015 Rocky Bernstein.
+
+// set print - limits on how values are shown
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+	"github.com/rocky/ssa-interp/interp"
+)
+
+func init() {
+	parent := "set"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: SetPrintSubcmd,
+		Help: `set print depth *n*
+set print elements *n*
+set print deref *n*
+
+"set print depth" sets how deeply nested arrays, slices, maps, and
+structs are shown. Anything nested more deeply is shown as {...}.
+
+"set print elements" sets how many elements of an array, slice, map,
+or struct, and how many characters of a string, are shown. The
+elements past that are shown as ...
+
+In both cases, 0 means there is no limit. By default depth is not
+limited and 200 elements are shown.
+
+"set print deref" sets how many levels of pointers are followed to
+show what they point to, as &{...}, rather than the pointer's address.
+By default it is 0 and pointers are not followed. A pointer can also
+be followed explicitly, as in "eval *p".
+
+These limits apply wherever values are shown, such as in "eval",
+"locals", and "environment".`,
+		Min_args: 2,
+		Max_args: 2,
+		Short_help: "limits on showing large values",
+		Name: "print",
+	})
+}
+
+func SetPrintSubcmd(args []string) {
+	what := args[2]
+	switch what {
+	case "depth", "elements", "deref":
+	default:
+		gub.Errmsg("Expecting 'depth', 'elements', or 'deref', got '%s'; nothing done", what)
+		return
+	}
+	n, err := gub.GetInt(args[3], "print " + what, 0, 0)
+	if err != nil { return }
+	switch what {
+	case "depth":
+		interp.SetPrintDepth(n)
+	case "elements":
+		interp.SetPrintElements(n)
+	case "deref":
+		interp.SetPrintDeref(n)
+	}
+	gub.Msg("Setting print %s to %d", what, n)
+}
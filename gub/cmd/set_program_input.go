@@ -0,0 +1,48 @@
+// Copyright 2015 Rocky Bernstein.
+
+// set program-input - where the program's input comes from
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	parent := "set"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: SetProgramInputSubcmd,
+		Help: `set program-input terminal|shared|buffer|*file*
+
+Sets where what the program reads from its standard input comes from:
+
+   terminal  our standard input, as usual; the default
+   shared    the terminal, a line at a time, read with an "input> "
+             prompt taking turns with the debugger's own prompt
+   buffer    text given with the "input" command, after which the
+             program gets end of file
+   *file*    *file*
+
+Use "shared" when the program reads the terminal it is being
+debugged from, so that its reads and ours don't fight over it.
+
+Examples:
+   set program-input shared
+   set program-input testdata/in.txt
+   set program-input buffer
+
+See also "show program-input" and "input".`,
+		Min_args: 1,
+		Max_args: 1,
+		Short_help: "where the program's input comes from",
+		Name: "program-input",
+	})
+}
+
+func SetProgramInputSubcmd(args []string) {
+	if err := gub.SetProgramInput(args[2]); err != nil {
+		gub.Errmsg("Can't read program input from %s: %s", args[2], err)
+		return
+	}
+	ShowProgramInputSubcmd(args)
+}
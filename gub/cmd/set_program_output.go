@@ -0,0 +1,70 @@
+// Copyright 2015 Rocky Bernstein.
+
+// set program-output - where the program's output goes
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	parent := "set"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: SetProgramOutputSubcmd,
+		Help: `set program-output [stdout|stderr] terminal|tag|buffer|*file*
+
+Sets where what the program writes to its standard output and
+standard error goes, or just to the one given:
+
+   terminal  to the terminal, as usual; the default
+   tag       to the terminal, each line starting with "stdout> " or
+             "stderr> " to tell it apart from what the debugger says
+   buffer    to a buffer, shown with "info program-output"
+   *file*    to *file*, which is created afresh
+
+This covers the traceback of a panic that ends the program and what
+the processes it starts write. However it goes, a line the program
+leaves unfinished on the terminal is ended before the debugger says
+anything.
+
+Examples:
+   set program-output prog.log
+   set program-output stderr tag
+   set program-output terminal
+
+See also "show program-output".`,
+		Min_args: 1,
+		Max_args: 2,
+		Short_help: "where the program's output goes",
+		Name: "program-output",
+	})
+}
+
+func SetProgramOutputSubcmd(args []string) {
+	fds := []int{1, 2}
+	dest := args[2]
+	if len(args) == 3 && (dest == "stdout" || dest == "stderr") {
+		gub.Errmsg("Expecting where %s is to go after %s", dest, dest)
+		return
+	}
+	if len(args) == 4 {
+		switch args[2] {
+		case "stdout":
+			fds = []int{1}
+		case "stderr":
+			fds = []int{2}
+		default:
+			gub.Errmsg("Expecting stdout or stderr; got %s", args[2])
+			return
+		}
+		dest = args[3]
+	}
+	for _, fd := range fds {
+		if err := gub.SetProgramOutput(fd, dest); err != nil {
+			gub.Errmsg("Can't send program output to %s: %s", dest, err)
+			return
+		}
+	}
+	ShowProgramOutputSubcmd(args)
+}
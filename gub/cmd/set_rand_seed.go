@@ -0,0 +1,54 @@
+// Copyright 2015 Rocky Bernstein.
+
+// set rand-seed - how math/rand's default source is seeded
+
+package gubcmd
+
+import (
+	"strconv"
+
+	"github.com/rocky/ssa-interp/gub"
+	"github.com/rocky/ssa-interp/interp"
+)
+
+func init() {
+	parent := "set"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: SetRandSeedSubcmd,
+		Help: `set rand-seed go|*seed*
+
+Sets how the default source of math/rand is seeded:
+
+   go      as Go seeds it; the default
+   *seed*  with integer *seed*, once math/rand is initialized
+
+With a seed, the program's own calls of rand.Seed get that seed too,
+so the top-level functions of math/rand give the same numbers each
+time the program is run, for example after "run" restarts it.
+Sources the program makes with rand.NewSource are left alone.
+
+Examples:
+   set rand-seed 42
+   set rand-seed go
+
+See also "show rand-seed" and tortoise's -randseed.`,
+		Min_args: 1,
+		Max_args: 1,
+		Short_help: "how math/rand is seeded",
+		Name: "rand-seed",
+	})
+}
+
+func SetRandSeedSubcmd(args []string) {
+	if args[2] == "go" {
+		interp.SetRandGo()
+	} else {
+		seed, err := strconv.ParseInt(args[2], 10, 64)
+		if err != nil {
+			gub.Errmsg("Expecting go or an integer seed; got %s", args[2])
+			return
+		}
+		interp.SetRandSeed(seed)
+	}
+	ShowRandSeedSubcmd(args)
+}
@@ -0,0 +1,55 @@
+// Copyright 2015 Rocky Bernstein.
+
+// set record - record statements executed?
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+	"github.com/rocky/ssa-interp/interp"
+)
+
+func init() {
+	parent := "set"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: SetRecordSubcmd,
+		Help: `set record [on|off]
+
+Sets whether each statement executed is recorded along with the values
+of its function's parameters and local variables, so that
+"reverse-step", "reverse-next" and "reverse-continue" can look back
+at them. Only the most recent statements are kept.
+
+Turning recording on clears what was recorded before.`,
+		Min_args: 0,
+		Max_args: 1,
+		Short_help: "record statements executed",
+		Name: "record",
+	})
+}
+
+func SetRecordSubcmd(args []string) {
+	onoff := "on"
+	if len(args) == 3 {
+		onoff = args[2]
+	}
+	switch ParseOnOff(onoff) {
+	case ONOFF_ON:
+		if interp.Recording() {
+			gub.Errmsg("Recording is already on")
+		} else {
+			gub.Msg("Setting recording on; keeping the last %d statements",
+				interp.RecordMax)
+			interp.SetRecording(true)
+		}
+	case ONOFF_OFF:
+		if !interp.Recording() {
+			gub.Errmsg("Recording is already off")
+		} else {
+			gub.Msg("Setting recording off")
+			interp.SetRecording(false)
+		}
+	case ONOFF_UNKNOWN:
+		gub.Msg("Expecting 'on' or 'off', got '%s'; nothing done", onoff)
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright 2015 Rocky Bernstein.
+
+// set scheduler - how goroutines take turns running
+
+package gubcmd
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/rocky/ssa-interp/gub"
+	"github.com/rocky/ssa-interp/interp"
+)
+
+func init() {
+	parent := "set"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: SetSchedulerSubcmd,
+		Help: `set scheduler go|round-robin|random [*seed*]
+
+Sets how the goroutines of the program take turns running:
+
+   go           as the Go runtime sees fit, all at once; the default
+   round-robin  one at a time, in order of goroutine number
+   random       one at a time, the next picked at random
+
+With round-robin and random, a goroutine runs until it blocks, say on
+a channel, finishes, or has run a number of instructions. The program
+then runs the same way each time it is given the same input, and so
+does a bug that depends on how goroutines interleave. random with
+different seeds tries out different interleavings, while the same
+seed gives the same one. Without a seed, one based on the time is
+used; "show scheduler" shows it.
+
+While the program is stopped with round-robin or random, its other
+goroutines don't run.
+
+Examples:
+   set scheduler round-robin
+   set scheduler random 42
+   set scheduler go
+
+See also "show scheduler" and tortoise's -interp=D and -interp=X.`,
+		Min_args: 1,
+		Max_args: 2,
+		Short_help: "how goroutines take turns running",
+		Name: "scheduler",
+	})
+}
+
+func SetSchedulerSubcmd(args []string) {
+	switch args[2] {
+	case "go":
+		if len(args) > 3 {
+			gub.Errmsg("go takes no seed")
+			return
+		}
+		interp.SetScheduler(nil)
+	case "round-robin":
+		if len(args) > 3 {
+			gub.Errmsg("round-robin takes no seed")
+			return
+		}
+		interp.SetScheduler(interp.NewRoundRobin())
+	case "random":
+		seed := time.Now().UnixNano()
+		if len(args) > 3 {
+			var err error
+			seed, err = strconv.ParseInt(args[3], 10, 64)
+			if err != nil {
+				gub.Errmsg("Expecting an integer seed; got %s", args[3])
+				return
+			}
+		}
+		interp.SetScheduler(interp.NewRandomScheduler(seed))
+	default:
+		gub.Errmsg("Expecting go, round-robin or random; got %s", args[2])
+		return
+	}
+	ShowSchedulerSubcmd(args)
+}
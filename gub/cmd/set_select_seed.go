@@ -0,0 +1,57 @@
+// Copyright 2015 Rocky Bernstein.
+
+// set select-seed - how a select statement picks among ready cases
+
+package gubcmd
+
+import (
+	"strconv"
+
+	"github.com/rocky/ssa-interp/gub"
+	"github.com/rocky/ssa-interp/interp"
+)
+
+func init() {
+	parent := "set"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: SetSelectSeedSubcmd,
+		Help: `set select-seed random|ordered|*seed*
+
+Sets how a select statement picks a case when more than one is ready:
+
+   random   at random, as Go does; the default
+   ordered  the first ready case in the order they are written
+   *seed*   pseudo-randomly starting from integer *seed*
+
+With ordered or a seed, the program takes the same branches each
+time it is run, for example after "run" restarts it, so that a
+debugging session can be replayed.
+
+Examples:
+   set select-seed 42
+   set select-seed ordered
+
+See also "show select-seed" and tortoise's -select.`,
+		Min_args: 1,
+		Max_args: 1,
+		Short_help: "how select picks among ready cases",
+		Name: "select-seed",
+	})
+}
+
+func SetSelectSeedSubcmd(args []string) {
+	switch args[2] {
+	case "random":
+		interp.SetSelectRandom()
+	case "ordered":
+		interp.SetSelectOrdered()
+	default:
+		seed, err := strconv.ParseInt(args[2], 10, 64)
+		if err != nil {
+			gub.Errmsg("Expecting random, ordered or an integer seed; got %s", args[2])
+			return
+		}
+		interp.SetSelectSeed(seed)
+	}
+	ShowSelectSeedSubcmd(args)
+}
@@ -0,0 +1,43 @@
+// Copyright 2015 Rocky Bernstein.
+
+// set step-defers - stop in deferred calls when stepping over a return?
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+	"github.com/rocky/ssa-interp/interp"
+)
+
+func init() {
+	parent := "set"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: SetStepDefersSubcmd,
+		Help: `set step-defers [on|off]
+
+Sets whether "next" stops inside the deferred functions that run when
+it steps over a return. When off, the default, deferred calls are
+stepped over like any other call.`,
+		Min_args: 0,
+		Max_args: 1,
+		Short_help: "stop in deferred calls when stepping over a return",
+		Name: "step-defers",
+	})
+}
+
+func SetStepDefersSubcmd(args []string) {
+	onoff := "on"
+	if len(args) == 3 {
+		onoff = args[2]
+	}
+	switch ParseOnOff(onoff) {
+	case ONOFF_ON:
+		gub.Msg("Setting step-defers on")
+		interp.SetStepDefers(true)
+	case ONOFF_OFF:
+		gub.Msg("Setting step-defers off")
+		interp.SetStepDefers(false)
+	case ONOFF_UNKNOWN:
+		gub.Msg("Expecting 'on' or 'off', got '%s'; nothing done", onoff)
+	}
+}
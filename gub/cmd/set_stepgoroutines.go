@@ -0,0 +1,43 @@
+// Copyright 2015 Rocky Bernstein.
+
+// set step-goroutines - stop in goroutines started while stepping?
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+	"github.com/rocky/ssa-interp/interp"
+)
+
+func init() {
+	parent := "set"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: SetStepGoroutinesSubcmd,
+		Help: `set step-goroutines [on|off]
+
+Sets whether stepping stops at the start of a goroutine that a go
+statement starts while we are stepping. When off, the default, new
+goroutines run without stopping unless they hit a breakpoint.`,
+		Min_args: 0,
+		Max_args: 1,
+		Short_help: "stop in goroutines started while stepping",
+		Name: "step-goroutines",
+	})
+}
+
+func SetStepGoroutinesSubcmd(args []string) {
+	onoff := "on"
+	if len(args) == 3 {
+		onoff = args[2]
+	}
+	switch ParseOnOff(onoff) {
+	case ONOFF_ON:
+		gub.Msg("Setting step-goroutines on")
+		interp.SetStepGoroutines(true)
+	case ONOFF_OFF:
+		gub.Msg("Setting step-goroutines off")
+		interp.SetStepGoroutines(false)
+	case ONOFF_UNKNOWN:
+		gub.Msg("Expecting 'on' or 'off', got '%s'; nothing done", onoff)
+	}
+}
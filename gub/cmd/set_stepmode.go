@@ -0,0 +1,44 @@
+// Copyright 2015 Rocky Bernstein.
+
+// set step-mode - what counts as one step?
+
+package gubcmd
+
+import (
+	"strings"
+
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	parent := "set"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: SetStepModeSubcmd,
+		Help: `set step-mode statement|line|instruction
+
+Sets what "step" counts as one step.
+
+"statement", the default, stops at each statement boundary, so a line
+with several statements, like "a, b = b, a; i++", takes several
+steps. "line" keeps going until a different line is reached; "next"
+honors this too. "instruction" stops at each SSA instruction, like
+"stepi".`,
+		Min_args: 1,
+		Max_args: 1,
+		Short_help: "what counts as one step",
+		Name: "step-mode",
+	})
+}
+
+func SetStepModeSubcmd(args []string) {
+	mode := args[2]
+	for _, m := range gub.StepModes {
+		if m == mode {
+			gub.StepMode = mode
+			gub.Msg("Step mode is %s", mode)
+			return
+		}
+	}
+	gub.Errmsg("Expecting one of %s; got %s", strings.Join(gub.StepModes, ", "),
+		mode)
+}
@@ -0,0 +1,45 @@
+// Copyright 2015 Rocky Bernstein.
+
+// set step-package - keep step in the current package?
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	parent := "set"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: SetStepPackageSubcmd,
+		Help: `set step-package [on|off]
+
+Sets whether "step" stays in the package it is stepping in. When on,
+calls to functions of other packages are stepped over as "next"
+would, so library code isn't entered. Breakpoints in other packages
+still stop.
+
+See also "skip" for finer control.`,
+		Min_args: 0,
+		Max_args: 1,
+		Short_help: "keep step in the current package",
+		Name: "step-package",
+	})
+}
+
+func SetStepPackageSubcmd(args []string) {
+	onoff := "on"
+	if len(args) == 3 {
+		onoff = args[2]
+	}
+	switch ParseOnOff(onoff) {
+	case ONOFF_ON:
+		gub.Msg("Setting step-package on")
+		gub.StepPackage = true
+	case ONOFF_OFF:
+		gub.Msg("Setting step-package off")
+		gub.StepPackage = false
+	case ONOFF_UNKNOWN:
+		gub.Msg("Expecting 'on' or 'off', got '%s'; nothing done", onoff)
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright 2015 Rocky Bernstein.
+
+// set theme - colors of the parts of debugger output
+
+package gubcmd
+
+import (
+	"strings"
+
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	parent := "set"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: SetThemeSubcmd,
+		Help: `set theme *kind* *color*
+
+Sets the color that a kind of debugger output is shown in when
+highlighting is on. The kinds are:
+
+   error          error messages
+   section        section headers
+   current-frame  the selected frame in a backtrace
+   current-line   the line we are stopped at in "list"
+   breakpoint     breakpoint markers in "list"
+
+Colors are black, darkred, darkgreen, brown, darkblue, purple, teal,
+lightgray, darkgray, red, green, yellow, blue, fuchsia, turquoise and
+white, or attributes bold, faint, underline and blink. "reset" shows
+the output in the terminal's usual color.
+
+Example:
+   set theme error fuchsia
+
+Highlighting is turned off when output isn't going to a terminal.
+See also "set highlight".`,
+		Min_args: 2,
+		Max_args: 2,
+		Short_help: "colors of the parts of debugger output",
+		Name: "theme",
+	})
+}
+
+func SetThemeSubcmd(args []string) {
+	name, color := args[2], args[3]
+	if _, ok := gub.Theme[name]; !ok {
+		gub.Errmsg("Expecting one of %s; got %s",
+			strings.Join(gub.ThemeNames(), ", "), name)
+		return
+	}
+	if !gub.SetTheme(name, color) {
+		gub.Errmsg("Unknown color %s", color)
+		return
+	}
+	gub.Msg("%s is shown in %s", name, gub.Themed(name, color))
+}
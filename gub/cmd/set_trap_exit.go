@@ -0,0 +1,49 @@
+// Copyright 2015 Rocky Bernstein.
+
+// set trap-exit - stop when the program calls os.Exit?
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+	"github.com/rocky/ssa-interp/interp"
+)
+
+func init() {
+	parent := "set"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: SetTrapExitSubcmd,
+		Help: `set trap-exit [on|off]
+
+Sets whether the program stops when it calls os.Exit. When on, the
+default, it stops at the call with its goroutines and frames still
+there to be examined, and $_exitcode set to the exit status. Resuming
+it, say with "continue", asks whether to let it exit; answering no
+gives the debugger back. "run" starts it over instead, and "quit"
+leaves.
+
+When off, os.Exit ends the program and the debugger with it, once
+any stop for the end of the program is over.`,
+		Min_args: 0,
+		Max_args: 1,
+		Short_help: "stop when the program calls os.Exit",
+		Name: "trap-exit",
+	})
+}
+
+func SetTrapExitSubcmd(args []string) {
+	onoff := "on"
+	if len(args) == 3 {
+		onoff = args[2]
+	}
+	switch ParseOnOff(onoff) {
+	case ONOFF_ON:
+		gub.Msg("Setting trap-exit on")
+		interp.SetExitTrap(true)
+	case ONOFF_OFF:
+		gub.Msg("Setting trap-exit off")
+		interp.SetExitTrap(false)
+	case ONOFF_UNKNOWN:
+		gub.Msg("Expecting 'on' or 'off', got '%s'; nothing done", onoff)
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright 2015 Rocky Bernstein.
+
+// set var - change a variable of the program
+
+package gubcmd
+
+import (
+	"strings"
+
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	parent := "set"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: SetVarSubcmd,
+		Help: `set var *lvalue* = *expr*
+
+Evaluates Go expression *expr* in the selected frame and stores it
+into *lvalue*, which can be a local variable, a parameter, a package
+variable, a struct field, an array or slice element, a map entry, or
+something a pointer points to. The value is converted to the type of
+*lvalue* as in a Go assignment, so untyped constants take on its type
+and a value assigned to an interface variable is boxed.
+
+Examples:
+
+   set var x = 5
+   set var p.name = "bob"
+   set var a[i+1] = a[i]
+   set var m["key"] = 10
+   set var *ip = 0
+   set var main.debug = true
+
+See also "set $*name*" for debugger-only variables.`,
+		Min_args: 1,
+		Max_args: -1,
+		Short_help: "assign to a variable of the program",
+		Name: "var",
+	})
+}
+
+func SetVarSubcmd(args []string) {
+	// Don't use args, but gub.CmdArgstr which preserves blanks inside quotes
+	argstr := strings.TrimLeft(gub.CmdArgstr[len(args[1]):], " ")
+	lhs, rhs, ok := gub.SplitAssign(argstr)
+	if !ok {
+		gub.Errmsg("Expecting: set var *lvalue* = *expr*")
+		return
+	}
+	lhs, rhs = strings.TrimSpace(lhs), strings.TrimSpace(rhs)
+	v, t, err := gub.AssignExpr(gub.CurFrame(), gub.CurScope(), lhs, rhs)
+	if err != nil {
+		gub.Errmsg(err.Error())
+		return
+	}
+	gub.Msg("%s = (%s) %s", lhs, t, gub.FormatValue(v, t))
+}
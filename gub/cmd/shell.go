@@ -0,0 +1,76 @@
+// Copyright 2015 Rocky Bernstein.
+
+// shell command
+
+package gubcmd
+
+import (
+	"os"
+	"os/exec"
+
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	name := "shell"
+	gub.Cmds[name] = &gub.CmdInfo{
+		Fn: ShellCommand,
+		Help: `shell [*command*]
+
+Run *command* with the shell given by the SHELL environment variable,
+or /bin/sh, and show its output. The debugged program stays where it
+is. Without a command, an interactive shell is started; leave it to
+get back to the debugger.
+
+"!" is short for "shell" and needs no blank after it, except before
+a variable: "!ok" shows the negation of variable ok.
+
+A shell can only be run from the terminal gub was started from, not
+by a remote client, an MI front end or a program embedding gub.
+
+Examples:
+   shell grep -n gcd *.go
+   !go vet ./...
+`,
+		Min_args: 0,
+		Max_args: -1,
+	}
+	gub.AddToCategory("support", name)
+	gub.AddAlias("!", name)
+}
+
+// outputWriter passes what is written to it to the debugger's output.
+type outputWriter struct{}
+
+func (outputWriter) Write(p []byte) (int, error) {
+	return gub.MsgNoCr("%s", p)
+}
+
+// ShellCommand implements the debugger command:
+//    shell [*command*]
+// which runs *command* in a shell.
+func ShellCommand(args []string) {
+	if !gub.LocalTerminal() {
+		gub.Errmsg("shell can only be run from the terminal gub was started from")
+		return
+	}
+	shell := os.Getenv("SHELL")
+	if shell == "" {
+		shell = "/bin/sh"
+	}
+	var cmd *exec.Cmd
+	if len(args) == 1 {
+		cmd = exec.Command(shell)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+	} else {
+		// Use gub.CmdArgstr which preserves blanks inside quotes.
+		cmd = exec.Command(shell, "-c", gub.CmdArgstr)
+		cmd.Stdout = outputWriter{}
+		cmd.Stderr = outputWriter{}
+	}
+	cmd.Stdin = os.Stdin
+	if err := cmd.Run(); err != nil {
+		gub.Errmsg("%s", err)
+	}
+}
@@ -0,0 +1,28 @@
+// Copyright 2015 Rocky Bernstein.
+
+// show convenience - the convenience variables
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	parent := "show"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: ShowConvenienceSubcmd,
+		Help: `show convenience
+
+Show the convenience variables set with "set $*name* = *expr*" and
+their values.`,
+		Min_args: 0,
+		Max_args: 0,
+		Short_help: "show convenience variables",
+		Name: "convenience",
+	})
+}
+
+func ShowConvenienceSubcmd(args []string) {
+	gub.PrintConvVars()
+}
@@ -0,0 +1,44 @@
+// Copyright 2015 Rocky Bernstein.
+
+// show environment - what environment does the program start with?
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+	"github.com/rocky/ssa-interp/interp"
+)
+
+func init() {
+	parent := "show"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: ShowEnvironmentSubcmd,
+		Help: `show environment [*name*]
+
+Show the environment the program starts with, or, if *name* is given,
+the value of variable *name* in it. GOSSAINTERP and GOARCH, which are
+always added, are left out.
+See "set environment".`,
+		Min_args: 0,
+		Max_args: 1,
+		Short_help: "the program's environment variables",
+		Name: "environment",
+	})
+}
+
+func ShowEnvironmentSubcmd(args []string) {
+	if len(args) == 3 {
+		if value, ok := interp.ProgramGetenv(args[2]); ok {
+			gub.Msg("%s=%s", args[2], value)
+		} else {
+			gub.Msg("Environment variable %s is not set", args[2])
+		}
+		return
+	}
+	if interp.ProgramEnvInherited() {
+		gub.Msg("The program starts with a copy of our environment:")
+	}
+	for _, s := range interp.ProgramEnv() {
+		gub.Msg("%s", s)
+	}
+}
@@ -0,0 +1,32 @@
+// Copyright 2015 Rocky Bernstein.
+
+// show heap-limit - how much memory may the program use?
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+	"github.com/rocky/ssa-interp/interp"
+)
+
+func init() {
+	parent := "show"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: ShowHeapLimitSubcmd,
+		Help: `show heap-limit
+
+Show the most memory the program may have in use. See "set heap-limit".`,
+		Min_args: 0,
+		Max_args: 0,
+		Short_help: "most memory the program may use",
+		Name: "heap-limit",
+	})
+}
+
+func ShowHeapLimitSubcmd(args []string) {
+	if limit := interp.HeapLimit(); limit == 0 {
+		gub.Msg("heap limit: none")
+	} else {
+		gub.Msg("heap limit: %d bytes", limit)
+	}
+}
@@ -0,0 +1,32 @@
+// Copyright 2015 Rocky Bernstein.
+
+// show height - number of lines before output pauses
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	parent := "show"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: ShowHeightSubcmd,
+		Help: `show height
+
+Show the number of lines before output pauses with a "--More--"
+prompt`,
+		Min_args: 0,
+		Max_args: 0,
+		Short_help: "number of lines before output pauses",
+		Name: "height",
+	})
+}
+
+func ShowHeightSubcmd(args []string) {
+	if gub.Height == 0 {
+		gub.Msg("Paging is off.")
+	} else {
+		gub.Msg("Number of lines before output pauses is %d.", gub.Height)
+	}
+}
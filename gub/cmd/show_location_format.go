@@ -0,0 +1,36 @@
+// Copyright 2015 Rocky Bernstein.
+
+// show location-format - how are positions in the program shown?
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp"
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	parent := "show"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: ShowLocationFormatSubcmd,
+		Help: `show location-format
+
+Show how file names, columns and function names in positions are
+shown. See "set location-format".`,
+		Min_args: 0,
+		Max_args: 0,
+		Short_help: "how file positions are shown",
+		Name: "location-format",
+	})
+}
+
+func ShowLocationFormatSubcmd(args []string) {
+	column, function := "column", "function"
+	if !ssa2.LocationColumn {
+		column = "nocolumn"
+	}
+	if !ssa2.LocationFunction {
+		function = "nofunction"
+	}
+	gub.Msg("Location format is %s %s %s", ssa2.LocationPath, column, function)
+}
@@ -0,0 +1,41 @@
+// Copyright 2015 Rocky Bernstein.
+
+// show network - what network can the program reach?
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+	"github.com/rocky/ssa-interp/interp"
+)
+
+func init() {
+	parent := "show"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: ShowNetworkSubcmd,
+		Help: `show network
+
+Show what network the program's dials and listens reach.
+See "set network".`,
+		Min_args: 0,
+		Max_args: 0,
+		Short_help: "what network the program can reach",
+		Name: "network",
+	})
+}
+
+func ShowNetworkSubcmd(args []string) {
+	switch t := interp.CurrentNetTransport(); t.(type) {
+	case *interp.NetMock:
+		gub.Msg("The program reaches an in-memory network")
+	default:
+		switch t {
+		case interp.NetDeny:
+			gub.Msg("The program can't reach any network")
+		case interp.NetLoopback:
+			gub.Msg("The program reaches loopback addresses only")
+		default:
+			gub.Msg("The program reaches the network through %T", t)
+		}
+	}
+}
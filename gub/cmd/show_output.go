@@ -0,0 +1,28 @@
+// Copyright 2015 Rocky Bernstein.
+
+// show output - show output for people or as JSON for tools?
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	parent := "show"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: ShowOutputSubcmd,
+		Help: `show output
+
+Show whether breakpoint lists, backtraces, locals and stops are shown
+as text or as JSON`,
+		Min_args: 0,
+		Max_args: 0,
+		Short_help: "show output as text or JSON",
+		Name: "output",
+	})
+}
+
+func ShowOutputSubcmd(args []string) {
+	gub.Msg("Output format is %s", gub.OutputFormat)
+}
@@ -0,0 +1,54 @@
+// Copyright 2015 Rocky Bernstein.
+
+// show print - limits on how values are shown
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+	"github.com/rocky/ssa-interp/interp"
+)
+
+func init() {
+	parent := "show"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: ShowPrintSubcmd,
+		Help: `show print [depth|elements|deref]
+
+Show the limits on showing large values. See "set print".`,
+		Min_args: 0,
+		Max_args: 1,
+		Short_help: "show limits on showing large values",
+		Name: "print",
+	})
+}
+
+// showLimit shows print setting what whose value is n.
+func showLimit(what string, n int) {
+	if n == 0 {
+		gub.Msg("print %s is unlimited.", what)
+	} else {
+		gub.Msg("print %s is %d.", what, n)
+	}
+}
+
+func ShowPrintSubcmd(args []string) {
+	what := ""
+	if len(args) == 3 {
+		what = args[2]
+	}
+	switch what {
+	case "":
+		showLimit("depth", interp.PrintDepth())
+		showLimit("elements", interp.PrintElements())
+		gub.Msg("print deref is %d.", interp.PrintDeref())
+	case "depth":
+		showLimit(what, interp.PrintDepth())
+	case "elements":
+		showLimit(what, interp.PrintElements())
+	case "deref":
+		gub.Msg("print deref is %d.", interp.PrintDeref())
+	default:
+		gub.Errmsg("Expecting 'depth', 'elements', or 'deref', got '%s'", what)
+	}
+}
@@ -0,0 +1,28 @@
+// Copyright 2015 Rocky Bernstein.
+
+// show program-input - where does the program's input come from?
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	parent := "show"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: ShowProgramInputSubcmd,
+		Help: `show program-input
+
+Show where the program's standard input comes from.
+See "set program-input".`,
+		Min_args: 0,
+		Max_args: 0,
+		Short_help: "where the program's input comes from",
+		Name: "program-input",
+	})
+}
+
+func ShowProgramInputSubcmd(args []string) {
+	gub.Msg("Program standard input comes from: %s", gub.ProgramInputSource())
+}
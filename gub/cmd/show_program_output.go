@@ -0,0 +1,29 @@
+// Copyright 2015 Rocky Bernstein.
+
+// show program-output - where does the program's output go?
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	parent := "show"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: ShowProgramOutputSubcmd,
+		Help: `show program-output
+
+Show where the program's standard output and standard error go.
+See "set program-output".`,
+		Min_args: 0,
+		Max_args: 0,
+		Short_help: "where the program's output goes",
+		Name: "program-output",
+	})
+}
+
+func ShowProgramOutputSubcmd(args []string) {
+	gub.Msg("Program standard output goes to: %s", gub.ProgramOutputDest(1))
+	gub.Msg("Program standard error goes to: %s", gub.ProgramOutputDest(2))
+}
@@ -0,0 +1,33 @@
+// Copyright 2015 Rocky Bernstein.
+
+// show rand-seed - how is math/rand's default source seeded?
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+	"github.com/rocky/ssa-interp/interp"
+)
+
+func init() {
+	parent := "show"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: ShowRandSeedSubcmd,
+		Help: `show rand-seed
+
+Show how the default source of math/rand is seeded.
+See "set rand-seed".`,
+		Min_args: 0,
+		Max_args: 0,
+		Short_help: "how math/rand is seeded",
+		Name: "rand-seed",
+	})
+}
+
+func ShowRandSeedSubcmd(args []string) {
+	if choice := interp.RandSeedChoice(); choice == "go" {
+		gub.Msg("math/rand is seeded as Go seeds it")
+	} else {
+		gub.Msg("math/rand is seeded with %s", choice)
+	}
+}
@@ -0,0 +1,29 @@
+// Copyright 2015 Rocky Bernstein.
+
+// show record - are statements executed being recorded?
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+	"github.com/rocky/ssa-interp/interp"
+)
+
+func init() {
+	parent := "show"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: ShowRecordSubcmd,
+		Help: `show record
+
+Show whether statements executed are recorded, and how many are`,
+		Min_args: 0,
+		Max_args: 0,
+		Short_help: "show execution recording",
+		Name: "record",
+	})
+}
+
+func ShowRecordSubcmd(args []string) {
+	ShowOnOff(args[1], interp.Recording())
+	gub.Msg("%d statements recorded", len(interp.Records()))
+}
@@ -0,0 +1,33 @@
+// Copyright 2015 Rocky Bernstein.
+
+// show scheduler - how do goroutines take turns running?
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+	"github.com/rocky/ssa-interp/interp"
+)
+
+func init() {
+	parent := "show"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: ShowSchedulerSubcmd,
+		Help: `show scheduler
+
+Show how goroutines take turns running and, for random, the seed. See
+"set scheduler".`,
+		Min_args: 0,
+		Max_args: 0,
+		Short_help: "how goroutines take turns running",
+		Name: "scheduler",
+	})
+}
+
+func ShowSchedulerSubcmd(args []string) {
+	if s := interp.GetScheduler(); s != nil {
+		gub.Msg("Goroutines are scheduled %s", s)
+	} else {
+		gub.Msg("Goroutines are scheduled by the Go runtime")
+	}
+}
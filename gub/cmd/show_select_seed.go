@@ -0,0 +1,34 @@
+// Copyright 2015 Rocky Bernstein.
+
+// show select-seed - how does a select statement pick among ready cases?
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+	"github.com/rocky/ssa-interp/interp"
+)
+
+func init() {
+	parent := "show"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: ShowSelectSeedSubcmd,
+		Help: `show select-seed
+
+Show how a select statement picks a case when more than one is ready.
+See "set select-seed".`,
+		Min_args: 0,
+		Max_args: 0,
+		Short_help: "how select picks among ready cases",
+		Name: "select-seed",
+	})
+}
+
+func ShowSelectSeedSubcmd(args []string) {
+	switch choice := interp.SelectChoice(); choice {
+	case "random", "ordered":
+		gub.Msg("select picks among ready cases: %s", choice)
+	default:
+		gub.Msg("select picks among ready cases with seed %s", choice)
+	}
+}
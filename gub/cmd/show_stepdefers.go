@@ -0,0 +1,28 @@
+// Copyright 2015 Rocky Bernstein.
+
+// show step-defers - stop in deferred calls when stepping over a return?
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+	"github.com/rocky/ssa-interp/interp"
+)
+
+func init() {
+	parent := "show"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: ShowStepDefersSubcmd,
+		Help: `show step-defers
+
+Show whether to stop in deferred calls when stepping over a return`,
+		Min_args: 0,
+		Max_args: 0,
+		Short_help: "show whether to stop in deferred calls when stepping over a return",
+		Name: "step-defers",
+	})
+}
+
+func ShowStepDefersSubcmd(args []string) {
+	ShowOnOff(args[1], interp.StepDefers())
+}
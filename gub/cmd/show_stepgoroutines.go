@@ -0,0 +1,28 @@
+// Copyright 2015 Rocky Bernstein.
+
+// show step-goroutines - stop in goroutines started while stepping?
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+	"github.com/rocky/ssa-interp/interp"
+)
+
+func init() {
+	parent := "show"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: ShowStepGoroutinesSubcmd,
+		Help: `show step-goroutines
+
+Show whether to stop in goroutines started while stepping`,
+		Min_args: 0,
+		Max_args: 0,
+		Short_help: "show whether to stop in goroutines started while stepping",
+		Name: "step-goroutines",
+	})
+}
+
+func ShowStepGoroutinesSubcmd(args []string) {
+	ShowOnOff(args[1], interp.StepGoroutines())
+}
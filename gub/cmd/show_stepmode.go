@@ -0,0 +1,27 @@
+// Copyright 2015 Rocky Bernstein.
+
+// show step-mode - what counts as one step?
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	parent := "show"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: ShowStepModeSubcmd,
+		Help: `show step-mode
+
+Show what "step" counts as one step`,
+		Min_args: 0,
+		Max_args: 0,
+		Short_help: "show what counts as one step",
+		Name: "step-mode",
+	})
+}
+
+func ShowStepModeSubcmd(args []string) {
+	gub.Msg("Step mode is %s", gub.StepMode)
+}
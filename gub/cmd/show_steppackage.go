@@ -0,0 +1,27 @@
+// Copyright 2015 Rocky Bernstein.
+
+// show step-package - keep step in the current package?
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	parent := "show"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: ShowStepPackageSubcmd,
+		Help: `show step-package
+
+Show whether "step" stays in the current package`,
+		Min_args: 0,
+		Max_args: 0,
+		Short_help: "show whether step stays in the current package",
+		Name: "step-package",
+	})
+}
+
+func ShowStepPackageSubcmd(args []string) {
+	ShowOnOff(args[1], gub.StepPackage)
+}
@@ -0,0 +1,30 @@
+// Copyright 2015 Rocky Bernstein.
+
+// show theme - colors of the parts of debugger output
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	parent := "show"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: ShowThemeSubcmd,
+		Help: `show theme
+
+Show the color each kind of debugger output is shown in when
+highlighting is on`,
+		Min_args: 0,
+		Max_args: 0,
+		Short_help: "colors of the parts of debugger output",
+		Name: "theme",
+	})
+}
+
+func ShowThemeSubcmd(args []string) {
+	for _, name := range gub.ThemeNames() {
+		gub.Msg("%-14s %s", name, gub.Themed(name, gub.Theme[name]))
+	}
+}
@@ -0,0 +1,28 @@
+// Copyright 2015 Rocky Bernstein.
+
+// show trap-exit - stop when the program calls os.Exit?
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+	"github.com/rocky/ssa-interp/interp"
+)
+
+func init() {
+	parent := "show"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: ShowTrapExitSubcmd,
+		Help: `show trap-exit
+
+Show whether the program stops when it calls os.Exit`,
+		Min_args: 0,
+		Max_args: 0,
+		Short_help: "show whether the program stops when it calls os.Exit",
+		Name: "trap-exit",
+	})
+}
+
+func ShowTrapExitSubcmd(args []string) {
+	ShowOnOff(args[1], interp.ExitTrap())
+}
@@ -0,0 +1,36 @@
+// Copyright 2015 Rocky Bernstein.
+
+// show values - the value history
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	parent := "show"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: ShowValuesSubcmd,
+		Help: `show values [*count*]
+
+Show the last *count* values, 10 by default, in the value history.
+Each value shown by "eval" is added to the history as $1, $2, and so
+on. They can be used in later expressions, as in "eval $3.Name". $ by
+itself is the last value.`,
+		Min_args: 0,
+		Max_args: 1,
+		Short_help: "show the value history",
+		Name: "values",
+	})
+}
+
+func ShowValuesSubcmd(args []string) {
+	count := 10
+	if len(args) == 3 {
+		var err error
+		count, err = gub.GetInt(args[2], "count", 1, 0)
+		if err != nil { return }
+	}
+	gub.PrintValueHistory(count)
+}
@@ -0,0 +1,96 @@
+// Copyright 2015 Rocky Bernstein.
+// Debugger skip command
+
+package gubcmd
+
+import (
+	"strconv"
+
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	name := "skip"
+	gub.Cmds[name] = &gub.CmdInfo{
+		Fn: SkipCommand,
+		Help: `skip [function *regexp* | file *glob* | delete *num* | clear]
+
+Keep "step" from descending into functions whose names match regular
+expression *regexp*, or which are defined in files matching *glob*.
+Such calls are stepped over as "next" would. Breakpoints inside
+skipped code still stop. For example:
+
+   skip function ^fmt\.
+   skip file *_string.go
+
+A function name looks like fmt.Printf or (*bytes.Buffer).Write. A
+file glob is matched against both the full file name and its base
+name.
+
+With no arguments, the skips set are listed. "skip delete *num*"
+removes the skip numbered *num* in that list, and "skip clear"
+removes them all.
+
+See also "step".
+`,
+		Min_args: 0,
+		Max_args: 2,
+	}
+	gub.AddToCategory("running", name)
+}
+
+// SkipCommand implements the debugger command:
+//    skip [function *regexp* | file *glob* | delete *num* | clear]
+// which keeps "step" from descending into matching functions or
+// files.
+//
+// See also "step".
+func SkipCommand(args []string) {
+	if len(args) == 1 {
+		if len(gub.Skips) == 0 {
+			gub.Msg("No skips set")
+			return
+		}
+		gub.Section("Num Type     Pattern")
+		for i, skip := range gub.Skips {
+			gub.Msg("%3d %-8s %s", i, skip.Kind, skip.Pattern)
+		}
+		return
+	}
+	switch args[1] {
+	case "clear":
+		gub.Skips = nil
+		gub.Msg("All skips removed")
+		return
+	case "function", "file", "delete":
+		if len(args) != 3 {
+			gub.Errmsg("skip %s needs an argument", args[1])
+			return
+		}
+	default:
+		gub.Errmsg("Expecting \"function\", \"file\", \"delete\", or \"clear\"; got %s", args[1])
+		return
+	}
+	var err error
+	switch args[1] {
+	case "function":
+		err = gub.SkipFunctionAdd(args[2])
+	case "file":
+		err = gub.SkipFileAdd(args[2])
+	case "delete":
+		n, err := gub.GetInt(args[2], "skip number", 0, 0)
+		if err != nil { return }
+		if n >= len(gub.Skips) {
+			gub.Errmsg("Skip %d doesn't exist", n)
+			return
+		}
+		gub.Skips = append(gub.Skips[:n], gub.Skips[n+1:]...)
+		gub.Msg("Skip %d deleted", n)
+		return
+	}
+	if err != nil {
+		gub.Errmsg("Bad %s pattern %s: %s", args[1], strconv.Quote(args[2]), err)
+		return
+	}
+	gub.Msg("Skip %d set on %s %s", len(gub.Skips)-1, args[1], args[2])
+}
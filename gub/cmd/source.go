@@ -0,0 +1,61 @@
+// Copyright 2015 Rocky Bernstein.
+
+// source command
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	name := "source"
+	gub.Cmds[name] = &gub.CmdInfo{
+		Fn: SourceCommand,
+		Help: `source [-e] [-v] *file*
+
+Run the debugger commands in *file*, one per line. Blank lines and
+lines starting with # are skipped.
+
+With -e, the rest of the file is skipped once a command reports an
+error; otherwise we go on with the next command. With -v, each command
+is shown before it is run.
+
+A command in the file that resumes the program, like "continue" or
+"next", lets it run; the rest of the file is run when the program next
+stops. So a file can set breakpoints, run to them and show values
+there, which makes for reproducible debugging sessions.
+
+The commands in ~/.gubrc, and then those in .gubrc in the current
+directory, are run this way when the debugger first stops, unless gub
+is given the -nx option. That is a good place for things like aliases,
+settings and breakpoints you always want. The .gubrc in the current
+directory is skipped unless it is yours and not everyone can write
+to it.
+`,
+		Min_args: 1,
+		Max_args: 3,
+	}
+	gub.AddToCategory("support", name)
+}
+
+// SourceCommand implements the debugger command:
+//    source [-e] [-v] *file*
+// which runs the debugger commands in *file*.
+func SourceCommand(args []string) {
+	stopOnError, verbose := false, false
+	for _, opt := range args[1:len(args)-1] {
+		switch opt {
+		case "-e":
+			stopOnError = true
+		case "-v":
+			verbose = true
+		default:
+			gub.Errmsg("Unknown option %s; expecting -e or -v", opt)
+			return
+		}
+	}
+	if err := gub.SourceFile(args[len(args)-1], stopOnError, verbose); err != nil {
+		gub.Errmsg(err.Error())
+	}
+}
@@ -12,15 +12,22 @@ func init() {
 	name := "step"
 	gub.Cmds[name] = &gub.CmdInfo{
 		Fn: StepCommand,
-		Help: `step
+		Help: `step [--into [*n*]]
 
 Execute the current statement, stopping at the next event.  Sometimes this
 is called 'step into'.
 
-See also: stepi, continue, finish, and next.
+When a statement makes several calls, as in f(g(), h()), "step --into
+*n*" steps over the calls made before the *n*th one and steps into
+that. Calls are numbered from 1 in the order they are made. "step
+--into" by itself lists the calls the current statement will make.
+
+What counts as one step is set by "set step-mode".
+
+See also: stepi, continue, finish, next, and set step-mode.
 `,
 		Min_args: 0,
-		Max_args: 0,
+		Max_args: 2,
 	}
 	gub.AddToCategory("running", name)
 	// Down the line we'll have abbrevs
@@ -32,10 +39,56 @@ See also: stepi, continue, finish, and next.
 // This executes the current statement, stopping at the next event.
 // Sometimes this is called 'step into'.
 //
+// With "--into *n*" it steps into the *n*th call of the statement.
+//
 // See also: stepi, continue, finish, and next.
 func StepCommand(args []string) {
-	gub.Msg("Stepping...")
-	interp.SetStepIn(gub.CurFrame())
+	if len(args) > 1 {
+		if args[1] != "--into" {
+			gub.Errmsg("Expecting \"--into\"; got %s", args[1])
+			return
+		}
+		if len(args) == 2 {
+			listPendingCalls()
+			return
+		}
+		n, err := gub.GetInt(args[2], "call number", 1, 0)
+		if err != nil { return }
+		gub.Msg("Stepping into call %d...", n)
+		interp.SetStepInto(gub.CurFrame(), n)
+		gub.LastCommand = "step"
+		gub.InCmdLoop = false
+		return
+	}
+	switch gub.StepMode {
+	case "instruction":
+		gub.Msg("Stepping Instruction...")
+		interp.SetStepInstruction(gub.CurFrame())
+	case "line":
+		gub.Msg("Stepping line...")
+		gub.LineStepStart(gub.CurFrame())
+		interp.SetStepIn(gub.CurFrame())
+	default:
+		gub.Msg("Stepping...")
+		interp.SetStepIn(gub.CurFrame())
+	}
 	gub.LastCommand = "step " + gub.CmdArgstr
 	gub.InCmdLoop = false
 }
+
+// listPendingCalls shows the calls the current statement will make,
+// numbered as "step --into" counts them.
+func listPendingCalls() {
+	calls := gub.PendingCalls(gub.CurFrame())
+	if len(calls) == 0 {
+		gub.Msg("No calls found before the next statement")
+		return
+	}
+	for i, c := range calls {
+		callee := c.String()
+		if fn := c.StaticCallee(); fn != nil {
+			callee = fn.String()
+		}
+		gub.Msg("%3d: %s (%s)", i+1, callee, c.Description())
+	}
+}
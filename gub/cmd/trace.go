@@ -0,0 +1,68 @@
+// Copyright 2015 Rocky Bernstein.
+// Debugger trace command which sets tracepoints
+
+package gubcmd
+
+import (
+	"strings"
+
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	name := "trace"
+	gub.Cmds[name] = &gub.CmdInfo{
+		Fn: TraceCommand,
+		Help: `trace *location* [*expr1*, *expr2* ...]
+
+Set a tracepoint at *location*, which is a function name or a
+[*file*:]*line* as in "breakpoint". Whenever execution reaches there,
+the function, goroutine, and position are recorded along with the
+values of the expressions given, and execution continues without
+stopping. For example:
+
+   trace 24 i, name
+
+Use "info tracepoints" to see what has been collected. Tracepoints
+are listed by "info break" and can be disabled, enabled, or deleted
+like breakpoints.
+
+See also "dprintf", "breakpoint", and "info tracepoints".
+`,
+
+		Min_args: 1,
+		Max_args: -1,
+	}
+	gub.AddToCategory("breakpoints", name)
+}
+
+// TraceCommand implements the debugger command:
+//    trace *location* [*expr1*, *expr2* ...]
+// which sets a tracepoint that records each time *location* is
+// reached along with the values of the expressions, without stopping.
+//
+// See also "dprintf", "breakpoint", and "info tracepoints".
+func TraceCommand(args []string) {
+	argstr := strings.TrimSpace(gub.CmdArgstr)
+	loc := args[1]
+	var collect []string
+	if rest := strings.TrimSpace(argstr[len(loc):]); rest != "" {
+		var err error
+		collect, err = gub.ParseExprList(rest)
+		if err != nil {
+			gub.Errmsg("Bad trace expressions %s: %s", rest, err)
+			return
+		}
+	}
+	bp := &gub.Breakpoint {
+		Hits: 0,
+		Ignore: 0,
+		Temp: false,
+		Enabled: true,
+		Trace: true,
+		Collect: collect,
+	}
+	bpnum, where := setLocBreakpoint(loc, bp)
+	if bpnum < 0 { return }
+	gub.Msg("Tracepoint %d set in %s", bpnum, where)
+}
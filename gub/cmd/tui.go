@@ -0,0 +1,55 @@
+// Copyright 2015 Rocky Bernstein.
+
+// tui command
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	name := "tui"
+	gub.Cmds[name] = &gub.CmdInfo{
+		Fn: TuiCommand,
+		Help: `tui [on | off | asm]
+
+Split the terminal into panes. The top half shows the source around
+where the program is stopped, with the line marked "=>" and
+breakpoints marked "B", or "b" if disabled. With "asm" the SSA
+instructions of the current block are shown below the source. The
+bottom half is for commands and their output. The panes follow the
+program as it runs and the frame selected with "up", "down" and
+"frame".
+
+"tui off" goes back to using the whole terminal for commands.
+
+The size of the terminal is taken from "set height" and the COLS
+environment variable.
+`,
+		Min_args: 0,
+		Max_args: 1,
+	}
+	gub.AddToCategory("support", name)
+}
+
+// TuiCommand implements the debugger command:
+//    tui [on | off | asm]
+// which splits the terminal into source, disassembly and command
+// panes.
+func TuiCommand(args []string) {
+	what := "on"
+	if len(args) == 2 {
+		what = args[1]
+	}
+	switch what {
+	case "on", "asm":
+		if err := gub.TUIEnable(what == "asm"); err != nil {
+			gub.Errmsg(err.Error())
+		}
+	case "off":
+		gub.TUIDisable()
+	default:
+		gub.Errmsg("Expecting 'on', 'off' or 'asm', got '%s'", what)
+	}
+}
@@ -0,0 +1,36 @@
+// Copyright 2015 Rocky Bernstein.
+
+// unalias command
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	name := "unalias"
+	gub.Cmds[name] = &gub.CmdInfo{
+		Fn: UnaliasCommand,
+		Help: `unalias *name*...
+
+Remove the aliases *name*....
+
+See also "alias" and "aliases".
+`,
+		Min_args: 1,
+		Max_args: -1,
+	}
+	gub.AddToCategory("support", name)
+}
+
+// UnaliasCommand implements the debugger command:
+//    unalias *name*...
+// which removes aliases.
+func UnaliasCommand(args []string) {
+	for _, alias := range args[1:] {
+		if !gub.RemoveAlias(alias) {
+			gub.Errmsg("No alias %s", alias)
+		}
+	}
+}
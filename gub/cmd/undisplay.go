@@ -0,0 +1,51 @@
+// Copyright 2015 Rocky Bernstein.
+// Debugger undisplay command
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	name := "undisplay"
+	gub.Cmds[name] = &gub.CmdInfo{
+		Fn: UndisplayCommand,
+		Help: `undisplay [*num*...]
+
+Remove the display expressions with the given numbers. Without
+numbers, remove all of them.
+
+See also "display" and "info display".
+`,
+		Min_args: 0,
+		Max_args: -1,
+	}
+	gub.AddToCategory("inspecting", name)
+}
+
+// UndisplayCommand implements the debugger command:
+//    undisplay [*num*...]
+// which removes expressions added by "display".
+//
+// See also "display" and "info display".
+func UndisplayCommand(args []string) {
+	if len(args) == 1 {
+		if !gub.Confirm("Delete all auto-display expressions?", true) {
+			return
+		}
+		for n := range gub.Displays {
+			gub.DisplayDelete(n)
+		}
+		return
+	}
+	for _, arg := range args[1:] {
+		n, err := gub.GetInt(arg, "display number", 0, 0)
+		if err != nil {
+			continue
+		}
+		if !gub.DisplayDelete(n) {
+			gub.Errmsg("Display %d doesn't exist", n)
+		}
+	}
+}
@@ -11,7 +11,8 @@ func init() {
 		Help: `up [*count*]
 
 Move the current frame up in the stack trace (to a older frame). 0
-is the most-recent frame. If no count is given, move down 1.
+is the most-recent frame. If no count is given, move up 1. A count
+that goes past the oldest frame stops there.
 
 See also 'down' and 'frame'.
 `,
@@ -0,0 +1,75 @@
+// Copyright 2015 Rocky Bernstein.
+// Debugger watch command
+
+package gubcmd
+
+import (
+	"strings"
+
+	"github.com/rocky/ssa-interp"
+	"github.com/rocky/ssa-interp/gub"
+	"github.com/rocky/ssa-interp/interp"
+)
+
+func init() {
+	name := "watch"
+	gub.Cmds[name] = &gub.CmdInfo{
+		Fn: WatchCommand,
+		Help: `watch *name* | *name*[*index*]
+
+Set a watchpoint on variable *name*. Execution stops whenever a new
+value is stored into the variable, and the old and new values are
+shown. *name* can be a local variable, a global variable of the
+current package, or a package-qualified global like pkg.var.
+
+An element of a slice, array, or map can be watched by giving an index
+expression like s[3] or m["key"]. For a map, execution stops when that
+key's element is set, whether or not the key was in the map before.
+
+A watchpoint on a local variable is tied to the activation of the
+function it was set in.
+
+See also "rwatch", "info break", "enable", "disable", and "delete".
+`,
+
+		Min_args: 1,
+		Max_args: -1,
+	}
+	gub.AddToCategory("breakpoints", name)
+}
+
+// WatchCommand implements the debugger command:
+//    watch *name* | *name*[*index*]
+// which sets a write watchpoint on variable *name* or on an element of
+// it.
+//
+// See also "info break", "enable", "disable", and "delete".
+func WatchCommand(args []string) {
+	name := strings.TrimSpace(gub.CmdArgstr)
+	fr := gub.CurFrame()
+	if strings.Contains(name, "[") {
+		addr, entry, err := gub.WatchExprLookup(fr, name, gub.CurScope())
+		if err != nil {
+			gub.Errmsg(err.Error())
+			return
+		}
+		var bpnum int
+		if entry != nil {
+			bpnum = gub.MapWatchpointAdd(name, entry, fr.StartP(), fr.EndP())
+		} else {
+			bpnum = gub.WatchpointAdd(name, addr, fr.StartP(), fr.EndP(),
+				interp.WATCH_WRITE)
+		}
+		gub.Msg("Watchpoint %d set on %s", bpnum, name)
+		return
+	}
+	addr, nameVal, err := gub.VarAddrLookup(fr, name, gub.CurScope())
+	if err != nil {
+		gub.Errmsg(err.Error())
+		return
+	}
+	bpnum := gub.WatchpointAdd(name, addr, nameVal.Pos(), nameVal.Pos(),
+		interp.WATCH_WRITE)
+	gub.Msg("Watchpoint %d set on %s at %s", bpnum, name,
+		ssa2.FmtPos(fr.Fset(), nameVal.Pos()))
+}
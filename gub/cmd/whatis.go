@@ -11,6 +11,7 @@ func init() {
 		Help: `whatis *name*
 
 print information about *name* which can include a dotted variable name.
+Anything more complicated than a name is evaluated as in "eval".
 `,
 		Min_args: 1,
 		Max_args: 1,
@@ -2,6 +2,12 @@
 
 package gub
 
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
 type CmdFunc func([]string)
 
 type CmdInfo struct {
@@ -38,6 +44,81 @@ func AddAlias(alias string, cmdname string) bool {
 	return true
 }
 
+// AliasArgs holds the arguments that user-defined aliases, added by
+// the "alias" command, put in front of the arguments given. For
+// example after "alias bm break main.main", AliasArgs["bm"] is
+// "main.main".
+var AliasArgs map[string]string = make(map[string]string)
+
+// userAliases records which aliases were added by the user rather
+// than by the debugger itself.
+var userAliases map[string]bool = make(map[string]bool)
+
+// AddUserAlias adds user-defined alias "alias" for command "cmdname",
+// which can itself be an alias, with arguments args.
+func AddUserAlias(alias string, cmdname string, args string) error {
+	if Cmds[alias] != nil {
+		return fmt.Errorf("%s is a command; it can't be an alias", alias)
+	}
+	if Aliases[alias] != "" && !userAliases[alias] {
+		return fmt.Errorf("%s is already an alias for %s", alias, Aliases[alias])
+	}
+	if expansion := AliasArgs[cmdname]; expansion != "" && args != "" {
+		args = expansion + " " + args
+	} else if expansion != "" {
+		args = expansion
+	}
+	realCmd := LookupCmd(cmdname)
+	if Cmds[realCmd] == nil {
+		return fmt.Errorf("no command %s", cmdname)
+	}
+	if userAliases[alias] {
+		RemoveAlias(alias)
+	}
+	AddAlias(alias, realCmd)
+	userAliases[alias] = true
+	if args != "" {
+		AliasArgs[alias] = args
+	}
+	return nil
+}
+
+// RemoveAlias removes alias "alias". It returns false if there is no
+// such alias.
+func RemoveAlias(alias string) bool {
+	cmdname := Aliases[alias]
+	if cmdname == "" {
+		return false
+	}
+	delete(Aliases, alias)
+	delete(AliasArgs, alias)
+	delete(userAliases, alias)
+	cmd := Cmds[cmdname]
+	for i, a := range cmd.Aliases {
+		if a == alias {
+			cmd.Aliases = append(cmd.Aliases[:i], cmd.Aliases[i+1:]...)
+			break
+		}
+	}
+	return true
+}
+
+// ExpandAlias returns command line line with a user-defined alias at
+// the start replaced by what it stands for.
+func ExpandAlias(line string) string {
+	name := strings.SplitN(line, " ", 2)[0]
+	args := AliasArgs[name]
+	if args == "" {
+		return line
+	}
+	rest := strings.TrimLeft(line[len(name):], " ")
+	line = Aliases[name] + " " + args
+	if rest != "" {
+		line += " " + rest
+	}
+	return line
+}
+
 // AddToCategory adds "cmdname" into general debugger category "category".
 func AddToCategory(category string, cmdname string) {
 	Categories[category] = append(Categories[category], cmdname)
@@ -46,10 +127,35 @@ func AddToCategory(category string, cmdname string) {
 
 
 // LookupCmd canonicalize parameter cmd, by changing it to the underlying
-// debugger command if it is an alias.
+// debugger command if it is an alias or a unique prefix of a command
+// name, like "disa" for "disassemble". An exact command name wins over
+// an alias, and an alias over a prefix. "" is returned if cmd is none
+// of these; see CmdCandidates for telling why.
 func LookupCmd(cmd string) (string) {
-	if Cmds[cmd] == nil {
-		cmd = Aliases[cmd];
+	if Cmds[cmd] != nil {
+		return cmd
+	}
+	if realCmd := Aliases[cmd]; realCmd != "" {
+		return realCmd
+	}
+	if candidates := CmdCandidates(cmd); len(candidates) == 1 {
+		return candidates[0]
+	}
+	return ""
+}
+
+// CmdCandidates returns the sorted names of the commands that start
+// with prefix.
+func CmdCandidates(prefix string) []string {
+	var candidates []string
+	if prefix == "" {
+		return candidates
+	}
+	for name := range Cmds {
+		if strings.HasPrefix(name, prefix) {
+			candidates = append(candidates, name)
+		}
 	}
-	return cmd
+	sort.Strings(candidates)
+	return candidates
 }
@@ -0,0 +1,165 @@
+// Copyright 2015 Rocky Bernstein.
+// Completion of command names, subcommands, functions and variables
+
+package gub
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/rocky/ssa-interp"
+)
+
+// funcArgCmds are the commands whose argument is a location, so
+// function names are completed.
+var funcArgCmds = map[string]bool{
+	"breakpoint": true,
+	"disassemble": true,
+	"dprintf": true,
+	"list": true,
+	"trace": true,
+}
+
+// exprArgCmds are the commands whose argument is an expression, so
+// names of variables in scope are completed.
+var exprArgCmds = map[string]bool{
+	"call": true,
+	"display": true,
+	"eval": true,
+	"rwatch": true,
+	"watch": true,
+	"whatis": true,
+}
+
+// Complete returns the ways line, a partial command line, can be
+// completed. Each completion is a full command line.
+func Complete(line string) []string {
+	line = strings.TrimLeft(line, " ")
+	words := strings.Split(line, " ")
+	last := words[len(words)-1]
+	prefix := line[:len(line)-len(last)]
+	if len(words) == 1 {
+		return completions(prefix, last, commandNames())
+	}
+	name := LookupCmd(words[0])
+	cmd := Cmds[name]
+	if cmd == nil {
+		return nil
+	}
+	switch {
+	case cmd.SubcmdMgr != nil && len(words) == 2:
+		var names []string
+		for subcmd := range cmd.SubcmdMgr.Subcmds {
+			names = append(names, subcmd)
+		}
+		return completions(prefix, last, names)
+	case funcArgCmds[name] && len(words) == 2:
+		return completions(prefix, last, functionNames())
+	case exprArgCmds[name]:
+		// Complete the identifier at the end of the expression.
+		i := len(last)
+		for i > 0 && (isIdentChar(last[i-1]) || last[i-1] == '$' ||
+			last[i-1] >= 0x80) {
+			i--
+		}
+		if i > 0 && last[i-1] == '.' {
+			// A field or method; a package member is all we know.
+			j := i - 1
+			for j > 0 && isIdentChar(last[j-1]) {
+				j--
+			}
+			if pkg := program.PackagesByName[last[j:i-1]]; pkg != nil {
+				var names []string
+				for member := range pkg.Members {
+					names = append(names, member)
+				}
+				return completions(prefix+last[:i], last[i:], names)
+			}
+			return nil
+		}
+		return completions(prefix+last[:i], last[i:], scopeNames())
+	}
+	return nil
+}
+
+// completions returns prefix followed by each of names that starts
+// with word, sorted.
+func completions(prefix, word string, names []string) []string {
+	var list []string
+	seen := make(map[string]bool)
+	for _, name := range names {
+		if strings.HasPrefix(name, word) && !seen[name] {
+			seen[name] = true
+			list = append(list, prefix+name)
+		}
+	}
+	sort.Strings(list)
+	return list
+}
+
+// commandNames returns the names of all debugger commands and their
+// aliases.
+func commandNames() []string {
+	var names []string
+	for name := range Cmds {
+		names = append(names, name)
+	}
+	for alias := range Aliases {
+		names = append(names, alias)
+	}
+	return names
+}
+
+// functionNames returns the names of the functions of the current
+// package, and those of the other packages as pkg.Func.
+func functionNames() []string {
+	var names []string
+	cur := curFrame.Fn().Pkg
+	for pkgName, pkg := range program.PackagesByName {
+		for name, member := range pkg.Members {
+			if _, ok := member.(*ssa2.Function); !ok {
+				continue
+			}
+			if pkg == cur {
+				names = append(names, name)
+			}
+			names = append(names, pkgName+"."+name)
+		}
+	}
+	return names
+}
+
+// scopeNames returns the names visible from where we are stopped:
+// variables in scope in the current frame, free variables, members of
+// the current package, package names and convenience variables.
+func scopeNames() []string {
+	var names []string
+	fn := curFrame.Fn()
+	inScope := make(map[*ssa2.Scope]bool)
+	for scope := curScope; scope != nil; scope = parentScope(fn, scope) {
+		inScope[scope] = true
+	}
+	for nameScope := range fn.LocalsByName {
+		if inScope[nameScope.Scope] {
+			names = append(names, nameScope.Name)
+		}
+	}
+	for _, p := range fn.Params {
+		names = append(names, p.Name())
+	}
+	for _, fv := range fn.FreeVars {
+		names = append(names, fv.Name())
+	}
+	if fn.Pkg != nil {
+		for name := range fn.Pkg.Members {
+			names = append(names, name)
+		}
+	}
+	for pkgName := range program.PackagesByName {
+		names = append(names, pkgName)
+	}
+	for name := range convVars {
+		names = append(names, "$"+name)
+	}
+	return names
+}
@@ -0,0 +1,34 @@
+// Copyright 2015 Rocky Bernstein.
+// Asking the user to confirm a dangerous command
+
+package gub
+
+import (
+	"strings"
+)
+
+// Confirm asks question and returns true if the answer is yes. When
+// commands come from a file rather than a terminal, we don't ask and
+// dflt is the answer.
+func Confirm(question string, dflt bool) bool {
+	if inputReader != nil || BatchMode() {
+		return dflt
+	}
+	if MIMode() {
+		// Front ends ask before they send a command.
+		return true
+	}
+	for {
+		line, err := readLine(question + " (y or n) ", false)
+		if err != nil {
+			return false
+		}
+		switch strings.ToLower(strings.TrimSpace(line)) {
+		case "y", "yes":
+			return true
+		case "n", "no":
+			return false
+		}
+		Msg("Please answer y or n.")
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright 2015 Rocky Bernstein.
+// User-defined commands made up of other debugger commands
+
+package gub
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// UserCmds holds the body of each user-defined command, keyed by
+// command name.
+var UserCmds = make(map[string][]string)
+
+// userCmdDepth is how deeply user-defined commands are running one
+// another, so that a command running itself doesn't go on forever.
+var userCmdDepth int
+
+// argRef matches the references to arguments in the body of a
+// user-defined command: $argc and $arg0, $arg1, ...
+var argRef = regexp.MustCompile(`\$arg(c|[0-9]+)`)
+
+// hookPrefixes are the prefixes of the names of user-defined
+// commands that are hooks run before and after a debugger command.
+// For example "hook-list" is run before each "list" and
+// "hookpost-list" after it. "hook-stop" is run each time the program
+// stops, before we ask for commands.
+var hookPrefixes = []string{"hook-", "hookpost-"}
+
+// StopHook is the name of the user-defined command run at each stop.
+const StopHook = "hook-stop"
+
+// runningHooks are the hooks that are running, so that a hook that
+// runs the command it is a hook of doesn't run itself again.
+var runningHooks = make(map[string]bool)
+
+// hookName returns the name under which user-defined command name is
+// kept. For a hook of a command given by an alias or an abbreviation,
+// like "hook-c", that is the hook of the command, "hook-continue".
+func hookName(name string) (string, error) {
+	for _, prefix := range hookPrefixes {
+		if !strings.HasPrefix(name, prefix) || name == StopHook {
+			continue
+		}
+		cmd := LookupCmd(name[len(prefix):])
+		if cmd == "" {
+			return "", fmt.Errorf("no command %s to hook", name[len(prefix):])
+		}
+		return prefix + cmd, nil
+	}
+	return name, nil
+}
+
+// RunHook runs user-defined hook command name if there is one.
+func RunHook(name string) {
+	if UserCmds[name] == nil || runningHooks[name] {
+		return
+	}
+	runningHooks[name] = true
+	defer delete(runningHooks, name)
+	runUserCmd(name, nil)
+}
+
+// DefineCommand adds user-defined command name which runs the
+// debugger commands in body. A command already defined by the user is
+// replaced.
+func DefineCommand(name string, body []string) error {
+	name, err := hookName(name)
+	if err != nil {
+		return err
+	}
+	if cmd := Cmds[name]; cmd != nil && UserCmds[name] == nil {
+		return fmt.Errorf("%s is a debugger command; it can't be redefined", name)
+	}
+	if Aliases[name] != "" {
+		return fmt.Errorf("%s is an alias for %s", name, Aliases[name])
+	}
+	if UserCmds[name] == nil {
+		AddToCategory("user-defined", name)
+	}
+	UserCmds[name] = body
+	Cmds[name] = &CmdInfo{
+		Fn: func(args []string) { runUserCmd(name, args[1:]) },
+		Help: fmt.Sprintf("User-defined command %s:\n\n   %s", name,
+			strings.Join(body, "\n   ")),
+		Min_args: 0,
+		Max_args: -1,
+	}
+	return nil
+}
+
+// runUserCmd runs the commands of user-defined command name with
+// $argc and $argN in them replaced by the arguments args. As with a
+// file of commands, when one resumes the program the rest are run
+// when we next stop.
+func runUserCmd(name string, args []string) {
+	if userCmdDepth >= 20 {
+		Errmsg("User-defined commands nested too deeply running %s", name)
+		return
+	}
+	userCmdDepth++
+	defer func() { userCmdDepth-- }()
+	var lines []string
+	for _, line := range UserCmds[name] {
+		line = argRef.ReplaceAllStringFunc(line, func(ref string) string {
+			n := ref[len("$arg"):]
+			if n == "c" {
+				return strconv.Itoa(len(args))
+			}
+			if i, _ := strconv.Atoi(n); i < len(args) {
+				return args[i]
+			}
+			return ref
+		})
+		lines = append(lines, line)
+	}
+	depth := len(sources)
+	sources = append(sources, &cmdSource{name, lines, false, false})
+	runSources(depth)
+}
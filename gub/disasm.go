@@ -2,9 +2,24 @@
 package gub
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/rocky/ssa-interp"
 )
 
+// blockList shows blocks as ".1 .3", or "-" when there are none.
+func blockList(blocks []*ssa2.BasicBlock) string {
+	if len(blocks) == 0 {
+		return "-"
+	}
+	names := make([]string, len(blocks))
+	for i, b := range blocks {
+		names[i] = fmt.Sprintf(".%s", b)
+	}
+	return strings.Join(names, " ")
+}
+
 func DisasmPrefix(block *ssa2.BasicBlock) bool {
 	if block == nil {
 		Msg(":.nil:")
@@ -12,7 +27,12 @@ func DisasmPrefix(block *ssa2.BasicBlock) bool {
 	} else if block.Scope != nil {
 		Section("# scope %d", block.Scope.ScopeId())
 	}
-	Section("Block .%s:", block)
+	if block.Comment != "" {
+		Section("Block .%s: # %s", block, block.Comment)
+	} else {
+		Section("Block .%s:", block)
+	}
+	Msg("# preds: %s; succs: %s", blockList(block.Preds), blockList(block.Succs))
 	return true
 }
 
@@ -28,7 +48,7 @@ func DisasmInst(f *ssa2.Function, bnum int, inst uint64) {
 		return
 	}
 	if 	b := f.Blocks[bnum]; DisasmPrefix(b) {
-		Msg("%3d: %s",  inst, ssa2.DisasmInst(b.Instrs[inst], Maxwidth))
+		Msg("%3d: %s",  inst, disasmInst(b.Instrs[inst]))
 	}
 }
 
@@ -39,10 +59,98 @@ func DisasmBlock(f *ssa2.Function, i int, pc int) {
 		return
 	}
 	if b := f.Blocks[i]; DisasmPrefix(b) {
-		for i, instr := range b.Instrs {
-			prefix := "  "
-			if i == pc { prefix = "=>" }
-			Msg("%s%3d: %s",  prefix, i, ssa2.DisasmInst(instr, Maxwidth))
+		disasmInstrs(b, pc, 0, 0)
+	}
+}
+
+// instrLines returns the source line of each instruction of block b.
+// An instruction without a position of its own, like a jump, gets the
+// line of the instruction before it.
+func instrLines(b *ssa2.BasicBlock) []int {
+	lines := make([]int, len(b.Instrs))
+	line := 0
+	for i, instr := range b.Instrs {
+		if pos := instr.Pos(); pos.IsValid() {
+			line = program.Fset.Position(pos).Line
+		}
+		lines[i] = line
+	}
+	return lines
+}
+
+// disasmInstrs shows the instructions of block b, marking instruction
+// pc with "=>". Before the first instruction of each source line we
+// show that line as a comment. If last is not 0, only instructions
+// from source lines first to last are shown.
+func disasmInstrs(b *ssa2.BasicBlock, pc int, first, last int) {
+	var srcLines []string
+	if pos := b.Parent().Pos(); pos.IsValid() {
+		srcLines, _ = SourceLines(program.Fset.Position(pos).Filename)
+	}
+	lastLine := 0
+	for i, line := range instrLines(b) {
+		if last != 0 && (line < first || line > last) {
+			continue
+		}
+		if line != 0 && line != lastLine {
+			lastLine = line
+			text := ""
+			if line <= len(srcLines) {
+				text = strings.TrimSpace(srcLines[line-1])
+			}
+			Msg("     # %d: %s", line, text)
 		}
+		instr := b.Instrs[i]
+		prefix := "  "
+		if i == pc { prefix = "=>" }
+		Msg("%s%3d: %s",  prefix, i, disasmInst(instr))
+	}
+}
+
+// DisasmFunction shows the blocks of function f. If f is the function
+// of the current frame, the instruction it is stopped at is marked.
+func DisasmFunction(f *ssa2.Function) {
+	Section("Function %s", f)
+	if len(f.Blocks) == 0 {
+		Msg("# external function, no instructions")
+		return
+	}
+	for _, b := range f.Blocks {
+		pc := -1
+		if f == curFrame.Fn() && b == curFrame.Block() {
+			pc = curFrame.PC()
+		}
+		if DisasmPrefix(b) {
+			disasmInstrs(b, pc, 0, 0)
+		}
+	}
+}
+
+// DisasmLines shows the instructions of function f that come from
+// source lines first to last, along with the blocks they are in.
+func DisasmLines(f *ssa2.Function, first, last int) {
+	found := false
+	for _, b := range f.Blocks {
+		inRange := false
+		for _, line := range instrLines(b) {
+			if line >= first && line <= last {
+				inRange = true
+				break
+			}
+		}
+		if !inRange {
+			continue
+		}
+		found = true
+		pc := -1
+		if f == curFrame.Fn() && b == curFrame.Block() {
+			pc = curFrame.PC()
+		}
+		if DisasmPrefix(b) {
+			disasmInstrs(b, pc, first, last)
+		}
+	}
+	if !found {
+		Errmsg("No instructions of %s come from lines %d-%d", f, first, last)
 	}
 }
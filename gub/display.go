@@ -0,0 +1,67 @@
+// Copyright 2015 Rocky Bernstein.
+// Expressions shown each time the debugger stops
+
+package gub
+
+// A Display is an expression that is evaluated and shown every time
+// we stop.
+type Display struct {
+	Id      int    // Display number
+	Expr    string // Go expression to show
+	Format  string // Format letter, as in print/x, or "" for the print format
+	Deleted bool   // Set when the display has been removed
+}
+
+// Displays are the auto-display expressions. A display's number is
+// its index in Displays.
+var Displays []*Display
+
+// DisplayAdd adds expr as an auto-display expression shown using
+// format letter format. It returns the number of the display, or -1
+// if format is not valid.
+func DisplayAdd(expr string, format string) int {
+	if !checkFormat(format) {
+		return -1
+	}
+	d := &Display{Id: len(Displays), Expr: expr, Format: format}
+	Displays = append(Displays, d)
+	return d.Id
+}
+
+// DisplayExists returns true if there is an auto-display numbered n.
+func DisplayExists(n int) bool {
+	return n >= 0 && n < len(Displays) && !Displays[n].Deleted
+}
+
+// DisplayDelete removes auto-display n. It returns false if there is
+// no such display.
+func DisplayDelete(n int) bool {
+	if !DisplayExists(n) {
+		return false
+	}
+	Displays[n].Deleted = true
+	return true
+}
+
+// PrintDisplay evaluates display d in the current frame and shows it.
+func PrintDisplay(d *Display) {
+	v, t, err := EvalExpr(curFrame, curScope, d.Expr)
+	if err != nil {
+		Msg("%d: %s = <%s>", d.Id, d.Expr, err)
+		return
+	}
+	f := d.Format
+	if f == "" {
+		f = PrintFormat
+	}
+	Msg("%d: %s = %s", d.Id, d.Expr, formatValue(v, t, f))
+}
+
+// PrintDisplays shows all of the auto-display expressions.
+func PrintDisplays() {
+	for _, d := range Displays {
+		if !d.Deleted {
+			PrintDisplay(d)
+		}
+	}
+}
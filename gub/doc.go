@@ -0,0 +1,53 @@
+// Copyright 2015 Rocky Bernstein.
+
+// Package gub is a debugger for Go programs run by the SSA
+// interpreter in package interp.
+//
+// The debugger is made of layers:
+//
+//   - the command table, Cmds, filled in when package gub/cmd is
+//     initialized, along with Aliases, user-defined commands and
+//     subcommands of "info", "set" and "show";
+//   - run control: breakpoints, watchpoints, catchpoints and the
+//     stepping modes, acted on by the interpreter's trace hook
+//     GubTraceHook;
+//   - inspection: frames, evaluating expressions, listing source and
+//     disassembling SSA.
+//
+// Normally the debugger is run by "tortoise -run -interp=S" and talks
+// to a person at a terminal. A program can instead embed it with a
+// front end of its own: it implements Interface, through which all
+// of the debugger's input and output goes, and starts a Session for
+// the program to be debugged. The commands are defined by package
+// gub/cmd, which must be imported, if only for its side effects, or
+// there are none:
+//
+//	import _ "github.com/rocky/ssa-interp/gub/cmd"
+//
+//	s, err := gub.NewSession(prog, myInterface)
+//	if err != nil { ... }
+//	defer s.End()
+//	s.BreakFunction(mainPkg.Func("main"))
+//	interp.Interpret(...)
+//
+// Each time the program stops, Interface.ReadLine is called for
+// commands until one of them resumes the program. While stopped, the
+// front end can also use the Session's methods to look at the
+// program's state.
+//
+// The command table and the run control and inspection state are
+// package variables, not fields of Session; a Session is only a
+// handle on them. So there can be only one session at a time:
+// NewSession fails until the running one is ended with Session.End,
+// which removes its breakpoints, displays and event subscribers.
+//
+// Rather than parse the debugger's output, a front end can follow
+// what happens through typed events, given to a function passed to
+// Subscribe or sent on the channel Events returns:
+//
+//	for e := range gub.Events(100) {
+//		if e.Kind == gub.EventStopped {
+//			showSource(e.File, e.Line)
+//		}
+//	}
+package gub
@@ -0,0 +1,105 @@
+// Copyright 2015 Rocky Bernstein.
+// Dynamic printf breakpoints which print but don't stop
+
+package gub
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+
+	"github.com/rocky/ssa-interp/interp"
+)
+
+// parseArgs parses s, which looks like the arguments of a function
+// call, and returns the argument expressions along with the source
+// text the argument positions refer to.
+func parseArgs(s string) ([]ast.Expr, string, error) {
+	// Parse it as a call so the Go parser splits the arguments for us.
+	call := "f(" + s + ")"
+	node, err := parser.ParseExpr(call)
+	if err != nil {
+		return nil, "", err
+	}
+	callExpr, ok := node.(*ast.CallExpr)
+	if !ok {
+		return nil, "", fmt.Errorf("expecting a list of expressions")
+	}
+	return callExpr.Args, call, nil
+}
+
+// exprText returns the text of expression e parsed from src.
+func exprText(src string, e ast.Expr) string {
+	// Positions start at 1 for an expression parsed by itself.
+	return src[e.Pos()-1:e.End()-1]
+}
+
+// ParseExprList splits s, a comma-separated list of expressions, into
+// the source text of each expression.
+func ParseExprList(s string) ([]string, error) {
+	exprs, src, err := parseArgs(s)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]string, len(exprs))
+	for i, e := range exprs {
+		list[i] = exprText(src, e)
+	}
+	return list, nil
+}
+
+// ParseDprintf splits s, which looks like the arguments of a call to
+// fmt.Printf: "format", arg1, arg2 ..., into the format string and
+// the source text of each of the argument expressions.
+func ParseDprintf(s string) (format string, args []string, err error) {
+	exprs, src, err := parseArgs(s)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(exprs) == 0 {
+		return "", nil, fmt.Errorf("expecting a format string")
+	}
+	lit, ok := exprs[0].(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", nil, fmt.Errorf("expecting a format string, got %s",
+			exprText(src, exprs[0]))
+	}
+	format, err = strconv.Unquote(lit.Value)
+	if err != nil {
+		return "", nil, err
+	}
+	for _, arg := range exprs[1:] {
+		args = append(args, exprText(src, arg))
+	}
+	return format, args, nil
+}
+
+// dprintfValue converts interpreter value v into something to hand to
+// fmt. Basic values are passed as is so verbs like %d and %x work;
+// anything else is shown the way the debugger would show it.
+func dprintfValue(v interp.Value) interface{} {
+	switch v.(type) {
+	case bool, int, int8, int16, int32, int64,
+		uint, uint8, uint16, uint32, uint64, uintptr,
+		float32, float64, complex64, complex128, string:
+		return v
+	}
+	return interp.ToInspect(v, nil)
+}
+
+// Dprintf evaluates the arguments of dprintf breakpoint bp in frame
+// fr and prints them using its format.
+func Dprintf(fr *interp.Frame, bp *Breakpoint) {
+	vals := make([]interface{}, len(bp.FmtArgs))
+	for i, arg := range bp.FmtArgs {
+		v, _, err := EvalExpr(fr, fr.Scope(), arg)
+		if err != nil {
+			Errmsg("dprintf %d: %s", bp.Id, err)
+			return
+		}
+		vals[i] = dprintfValue(v)
+	}
+	MsgNoCr("%s", fmt.Sprintf(bp.Format, vals...))
+}
@@ -6,6 +6,7 @@ package gub
 import (
 	"fmt"
 	"strings"
+	"github.com/rocky/go-types"
 	"github.com/rocky/ssa-interp"
 	"github.com/rocky/ssa-interp/interp"
 )
@@ -36,11 +37,42 @@ func PrintInEnvironment(fr *interp.Frame, name string, nameVal ssa2.Value,
 	return true
 }
 
+// parentScope returns the scope of function fn that scope is nested
+// in, or nil. ssa2.ParentScope doesn't find it yet.
+func parentScope(fn *ssa2.Function, scope *ssa2.Scope) *ssa2.Scope {
+	if scope.Scope == nil {
+		return nil
+	}
+	return fn.Pkg.TypeScope2Scope[scope.Scope.Parent()]
+}
+
+// paramStored returns true if frame fr has copied parameter p to the
+// variable that holds it in the function body.
+func paramStored(fr *interp.Frame, p *ssa2.Parameter) bool {
+	fn := fr.Fn()
+	if len(fn.Blocks) == 0 || fr.Block() != fn.Blocks[0] {
+		return true
+	}
+	for i, instr := range fn.Blocks[0].Instrs {
+		if store, ok := instr.(*ssa2.Store); ok && store.Val == ssa2.Value(p) {
+			return fr.PC() > i
+		}
+	}
+	return true
+}
+
 func EnvLookup(fr *interp.Frame, name string,
 	scope *ssa2.Scope) (ssa2.Value, interp.Value, *ssa2.Scope) {
 	fn := fr.Fn()
 	reg := fr.Var2Reg[name]
-	for ; scope != nil;  scope = ssa2.ParentScope(fn, scope) {
+	// When stopped on entry to the function, parameters haven't
+	// been copied to their variables yet.
+	for _, p := range fn.Params {
+		if p.Name() == name && !paramStored(fr, p) {
+			return p, fr.Env()[p], nil
+		}
+	}
+	for ; scope != nil;  scope = parentScope(fn, scope) {
 		nameScope := ssa2.NameScope{
 			Name: name,
 			Scope: scope,
@@ -50,6 +82,14 @@ func EnvLookup(fr *interp.Frame, name string,
 			val     := fr.Env()[nameVal]
 			return nameVal, val, nameVal.Scope
 		}
+		// A variable whose address is taken lives in the heap
+		// rather than in Locals.
+		for nameVal, val := range fr.Env() {
+			if alloc, ok := nameVal.(*ssa2.Alloc); ok && alloc.Heap &&
+				alloc.Comment == name && alloc.Scope == scope {
+				return alloc, val, scope
+			}
+		}
 	}
 	names := []string{name, reg}
 	for _, name := range names {
@@ -88,3 +128,32 @@ func GetFunction(name string) *ssa2.Function {
 	}
 	return nil
 }
+
+// GetInterfaceMethods returns the concrete functions that a call of
+// the interface method name, given as I.Method or pkg.I.Method, can
+// dispatch to. If name doesn't name an interface method, nil is
+// returned.
+func GetInterfaceMethods(name string) []*ssa2.Function {
+	pkg := curFrame.Fn().Pkg
+	ids := strings.Split(name, ".")
+	switch len(ids) {
+	case 2:
+	case 3:
+		try_pkg := program.PackagesByName[ids[0]]
+		if try_pkg == nil { return nil }
+		pkg = try_pkg
+		ids = ids[1:]
+	default:
+		return nil
+	}
+	obj, ok := pkg.Object.Scope().Lookup(ids[0]).(*types.TypeName)
+	if !ok { return nil }
+	iface, ok := obj.Type().Underlying().(*types.Interface)
+	if !ok { return nil }
+	for i := 0; i < iface.NumMethods(); i++ {
+		if m := iface.Method(i); m.Name() == ids[1] {
+			return program.InterfaceMethodImpls(iface, m.Pkg(), m.Name())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+// Copyright 2015 Rocky Bernstein.
+// Typed events for front ends that don't want to parse our output
+
+package gub
+
+import (
+	"sync"
+
+	"github.com/rocky/ssa-interp"
+	"github.com/rocky/ssa-interp/interp"
+)
+
+// An EventKind says what an Event is about.
+type EventKind string
+
+const (
+	// The program has stopped and the debugger is reading commands.
+	EventStopped EventKind = "stopped"
+	// A breakpoint, watchpoint or catchpoint has been hit. It comes
+	// just before the EventStopped for the stop.
+	EventBreakpointHit EventKind = "breakpoint-hit"
+	// A go statement has started a goroutine.
+	EventGoroutineCreated EventKind = "goroutine-created"
+	// The debugger has shown some output.
+	EventOutput EventKind = "output"
+	// The program has finished.
+	EventExited EventKind = "exited"
+)
+
+// An Event is something that happened in the debugger or the program
+// being debugged. Which of the fields beside Kind are set depends on
+// the kind of event.
+type Event struct {
+	Kind       EventKind
+	Reason     string // what we stopped for, e.g. "Breakpoint"
+	Breakpoint int    // number of the breakpoint hit
+	Goroutine  int    // goroutine stopped in or started
+	Function   string // function stopped in or running the go statement
+	File       string
+	Line       int
+	Text       string // the output shown, for EventOutput
+}
+
+// eventLock guards subscribers; goroutines can be started while
+// another goroutine is stopped in the debugger.
+var eventLock sync.Mutex
+
+// subscribers are the functions to call with each event.
+var subscribers []func(Event)
+
+// Subscribe arranges for fn to be called with each event from now on.
+// fn is called from the goroutine the event happens in, so it must
+// not block for long and must not show debugger output itself. It
+// can be called from more than one goroutine at once.
+func Subscribe(fn func(Event)) {
+	eventLock.Lock()
+	defer eventLock.Unlock()
+	if len(subscribers) == 0 {
+		interp.SetGoroutineHook(goroutineCreated)
+	}
+	subscribers = append(subscribers, fn)
+}
+
+// Events returns a channel on which events are sent from now on,
+// holding up to size of them. If the channel is full, events are
+// dropped rather than holding up the program.
+func Events(size int) <-chan Event {
+	ch := make(chan Event, size)
+	Subscribe(func(e Event) {
+		select {
+		case ch <- e:
+		default:
+		}
+	})
+	return ch
+}
+
+// emitEvent passes e to each of the subscribers. They are called
+// without eventLock held, so that one can subscribe another.
+func emitEvent(e Event) {
+	eventLock.Lock()
+	fns := subscribers
+	eventLock.Unlock()
+	for _, fn := range fns {
+		fn(e)
+	}
+}
+
+// subscribed returns true if there are subscribers.
+func subscribed() bool {
+	eventLock.Lock()
+	defer eventLock.Unlock()
+	return len(subscribers) > 0
+}
+
+// frameEvent is an event of kind kind for frame fr.
+func frameEvent(kind EventKind, fr *interp.Frame) Event {
+	e := Event{Kind: kind, Goroutine: fr.GoNum(), Function: fr.Fn().String()}
+	pos := fr.Position()
+	e.File, e.Line = pos.Filename, pos.Line
+	return e
+}
+
+// stoppedEvents emits the events for stopping in frame fr on event:
+// EventBreakpointHit if a breakpoint caused it, and EventStopped, or
+// EventExited if the program has finished.
+func stoppedEvents(fr *interp.Frame, event ssa2.TraceEvent) {
+	if !subscribed() {
+		return
+	}
+	if event == ssa2.PROGRAM_TERMINATION {
+		emitEvent(Event{Kind: EventExited, Reason: ssa2.Event2Name[event]})
+		return
+	}
+	if curBpnum != NoBp {
+		e := frameEvent(EventBreakpointHit, fr)
+		e.Breakpoint = curBpnum
+		emitEvent(e)
+	}
+	e := frameEvent(EventStopped, fr)
+	e.Reason = ssa2.Event2Name[event]
+	if curBpnum != NoBp {
+		e.Breakpoint = curBpnum
+	}
+	emitEvent(e)
+}
+
+// goroutineCreated emits EventGoroutineCreated for goroutine goNum
+// started by frame fr.
+func goroutineCreated(fr *interp.Frame, goNum int) {
+	e := frameEvent(EventGoroutineCreated, fr)
+	e.Goroutine = goNum
+	emitEvent(e)
+}
+
+// outputEvent emits EventOutput for debugger output s.
+func outputEvent(s string) {
+	if subscribed() {
+		emitEvent(Event{Kind: EventOutput, Text: s})
+	}
+}
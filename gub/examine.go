@@ -0,0 +1,94 @@
+// Copyright 2015 Rocky Bernstein.
+// Examining SSA registers
+
+package gub
+
+import (
+	"fmt"
+
+	"github.com/rocky/ssa-interp"
+	"github.com/rocky/ssa-interp/interp"
+)
+
+// RegisterLookup finds the SSA value of function fn named name: a
+// register like t12 defined by an instruction, or a parameter or free
+// variable.
+func RegisterLookup(fn *ssa2.Function, name string) ssa2.Value {
+	for _, p := range fn.Params {
+		if p.Name() == name {
+			return p
+		}
+	}
+	for _, fv := range fn.FreeVars {
+		if fv.Name() == name {
+			return fv
+		}
+	}
+	for _, b := range fn.Blocks {
+		for _, instr := range b.Instrs {
+			if v, ok := instr.(ssa2.Value); ok && v.Name() == name {
+				return v
+			}
+		}
+	}
+	return nil
+}
+
+// instrString shows instruction instr along with the block and index
+// it is at.
+func instrString(instr ssa2.Instruction) string {
+	b := instr.Block()
+	ic := -1
+	for i, in := range b.Instrs {
+		if in == instr {
+			ic = i
+			break
+		}
+	}
+	s := instr.String()
+	if v, ok := instr.(ssa2.Value); ok && v.Name() != "" {
+		s = v.Name() + " = " + s
+	}
+	return fmt.Sprintf(".%d %3d: %s", b.Index, ic, s)
+}
+
+// ExamineRegister shows SSA register name of frame fr: the
+// instruction that defines it, its type, the instructions that use
+// it, and its value in fr.
+func ExamineRegister(fr *interp.Frame, name string) bool {
+	fn := fr.Fn()
+	v := RegisterLookup(fn, name)
+	if v == nil {
+		return false
+	}
+	switch v := v.(type) {
+	case *ssa2.Parameter:
+		Msg("%s is a parameter of %s", name, fn)
+	case *ssa2.FreeVar:
+		Msg("%s is a free variable of %s", name, fn)
+	case ssa2.Instruction:
+		Msg("%s is defined by:", name)
+		Msg("\t%s", instrString(v))
+		if pos := v.Pos(); pos.IsValid() {
+			Msg("\tat %s", ssa2.FmtPos(fr.Fset(), pos))
+		}
+	}
+	Msg("type: %s", v.Type())
+	if varName := fr.Reg2Var[name]; varName != "" {
+		Msg("source variable: %s", varName)
+	}
+	if refs := v.Referrers(); refs != nil && len(*refs) > 0 {
+		Msg("referrers:")
+		for _, ref := range *refs {
+			Msg("\t%s", instrString(ref))
+		}
+	} else {
+		Msg("referrers: none")
+	}
+	if val, ok := fr.Env()[v]; ok {
+		Msg("value: %s", interp.ToInspect(val, &v))
+	} else {
+		Msg("value: not computed yet")
+	}
+	return true
+}
@@ -0,0 +1,112 @@
+// Copyright 2015 Rocky Bernstein.
+// The exit status of gub and the summary line shown at the end
+
+package gub
+
+import (
+	"fmt"
+
+	"github.com/rocky/go-types"
+	"github.com/rocky/ssa-interp"
+	"github.com/rocky/ssa-interp/interp"
+)
+
+// Exit statuses of gub that aren't the program's own.
+const (
+	// The program panicked, as with a Go program.
+	ExitPanic = 2
+	// The program finished with status 0 but, in batch mode, a
+	// debugger command reported an error.
+	ExitDebuggerError = 3
+)
+
+// ExitStatus is the status gub exits with once the program has
+// finished: ExitPanic if it panicked, otherwise its own exit status if
+// that isn't 0, otherwise ExitDebuggerError if in batch mode a
+// debugger command reported an error, and 0 if all went well. The
+// summary line tells these apart when a program's own status could
+// be mistaken for one of ours.
+func ExitStatus() int {
+	status, reason := interp.ExitStatus()
+	switch {
+	case reason == "panic":
+		return ExitPanic
+	case status != 0:
+		return status
+	case BatchMode() && ErrorCount > 0:
+		return ExitDebuggerError
+	}
+	return 0
+}
+
+// Summary shows the line saying how the program and the debugging
+// session ended, for scripts to check: gub's exit status, the
+// program's exit status, how it finished ("normal", "exit", "panic",
+// "deadlock", "limit", "no-main" or "quit"), and the number of debugger errors. The
+// program's status is -1 if it hadn't finished. The line is shown
+// when the program finishes and when leaving with "quit", in
+// batch mode and when the output format is JSON, e.g.
+//
+//   gub: status=0 program-status=0 reason=normal errors=0
+func Summary(status int, programStatus int, reason string) {
+	if !BatchMode() && !JSONOutput() {
+		return
+	}
+	if JSONOutput() {
+		MsgJSON(map[string]interface{}{
+			"event":          "exit",
+			"status":         status,
+			"program_status": programStatus,
+			"reason":         reason,
+			"errors":         ErrorCount,
+		})
+		return
+	}
+	writeOutput(fmt.Sprintf("gub: status=%d program-status=%d reason=%s errors=%d\n",
+		status, programStatus, reason, ErrorCount))
+}
+
+// programSummary shows the summary line for the program having
+// finished.
+func programSummary() {
+	programStatus, reason := interp.ExitStatus()
+	Summary(ExitStatus(), programStatus, reason)
+}
+
+// QuitSummary shows the summary line for leaving with "quit" and exit
+// status status.
+func QuitSummary(status int) {
+	programStatus, reason := interp.ExitStatus()
+	if reason == "" {
+		programStatus = -1
+	}
+	Summary(status, programStatus, "quit")
+}
+
+// programExited sets convenience variable $_exitcode to the exit
+// status of the program, which has finished or is stopped in a call
+// of os.Exit, and in the latter case says what happens next.
+func programExited() {
+	status, _ := interp.ExitStatus()
+	convVars["_exitcode"] = exprVal{status, types.Typ[types.Int]}
+	if interp.ExitTrapped() {
+		Msg("Program called os.Exit(%d). It exits when resumed; until then, its goroutines",
+			status)
+		Msg("and frames can still be examined. $_exitcode is the exit status.")
+	}
+}
+
+// stayAtExit returns true if, with the program stopped in a call of
+// os.Exit and resumed, the user would rather it didn't exit yet, so
+// that the command loop should go on.
+func stayAtExit(event ssa2.TraceEvent) bool {
+	if event != ssa2.PROGRAM_TERMINATION || !interp.ExitTrapped() || RestartRequested {
+		return false
+	}
+	status, _ := interp.ExitStatus()
+	if Confirm(fmt.Sprintf("Let the program exit with status %d?", status), true) {
+		return false
+	}
+	InCmdLoop = true
+	return true
+}
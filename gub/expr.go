@@ -0,0 +1,596 @@
+// Copyright 2015 Rocky Bernstein.
+// A small evaluator for Go expressions over interpreter values. It is
+// used for things like breakpoint conditions, where we need to
+// compute a value in the context of a stopped frame.
+
+package gub
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
+
+	"github.com/rocky/go-types"
+	"github.com/rocky/ssa-interp"
+	"github.com/rocky/ssa-interp/interp"
+)
+
+// exprVal is an interpreter value along with its static type.
+type exprVal struct {
+	v interp.Value
+	t types.Type
+}
+
+// EvalExpr evaluates Go expression expr in frame fr using scope to
+// resolve local names. It returns the value and its type.
+func EvalExpr(fr *interp.Frame, scope *ssa2.Scope, expr string) (v interp.Value, t types.Type, err error) {
+	x, err := evalString(fr, scope, expr)
+	if err != nil {
+		return nil, nil, err
+	}
+	if isUntyped(x.t) {
+		x = convertUntyped(x, defaultType(x.t))
+	}
+	return x.v, x.t, nil
+}
+
+// evalString is EvalExpr but leaves an untyped constant result
+// untyped, for when the type it is to have is known.
+func evalString(fr *interp.Frame, scope *ssa2.Scope, expr string) (x exprVal, err error) {
+	node, err := parser.ParseExpr(dollarExpr(expr))
+	if err != nil {
+		return x, err
+	}
+	// The interpreter's operations panic on bad operands, such as a
+	// divide by zero.
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("evaluating %s: %v", expr, r)
+		}
+	}()
+	return evalNode(fr, scope, node)
+}
+
+// EvalCondition evaluates expr in frame fr and returns its boolean
+// value.
+func EvalCondition(fr *interp.Frame, scope *ssa2.Scope, expr string) (bool, error) {
+	v, _, err := EvalExpr(fr, scope, expr)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("%s is not a boolean expression", expr)
+	}
+	return b, nil
+}
+
+func isUntyped(t types.Type) bool {
+	b, ok := t.(*types.Basic)
+	return ok && b.Info()&types.IsUntyped != 0
+}
+
+// defaultType gives the type an untyped constant of type t has when
+// there is nothing else to go by.
+func defaultType(t types.Type) types.Type {
+	if b, ok := t.(*types.Basic); ok {
+		switch b.Kind() {
+		case types.UntypedBool:
+			return types.Typ[types.Bool]
+		case types.UntypedInt:
+			return types.Typ[types.Int]
+		case types.UntypedRune:
+			return types.Typ[types.Int32]
+		case types.UntypedFloat:
+			return types.Typ[types.Float64]
+		case types.UntypedString:
+			return types.Typ[types.String]
+		}
+	}
+	return t
+}
+
+// convertUntyped converts untyped constant x to type t.
+func convertUntyped(x exprVal, t types.Type) exprVal {
+	if !isUntyped(x.t) {
+		return x
+	}
+	if x.t.(*types.Basic).Kind() == types.UntypedNil {
+		return exprVal{interp.Zero(t), t}
+	}
+	if _, ok := t.Underlying().(*types.Interface); ok {
+		t = defaultType(x.t)
+	}
+	if x.t.(*types.Basic).Kind() == types.UntypedBool {
+		// Booleans are held as bool whatever their type.
+		return exprVal{x.v, t}
+	}
+	return exprVal{interp.Conv(t, defaultType(x.t), x.v), t}
+}
+
+func evalNode(fr *interp.Frame, scope *ssa2.Scope, node ast.Expr) (exprVal, error) {
+	switch e := node.(type) {
+	case *ast.BasicLit:
+		return evalLit(e)
+	case *ast.Ident:
+		return evalIdent(fr, scope, e.Name)
+	case *ast.ParenExpr:
+		return evalNode(fr, scope, e.X)
+	case *ast.SelectorExpr:
+		if id, ok := e.X.(*ast.Ident); ok {
+			if pkg := PkgLookup(id.Name); pkg != nil {
+				if _, _, err := EnvLookupOK(fr, id.Name, scope); err != nil {
+					return evalGlobal(fr, pkg, e.Sel.Name)
+				}
+			}
+		}
+		x, err := evalNode(fr, scope, e.X)
+		if err != nil {
+			return x, err
+		}
+		return evalField(x, e.Sel.Name)
+	case *ast.IndexExpr:
+		x, err := evalNode(fr, scope, e.X)
+		if err != nil {
+			return x, err
+		}
+		idx, err := evalNode(fr, scope, e.Index)
+		if err != nil {
+			return idx, err
+		}
+		return evalIndex(x, idx)
+	case *ast.StarExpr:
+		x, err := evalNode(fr, scope, e.X)
+		if err != nil {
+			return x, err
+		}
+		ptr, ok := x.t.Underlying().(*types.Pointer)
+		if !ok {
+			return x, fmt.Errorf("can't dereference non-pointer type %s", x.t)
+		}
+		addr, _ := x.v.(*interp.Value)
+		if addr == nil {
+			return x, fmt.Errorf("nil pointer dereference")
+		}
+		return exprVal{*addr, ptr.Elem()}, nil
+	case *ast.UnaryExpr:
+		x, err := evalNode(fr, scope, e.X)
+		if err != nil {
+			return x, err
+		}
+		return evalUnary(e.Op, x)
+	case *ast.BinaryExpr:
+		x, err := evalNode(fr, scope, e.X)
+		if err != nil {
+			return x, err
+		}
+		// Short-circuit evaluation of && and ||.
+		if e.Op == token.LAND || e.Op == token.LOR {
+			b, ok := x.v.(bool)
+			if !ok {
+				return x, fmt.Errorf("operand of %s is not boolean", e.Op)
+			}
+			if b == (e.Op == token.LOR) {
+				return exprVal{b, types.Typ[types.Bool]}, nil
+			}
+			y, err := evalNode(fr, scope, e.Y)
+			if err != nil {
+				return y, err
+			}
+			if _, ok := y.v.(bool); !ok {
+				return y, fmt.Errorf("operand of %s is not boolean", e.Op)
+			}
+			return exprVal{y.v, types.Typ[types.Bool]}, nil
+		}
+		y, err := evalNode(fr, scope, e.Y)
+		if err != nil {
+			return y, err
+		}
+		return evalBinary(e.Op, x, y)
+	case *ast.CallExpr:
+		return evalCall(fr, scope, e)
+	case *ast.TypeAssertExpr:
+		x, err := evalNode(fr, scope, e.X)
+		if err != nil {
+			return x, err
+		}
+		if e.Type == nil {
+			return x, fmt.Errorf("can't evaluate x.(type) outside of a type switch")
+		}
+		t := evalType(fr, scope, e.Type)
+		if t == nil {
+			return x, fmt.Errorf("%s is not a type", e.Type)
+		}
+		return evalAssert(fr, x, t)
+	}
+	return exprVal{}, fmt.Errorf("can't evaluate expressions like %T yet", node)
+}
+
+// evalCall evaluates call expression e. Only the builtins len and
+// cap, and type conversions are handled.
+func evalCall(fr *interp.Frame, scope *ssa2.Scope, e *ast.CallExpr) (exprVal, error) {
+	if t := evalType(fr, scope, e.Fun); t != nil {
+		if len(e.Args) != 1 {
+			return exprVal{}, fmt.Errorf("conversion to %s needs exactly one argument", t)
+		}
+		x, err := evalNode(fr, scope, e.Args[0])
+		if err != nil {
+			return x, err
+		}
+		return evalConv(fr, t, x)
+	}
+	if id, ok := e.Fun.(*ast.Ident); ok && (id.Name == "len" || id.Name == "cap") {
+		if _, _, err := EnvLookupOK(fr, id.Name, scope); err != nil {
+			if len(e.Args) != 1 {
+				return exprVal{}, fmt.Errorf("%s takes exactly one argument", id.Name)
+			}
+			x, err := evalNode(fr, scope, e.Args[0])
+			if err != nil {
+				return x, err
+			}
+			return evalLenCap(id.Name, x)
+		}
+	}
+	return exprVal{}, fmt.Errorf("can't call functions in expressions")
+}
+
+// evalType returns the type that e names, or nil if e isn't a type.
+func evalType(fr *interp.Frame, scope *ssa2.Scope, e ast.Expr) types.Type {
+	switch e := e.(type) {
+	case *ast.Ident:
+		if _, _, err := EnvLookupOK(fr, e.Name, scope); err == nil {
+			// A local variable hides any type of the same name.
+			return nil
+		}
+		if pkg := fr.Fn().Pkg; pkg != nil {
+			if t := pkg.Type(e.Name); t != nil {
+				return t.Type()
+			}
+		}
+		if obj, ok := types.Universe.Lookup(e.Name).(*types.TypeName); ok {
+			return obj.Type()
+		}
+	case *ast.SelectorExpr:
+		if id, ok := e.X.(*ast.Ident); ok {
+			if pkg := PkgLookup(id.Name); pkg != nil {
+				if t := pkg.Type(e.Sel.Name); t != nil {
+					return t.Type()
+				}
+			}
+		}
+	case *ast.ParenExpr:
+		return evalType(fr, scope, e.X)
+	case *ast.StarExpr:
+		if elem := evalType(fr, scope, e.X); elem != nil {
+			return types.NewPointer(elem)
+		}
+	case *ast.ArrayType:
+		if e.Len == nil {
+			if elem := evalType(fr, scope, e.Elt); elem != nil {
+				return types.NewSlice(elem)
+			}
+		}
+	}
+	return nil
+}
+
+// evalAssert evaluates the type assertion x.(t).
+func evalAssert(fr *interp.Frame, x exprVal, t types.Type) (exprVal, error) {
+	if _, ok := x.t.Underlying().(*types.Interface); !ok {
+		return x, fmt.Errorf("%s is not an interface type", x.t)
+	}
+	dt, dv, ok := interp.IfaceValue(x.v)
+	if !ok {
+		return x, fmt.Errorf("interface is nil, not %s", t)
+	}
+	iface, ok := t.Underlying().(*types.Interface)
+	if !ok {
+		if !types.Identical(dt, t) {
+			return x, fmt.Errorf("interface holds %s, not %s", dt, t)
+		}
+		return exprVal{dv, t}, nil
+	}
+	mset := fr.Fn().Prog.MethodSets.MethodSet(dt)
+	for i := 0; i < iface.NumMethods(); i++ {
+		m := iface.Method(i)
+		if mset.Lookup(m.Pkg(), m.Name()) == nil {
+			return x, fmt.Errorf("%s is not %s: missing method %s", dt, t, m.Name())
+		}
+	}
+	return exprVal{x.v, t}, nil
+}
+
+// evalConv converts x to type t. Converting an interface value to a
+// concrete type, as in (T)(x), asserts that x holds a T.
+func evalConv(fr *interp.Frame, t types.Type, x exprVal) (exprVal, error) {
+	if _, ok := x.t.Underlying().(*types.Interface); ok {
+		return evalAssert(fr, x, t)
+	}
+	if _, ok := t.Underlying().(*types.Interface); ok {
+		return x, fmt.Errorf("can't convert to interface type %s", t)
+	}
+	if isUntyped(x.t) {
+		if x.t.(*types.Basic).Kind() == types.UntypedNil {
+			return convertUntyped(x, t), nil
+		}
+		x = convertUntyped(x, defaultType(x.t))
+	}
+	return exprVal{interp.Conv(t, x.t, x.v), t}, nil
+}
+
+// evalLenCap evaluates builtin len or cap, given by name, on x.
+func evalLenCap(name string, x exprVal) (exprVal, error) {
+	if p, ok := x.t.Underlying().(*types.Pointer); ok {
+		// len and cap work on pointers to arrays.
+		if _, ok := p.Elem().Underlying().(*types.Array); ok {
+			addr, _ := x.v.(*interp.Value)
+			if addr == nil {
+				return x, fmt.Errorf("nil pointer dereference")
+			}
+			x = exprVal{*addr, p.Elem()}
+		}
+	}
+	if isUntyped(x.t) {
+		x = convertUntyped(x, defaultType(x.t))
+	}
+	var n int
+	var ok bool
+	if name == "len" {
+		n, ok = interp.Len(x.v)
+	} else {
+		n, ok = interp.Cap(x.v)
+	}
+	if !ok {
+		return x, fmt.Errorf("invalid argument for %s: type %s", name, x.t)
+	}
+	return exprVal{n, types.Typ[types.Int]}, nil
+}
+
+func evalLit(lit *ast.BasicLit) (exprVal, error) {
+	switch lit.Kind {
+	case token.INT:
+		n, err := strconv.ParseInt(lit.Value, 0, 64)
+		if err != nil {
+			return exprVal{}, err
+		}
+		return exprVal{int(n), types.Typ[types.UntypedInt]}, nil
+	case token.FLOAT:
+		f, err := strconv.ParseFloat(lit.Value, 64)
+		if err != nil {
+			return exprVal{}, err
+		}
+		return exprVal{f, types.Typ[types.UntypedFloat]}, nil
+	case token.CHAR:
+		s, err := strconv.Unquote(lit.Value)
+		if err != nil || len([]rune(s)) != 1 {
+			return exprVal{}, fmt.Errorf("bad character literal %s", lit.Value)
+		}
+		return exprVal{[]rune(s)[0], types.Typ[types.UntypedRune]}, nil
+	case token.STRING:
+		s, err := strconv.Unquote(lit.Value)
+		if err != nil {
+			return exprVal{}, err
+		}
+		return exprVal{s, types.Typ[types.UntypedString]}, nil
+	}
+	return exprVal{}, fmt.Errorf("can't handle literal %s", lit.Value)
+}
+
+// EnvLookupOK is EnvLookup but returns an error when name isn't found
+// in the environment of fr.
+func EnvLookupOK(fr *interp.Frame, name string,
+	scope *ssa2.Scope) (ssa2.Value, interp.Value, error) {
+	nameVal, interpVal, _ := EnvLookup(fr, name, scope)
+	if nameVal == nil {
+		return nil, nil, fmt.Errorf("can't find %s", name)
+	}
+	if _, ok := nameVal.(*ssa2.Global); ok {
+		return nil, nil, fmt.Errorf("%s is a global", name)
+	}
+	return nameVal, interpVal, nil
+}
+
+func evalIdent(fr *interp.Frame, scope *ssa2.Scope, name string) (exprVal, error) {
+	switch name {
+	case "true", "false":
+		return exprVal{name == "true", types.Typ[types.UntypedBool]}, nil
+	case "nil":
+		return exprVal{nil, types.Typ[types.UntypedNil]}, nil
+	}
+	if strings.HasPrefix(name, histPrefix) {
+		return evalHist(name)
+	}
+	if strings.HasPrefix(name, convPrefix) {
+		return evalConvVar(name)
+	}
+	nameVal, interpVal, err := EnvLookupOK(fr, name, scope)
+	if err != nil {
+		return evalGlobal(fr, fr.Fn().Pkg, name)
+	}
+	if _, ok := nameVal.(*ssa2.Alloc); ok {
+		// An Alloc is the address of the variable.
+		addr, _ := interpVal.(*interp.Value)
+		if addr == nil {
+			return exprVal{}, fmt.Errorf("%s doesn't have a value yet", name)
+		}
+		return exprVal{*addr, deref(nameVal.Type())}, nil
+	}
+	if _, ok := nameVal.(*ssa2.FreeVar); ok {
+		// A closure captures the address of a variable.
+		if addr, ok := interpVal.(*interp.Value); ok && addr != nil {
+			return exprVal{*addr, deref(nameVal.Type())}, nil
+		}
+	}
+	return exprVal{interpVal, nameVal.Type()}, nil
+}
+
+func evalGlobal(fr *interp.Frame, pkg *ssa2.Package, name string) (exprVal, error) {
+	if g := pkg.Var(name); g != nil {
+		if addr, ok := fr.I().Global(name, pkg); ok && addr != nil {
+			return exprVal{*addr, deref(g.Type())}, nil
+		}
+	}
+	if c := pkg.Const(name); c != nil {
+		val := c.Value
+		return exprVal{interp.ConstValue(val), val.Type()}, nil
+	}
+	return exprVal{}, fmt.Errorf("can't find %s", name)
+}
+
+func evalField(x exprVal, name string) (exprVal, error) {
+	t := x.t
+	if p, ok := t.Underlying().(*types.Pointer); ok {
+		addr, _ := x.v.(*interp.Value)
+		if addr == nil {
+			return x, fmt.Errorf("nil pointer dereference")
+		}
+		x = exprVal{*addr, p.Elem()}
+		t = p.Elem()
+	}
+	st, ok := t.Underlying().(*types.Struct)
+	s, ok2 := x.v.(interp.Structure)
+	if !ok || !ok2 {
+		return x, fmt.Errorf("%s is not a struct", t)
+	}
+	for i := 0; i < st.NumFields(); i++ {
+		if st.Field(i).Name() == name {
+			v, err := s.Field(i)
+			return exprVal{v, st.Field(i).Type()}, err
+		}
+	}
+	return x, fmt.Errorf("%s has no field %s", t, name)
+}
+
+func evalIndex(x exprVal, idx exprVal) (exprVal, error) {
+	switch t := x.t.Underlying().(type) {
+	case *types.Map:
+		key := convertUntyped(idx, t.Key())
+		v, ok := interp.MapLookup(x.v, key.v)
+		if !ok {
+			v = interp.Zero(t.Elem())
+		}
+		return exprVal{v, t.Elem()}, nil
+	}
+	i, ok := asInt(convertUntyped(idx, types.Typ[types.Int]).v)
+	if !ok {
+		return x, fmt.Errorf("index must be an integer")
+	}
+	var elemType types.Type
+	switch t := x.t.Underlying().(type) {
+	case *types.Basic:
+		elemType = types.Typ[types.Byte]
+	case *types.Slice:
+		elemType = t.Elem()
+	case *types.Array:
+		elemType = t.Elem()
+	default:
+		return x, fmt.Errorf("can't index type %s", x.t)
+	}
+	v, err := interp.SeqIndex(x.v, i)
+	return exprVal{v, elemType}, err
+}
+
+// asInt returns integer value v as an int.
+func asInt(v interp.Value) (int, bool) {
+	switch v := v.(type) {
+	case int:
+		return v, true
+	case int8:
+		return int(v), true
+	case int16:
+		return int(v), true
+	case int32:
+		return int(v), true
+	case int64:
+		return int(v), true
+	case uint:
+		return int(v), true
+	case uint8:
+		return int(v), true
+	case uint16:
+		return int(v), true
+	case uint32:
+		return int(v), true
+	case uint64:
+		return int(v), true
+	case uintptr:
+		return int(v), true
+	}
+	return 0, false
+}
+
+func evalUnary(op token.Token, x exprVal) (exprVal, error) {
+	switch op {
+	case token.NOT:
+		b, ok := x.v.(bool)
+		if !ok {
+			return x, fmt.Errorf("operand of ! is not boolean")
+		}
+		return exprVal{!b, x.t}, nil
+	case token.ADD:
+		return x, nil
+	case token.SUB:
+		return exprVal{interp.Binop(token.SUB, x.t, interp.Zero(defaultType(x.t)), x.v), x.t}, nil
+	}
+	return x, fmt.Errorf("can't handle unary operator %s yet", op)
+}
+
+func evalBinary(op token.Token, x, y exprVal) (exprVal, error) {
+	if op == token.SHL || op == token.SHR {
+		return evalShift(op, x, y)
+	}
+	// Give untyped operands the type of the other operand.
+	switch {
+	case isUntyped(x.t) && isUntyped(y.t):
+		if x.t.(*types.Basic).Kind() == types.UntypedNil &&
+			y.t.(*types.Basic).Kind() == types.UntypedNil {
+			return x, fmt.Errorf("operator %s not defined on nil", op)
+		}
+		t := defaultType(x.t)
+		if y.t.(*types.Basic).Kind() > x.t.(*types.Basic).Kind() {
+			// e.g. 1 + 2.5 is a float
+			t = defaultType(y.t)
+		}
+		if x.t.(*types.Basic).Kind() == types.UntypedNil {
+			t = defaultType(y.t)
+		}
+		x, y = convertUntyped(x, t), convertUntyped(y, t)
+	case isUntyped(x.t):
+		x = convertUntyped(x, y.t)
+	case isUntyped(y.t):
+		y = convertUntyped(y, x.t)
+	}
+	switch op {
+	case token.EQL, token.NEQ, token.LSS, token.LEQ, token.GTR, token.GEQ:
+		return exprVal{interp.Binop(op, x.t, x.v, y.v), types.Typ[types.Bool]}, nil
+	}
+	if !types.Identical(x.t, y.t) {
+		return x, fmt.Errorf("mismatched types %s and %s", x.t, y.t)
+	}
+	return exprVal{interp.Binop(op, x.t, x.v, y.v), x.t}, nil
+}
+
+// evalShift evaluates x << y or x >> y. The shift count y isn't
+// converted to the type of x; the interpreter wants it unsigned.
+func evalShift(op token.Token, x, y exprVal) (exprVal, error) {
+	if isUntyped(y.t) {
+		y = convertUntyped(y, types.Typ[types.Uint])
+	}
+	if !isInteger(y.t) {
+		return x, fmt.Errorf("shift count must be an integer")
+	}
+	n, _ := asInt(y.v)
+	if n < 0 {
+		return x, fmt.Errorf("negative shift count %d", n)
+	}
+	if isUntyped(x.t) {
+		x = convertUntyped(x, defaultType(x.t))
+	}
+	if !isInteger(x.t) {
+		return x, fmt.Errorf("can't shift type %s", x.t)
+	}
+	return exprVal{interp.Binop(op, x.t, x.v, uint64(n)), x.t}, nil
+}
@@ -0,0 +1,123 @@
+// Copyright 2015 Rocky Bernstein.
+// Finding where a value is held
+
+package gub
+
+import (
+	"fmt"
+
+	"github.com/rocky/go-types"
+	"github.com/rocky/ssa-interp"
+	"github.com/rocky/ssa-interp/interp"
+)
+
+// MaxFindResults is the most places "find" reports.
+const MaxFindResults = 100
+
+// findRoots returns the variables a search for a value starts from:
+// the package variables, and the parameters, local variables and free
+// variables of every frame of every goroutine.
+func findRoots(fr *interp.Frame) []interp.FindRoot {
+	var roots []interp.FindRoot
+	for g, addr := range fr.I().Globals() {
+		if addr == nil || g.String() == "reflect.lookupCache" {
+			// See GlobalsCommand.
+			continue
+		}
+		roots = append(roots, interp.FindRoot{Path: g.String(), V: *addr, T: deref(g.Type())})
+	}
+	for goNum, goTop := range fr.I().GoTops() {
+		depth := 0
+		for f := goTop.Fr; f != nil; f = f.Caller(0) {
+			roots = append(roots, frameRoots(f,
+				fmt.Sprintf("goroutine %d #%d %s: ", goNum, depth, f.Fn().Name()))...)
+			depth++
+		}
+	}
+	return roots
+}
+
+// frameRoots returns the parameters, named local variables and free
+// variables of frame fr, with prefix in front of each name.
+func frameRoots(fr *interp.Frame, prefix string) []interp.FindRoot {
+	var roots []interp.FindRoot
+	fn := fr.Fn()
+	env := fr.Env()
+	// A parameter copied to a variable is found through that
+	// variable, once it has been copied.
+	unstored := make(map[ssa2.Value]bool)
+	for _, p := range fn.Params {
+		if spill := paramSpill(fn, p); spill != nil {
+			if paramStored(fr, p) {
+				continue
+			}
+			unstored[spill] = true
+		}
+		roots = append(roots, interp.FindRoot{Path: prefix + p.Name(), V: env[p], T: p.Type()})
+	}
+	for _, fv := range fn.FreeVars {
+		v := env[fv]
+		t := fv.Type()
+		if addr, ok := v.(*interp.Value); ok && addr != nil {
+			// A captured variable
+			v, t = *addr, deref(t)
+		}
+		roots = append(roots, interp.FindRoot{Path: prefix + fv.Name(), V: v, T: t})
+	}
+	names := localNames(fn)
+	for i, l := range fn.Locals {
+		if unstored[l] {
+			continue
+		}
+		if name, ok := names[uint(i)]; ok && i < len(fr.Locals()) {
+			roots = append(roots, interp.FindRoot{Path: prefix + name,
+				V: fr.Local(uint(i)), T: deref(l.Type())})
+		}
+	}
+	return roots
+}
+
+// localNames maps the index in fn.Locals of each named local variable
+// of function fn to its name.
+func localNames(fn *ssa2.Function) map[uint]string {
+	names := make(map[uint]string)
+	for nameScope, i := range fn.LocalsByName {
+		names[i-1] = nameScope.Name
+	}
+	return names
+}
+
+// paramSpill returns the local variable that function fn copies
+// parameter p to, or nil.
+func paramSpill(fn *ssa2.Function, p *ssa2.Parameter) ssa2.Value {
+	if len(fn.Blocks) == 0 {
+		return nil
+	}
+	for _, instr := range fn.Blocks[0].Instrs {
+		if store, ok := instr.(*ssa2.Store); ok && store.Val == ssa2.Value(p) {
+			if a, ok := store.Addr.(*ssa2.Alloc); ok && !a.Heap {
+				return a
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+// FindExpr evaluates expr in the current frame and returns the places
+// in the program that hold its value. A value of interface type is
+// looked for by its dynamic value.
+func FindExpr(expr string) ([]string, error) {
+	v, t, err := EvalExpr(curFrame, curScope, expr)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := t.Underlying().(*types.Interface); ok {
+		dt, dv, ok := interp.IfaceValue(v)
+		if !ok {
+			return nil, fmt.Errorf("%s is a nil interface value", expr)
+		}
+		v, t = dv, dt
+	}
+	return interp.FindValue(v, t, findRoots(curFrame), MaxFindResults), nil
+}
@@ -4,6 +4,10 @@
 package gub
 
 import (
+	"fmt"
+	"go/token"
+	"strings"
+
 	"github.com/rocky/ssa-interp"
 	"github.com/rocky/ssa-interp/interp"
 )
@@ -20,6 +24,10 @@ var curScope *ssa2.Scope
 var topBlock *ssa2.BasicBlock
 var curBlock *ssa2.BasicBlock
 
+// topScope is the scope of the newest frame, which frameInit may
+// have had to work out specially.
+var topScope *ssa2.Scope
+
 // stackSize is the size of call stack.
 var stackSize int
 
@@ -43,8 +51,9 @@ func frameInit(fr *interp.Frame) {
 	for stackSize=0; fr !=nil; fr = fr.Caller(0) {
 		stackSize++
 	}
-	switch TraceEvent  {
-	case ssa2.CALL_RETURN, ssa2.PROGRAM_TERMINATION:
+	switch {
+	case TraceEvent == ssa2.CALL_RETURN,
+		TraceEvent == ssa2.PROGRAM_TERMINATION && !interp.ExitTrapped():
 		/* These guys are not in a basic block, so curFrame.Scope
            won't work here. . Not sure why fr.Fn() memory crashes either.
            Otherwise, I'd use fr.Fn().Scope
@@ -67,6 +76,8 @@ func frameInit(fr *interp.Frame) {
 		// block_end?
 		curScope = curFrame.Scope()
 	}
+	topScope = curScope
+	topBlock = curBlock
 }
 
 func getFrame(frameNum int, absolutePos bool) (*interp.Frame, int) {
@@ -83,12 +94,22 @@ func getFrame(frameNum int, absolutePos bool) (*interp.Frame, int) {
 		  if frameNum < 0 { frameNum += stackSize }
       } else {
 		  frameNum += frameIndex
+		  // Counts that go too far stop at the oldest or newest
+		  // frame.
 		  if frameNum >= stackSize {
-			  Errmsg("Adjusting would put us beyond the oldest frame.")
-			  return nil, 0
+			  if frameIndex == stackSize-1 {
+				  Errmsg("Already at the oldest frame.")
+				  return nil, 0
+			  }
+			  frameNum = stackSize-1
+			  Msg("Stopping at the oldest frame, %d.", frameNum)
 		  } else if frameNum < 0 {
-			  Errmsg("Adjusting would put us beyond the newest frame.")
-			  return nil, 0
+			  if frameIndex == 0 {
+				  Errmsg("Already at the newest frame.")
+				  return nil, 0
+			  }
+			  frameNum = 0
+			  Msg("Stopping at the newest frame, 0.")
 		  }
       }
 
@@ -104,26 +125,133 @@ func AdjustFrame(frameNum int, absolutePos bool) {
 	if frame == nil { return }
 	curFrame = frame
 	frameIndex = frameNum
-	event := ssa2.CALL_ENTER
-	if (0 == frameIndex) {
-		event = TraceEvent
+	// Names are looked up in the scope of the selected frame.
+	if frameIndex == 0 {
+		curScope = topScope
+		curBlock = topBlock
+	} else {
+		curScope = frame.Scope()
+		curBlock = frame.Block()
+	}
+	printFrameChange()
+}
+
+// FrameByFunction returns the number of the newest frame running a
+// function named name, which can be given as in "main.gcd", as
+// "gcd", or in full as in "(*bytes.Buffer).Write".
+func FrameByFunction(name string) (int, bool) {
+	i := 0
+	for fr := topFrame; fr != nil; fr = fr.Caller(0) {
+		fn := fr.Fn()
+		full := fn.String()
+		if full == name || fn.Name() == name ||
+			(fn.Pkg != nil && fn.Pkg.Object.Name() + "." + fn.Name() == name) {
+			return i, true
+		}
+		i++
+	}
+	return 0, false
+}
+
+// printFrameChange shows the frame that has just been selected: its
+// number, function and arguments, position and source line.
+func printFrameChange() {
+	fn := curFrame.Fn()
+	args := make([]string, len(fn.Params))
+	for i, p := range fn.Params {
+		args[i] = p.Name() + "=" + interp.ToInspect(curFrame.Env()[p], nil)
+	}
+	Msg("#%d %s(%s)", frameIndex, fn, strings.Join(args, ", "))
+	Msg("%s", curFrame.PositionRange())
+	pos := curFrame.Position()
+	if !pos.IsValid() {
+		return
+	}
+	annotateSource(pos)
+	if lines, err := SourceLines(pos.Filename); err == nil && pos.Line <= len(lines) {
+		Msg("%s", sourceLine(lines[pos.Line-1], pos.Line, true,
+			BreakpointLines(pos.Filename)[pos.Line]))
 	}
-	printLocInfo(curFrame, nil, event)
 }
 
 func PrintStack(fr *interp.Frame, count int) {
+	printStack(fr, count, false)
+}
+
+// PrintStackFull is PrintStack but with the parameters and local
+// variables of each frame shown as well.
+func PrintStackFull(fr *interp.Frame, count int) {
+	printStack(fr, count, true)
+}
+
+func printStack(fr *interp.Frame, count int, full bool) {
 	if (fr == nil) { return }
+	if JSONOutput() {
+		stackJSON(fr, count, full)
+		return
+	}
 	for i:=0; fr !=nil && i < count; fr = fr.Caller(0) {
 		pointer := "   "
+		desc := fmt.Sprintf("#%d %s", i, fr.FnAndParamString())
 		if fr == curFrame {
 			pointer = "=> "
+			desc = Themed("current-frame", desc)
 		}
-		Msg("%s#%d %s", pointer, i, fr.FnAndParamString())
+		Msg("%s%s", pointer, desc)
 		Msg("\t%s", fr.PositionRange())
+		if full {
+			printFrameVars(fr)
+		}
 		i++
 	}
 }
 
+// A frameVar is the name and value of a parameter or local variable
+// of a frame.
+type frameVar struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// frameVars returns the parameters and the local variables of frame
+// fr that have source names.
+func frameVars(fr *interp.Frame) []frameVar {
+	fn := fr.Fn()
+	var vars []frameVar
+	// Parameters which are assigned to are spilled to a local,
+	// which has the current value.
+	names := make(map[uint]string)
+	spilled := make(map[string]bool)
+	for nameScope, i := range fn.LocalsByName {
+		names[i-1] = nameScope.Name
+		if nameScope.Scope == fn.Scope {
+			spilled[nameScope.Name] = true
+		}
+	}
+	for _, p := range fn.Params {
+		if !spilled[p.Name()] {
+			vars = append(vars, frameVar{p.Name(), interp.ToInspect(fr.Env()[p], nil)})
+		}
+	}
+	for i, l := range fn.Locals {
+		name, ok := names[uint(i)]
+		if !ok {
+			continue
+		}
+		ssaVal := ssa2.Value(l)
+		vars = append(vars, frameVar{name, interp.ToInspect(fr.Local(uint(i)), &ssaVal)})
+	}
+	return vars
+}
+
+// printFrameVars shows the parameters and the local variables of
+// frame fr that have source names.
+func printFrameVars(fr *interp.Frame) {
+	for _, v := range frameVars(fr) {
+		Msg("\t    %s = %s", v.Name, v.Value)
+	}
+}
+
 func PrintGoroutine(goNum int, goTops []*interp.GoreState) {
 	fr := goTops[goNum].Fr
 	if fr == nil {
@@ -140,3 +268,166 @@ func PrintGoroutine(goNum int, goTops []*interp.GoreState) {
 		Msg("Goroutine %d panic", goNum)
 	}
 }
+
+// PendingCalls returns the calls, other than those of built-in
+// functions, that frame fr will make before it reaches its next
+// statement. Only the current basic block is looked at.
+func PendingCalls(fr *interp.Frame) []*ssa2.CallCommon {
+	calls := make([]*ssa2.CallCommon, 0)
+	block := fr.Block()
+	if block == nil {
+		return calls
+	}
+	for _, instr := range block.Instrs[fr.PC()+1:] {
+		switch instr := instr.(type) {
+		case *ssa2.Trace:
+			return calls
+		case *ssa2.Call:
+			if _, ok := instr.Common().Value.(*ssa2.Builtin); !ok {
+				calls = append(calls, instr.Common())
+			}
+		}
+	}
+	return calls
+}
+
+// fileMatches reports whether name, a file name as given by the
+// user, names path: either all of it or its trailing path components.
+func fileMatches(path, name string) bool {
+	return path == name || strings.HasSuffix(path, "/"+name)
+}
+
+// StmtLookup returns the block and instruction index of the first
+// statement boundary of function fn on line line of file filename,
+// which may be just the trailing components of the file's path.
+// ok is false if there is none.
+func StmtLookup(fn *ssa2.Function, filename string, line int) (b *ssa2.BasicBlock, index int, ok bool) {
+	fset := fn.Prog.Fset
+	var best token.Pos
+	for _, block := range fn.Blocks {
+		for i, instr := range block.Instrs {
+			t, isTrace := instr.(*ssa2.Trace)
+			if !isTrace { continue }
+			pos := fset.Position(t.Start)
+			if pos.Line != line || !fileMatches(pos.Filename, filename) { continue }
+			if !ok || t.Start < best {
+				b, index, best, ok = block, i, t.Start, true
+			}
+		}
+	}
+	return b, index, ok
+}
+
+// leadingPhis returns the number of phi nodes at the start of block b.
+func leadingPhis(b *ssa2.BasicBlock) int {
+	n := 0
+	for n < len(b.Instrs) {
+		if _, ok := b.Instrs[n].(*ssa2.Phi); !ok { break }
+		n++
+	}
+	return n
+}
+
+// CanResumeAt returns an error if frame fr can't carry on at
+// instruction index of block b. A phi node picks its value by the
+// block we came from, so another block starting with phis can only be
+// entered from one of its predecessors, and only at the instruction
+// right after the phis so that they get run.
+func CanResumeAt(fr *interp.Frame, b *ssa2.BasicBlock, index int) error {
+	if b == fr.Block() {
+		return nil
+	}
+	n := leadingPhis(b)
+	if n == 0 {
+		return nil
+	}
+	isPred := false
+	for _, pred := range b.Preds {
+		if pred == fr.Block() {
+			isPred = true
+			break
+		}
+	}
+	if !isPred {
+		return fmt.Errorf("block %d has phi nodes and block %d isn't a predecessor of it",
+			b.Index, fr.Block().Index)
+	}
+	if index != n {
+		return fmt.Errorf("instruction %d of block %d isn't right after its phi nodes",
+			index, b.Index)
+	}
+	return nil
+}
+
+// SetResumePoint arranges for frame fr, which must be the top frame
+// stopped at a trace event, to carry on at instruction index of block
+// b rather than after the instruction it is stopped at. Check with
+// CanResumeAt first when b isn't fr's current block.
+func SetResumePoint(fr *interp.Frame, b *ssa2.BasicBlock, index int) {
+	if b != fr.Block() {
+		fr.SetBlock(b)
+		if n := leadingPhis(b); n > 0 && index == n {
+			// Run b's phi nodes on the way in.
+			index = 0
+		}
+	}
+	// The interpreter loop does pc++ before running the next
+	// instruction.
+	fr.SetPC(index-1)
+}
+
+// CurStmtIndex returns the index in the current block of frame fr of
+// the trace instruction for the statement fr is stopped in, or -1 if
+// there is none.
+func CurStmtIndex(fr *interp.Frame) int {
+	b := fr.Block()
+	if b == nil {
+		return -1
+	}
+	for i := fr.PC(); i >= 0; i-- {
+		if i >= len(b.Instrs) { continue }
+		if _, ok := b.Instrs[i].(*ssa2.Trace); ok {
+			return i
+		}
+	}
+	return -1
+}
+
+// ReturnValues evaluates exprs in frame fr as the values its function
+// is to return, converting them to the result types. With no exprs,
+// the current values of named results are used, or zero values for
+// unnamed ones.
+func ReturnValues(fr *interp.Frame, exprs []string) ([]interp.Value, error) {
+	results := fr.Fn().Signature.Results()
+	if len(exprs) != 0 && len(exprs) != results.Len() {
+		return nil, fmt.Errorf("%s returns %d values, got %d",
+			fr.Fn(), results.Len(), len(exprs))
+	}
+	values := make([]interp.Value, results.Len())
+	for i := 0; i < results.Len(); i++ {
+		t := results.At(i).Type()
+		if len(exprs) == 0 {
+			values[i] = interp.Zero(t)
+			name := results.At(i).Name()
+			if name == "" || name == "_" {
+				continue
+			}
+			nameVal, addr, _ := EnvLookup(fr, name, curScope)
+			if _, ok := nameVal.(*ssa2.Alloc); ok {
+				if addr, ok := addr.(*interp.Value); ok && addr != nil {
+					values[i] = *addr
+				}
+			}
+			continue
+		}
+		x, err := evalString(fr, curScope, exprs[i])
+		if err != nil {
+			return nil, err
+		}
+		if !assignable(x, t) {
+			return nil, fmt.Errorf("can't return %s as %s", x.t, t)
+		}
+		values[i] = interp.CopyVal(convertArg(x, t).v)
+	}
+	return values, nil
+}
@@ -22,6 +22,14 @@ var terse     = flag.Bool("terse", true, `abbreviated output`)
 var testing   = flag.Bool("testing", false, `used in testing`)
 var Highlight = flag.Bool("highlight", true, `use syntax highlighting in output`)
 var inputFilename = flag.String("cmdfile", "", `cmdfile *commandfile*.`)
+var noInit    = flag.Bool("nx", false, `don't run the commands in .gubrc files`)
+var batch     = flag.Bool("batch", false, `run the -command file at each stop without asking for commands`)
+var batchCommand = flag.String("command", "", `*commandfile* of commands -batch runs at each stop`)
+var tuiFlag   = flag.Bool("tui", false, `split the terminal into source and command panes`)
+var annotate  = flag.Int("annotate", 0, `annotation level for Emacs and other front ends; 1 marks positions`)
+var interpreter = flag.String("interpreter", "console", `"mi" for gdb/MI records front ends can read`)
+var listenAddr = flag.String("listen", "", `wait for a debugger to attach on *host:port*; without a host, only from this machine`)
+var listenToken = flag.String("listen-token", "", `token debuggers attaching to -listen must give; one is made up and shown if not set`)
 var inputFile *os.File
 var inputReader *bufio.Reader
 var buffer = bytes.NewBuffer(make([]byte, 1024))
@@ -52,18 +60,22 @@ var historyFile string
 
 // gnuReadLineSetup is boilerplate initialization for GNU Readline.
 func gnuReadLineSetup() {
-	historyFile = HistoryFile(".gub")
+	historyFile = HistoryFile(".gub_history")
 	if historyFile != "" && os.Getenv("TESTING") == "" {
 		gnureadline.ReadHistory(historyFile)
+		historyRead(historyFile)
 	}
 	// Set maximum number of history entries
-	gnureadline.StifleHistory(100)
+	gnureadline.StifleHistory(historyMax)
+	readlineCompletionSetup()
 }
 
-// gnuReadLineTermination has GNU Readline Termination tasks:
-// save history file if there is one, and reset the terminal.
-func gnuReadLineTermination() {
-	if historyFile != "" {
+// GnuReadLineTermination has GNU Readline Termination tasks:
+// save history file if there is one, and reset the terminal. It is
+// called when the debugger is about to exit.
+func GnuReadLineTermination() {
+	TUIDisable()
+	if historyFile != "" && os.Getenv("TESTING") == "" {
 		gnureadline.WriteHistory(historyFile)
 	}
 	if Term != "" {
@@ -81,9 +93,9 @@ func HistoryFile(history_basename string) string {
 		return ""
 	}
 	history_file := filepath.Join(home_dir, history_basename)
-	if fi, err := os.Stat(history_file); err != nil {
-		fmt.Println("No history file found to read in")
-	} else {
+	// It's fine if there is no history file yet; it is written when
+	// we leave.
+	if fi, err := os.Stat(history_file); err == nil {
 		if fi.IsDir() {
 			fmt.Printf("Ignoring history file %s; is a directory, should be a file",
 				history_file)
@@ -113,7 +125,9 @@ func process_options(options *string) {
 		// fmt.Println("Args are ", args)
 		os.Args = args
 		flag.Parse()
-		if *testing { *Highlight = false }
+		if *testing || MIMode() || !isTerminal(os.Stdout) {
+			*Highlight = false
+		}
 		if inputFilename != nil && len(*inputFilename) > 0 {
 			var err error
 			if inputFile, err = os.Open(*inputFilename); err != nil {
@@ -124,15 +138,31 @@ func process_options(options *string) {
 			inputReader = bufio.NewReader(inputFile)
 		} else {
 			gnuReadLineSetup()
-			defer gnuReadLineTermination()
+		}
+		if *batch {
+			*Highlight = false
+			if *batchCommand == "" {
+				fmt.Println("-batch needs a file of commands given with -command")
+				os.Exit(2)
+			}
+			if err := batchSetup(*batchCommand); err != nil {
+				fmt.Println("Error reading debugger command file:", err)
+				os.Exit(2)
+			}
+		}
+		if *listenAddr != "" {
+			if err := Listen(*listenAddr, *listenToken); err != nil {
+				fmt.Println("Error waiting for a debugger to attach:", err)
+				os.Exit(1)
+			}
 		}
 
 	}
 }
 
 func IntroText() {
-	fmt.Printf("Gub version %s\n", version)
-	fmt.Println("Type 'h' for help")
+	Msg("Gub version %s", version)
+	Msg("Type 'h' for help")
 }
 
 func Install(options *string, restart_args []string, prog *ssa2.Program) {
@@ -143,7 +173,7 @@ func Install(options *string, restart_args []string, prog *ssa2.Program) {
 	} else {
 		// gnuReadLineSetup()
 	}
-	defer gnuReadLineTermination()
 	interp.SetTraceHook(GubTraceHook)
+	interp.SetStepSkip(StepSkip)
 	process_options(options)
 }
@@ -16,6 +16,7 @@ const slash = string(os.PathSeparator)
 type testDatum struct {
 	gofile  string
 	baseName string
+	gubOpts string // more options for gub, if any
 }
 
 // Note we should order these from simple to more complex
@@ -26,6 +27,20 @@ var testData = []testDatum {
 	{gofile: "gcd",      baseName: "frame"},
 //	{gofile: "expr",     baseName: "eval"},
 	{gofile: "gcdBrkpt", baseName: "runtimeBrkpt"},
+	{gofile: "gcd",      baseName: "jump"},
+	{gofile: "gcd",      baseName: "print"},
+	{gofile: "call",     baseName: "call"},
+	{gofile: "gcd",      baseName: "display"},
+	{gofile: "gcd",      baseName: "examine"},
+	{gofile: "setvar",   baseName: "setvar"},
+	{gofile: "return",   baseName: "return"},
+	{gofile: "find",     baseName: "find"},
+	{gofile: "gcd",      baseName: "alias"},
+	{gofile: "gcd",      baseName: "define"},
+	{gofile: "gcd",      baseName: "source"},
+	{gofile: "gcd",      baseName: "infoline"},
+	{gofile: "gcd",      baseName: "json"},
+	{gofile: "gcd",      baseName: "mi", gubOpts: "-interpreter=mi"},
 }
 
 // Runs debugger on go program with baseName. Then compares output.
@@ -35,6 +50,9 @@ func run(t *testing.T, test testDatum) bool {
 	goFile    := fmt.Sprintf("testdata%s%s.go",  slash, test.gofile)
 	rightName := fmt.Sprintf("testdata%s%s.right",  slash, test.baseName)
 	gubOpt    := fmt.Sprintf("-gub=-cmdfile=testdata%s%s.cmd", slash, test.baseName)
+	if test.gubOpts != "" {
+		gubOpt += " " + test.gubOpts
+	}
 
 	file, err := os.Open(goFile) // For read access.
 	file.Close()
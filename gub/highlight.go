@@ -0,0 +1,112 @@
+// Copyright 2015 Rocky Bernstein.
+// Terminal coloring of source lines and disassembly
+
+package gub
+
+import (
+	"strings"
+
+	"github.com/rocky/ssa-interp"
+	"github.com/rocky/ssa-interp/terminal"
+)
+
+// highlightedLines caches the syntax-colored lines of files we have
+// listed with highlighting on.
+var highlightedLines = make(map[string][]string)
+
+// HighlightedLines returns the lines of source file filename with Go
+// keywords, strings and so on colored for the terminal. We color the
+// whole file at once so that comments and raw strings spanning lines
+// come out right.
+func HighlightedLines(filename string) ([]string, error) {
+	if lines, ok := highlightedLines[filename]; ok {
+		return lines, nil
+	}
+	lines, err := SourceLines(filename)
+	if err != nil {
+		return nil, err
+	}
+	text, err := ansiterm.AsTerm([]byte(strings.Join(lines, "\n")), true)
+	if err != nil {
+		return nil, err
+	}
+	colored := strings.Split(string(text), "\n")
+	if len(colored) != len(lines) {
+		// Shouldn't happen, but don't show the wrong lines.
+		colored = lines
+	}
+	highlightedLines[filename] = colored
+	return colored, nil
+}
+
+// BreakpointLines returns a margin marker for each line of filename
+// holding a breakpoint: "B" if it is enabled and "b" if not.
+func BreakpointLines(filename string) map[int]string {
+	marks := make(map[int]string)
+	for _, bp := range Breakpoints {
+		if bp == nil || bp.Deleted || !bp.Pos.IsValid() {
+			continue
+		}
+		pos := program.Fset.Position(bp.Pos)
+		if pos.Filename != filename {
+			continue
+		}
+		if bp.Enabled {
+			marks[pos.Line] = "B"
+		} else if marks[pos.Line] == "" {
+			marks[pos.Line] = "b"
+		}
+	}
+	return marks
+}
+
+// instrColor is the color an SSA instruction's mnemonic is shown in,
+// according to the kind of instruction it is.
+func instrColor(instr ssa2.Instruction) string {
+	switch instr.(type) {
+	case *ssa2.Jump, *ssa2.If, *ssa2.Return, *ssa2.Panic, *ssa2.RunDefers:
+		return "purple"
+	case *ssa2.Call, *ssa2.Go, *ssa2.Defer:
+		return "darkgreen"
+	case *ssa2.Alloc, *ssa2.Store, *ssa2.FieldAddr, *ssa2.IndexAddr,
+		*ssa2.Lookup, *ssa2.MapUpdate:
+		return "darkblue"
+	case *ssa2.Phi, *ssa2.Select, *ssa2.Send:
+		return "teal"
+	case *ssa2.Trace:
+		return "darkgray"
+	}
+	return "brown"
+}
+
+// disasmInst is ssa2.DisasmInst with the instruction's mnemonic
+// colored when highlighting is on.
+func disasmInst(instr ssa2.Instruction) string {
+	s := ssa2.DisasmInst(instr, Maxwidth)
+	if !*Highlight {
+		return s
+	}
+	text := instr.String()
+	i := strings.Index(s, text)
+	if i < 0 {
+		return s
+	}
+	// The mnemonic is the first word, e.g. "call" or "jump"; for
+	// operators like "t1 + t2" we color the whole instruction.
+	n := len(text)
+	if j := strings.IndexAny(text, " \t"); j > 0 && isMnemonic(text[:j]) {
+		n = j
+	}
+	return s[:i] + ansiterm.Colorize(instrColor(instr), text[:n]) + s[i+n:]
+}
+
+// isMnemonic is true if word looks like an SSA opcode name rather
+// than an operand.
+func isMnemonic(word string) bool {
+	for _, c := range word {
+		if !(c >= 'a' && c <= 'z') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,57 @@
+// Copyright 2015 Rocky Bernstein.
+// The list of commands entered, for the "history" command
+
+package gub
+
+import (
+	"io/ioutil"
+	"strings"
+)
+
+// historyMax is the number of commands kept in the history, here and
+// in GNU Readline's.
+const historyMax = 100
+
+// History holds the commands entered at the prompt, the oldest first.
+// It starts out with those in the history file saved when gub was
+// last left. GNU Readline keeps its own copy, which it uses for the
+// arrow keys and Ctrl-R search.
+var History []string
+
+// historyRead seeds History from history file filename.
+func historyRead(filename string) {
+	text, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return
+	}
+	for _, line := range strings.Split(string(text), "\n") {
+		// Lines starting with "#" hold timestamps.
+		if line != "" && !strings.HasPrefix(line, "#") {
+			historyAdd(line)
+		}
+	}
+}
+
+// historyAdd adds command line to History.
+func historyAdd(line string) {
+	line = strings.TrimRight(line, "\r\n")
+	if strings.TrimSpace(line) == "" {
+		return
+	}
+	History = append(History, line)
+	if len(History) > historyMax {
+		History = History[len(History)-historyMax:]
+	}
+}
+
+// HistorySearch returns the numbers, counting from 1, of the entries
+// in History containing s, the most recent last.
+func HistorySearch(s string) []int {
+	var found []int
+	for i, line := range History {
+		if strings.Contains(line, s) {
+			found = append(found, i+1)
+		}
+	}
+	return found
+}
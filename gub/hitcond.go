@@ -0,0 +1,83 @@
+// Copyright 2015 Rocky Bernstein.
+// Breakpoint hit-count conditions
+
+package gub
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// HitCond is a condition on the number of times a breakpoint has
+// been hit. The breakpoint stops only when hits Op N is true, or
+// (hits % Mod) Op N when Mod is nonzero.
+type HitCond struct {
+	Mod int
+	Op  string
+	N   int
+}
+
+// ParseHitCond parses the words of a hit-count condition such as
+// ">= 10" or "% 100 == 0".
+func ParseHitCond(args []string) (*HitCond, error) {
+	c := &HitCond{}
+	if len(args) > 0 && args[0] == "%" {
+		if len(args) < 2 {
+			return nil, fmt.Errorf("Expecting a number after %%")
+		}
+		mod, err := strconv.Atoi(args[1])
+		if err != nil || mod <= 0 {
+			return nil, fmt.Errorf("Expecting a positive modulus; got '%s'", args[1])
+		}
+		c.Mod = mod
+		args = args[2:]
+	}
+	if len(args) != 2 {
+		return nil, fmt.Errorf("Expecting hitcount [%% *m*] *op* *n*")
+	}
+	switch args[0] {
+	case "==", "!=", "<", "<=", ">", ">=":
+		c.Op = args[0]
+	default:
+		return nil, fmt.Errorf("Expecting a comparison operator; got '%s'", args[0])
+	}
+	n, err := strconv.Atoi(args[1])
+	if err != nil {
+		return nil, fmt.Errorf("Expecting an integer hit count; got '%s'", args[1])
+	}
+	c.N = n
+	return c, nil
+}
+
+// Check returns true if hits satisfies the condition. A nil
+// condition is always satisfied.
+func (c *HitCond) Check(hits int) bool {
+	if c == nil {
+		return true
+	}
+	if c.Mod != 0 {
+		hits %= c.Mod
+	}
+	switch c.Op {
+	case "==":
+		return hits == c.N
+	case "!=":
+		return hits != c.N
+	case "<":
+		return hits < c.N
+	case "<=":
+		return hits <= c.N
+	case ">":
+		return hits > c.N
+	case ">=":
+		return hits >= c.N
+	}
+	return true
+}
+
+func (c *HitCond) String() string {
+	if c.Mod != 0 {
+		return fmt.Sprintf("hitcount %% %d %s %d", c.Mod, c.Op, c.N)
+	}
+	return fmt.Sprintf("hitcount %s %d", c.Op, c.N)
+}
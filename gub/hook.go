@@ -8,6 +8,7 @@ import (
 	"strings"
 	"sync"
 	"runtime/debug"
+	"unicode"
 
 	"code.google.com/p/go-gnureadline"
 	"github.com/rocky/ssa-interp"
@@ -27,10 +28,38 @@ var InCmdLoop bool
 // "eval" need the exact text after the command.
 var CmdArgstr string
 
+// CmdFormat is the "/" and format letter that followed the command
+// name, as in "print/x", or "" if there was none.
+var CmdFormat string
+
 // NoBp contains the breakpoint number if we are stopped at and by a breakpoint.
 const NoBp = 0xfffff
 var curBpnum int
 
+// breakpointTriggered records a hit of enabled breakpoint bp in
+// frame fr and returns true if we should stop for it. We don't stop
+// if its condition is false, if its hit-count condition isn't met, or
+// if we still have hits to ignore.
+func breakpointTriggered(fr *interp.Frame, bp *Breakpoint) bool {
+	if bp.Condition != "" {
+		ok, err := EvalCondition(fr, fr.Scope(), bp.Condition)
+		if err != nil {
+			Errmsg("Error in condition for breakpoint %d: %s", bp.Id, err)
+		} else if !ok {
+			return false
+		}
+	}
+	bp.Hits ++
+	if !bp.HitCond.Check(bp.Hits) {
+		return false
+	}
+	if bp.Ignore > 0 {
+		bp.Ignore --
+		return false
+	}
+	return true
+}
+
 func skipEvent(fr *interp.Frame, event ssa2.TraceEvent) bool {
 	curBpnum = NoBp
 	if event == ssa2.BREAKPOINT {
@@ -38,13 +67,83 @@ func skipEvent(fr *interp.Frame, event ssa2.TraceEvent) bool {
 		for _, bpnum := range bps {
 			bp := Breakpoints[bpnum]
 			if !bp.Enabled { continue }
-			// FIXME: check things like the condition
+			if bp.GoOnly && bp.GoNum != fr.GoNum() { continue }
+			if !breakpointTriggered(fr, bp) { continue }
+			if bp.Format != "" {
+				// dprintf breakpoints print and keep going.
+				Dprintf(fr, bp)
+				continue
+			}
+			if bp.Trace {
+				// Tracepoints record the hit and keep going.
+				Tracepoint(fr, bp)
+				continue
+			}
+			curBpnum = bpnum
+			return false
+		}
+		// No enabled breakpoint here whose goroutine and hit-count
+		// conditions are met.
+		return true
+	} else if event == ssa2.WATCHPOINT {
+		addr, _ := interp.WatchHit(fr)
+		hitType := interp.WatchHitType(fr)
+		bps := BreakpointFindByAddr(addr)
+		if addr == nil {
+			bps = BreakpointFindByEntry(interp.WatchHitEntry(fr))
+		}
+		for _, bpnum := range bps {
+			bp := Breakpoints[bpnum]
+			if !bp.Enabled || bp.Watch&hitType == 0 { continue }
+			if !breakpointTriggered(fr, bp) { continue }
+			curBpnum = bpnum
+			return false
+		}
+		// No enabled watchpoint on this address or map element.
+		return true
+	} else if event == ssa2.PANIC {
+		bps := CatchpointFind("panic")
+		for _, bpnum := range bps {
+			bp := Breakpoints[bpnum]
+			if !bp.Enabled { continue }
+			if !breakpointTriggered(fr, bp) { continue }
 			curBpnum = bpnum
-			bp.Hits ++
-			break
+			return false
 		}
+		// We always stop on a panic unless all of the panic
+		// catchpoints set have been disabled.
+		return len(bps) > 0
+	} else if event == ssa2.DEFER_ENTER {
+		for _, bpnum := range CatchpointFind("defer") {
+			bp := Breakpoints[bpnum]
+			if !bp.Enabled { continue }
+			if !breakpointTriggered(fr, bp) { continue }
+			curBpnum = bpnum
+			return false
+		}
+		return true
+	} else if event == ssa2.CHAN_OP {
+		ch, _, _ := interp.ChanHit(fr)
+		for _, bpnum := range CatchpointFindByChan(ch) {
+			bp := Breakpoints[bpnum]
+			if !bp.Enabled { continue }
+			if !breakpointTriggered(fr, bp) { continue }
+			curBpnum = bpnum
+			return false
+		}
+		return true
+	} else if event == ssa2.EXTERNAL_CALL {
+		name, _ := interp.ExternalHit(fr)
+		for _, bpnum := range CatchpointFind("call " + name) {
+			bp := Breakpoints[bpnum]
+			if !bp.Enabled { continue }
+			if !breakpointTriggered(fr, bp) { continue }
+			curBpnum = bpnum
+			return false
+		}
+		return true
 	}
-	return false
+	return lineStepSkip(fr, event)
 }
 
 // computePrompt computes the gub read prompt. It has the command
@@ -76,21 +175,123 @@ func runCommand(name string, args []string) {
 		recover()
 	}()
 	cmd := Cmds[name]
+	PagerStart()
+	defer PagerStop()
 	if ArgCountOK(cmd.Min_args, cmd.Max_args, args) {
+		RunHook("hook-" + name)
 		cmd.Fn(args)
+		RunHook("hookpost-" + name)
 	}
+	TUIRefresh()
 }
 
 var FirstTime bool = true
 
+// initFileRun is set once the init file has been run.
+var initFileRun bool
+
+// RunLine runs debugger command line. It returns false if line
+// shouldn't be kept in the command history.
+func RunLine(line string) bool {
+	line = strings.Trim(line, " \t\n")
+	args  := strings.Split(line, " ")
+	if len(args) == 0 || len(args[0]) == 0 {
+		if len(LastCommand) == 0 {
+			Msg("Empty line skipped")
+			return false
+		}
+		line = LastCommand
+		args = strings.Split(line, " ")
+	}
+	if args[0][0] == '#' {
+		Msg(line) // echo line but do nothing
+		return false
+	}
+	if len(args[0]) > 1 && args[0][0] == '!' {
+		// "!cmd" is "! cmd", a shell escape, unless it negates a
+		// variable, as in "!ok".
+		if isVariable(leadingIdent(args[0][1:])) {
+			return PrintExpr(line)
+		}
+		line = "! " + line[1:]
+		args = strings.Split(line, " ")
+	}
+
+	if expanded := ExpandAlias(line); expanded != line {
+		line = expanded
+		args = strings.Split(line, " ")
+	}
+	name := args[0]
+	CmdArgstr = strings.TrimLeft(line[len(name):], " ")
+	CmdFormat = ""
+	if i := strings.Index(name, "/"); i > 0 && LookupCmd(name[:i]) != "" {
+		name, CmdFormat = name[:i], name[i:]
+		args[0] = name
+	}
+	// A variable in scope is shown rather than taken as the start
+	// of a command name, so "i" isn't "info" when there is an i.
+	if Cmds[name] == nil && Aliases[name] == "" && isVariable(name) {
+		LastCommand = ""
+		return WhatisName(name)
+	}
+	if newname := LookupCmd(name); newname != "" {
+		name = newname
+	}
+	cmd := Cmds[name];
+	LastCommand = ""
+
+	if cmd != nil {
+		runCommand(name, args)
+		return true
+	}
+	if candidates := CmdCandidates(name); len(candidates) > 1 {
+		Errmsg("Ambiguous command \"%s\": %s.", name,
+			strings.Join(candidates, ", "))
+		return false
+	}
+
+	return WhatisName(args[0])
+}
+
+// isVariable returns true if name is a variable in scope where we
+// are stopped, so that a command line starting with it is taken to be
+// about the variable rather than a command.
+func isVariable(name string) bool {
+	if name == "" || curFrame == nil {
+		return false
+	}
+	nameVal, _, _ := EnvLookup(curFrame, name, curScope)
+	return nameVal != nil
+}
+
+// leadingIdent returns the Go identifier s starts with, or "" if
+// there is none.
+func leadingIdent(s string) string {
+	for i, r := range s {
+		if !unicode.IsLetter(r) && r != '_' && (i == 0 || !unicode.IsDigit(r)) {
+			return s[:i]
+		}
+	}
+	return s
+}
+
+// RestartRequested is set by the "run" command so that when we leave
+// the command loop, the program is started over.
+var RestartRequested bool
+
 // GubTraceHook is the callback hook from interpreter. It contains
 // top-level statement breakout.
 func GubTraceHook(fr *interp.Frame, instr *ssa2.Instruction, event ssa2.TraceEvent) {
-	if !fr.I().TraceEventMask[event] { return }
+	// A trapped os.Exit stops even with PROGRAM_TERMINATION turned off.
+	forced := event == ssa2.PROGRAM_TERMINATION && interp.ExitTrapped()
+	if !fr.I().TraceEventOn(event) && !forced { return }
 	gubLock.Lock()
     defer gubLock.Unlock()
 	if skipEvent(fr, event) { return }
 	TraceEvent = event
+	histPos = -1
+	lineStepping = false
+	listFile = ""
 	frameInit(fr)
 	if instr == nil && event != ssa2.PROGRAM_TERMINATION {
 		instr = &curBlock.Instrs[fr.PC()]
@@ -104,58 +305,80 @@ func GubTraceHook(fr *interp.Frame, instr *ssa2.Instruction, event ssa2.TraceEve
 	if FirstTime {
 		IntroText()
 		FirstTime = false
+		if *tuiFlag {
+			if err := TUIEnable(false); err != nil {
+				Errmsg(err.Error())
+			}
+		}
+	}
+	if MIMode() {
+		miStopped(fr, event)
+	}
+	remoteBroadcast()
+	stoppedEvents(topFrame, event)
+	annotateEvent("stopped")
+	finished := printFinishResults()
+	if JSONOutput() {
+		stoppedJSON(topFrame, event)
+	} else {
+		printLocInfo(topFrame, instr, event, finished)
+	}
+	annotateSource(topFrame.Position())
+	if event == ssa2.PROGRAM_TERMINATION {
+		programSummary()
+		programExited()
+	}
+	PrintDisplays()
+	if curBpnum != NoBp {
+		BreakpointStopped(curBpnum)
+	}
+
+	InCmdLoop = true
+	if !initFileRun {
+		initFileRun = true
+		RunInitFile()
+	}
+	RunHook(StopHook)
+	TUIRefresh()
+	if BatchMode() {
+		runBatch(event)
+	} else {
+		// Commands left over from a file of commands that resumed
+		// the program.
+		RunSourceLines()
 	}
-	printLocInfo(topFrame, instr, event)
 
 	line := ""
 	var err error
-	for InCmdLoop = true; err == nil && InCmdLoop; cmdCount++ {
-		if inputReader != nil {
+	for ; err == nil && (InCmdLoop || stayAtExit(event)); cmdCount++ {
+		if inputReader != nil && iface == nil {
 			line, err = inputReader.ReadString('\n')
 		} else {
-			line, err = gnureadline.Readline(computePrompt(), true)
+			line, err = readLine(computePrompt(), true)
+			if err != nil {
+				// End of input, so we may not get another chance
+				// to save command history.
+				GnuReadLineTermination()
+			}
 		}
         if err != nil {
             break
         }
-		line = strings.Trim(line, " \t\n")
-		args  := strings.Split(line, " ")
-		if len(args) == 0 || len(args[0]) == 0 {
-			if len(LastCommand) == 0 {
-				Msg("Empty line skipped")
-				gnureadline.RemoveHistory(gnureadline.HistoryLength()-1)
-				continue
-			} else {
-				line = LastCommand
-				args = strings.Split(line, " ")
-			}
-		}
-		if args[0][0] == '#' {
-			gnureadline.RemoveHistory(gnureadline.HistoryLength()-1)
-			Msg(line) // echo line but do nothing
-			continue
-		}
-
-		name := args[0]
-		CmdArgstr = strings.TrimLeft(line[len(name):], " ")
-		if newname := LookupCmd(name); newname != "" {
-			name = newname
-		}
-		cmd := Cmds[name];
-		LastCommand = ""
-
-		if cmd != nil {
-			runCommand(name, args)
-			continue
-		}
-
-		if len(args) > 0 {
-			if !WhatisName(args[0]) {
-				gnureadline.RemoveHistory(gnureadline.HistoryLength()-1)
+		if MIMode() {
+			MIRunLine(line)
+		} else if RunLine(line) {
+			if inputReader == nil {
+				historyAdd(line)
 			}
-		} else {
+		} else if !Remote() && iface == nil {
 			gnureadline.RemoveHistory(gnureadline.HistoryLength()-1)
-			Errmsg("Unknown command %s\n", cmd)
 		}
 	}
+	annotateEvent("starting")
+	if RestartRequested {
+		// This doesn't return. It unwinds the interpreter which
+		// then starts the program over.
+		RestartRequested = false
+		interp.Restart()
+	}
 }
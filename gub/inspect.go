@@ -87,6 +87,19 @@ func PrintLocal(fr *interp.Frame, i uint, isPtr bool) {
 	}
 }
 
+// PrintFreeVars shows the free variables of the closure frame fr is
+// running along with their values. It returns false if there are
+// none.
+func PrintFreeVars(fr *interp.Frame) bool {
+	fn := fr.Fn()
+	for i, fv := range fn.FreeVars {
+		ssaVal := ssa2.Value(fv)
+		Msg("%3d:\t%s %s = %s", i, fv.Name(), deref(fv.Type()),
+			Deref2Str(fr.Env()[fv], &ssaVal))
+	}
+	return len(fn.FreeVars) > 0
+}
+
 func PrintIfLocal(fr *interp.Frame, varname string, isPtr bool) bool {
 	if i := LocalsLookup(curFrame, varname, curScope); i != 0 {
 		PrintLocal(curFrame, i-1, isPtr)
@@ -212,6 +225,11 @@ func printPackageInfo(name string, pkg *ssa2.Package) {
 
 func WhatisName(name string) bool {
 	if len(name) == 0 { return false }
+	if strings.IndexAny(name, "[]()+-/%<>=!&|^'\" ") >= 0 ||
+		strings.HasPrefix(name, "**") {
+		// Not just a name; evaluate it as an expression.
+		return PrintExpr(name)
+	}
 	isPtr := false
 	if name[0] == '*' {
 		isPtr = true
@@ -224,8 +242,10 @@ func WhatisName(name string) bool {
 		varname := ids[0]
 		// local lookup needs to take precedence over package lookup
 		if i := LocalsLookup(curFrame, varname, curScope); i != 0 {
-			Errmsg("Sorry, dotted variable lookup for local %s not supported yet", varname)
-			return false
+			if isPtr {
+				name = "*" + name
+			}
+			return PrintExpr(name)
 		} else {
 			try_pkg := PkgLookup(varname)
 			if try_pkg != nil {
@@ -283,3 +303,36 @@ func WhatisName(name string) bool {
 	}
 	return true
 }
+
+// PrintExpr evaluates Go expression expr in the current frame and
+// shows its type and value. It returns false if expr couldn't be
+// evaluated.
+func PrintExpr(expr string) bool {
+	return printExpr(curFrame, curScope, expr)
+}
+
+// PrintExprInFrame is PrintExpr but evaluates expr in frame frameNum,
+// counting from the newest frame, without making it the current
+// frame.
+func PrintExprInFrame(frameNum int, expr string) bool {
+	fr, frameNum := getFrame(frameNum, true)
+	if fr == nil {
+		return false
+	}
+	scope := topScope
+	if frameNum != 0 {
+		scope = fr.Scope()
+	}
+	return printExpr(fr, scope, expr)
+}
+
+func printExpr(fr *interp.Frame, scope *ssa2.Scope, expr string) bool {
+	v, t, err := EvalExpr(fr, scope, expr)
+	if err != nil {
+		Errmsg("%s", err)
+		return false
+	}
+	n := recordValue(v, t)
+	Msg("$%d = (%s) %s", n, t, FormatValue(v, t))
+	return true
+}
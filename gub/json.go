@@ -0,0 +1,207 @@
+// Copyright 2015 Rocky Bernstein.
+// Showing breakpoints, the stack, locals and stops as JSON for tools
+
+package gub
+
+import (
+	"encoding/json"
+
+	"github.com/rocky/ssa-interp"
+	"github.com/rocky/ssa-interp/interp"
+)
+
+// OutputFormats are the values "set output" accepts.
+var OutputFormats = []string{"text", "json"}
+
+// OutputFormat is how breakpoint lists, backtraces, locals and the
+// notices of where we stopped are shown: "text", the default, for
+// people or "json" for tools. In JSON each is one line holding one
+// JSON object.
+var OutputFormat = "text"
+
+// JSONOutput is true if output is to be shown as JSON.
+func JSONOutput() bool { return OutputFormat == "json" }
+
+// MsgJSON shows v as a line of JSON.
+func MsgJSON(v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		Errmsg("Can't show as JSON: %s", err)
+		return
+	}
+	writeOutput(string(b) + "\n")
+}
+
+type jsonBreakpoint struct {
+	Id        int      `json:"id"`
+	Kind      string   `json:"kind"`
+	Disp      string   `json:"disp"`
+	Enabled   bool     `json:"enabled"`
+	File      string   `json:"file,omitempty"`
+	Line      int      `json:"line,omitempty"`
+	Expr      string   `json:"expr,omitempty"`
+	Condition string   `json:"condition,omitempty"`
+	Label     string   `json:"label,omitempty"`
+	Ignore    int      `json:"ignore,omitempty"`
+	Hits      int      `json:"hits"`
+	Format    string   `json:"format,omitempty"`
+	FmtArgs   []string `json:"fmtargs,omitempty"`
+	Collect   []string `json:"collect,omitempty"`
+}
+
+// breakpointKind gives the kind of breakpoint bp for tools, as gdb
+// names it.
+func breakpointKind(bp *Breakpoint) string {
+	switch {
+	case bp.Kind == "Catchpoint":
+		return "catchpoint"
+	case bp.Kind == "Watchpoint" && bp.Watch == interp.WATCH_READ:
+		return "rwatchpoint"
+	case bp.Kind == "Watchpoint":
+		return "watchpoint"
+	case bp.Format != "":
+		return "dprintf"
+	case bp.Trace:
+		return "tracepoint"
+	}
+	return "breakpoint"
+}
+
+// breakpointDisp gives what happens to breakpoint bp when it stops,
+// as gdb names it: "keep", "del" or "dis".
+func breakpointDisp(bp *Breakpoint) string {
+	if bp.Temp {
+		return "del"
+	} else if bp.Once {
+		return "dis"
+	}
+	return "keep"
+}
+
+// BreakpointsJSON shows breakpoints bps as JSON.
+func BreakpointsJSON(bps []*Breakpoint) {
+	list := make([]jsonBreakpoint, 0, len(bps))
+	for _, bp := range bps {
+		jbp := jsonBreakpoint{
+			Id: bp.Id,
+			Kind: breakpointKind(bp),
+			Disp: breakpointDisp(bp),
+			Enabled: bp.Enabled,
+			Expr: bp.Expr,
+			Condition: bp.Condition,
+			Label: bp.Label,
+			Ignore: bp.Ignore,
+			Hits: bp.Hits,
+			Format: bp.Format,
+			FmtArgs: bp.FmtArgs,
+			Collect: bp.Collect,
+		}
+		if bp.Pos.IsValid() {
+			pos := program.Fset.Position(bp.Pos)
+			jbp.File, jbp.Line = pos.Filename, pos.Line
+		}
+		list = append(list, jbp)
+	}
+	MsgJSON(map[string]interface{}{"breakpoints": list})
+}
+
+type jsonFrame struct {
+	Level    int        `json:"level"`
+	Function string     `json:"function"`
+	File     string     `json:"file,omitempty"`
+	Line     int        `json:"line,omitempty"`
+	Current  bool       `json:"current,omitempty"`
+	Vars     []frameVar `json:"vars,omitempty"`
+}
+
+// stackJSON shows at most count frames of the stack starting at fr
+// as JSON. If full is set, the variables of each frame are included.
+func stackJSON(fr *interp.Frame, count int, full bool) {
+	frames := []jsonFrame{}
+	for i := 0; fr != nil && i < count; fr = fr.Caller(0) {
+		pos := fr.Position()
+		jfr := jsonFrame{
+			Level: i,
+			Function: fr.FnAndParamString(),
+			File: pos.Filename,
+			Line: pos.Line,
+			Current: fr == curFrame,
+		}
+		if full {
+			jfr.Vars = frameVars(fr)
+		}
+		frames = append(frames, jfr)
+		i++
+	}
+	MsgJSON(map[string]interface{}{"stack": frames})
+}
+
+type jsonLocal struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Value string `json:"value"`
+	Free  bool   `json:"free,omitempty"`
+}
+
+// LocalsJSON shows the local variables of frame fr, and the free
+// variables of a closure, as JSON.
+func LocalsJSON(fr *interp.Frame) {
+	MsgJSON(map[string]interface{}{"locals": localVars(fr)})
+}
+
+// localVars returns the local variables of frame fr followed by the
+// free variables of a closure.
+func localVars(fr *interp.Frame) []jsonLocal {
+	fn := fr.Fn()
+	names := localNames(fn)
+	// A parameter not yet copied to its variable has the value given
+	// to it.
+	unstored := make(map[ssa2.Value]*ssa2.Parameter)
+	for _, p := range fn.Params {
+		if spill := paramSpill(fn, p); spill != nil && !paramStored(fr, p) {
+			unstored[spill] = p
+		}
+	}
+	locals := []jsonLocal{}
+	for i, l := range fn.Locals {
+		ssaVal := ssa2.Value(l)
+		v := fr.Local(uint(i))
+		if p := unstored[l]; p != nil {
+			ssaVal, v = p, fr.Env()[p]
+		}
+		name, ok := names[uint(i)]
+		if !ok {
+			name = l.Name()
+			if varName := fr.Reg2Var[name]; varName != "" {
+				name = varName
+			}
+		}
+		locals = append(locals, jsonLocal{name, deref(l.Type()).String(),
+			interp.ToInspect(v, &ssaVal), false})
+	}
+	for _, fv := range fn.FreeVars {
+		ssaVal := ssa2.Value(fv)
+		locals = append(locals, jsonLocal{fv.Name(), deref(fv.Type()).String(),
+			Deref2Str(fr.Env()[fv], &ssaVal), true})
+	}
+	return locals
+}
+
+// stoppedJSON shows where frame fr stopped on event as JSON.
+func stoppedJSON(fr *interp.Frame, event ssa2.TraceEvent) {
+	stop := map[string]interface{}{
+		"event": "stopped",
+		"reason": ssa2.Event2Name[event],
+	}
+	if event == ssa2.BREAKPOINT && curBpnum != NoBp {
+		stop["breakpoint"] = curBpnum
+	}
+	if event != ssa2.PROGRAM_TERMINATION {
+		pos := fr.Position()
+		stop["function"] = fr.FnAndParamString()
+		stop["file"] = pos.Filename
+		stop["line"] = pos.Line
+		stop["goroutine"] = fr.GoNum()
+	}
+	MsgJSON(stop)
+}
@@ -0,0 +1,141 @@
+// Copyright 2015 Rocky Bernstein.
+// Which SSA instructions a source line turned into
+
+package gub
+
+import (
+	"fmt"
+
+	"github.com/rocky/ssa-interp"
+)
+
+// reachable returns the blocks of function fn that can be reached
+// from its entry block or, for a function with a recover block, from
+// there.
+func reachable(fn *ssa2.Function) map[*ssa2.BasicBlock]bool {
+	seen := make(map[*ssa2.BasicBlock]bool)
+	var walk func(b *ssa2.BasicBlock)
+	walk = func(b *ssa2.BasicBlock) {
+		if seen[b] {
+			return
+		}
+		seen[b] = true
+		for _, s := range b.Succs {
+			walk(s)
+		}
+	}
+	if len(fn.Blocks) > 0 {
+		walk(fn.Blocks[0])
+	}
+	if fn.Recover != nil {
+		walk(fn.Recover)
+	}
+	return seen
+}
+
+// instrIndex returns the index of instr in its block, or -1.
+func instrIndex(instr ssa2.Instruction) int {
+	for i, in := range instr.Block().Instrs {
+		if in == instr {
+			return i
+		}
+	}
+	return -1
+}
+
+// lineRanges describes the ranges of instructions of function fn
+// whose source line is line, e.g. "block 2, instructions 3-7".
+func lineRanges(fn *ssa2.Function, line int) []string {
+	var ranges []string
+	for _, b := range fn.Blocks {
+		first := -1
+		lines := instrLines(b)
+		for i := 0; i <= len(lines); i++ {
+			if i < len(lines) && lines[i] == line {
+				if first < 0 {
+					first = i
+				}
+				continue
+			}
+			if first < 0 {
+				continue
+			}
+			if first == i-1 {
+				ranges = append(ranges, fmt.Sprintf("block %d, instruction %d",
+					b.Index, first))
+			} else {
+				ranges = append(ranges, fmt.Sprintf("block %d, instructions %d-%d",
+					b.Index, first, i-1))
+			}
+			first = -1
+		}
+	}
+	return ranges
+}
+
+// LineInfo shows the stopping locations on line line of file
+// filename: the Trace instructions the builder put there, with the
+// block and function they are in and whether the interpreter can get
+// to them, along with the instructions the line turned into.
+func LineInfo(filename string, line int) {
+	locs := program.FileLineLocs(filename, line, -1)
+	if len(locs) == 0 {
+		Errmsg("No stopping locations at line %d of %s", line, filename)
+		for next := line + 1; next < line + 20; next++ {
+			if len(program.FileLineLocs(filename, next, -1)) > 0 {
+				Msg("The next line with a stopping location is %d.", next)
+				break
+			}
+		}
+		return
+	}
+	Section("Line %d of %s", line, filename)
+	var fns []*ssa2.Function
+	seenFn := make(map[*ssa2.Function]bool)
+	for _, l := range locs {
+		pos := program.Fset.Position(l.Pos())
+		var fn *ssa2.Function
+		if l.Trace != nil {
+			t := l.Trace
+			b := t.Block()
+			if b == nil {
+				Msg("column %d: %s event, removed by the SSA builder; a breakpoint here is never hit",
+					pos.Column, ssa2.Event2Name[t.Event])
+				continue
+			}
+			fn = b.Parent()
+			Msg("column %d: %s event in function %s, block %d, instruction %d",
+				pos.Column, ssa2.Event2Name[t.Event], fn, b.Index, instrIndex(t))
+			if !reachable(fn)[b] {
+				Msg("\tblock %d can't be reached; a breakpoint here is never hit", b.Index)
+			}
+			if t.Breakpoint {
+				Msg("\tthe interpreter stops here for a breakpoint")
+			}
+		} else if l.Fn != nil {
+			fn = l.Fn
+			Msg("column %d: entry to function %s", pos.Column, fn)
+			if fn.Breakpoint {
+				Msg("\tthe interpreter stops here for a breakpoint")
+			}
+		}
+		if fn != nil && !seenFn[fn] {
+			seenFn[fn] = true
+			fns = append(fns, fn)
+		}
+	}
+	for _, fn := range fns {
+		for _, r := range lineRanges(fn, line) {
+			Msg("%s: %s", fn, r)
+		}
+	}
+	seenBp := make(map[int]bool)
+	for _, l := range locs {
+		for _, bpnum := range BreakpointFindByPos(l.Pos()) {
+			if !seenBp[bpnum] {
+				seenBp[bpnum] = true
+				Msg("Breakpoint %d is set here.", bpnum)
+			}
+		}
+	}
+}
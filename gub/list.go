@@ -0,0 +1,151 @@
+// Copyright 2015 Rocky Bernstein.
+// Showing lines of source text
+
+package gub
+
+import (
+	"fmt"
+	"go/token"
+	"io/ioutil"
+	"strings"
+)
+
+// ListSize is the number of lines "list" shows.
+var ListSize = 10
+
+// listFile and listFirst, listLast are the file and range of lines
+// last shown by "list". listFile is "" when nothing has been shown
+// since we stopped.
+var listFile string
+var listFirst, listLast int
+
+// sourceLines caches the lines of files we have listed.
+var sourceLines = make(map[string][]string)
+
+// SourceLines returns the lines of source file filename.
+func SourceLines(filename string) ([]string, error) {
+	if lines, ok := sourceLines[filename]; ok {
+		return lines, nil
+	}
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	lines := strings.Split(string(data), "\n")
+	if n := len(lines); n > 0 && lines[n-1] == "" {
+		lines = lines[:n-1]
+	}
+	sourceLines[filename] = lines
+	return lines, nil
+}
+
+// FileLookup returns the full name of a source file of the program
+// named filename. filename can be the full name or a trailing part of
+// it that starts after a slash, for example "fmt/print.go".
+func FileLookup(filename string) (string, bool) {
+	found := ""
+	program.Fset.Iterate(func(f *token.File) bool {
+		name := f.Name()
+		if name == filename || strings.HasSuffix(name, "/"+filename) {
+			found = name
+			return false
+		}
+		return true
+	})
+	return found, found != ""
+}
+
+// ListCentered shows ListSize lines of filename centered on line.
+func ListCentered(filename string, line int) {
+	first := line - ListSize/2
+	if first < 1 {
+		first = 1
+	}
+	ListLines(filename, first)
+}
+
+// ListLines shows ListSize lines of filename starting at line first.
+// The line we are stopped at is marked with "=>" and lines with
+// breakpoints with "B", or "b" when the breakpoint is disabled. With
+// highlighting on the source is colored.
+func ListLines(filename string, first int) {
+	lines, err := SourceLines(filename)
+	if err == nil && *Highlight {
+		lines, err = HighlightedLines(filename)
+	}
+	if err != nil {
+		Errmsg("Can't read %s: %s", filename, err)
+		return
+	}
+	if first > len(lines) {
+		Errmsg("Line number %d out of range; %s has %d lines.",
+			first, filename, len(lines))
+		return
+	}
+	if first < 1 {
+		first = 1
+	}
+	last := first + ListSize - 1
+	if last > len(lines) {
+		last = len(lines)
+	}
+	cur := curFrame.Position()
+	bpMarks := BreakpointLines(filename)
+	for line := first; line <= last; line++ {
+		Msg("%s", sourceLine(lines[line-1], line,
+			cur.Filename == filename && cur.Line == line, bpMarks[line]))
+	}
+	listFile, listFirst, listLast = filename, first, last
+}
+
+// sourceLine formats source line text, line number line, for
+// showing: the line number, the breakpoint marker bpMark if there is
+// one and "=>" if it is the line we are stopped at.
+func sourceLine(text string, line int, isCur bool, bpMark string) string {
+	if bpMark == "" {
+		bpMark = " "
+	} else {
+		bpMark = Themed("breakpoint", bpMark)
+	}
+	marker := "  "
+	lineno := fmt.Sprintf("%4d", line)
+	if isCur {
+		marker = Themed("current-line", "=>")
+		lineno = Themed("current-line", lineno)
+	}
+	return lineno + bpMark + marker + " " + text
+}
+
+// ListNext shows the lines after the ones "list" last showed, or, if
+// nothing has been listed since we stopped, the lines around where we
+// are stopped.
+func ListNext() {
+	if listFile == "" {
+		ListHere()
+		return
+	}
+	ListLines(listFile, listLast+1)
+}
+
+// ListPrev shows the lines before the ones "list" last showed.
+func ListPrev() {
+	if listFile == "" {
+		ListHere()
+		return
+	}
+	if listFirst <= 1 {
+		Errmsg("Already at the start of %s.", listFile)
+		return
+	}
+	ListLines(listFile, listFirst-ListSize)
+}
+
+// ListHere shows the lines around where the current frame is stopped.
+func ListHere() {
+	position := curFrame.Position()
+	if !position.IsValid() {
+		Errmsg("No source position for the current frame")
+		return
+	}
+	ListCentered(position.Filename, position.Line)
+}
@@ -38,11 +38,47 @@ func init() {
 		ssa2.SWITCH_COND     : "sw?",
 		ssa2.STMT_IN_LIST    : "---",
 		ssa2.PROGRAM_TERMINATION : "FIN",
+		ssa2.WATCHPOINT      : "w! ",
+		ssa2.CHAN_OP         : "ch!",
+		ssa2.EXTERNAL_CALL   : "x->",
+		ssa2.DEADLOCK        : "zzz",
+		ssa2.LIMIT           : "lim",
 	}
 }
 
+// printFinishResults shows the values returned by the function a
+// "finish" stepped out of, if that's why we stopped, and returns true
+// if it did.
+func printFinishResults() bool {
+	fn, results := interp.FinishHit()
+	if fn == nil {
+		return false
+	}
+	switch len(results) {
+	case 0:
+		Msg("Returned from %s", fn)
+	case 1:
+		Msg("Value returned from %s: %s", fn,
+			interp.ToInspect(results[0], nil))
+	default:
+		Msg("Values returned from %s:", fn)
+		tuple := fn.Signature.Results()
+		for i, v := range results {
+			name := tuple.At(i).Name()
+			if name == "" {
+				name = fmt.Sprintf("#%d", i)
+			}
+			Msg("\t%s %s = %s", name, tuple.At(i).Type(),
+				interp.ToInspect(v, nil))
+		}
+	}
+	return true
+}
+
+// printLocInfo shows where frame fr is stopped for event. If finished
+// is set, printFinishResults has shown what was returned.
 func printLocInfo(fr *interp.Frame, inst *ssa2.Instruction,
-	event ssa2.TraceEvent) {
+	event ssa2.TraceEvent, finished bool) {
 	defer func() {
 		if x := recover(); x != nil {
 			Errmsg("Internal error in getting location info")
@@ -74,6 +110,9 @@ func printLocInfo(fr *interp.Frame, inst *ssa2.Instruction,
 
 	switch event {
 	case ssa2.CALL_RETURN:
+		if finished {
+			break
+		}
 		if sig.Results() == nil {
 			Msg("return void")
 		} else {
@@ -91,7 +130,54 @@ func printLocInfo(fr *interp.Frame, inst *ssa2.Instruction,
 			}
 		}
 	case ssa2.PANIC:
-		// fmt.Printf("panic arg: %s\n", fr.Get(instr.X))
+		if curBpnum != NoBp {
+			Msg("Catchpoint %d (panic)", curBpnum)
+			Msg("panic value: %s", interp.ToInspect(interp.PanicValue(fr), nil))
+		}
+	case ssa2.DEFER_ENTER:
+		if curBpnum != NoBp {
+			Msg("Catchpoint %d (defer): calling %s", curBpnum,
+				interp.ToInspect(interp.DeferHit(fr), nil))
+		}
+	case ssa2.CHAN_OP:
+		if curBpnum != NoBp {
+			_, op, v := interp.ChanHit(fr)
+			Msg("Catchpoint %d (%s): %s", curBpnum, Breakpoints[curBpnum].Expr,
+				interp.ChanOp2Name[op])
+			if op != interp.CHAN_CLOSE {
+				Msg("value: %s", interp.ToInspect(v, nil))
+			}
+		}
+	case ssa2.EXTERNAL_CALL:
+		if curBpnum != NoBp {
+			name, args := interp.ExternalHit(fr)
+			Msg("Catchpoint %d (call %s)", curBpnum, name)
+			for i, arg := range args {
+				Msg("arg %d: %s", i, interp.ToInspect(arg, nil))
+			}
+		}
+	case ssa2.LIMIT:
+		Msg("%s; continuing gives the program as much again.", interp.LimitHit())
+	case ssa2.DEADLOCK:
+		Msg("All goroutines are blocked: deadlock. \"info goroutines\" shows what each is waiting on.")
+	case ssa2.WATCHPOINT:
+		if curBpnum != NoBp {
+			bp := Breakpoints[curBpnum]
+			addr, old := interp.WatchHit(fr)
+			if interp.WatchHitType(fr) == interp.WATCH_READ {
+				Msg("Read watchpoint %d: %s", bp.Id, bp.Expr)
+				Msg("Value = %s", interp.ToInspect(old, nil))
+			} else {
+				Msg("Watchpoint %d: %s", bp.Id, bp.Expr)
+				Msg("Old value = %s", interp.ToInspect(old, nil))
+				if addr != nil {
+					Msg("New value = %s", interp.ToInspect(*addr, nil))
+				} else if entry := interp.WatchHitEntry(fr); entry != nil {
+					v, _ := entry.Value()
+					Msg("New value = %s", interp.ToInspect(v, nil))
+				}
+			}
+		}
 	}
 
 	Msg(fr.PositionRange())
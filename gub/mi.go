@@ -0,0 +1,484 @@
+// Copyright 2015 Rocky Bernstein.
+// A gdb/MI-style machine interface for front ends
+
+package gub
+
+import (
+	"bufio"
+	"fmt"
+	"go/parser"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/rocky/ssa-interp"
+	"github.com/rocky/ssa-interp/interp"
+)
+
+// MIMode is true when we were started with -interpreter=mi. Instead
+// of the output meant for people we then emit the line-oriented
+// records of gdb's machine interface: "^done" or "^error" after each
+// command, "*stopped" when the program stops, "=breakpoint-created"
+// and so on, with what we would normally show wrapped in "~" console
+// stream records. Both MI commands like "-exec-next" and ordinary
+// debugger commands are accepted. The MI commands whose results front
+// ends read, such as "-stack-list-frames", give them as the fields of
+// their "^done" record.
+func MIMode() bool { return *interpreter == "mi" }
+
+// lastError is the text of the last error message, which MI reports
+// in the "^error" record of the command.
+var lastError string
+
+// miReader reads MI commands from standard input; MI front ends
+// don't want readline's line editing.
+var miReader *bufio.Reader
+
+// miCommands maps the MI commands we understand to the debugger
+// commands that carry them out.
+var miCommands = map[string]string{
+	"-break-delete":          "delete",
+	"-break-disable":         "disable",
+	"-break-enable":          "enable",
+	"-break-condition":       "condition",
+	"-break-watch":           "watch",
+	"-data-disassemble":      "disassemble",
+	"-exec-continue":         "continue",
+	"-exec-finish":           "finish",
+	"-exec-next":             "next",
+	"-exec-next-instruction": "stepi",
+	"-exec-return":           "return",
+	"-exec-run":              "run",
+	"-exec-step":             "step",
+	"-exec-step-instruction": "stepi",
+	"-gdb-exit":              "quit",
+	"-stack-info-frame":      "frame",
+	"-stack-select-frame":    "frame",
+	"-thread-info":           "goroutines",
+}
+
+// miResults maps the MI commands whose results front ends read to
+// the functions that carry them out. Each is given the command's
+// arguments and returns the fields of its "^done" record.
+var miResults map[string]func(args string) (string, error)
+
+func init() {
+	miResults = map[string]func(args string) (string, error){
+		"-break-insert":             miBreakInsert,
+		"-break-list":               miBreakList,
+		"-data-evaluate-expression": miEvaluate,
+		"-stack-list-frames":        miStackListFrames,
+		"-stack-list-locals":        miStackListLocals,
+	}
+}
+
+// miInserting is set while -break-insert sets breakpoints, whose
+// "=breakpoint-created" records are then left out as gdb does; they
+// are given in its result instead.
+var miInserting bool
+
+// miWrite writes MI record s as is.
+func miWrite(s string) {
+	io.WriteString(output, s)
+}
+
+// miStream wraps debugger output s in a console stream record.
+func miStream(s string) (int, error) {
+	miWrite("~" + strconv.Quote(s) + "\n")
+	return len(s), nil
+}
+
+// miReadLine shows the MI prompt and reads an MI command.
+func miReadLine() (string, error) {
+	if Remote() {
+		return remoteReadLine("(gdb) \n", true)
+	}
+	miWrite("(gdb) \n")
+	if miReader == nil {
+		miReader = bufio.NewReader(os.Stdin)
+	}
+	return miReader.ReadString('\n')
+}
+
+// miCommand splits MI input line into its token, the number a front
+// end can put in front of a command to match it to its result, and
+// either the debugger command line to run for it or the function
+// giving its result.
+func miCommand(line string) (token string, cmd string, result func() (string, error), err error) {
+	line = strings.TrimSpace(line)
+	i := 0
+	for i < len(line) && line[i] >= '0' && line[i] <= '9' {
+		i++
+	}
+	token, line = line[:i], line[i:]
+	if !strings.HasPrefix(line, "-") {
+		return token, line, nil, nil
+	}
+	fields := strings.SplitN(line, " ", 2)
+	args := ""
+	if len(fields) == 2 {
+		args = strings.TrimSpace(fields[1])
+	}
+	if fields[0] == "-interpreter-exec" {
+		// -interpreter-exec console "command"
+		words := strings.SplitN(args, " ", 2)
+		if len(words) != 2 || words[0] != "console" {
+			return token, "", nil, fmt.Errorf("-interpreter-exec: expecting console \"command\"")
+		}
+		cmd, err := strconv.Unquote(strings.TrimSpace(words[1]))
+		return token, cmd, nil, err
+	}
+	if fn, ok := miResults[fields[0]]; ok {
+		return token, "", func() (string, error) { return fn(args) }, nil
+	}
+	cmd, ok := miCommands[fields[0]]
+	if !ok {
+		return token, "", nil, fmt.Errorf("Undefined MI command: %s", fields[0][1:])
+	}
+	if args != "" {
+		cmd += " " + args
+	}
+	return token, cmd, nil, nil
+}
+
+// miArgs splits MI command arguments s into words. A word in double
+// quotes is a C string, which may hold blanks.
+func miArgs(s string) ([]string, error) {
+	var words []string
+	for s = strings.TrimSpace(s); s != ""; s = strings.TrimSpace(s) {
+		if s[0] != '"' {
+			end := strings.IndexAny(s, " \t")
+			if end < 0 {
+				end = len(s)
+			}
+			words = append(words, s[:end])
+			s = s[end:]
+			continue
+		}
+		end := 1
+		for end < len(s) && s[end] != '"' {
+			if s[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		if end >= len(s) {
+			return nil, fmt.Errorf("unterminated string: %s", s)
+		}
+		word, err := strconv.Unquote(s[:end+1])
+		if err != nil {
+			return nil, fmt.Errorf("bad string %s: %s", s[:end+1], err)
+		}
+		words = append(words, word)
+		s = s[end+1:]
+	}
+	return words, nil
+}
+
+// miRun runs debugger command line cmd for an MI command, returning
+// the error it reported, if any.
+func miRun(cmd string) error {
+	errors := ErrorCount
+	RunLine(cmd)
+	if ErrorCount > errors {
+		return fmt.Errorf("%s", lastError)
+	}
+	return nil
+}
+
+// MIRunLine runs MI input line and emits its result record: "^done"
+// if it worked, "^running" if it resumed the program and "^error"
+// with the error message otherwise.
+func MIRunLine(line string) {
+	token, cmd, result, err := miCommand(line)
+	if err == nil && result != nil {
+		var fields string
+		if fields, err = result(); err == nil {
+			miWrite(token + "^done," + fields + "\n")
+			return
+		}
+	}
+	if err != nil {
+		miWrite(fmt.Sprintf("%s^error,msg=%s\n", token, strconv.Quote(err.Error())))
+		return
+	}
+	errors := ErrorCount
+	RunLine(cmd)
+	switch {
+	case ErrorCount > errors:
+		miWrite(fmt.Sprintf("%s^error,msg=%s\n", token, strconv.Quote(lastError)))
+	case !InCmdLoop:
+		miWrite(token + "^running\n")
+		miWrite("*running,thread-id=\"all\"\n")
+	default:
+		miWrite(token + "^done\n")
+	}
+}
+
+// miStopReason gives the MI reason for stopping on event.
+func miStopReason(event ssa2.TraceEvent) string {
+	switch event {
+	case ssa2.BREAKPOINT:
+		return "breakpoint-hit"
+	case ssa2.WATCHPOINT:
+		return "watchpoint-trigger"
+	case ssa2.CALL_RETURN:
+		return "function-finished"
+	case ssa2.PROGRAM_TERMINATION:
+		if ExitStatus() != 0 {
+			return "exited"
+		}
+		return "exited-normally"
+	}
+	return "end-stepping-range"
+}
+
+// miStopped emits the "*stopped" record for stopping in frame fr on
+// event.
+func miStopped(fr *interp.Frame, event ssa2.TraceEvent) {
+	s := "*stopped,reason=\"" + miStopReason(event) + "\""
+	if event == ssa2.BREAKPOINT && curBpnum != NoBp {
+		s += fmt.Sprintf(",bkptno=\"%d\"", curBpnum)
+	}
+	if event == ssa2.PROGRAM_TERMINATION && ExitStatus() != 0 {
+		// gdb gives the exit code in octal.
+		s += fmt.Sprintf(",exit-code=\"%02o\"", ExitStatus())
+	}
+	if event != ssa2.PROGRAM_TERMINATION {
+		s += ",frame={" + miFrameFields(fr) + "}"
+		s += fmt.Sprintf(",thread-id=\"%d\"", fr.GoNum())
+	}
+	miWrite(s + "\n")
+}
+
+// miFrameFields gives the MI fields describing where frame fr is.
+func miFrameFields(fr *interp.Frame) string {
+	pos := fr.Position()
+	return fmt.Sprintf("func=%s,file=%s,fullname=%s,line=\"%d\"",
+		strconv.Quote(fr.Fn().String()), strconv.Quote(pos.Filename),
+		strconv.Quote(pos.Filename), pos.Line)
+}
+
+// miBkpt gives the MI tuple describing breakpoint bp.
+func miBkpt(bp *Breakpoint) string {
+	kind := breakpointKind(bp)
+	enabled := "n"
+	if bp.Enabled {
+		enabled = "y"
+	}
+	s := fmt.Sprintf("{number=\"%d\",type=\"%s\",disp=\"%s\",enabled=\"%s\"",
+		bp.Id, kind, breakpointDisp(bp), enabled)
+	if bp.Pos.IsValid() && kind != "watchpoint" && kind != "rwatchpoint" && kind != "catchpoint" {
+		pos := program.Fset.Position(bp.Pos)
+		s += fmt.Sprintf(",file=%s,fullname=%s,line=\"%d\"",
+			strconv.Quote(pos.Filename), strconv.Quote(pos.Filename), pos.Line)
+	}
+	if bp.Expr != "" {
+		s += ",what=" + strconv.Quote(bp.Expr)
+	}
+	if bp.GoOnly {
+		s += fmt.Sprintf(",thread=\"%d\"", bp.GoNum)
+	}
+	if bp.Condition != "" {
+		s += ",cond=" + strconv.Quote(bp.Condition)
+	}
+	if bp.Ignore != 0 {
+		s += fmt.Sprintf(",ignore=\"%d\"", bp.Ignore)
+	}
+	return s + fmt.Sprintf(",times=\"%d\"}", bp.Hits)
+}
+
+// miBreakpointCreated emits the "=breakpoint-created" record for
+// new breakpoint bp.
+func miBreakpointCreated(bp *Breakpoint) {
+	if MIMode() && !miInserting {
+		miWrite("=breakpoint-created,bkpt=" + miBkpt(bp) + "\n")
+	}
+}
+
+// miBreakpointDeleted emits the "=breakpoint-deleted" record for
+// breakpoint bpnum.
+func miBreakpointDeleted(bpnum int) {
+	if MIMode() {
+		miWrite(fmt.Sprintf("=breakpoint-deleted,id=\"%d\"\n", bpnum))
+	}
+}
+
+// miBreakInsert carries out
+//    -break-insert [-t] [-d] [-h] [-f] [-c *cond*] [-i *count*] [-p *goroutine*] *location*
+// which sets a breakpoint at *location* as "breakpoint" does. -t makes
+// it temporary, -d disabled, -c gives its condition, -i its ignore
+// count and -p the only goroutine it stops. -h and -f, for hardware
+// and pending breakpoints, have no effect here.
+func miBreakInsert(args string) (string, error) {
+	words, err := miArgs(args)
+	if err != nil {
+		return "", err
+	}
+	temp, disabled, cond, ignore, goroutine := false, false, "", 0, ""
+	for len(words) > 0 && strings.HasPrefix(words[0], "-") {
+		opt := words[0]
+		words = words[1:]
+		switch opt {
+		case "-t":
+			temp = true
+		case "-d":
+			disabled = true
+		case "-h", "-f":
+		case "-c", "-i", "-p":
+			if len(words) == 0 {
+				return "", fmt.Errorf("-break-insert: %s needs an argument", opt)
+			}
+			arg := words[0]
+			words = words[1:]
+			switch opt {
+			case "-c":
+				if _, err := parser.ParseExpr(arg); err != nil {
+					return "", fmt.Errorf("Bad condition %s: %s", arg, err)
+				}
+				cond = arg
+			case "-i":
+				if ignore, err = strconv.Atoi(arg); err != nil || ignore < 0 {
+					return "", fmt.Errorf("-break-insert: bad ignore count %s", arg)
+				}
+			case "-p":
+				goroutine = " goroutine " + arg
+			}
+		default:
+			return "", fmt.Errorf("-break-insert: unknown option %s", opt)
+		}
+	}
+	if len(words) != 1 {
+		return "", fmt.Errorf("-break-insert: expecting a location")
+	}
+	first := len(Breakpoints)
+	miInserting = true
+	err = miRun("breakpoint " + words[0] + goroutine)
+	miInserting = false
+	if err != nil {
+		return "", err
+	}
+	if len(Breakpoints) == first {
+		return "", fmt.Errorf("-break-insert: no breakpoint set at %s", words[0])
+	}
+	var bkpts []string
+	for _, bp := range Breakpoints[first:] {
+		bp.Temp = temp
+		bp.Enabled = !disabled
+		bp.Condition = cond
+		bp.Ignore = ignore
+		bkpts = append(bkpts, "bkpt="+miBkpt(bp))
+	}
+	return strings.Join(bkpts, ","), nil
+}
+
+// miBreakList carries out -break-list, giving the breakpoint table.
+func miBreakList(args string) (string, error) {
+	var body []string
+	for _, bp := range Breakpoints {
+		if !bp.Deleted {
+			body = append(body, "bkpt="+miBkpt(bp))
+		}
+	}
+	return fmt.Sprintf("BreakpointTable={nr_rows=\"%d\",nr_cols=\"6\","+
+		"hdr=[{width=\"3\",alignment=\"-1\",col_name=\"number\",colhdr=\"Num\"},"+
+		"{width=\"14\",alignment=\"-1\",col_name=\"type\",colhdr=\"Type\"},"+
+		"{width=\"4\",alignment=\"-1\",col_name=\"disp\",colhdr=\"Disp\"},"+
+		"{width=\"3\",alignment=\"-1\",col_name=\"enabled\",colhdr=\"Enb\"},"+
+		"{width=\"10\",alignment=\"-1\",col_name=\"addr\",colhdr=\"Address\"},"+
+		"{width=\"40\",alignment=\"2\",col_name=\"what\",colhdr=\"What\"}],"+
+		"body=[%s]}", len(body), strings.Join(body, ",")), nil
+}
+
+// miEvaluate carries out
+//    -data-evaluate-expression *expr*
+// giving the value of *expr* in the current frame.
+func miEvaluate(args string) (string, error) {
+	expr := strings.TrimSpace(args)
+	if strings.HasPrefix(expr, "\"") {
+		var err error
+		if expr, err = strconv.Unquote(expr); err != nil {
+			return "", fmt.Errorf("bad expression string %s: %s", args, err)
+		}
+	}
+	if expr == "" {
+		return "", fmt.Errorf("-data-evaluate-expression: expecting an expression")
+	}
+	if curFrame == nil {
+		return "", fmt.Errorf("No frame selected.")
+	}
+	v, t, err := EvalExpr(curFrame, curScope, expr)
+	if err != nil {
+		return "", err
+	}
+	return "value=" + strconv.Quote(FormatValue(v, t)), nil
+}
+
+// miStackListFrames carries out
+//    -stack-list-frames [*low* *high*]
+// giving the frames of the stack from level *low* to level *high*,
+// or all of them.
+func miStackListFrames(args string) (string, error) {
+	low, high := 0, MAXSTACKSHOW-1
+	switch words := strings.Fields(args); len(words) {
+	case 0:
+	case 2:
+		var err1, err2 error
+		low, err1 = strconv.Atoi(words[0])
+		high, err2 = strconv.Atoi(words[1])
+		if err1 != nil || err2 != nil || low < 0 || high < low {
+			return "", fmt.Errorf("-stack-list-frames: bad frame levels %s", args)
+		}
+	default:
+		return "", fmt.Errorf("-stack-list-frames: expecting low and high frame levels")
+	}
+	if topFrame == nil {
+		return "", fmt.Errorf("No stack.")
+	}
+	var frames []string
+	level := 0
+	for fr := topFrame; fr != nil && level <= high; fr = fr.Caller(0) {
+		if level >= low {
+			frames = append(frames,
+				fmt.Sprintf("frame={level=\"%d\",%s}", level, miFrameFields(fr)))
+		}
+		level++
+	}
+	return "stack=[" + strings.Join(frames, ",") + "]", nil
+}
+
+// miStackListLocals carries out
+//    -stack-list-locals [0|1|2|--no-values|--all-values|--simple-values]
+// giving the local variables of the current frame: their names only,
+// their names and values, the default, or their names, types and
+// values.
+func miStackListLocals(args string) (string, error) {
+	show := 1
+	switch strings.TrimSpace(args) {
+	case "0", "--no-values":
+		show = 0
+	case "", "1", "--all-values":
+	case "2", "--simple-values":
+		show = 2
+	default:
+		return "", fmt.Errorf("-stack-list-locals: unknown print-values %s", args)
+	}
+	if curFrame == nil {
+		return "", fmt.Errorf("No frame selected.")
+	}
+	var locals []string
+	for _, l := range localVars(curFrame) {
+		switch show {
+		case 0:
+			locals = append(locals, "name="+strconv.Quote(l.Name))
+		case 1:
+			locals = append(locals, fmt.Sprintf("{name=%s,value=%s}",
+				strconv.Quote(l.Name), strconv.Quote(l.Value)))
+		default:
+			locals = append(locals, fmt.Sprintf("{name=%s,type=%s,value=%s}",
+				strconv.Quote(l.Name), strconv.Quote(l.Type), strconv.Quote(l.Value)))
+		}
+	}
+	return "locals=[" + strings.Join(locals, ",") + "]", nil
+}
@@ -6,45 +6,64 @@ import (
 	"go/ast"
 	"go/format"
 	"go/token"
-	"os"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/rocky/ssa-interp/terminal"
 	"code.google.com/p/go-columnize"
 )
 
+// ErrorCount is the number of error messages shown so far. Comparing
+// it before and after running a command tells whether the command
+// reported an error.
+var ErrorCount int
+
 func Errmsg(format string, a ...interface{}) (n int, err error) {
+	ErrorCount++
+	if iface != nil || MIMode() {
+		format += "\n"
+		msg := fmt.Sprintf(format, a...)
+		outputEvent(msg)
+		if iface != nil {
+			iface.Error(strings.TrimSuffix(msg, "\n"))
+			return len(msg), nil
+		}
+		// MI reports the error in the command's "^error" record
+		// and as a log stream record.
+		lastError = strings.TrimSuffix(msg, "\n")
+		miWrite("&" + strconv.Quote(msg) + "\n")
+		return len(msg), nil
+	}
 	if *Highlight {
-		format = ansiterm.Colorize("bold", format) + "\n"
+		format = Themed("error", format) + "\n"
 	} else {
 		format = "** " + format + "\n"
 	}
-	return fmt.Fprintf(os.Stdout, format, a...)
+	return writeOutput(fmt.Sprintf(format, a...))
 }
 
 func MsgNoCr(format string, a ...interface{}) (n int, err error) {
-	format = format
-	return fmt.Fprintf(os.Stdout, format, a...)
+	return writeOutput(fmt.Sprintf(format, a...))
 }
 
 func Msg(format string, a ...interface{}) (n int, err error) {
 	format = format + "\n"
-	return fmt.Fprintf(os.Stdout, format, a...)
+	return writeOutput(fmt.Sprintf(format, a...))
 }
 
 func MsgRaw(msg string) (n int, err error) {
-	return fmt.Println(msg)
+	return writeOutput(msg + "\n")
 }
 
 // A more emphasized version of msg. For section headings.
 func Section(format string, a ...interface{}) (n int, err error) {
 	if *Highlight {
-		format = ansiterm.Colorize("bold", format) + "\n"
+		format = Themed("section", format) + "\n"
 	} else {
 		format = format + "\n" + strings.Repeat("-", len(format)) + "\n"
 	}
-	return fmt.Fprintf(os.Stdout, format, a...)
+	return writeOutput(fmt.Sprintf(format, a...))
 }
 
 func PrintSorted(title string, names []string) {
@@ -0,0 +1,110 @@
+// Copyright 2015 Rocky Bernstein.
+// Paging long command output a screenful at a time
+
+package gub
+
+import (
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Height is the number of lines on the terminal. Output of a command
+// that is longer than this stops with a "--More--" prompt every
+// screenful. 0 turns paging off. It is like the LINES environment
+// variable.
+var Height int
+
+// pagerOn is set while output of a command run from the terminal is
+// being paged; pagerLines counts the lines shown since the last
+// prompt. pagerQuit is set when the user has asked to skip the rest
+// of the output.
+var pagerOn bool
+var pagerLines int
+var pagerQuit bool
+
+// output is where debugger output goes: the terminal, or the
+// connection to an attached debugger client.
+var output io.Writer = os.Stdout
+
+func init() {
+	Height = 24
+	if lines := os.Getenv("LINES"); lines != "" {
+		if i, err := strconv.Atoi(lines); err == nil && i >= 0 {
+			Height = i
+		}
+	}
+}
+
+// PagerStart starts paging the output of a command. Output is only
+// paged when commands come from the terminal rather than from a
+// command file, a sourced file or a test.
+func PagerStart() {
+	pagerLines = 0
+	pagerQuit = false
+	pagerOn = Height > 1 && inputReader == nil && !Remote() &&
+		len(sources) == 0 && !TUI && os.Getenv("TESTING") == ""
+}
+
+// PagerStop stops paging output.
+func PagerStop() {
+	pagerOn = false
+	pagerQuit = false
+}
+
+// pagerPrompt asks whether to go on after a screenful of output. It
+// returns false if the rest of the output should be skipped.
+func pagerPrompt() bool {
+	line, err := readLine("--More-- (RET to continue, q to quit) ", false)
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(strings.TrimSpace(line)) {
+	case "q", "quit":
+		return false
+	}
+	return true
+}
+
+// writeOutput writes s to the terminal, stopping every screenful to
+// ask whether to go on when output is being paged.
+func writeOutput(s string) (int, error) {
+	outputEvent(s)
+	if iface != nil {
+		iface.Write(s)
+		return len(s), nil
+	}
+	if MIMode() {
+		return miStream(s)
+	}
+	endProgramLine()
+	if !pagerOn {
+		return io.WriteString(output, s)
+	}
+	n := 0
+	for len(s) > 0 {
+		if pagerQuit {
+			return n, nil
+		}
+		if pagerLines >= Height-1 {
+			pagerLines = 0
+			if !pagerPrompt() {
+				pagerQuit = true
+				return n, nil
+			}
+		}
+		line := s
+		if i := strings.IndexByte(s, '\n'); i >= 0 {
+			line = s[:i+1]
+			pagerLines++
+		}
+		m, err := io.WriteString(output, line)
+		n += m
+		if err != nil {
+			return n, err
+		}
+		s = s[len(line):]
+	}
+	return n, nil
+}
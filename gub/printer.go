@@ -0,0 +1,136 @@
+// Copyright 2015 Rocky Bernstein.
+// Custom printers for values of named types set up from the debugger
+
+package gub
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rocky/go-types"
+	"github.com/rocky/ssa-interp/interp"
+)
+
+// PrinterDescs describes how each type with a printer added by the
+// debugger is shown, keyed by type name.
+var PrinterDescs = make(map[string]string)
+
+// TypeLookup finds named type name, given as pkg.Type or, for a type
+// in the current package, just Type.
+func TypeLookup(name string) (types.Type, error) {
+	pkg := curFrame.Fn().Pkg
+	typeName := name
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		pkg = PkgLookup(name[:i])
+		if pkg == nil {
+			return nil, fmt.Errorf("can't find package %s", name[:i])
+		}
+		typeName = name[i+1:]
+	}
+	if pkg == nil {
+		return nil, fmt.Errorf("can't find type %s", name)
+	}
+	t := pkg.Type(typeName)
+	if t == nil {
+		return nil, fmt.Errorf("%s is not a type in %s", typeName, pkg.Object.Name())
+	}
+	return t.Type(), nil
+}
+
+// PrinterMethodAdd arranges for values of type t to be shown by
+// calling their method name, which takes no arguments.
+func PrinterMethodAdd(t types.Type, name string) error {
+	x := exprVal{interp.Zero(t), t}
+	f, _, ok := method(curFrame, x, name)
+	if !ok {
+		return fmt.Errorf("%s has no method %s", t, name)
+	}
+	if f.Signature.Params().Len() != 0 || f.Signature.Results().Len() != 1 {
+		return fmt.Errorf("method %s of %s should take no arguments and return one value",
+			name, t)
+	}
+	interp.RegisterPrinter(t.String(), func(v interp.Value) string {
+		f, recv, _ := method(curFrame, exprVal{v, t}, name)
+		results, err := interp.CallFunction(curFrame, f, []interp.Value{recv.v})
+		if err != nil {
+			return "<" + err.Error() + ">"
+		}
+		if s, ok := results[0].(string); ok {
+			return s
+		}
+		return interp.ToInspect(results[0], nil)
+	})
+	PrinterDescs[t.String()] = "method " + name
+	return nil
+}
+
+// PrinterFormatAdd arranges for values of type t to be shown using
+// fmt format on the values of fields. A field can be a path like
+// a.b when fields are themselves structs.
+func PrinterFormatAdd(t types.Type, format string, fields []string) error {
+	for _, field := range fields {
+		if err := checkFieldPath(t, field); err != nil {
+			return err
+		}
+	}
+	interp.RegisterPrinter(t.String(), func(v interp.Value) string {
+		vals := make([]interface{}, len(fields))
+		for i, field := range fields {
+			fv, err := fieldPath(exprVal{v, t}, field)
+			if err != nil {
+				return "<" + err.Error() + ">"
+			}
+			vals[i] = dprintfValue(fv.v)
+		}
+		return fmt.Sprintf(format, vals...)
+	})
+	desc := fmt.Sprintf("format %q", format)
+	if len(fields) > 0 {
+		desc += ", " + strings.Join(fields, ", ")
+	}
+	PrinterDescs[t.String()] = desc
+	return nil
+}
+
+// fieldPath selects the field given by path, e.g. a.b, out of x.
+func fieldPath(x exprVal, path string) (exprVal, error) {
+	for _, name := range strings.Split(path, ".") {
+		var err error
+		if x, err = evalField(x, strings.TrimSpace(name)); err != nil {
+			return x, err
+		}
+	}
+	return x, nil
+}
+
+// checkFieldPath checks that fieldPath can select path out of a
+// value of type t.
+func checkFieldPath(t types.Type, path string) error {
+	for _, name := range strings.Split(path, ".") {
+		name = strings.TrimSpace(name)
+		if p, ok := t.Underlying().(*types.Pointer); ok {
+			t = p.Elem()
+		}
+		st, ok := t.Underlying().(*types.Struct)
+		if !ok {
+			return fmt.Errorf("%s is not a struct", t)
+		}
+		found := false
+		for i := 0; i < st.NumFields(); i++ {
+			if st.Field(i).Name() == name {
+				t, found = st.Field(i).Type(), true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%s has no field %s", t, name)
+		}
+	}
+	return nil
+}
+
+// PrinterDelete removes the printer for type t.
+func PrinterDelete(t types.Type) bool {
+	delete(PrinterDescs, t.String())
+	return interp.UnregisterPrinter(t.String())
+}
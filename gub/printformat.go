@@ -0,0 +1,121 @@
+// Copyright 2015 Rocky Bernstein.
+// Output formats for showing integer values, as in gdb's print/x
+
+package gub
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rocky/go-types"
+	"github.com/rocky/ssa-interp/interp"
+)
+
+// PrintFormat is the format letter used to show integer values. It
+// is one of the letters in PrintFormats, or "" to show values
+// normally. It stays in effect until another format is given.
+var PrintFormat = ""
+
+// PrintFormats describes the format letters PrintFormat can be.
+var PrintFormats = map[string]string{
+	"x": "hexadecimal",
+	"o": "octal",
+	"b": "binary",
+	"c": "character",
+	"d": "decimal",
+}
+
+// SetPrintFormat sets PrintFormat from the command's CmdFormat, e.g.
+// "/x". A "/" without a letter goes back to showing values normally.
+// It returns false if the format letter is not valid.
+func SetPrintFormat() bool {
+	if CmdFormat == "" {
+		return true
+	}
+	f := CmdFormat[1:]
+	if !checkFormat(f) {
+		return false
+	}
+	PrintFormat = f
+	return true
+}
+
+// checkFormat returns true if f is "" or a valid format letter.
+func checkFormat(f string) bool {
+	if f != "" && PrintFormats[f] == "" {
+		Errmsg("Invalid format letter '%s'; use x, o, b, c, or d", f)
+		return false
+	}
+	return true
+}
+
+// isInteger returns true if t is an integer type.
+func isInteger(t types.Type) bool {
+	b, ok := t.Underlying().(*types.Basic)
+	return ok && b.Info()&types.IsInteger != 0
+}
+
+// formatInt shows integer value v using format letter f.
+func formatInt(v interp.Value, f string) string {
+	n, ok := asInt(v)
+	if !ok {
+		return interp.ToInspect(v, nil)
+	}
+	// v is a Go integer of the same size and signedness as the
+	// program's, so fmt can format it directly.
+	switch f {
+	case "x":
+		return fmt.Sprintf("%#x", v)
+	case "o":
+		return fmt.Sprintf("%#o", v)
+	case "b":
+		return fmt.Sprintf("%b", v)
+	case "c":
+		return fmt.Sprintf("%d %q", v, rune(n))
+	}
+	return fmt.Sprintf("%d", v)
+}
+
+// FormatValue shows value v of type t using PrintFormat. Integers,
+// and arrays and slices of them, are shown in the format; anything
+// else is shown normally.
+func FormatValue(v interp.Value, t types.Type) string {
+	return formatValue(v, t, PrintFormat)
+}
+
+// formatValue is FormatValue using format letter f.
+func formatValue(v interp.Value, t types.Type, f string) string {
+	if f == "" {
+		return interp.ToInspectType(v, t)
+	}
+	if isInteger(t) {
+		return formatInt(v, f)
+	}
+	var elem types.Type
+	switch t := t.Underlying().(type) {
+	case *types.Slice:
+		elem = t.Elem()
+	case *types.Array:
+		elem = t.Elem()
+	}
+	if elem == nil || !isInteger(elem) {
+		return interp.ToInspectType(v, t)
+	}
+	n, _ := interp.Len(v)
+	elided := false
+	if max := interp.PrintElements(); max > 0 && n > max {
+		n, elided = max, true
+	}
+	elems := make([]string, n)
+	for i := range elems {
+		e, err := interp.SeqIndex(v, i)
+		if err != nil {
+			return interp.ToInspect(v, nil)
+		}
+		elems[i] = formatInt(e, f)
+	}
+	if elided {
+		elems = append(elems, "...")
+	}
+	return "{" + strings.Join(elems, ", ") + "}"
+}
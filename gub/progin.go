@@ -0,0 +1,106 @@
+// Copyright 2015 Rocky Bernstein.
+// Giving the debugged program its input
+
+package gub
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/rocky/ssa-interp/interp"
+)
+
+// What the program reads from standard input can come:
+//
+//   terminal  from our standard input, as it normally would
+//   shared    from the terminal a line at a time through us, with an
+//             "input> " prompt, taking turns with our own reading of
+//             commands so that the two don't fight over it
+//   buffer    from text given with the "input" command; once that is
+//             used up, the program gets end of file
+//   a file    from the named file
+//
+// The program's reads block while we are reading commands.
+
+// programInputDest says where standard input of the program comes
+// from; programInputFile is the file open for it, if any.
+var programInputDest = "terminal"
+var programInputFile *os.File
+
+// terminalLock is held while the terminal is being read, by us or
+// for the program.
+var terminalLock sync.Mutex
+
+// inputLock guards inputBuffer, the text given with "input".
+var inputLock sync.Mutex
+var inputBuffer bytes.Buffer
+
+// sharedReader reads lines from the terminal for the program.
+type sharedReader struct {
+	pending []byte // What is left of the last line read
+}
+
+func (s *sharedReader) Read(b []byte) (int, error) {
+	if len(s.pending) == 0 {
+		line, err := readLine("input> ", false)
+		if err != nil {
+			return 0, io.EOF
+		}
+		s.pending = []byte(line + "\n")
+	}
+	n := copy(b, s.pending)
+	s.pending = s.pending[n:]
+	return n, nil
+}
+
+// bufferReader reads from inputBuffer.
+type bufferReader struct{}
+
+func (bufferReader) Read(b []byte) (int, error) {
+	inputLock.Lock()
+	defer inputLock.Unlock()
+	return inputBuffer.Read(b)
+}
+
+// SetProgramInput has standard input of the program come from src:
+// "terminal", "shared", "buffer" or the name of a file.
+func SetProgramInput(src string) error {
+	var r io.Reader
+	var f *os.File
+	switch src {
+	case "terminal":
+	case "shared":
+		r = &sharedReader{}
+	case "buffer":
+		r = bufferReader{}
+	default:
+		var err error
+		if f, err = os.Open(src); err != nil {
+			return err
+		}
+		r = f
+	}
+	interp.SetProgramInput(r)
+	if programInputFile != nil {
+		programInputFile.Close()
+	}
+	programInputDest, programInputFile = src, f
+	return nil
+}
+
+// ProgramInputSource says where standard input of the program comes
+// from.
+func ProgramInputSource() string {
+	return programInputDest
+}
+
+// AddProgramInput adds text to what the program reads with input from
+// "buffer", and returns how much is waiting to be read.
+func AddProgramInput(text string) int {
+	inputLock.Lock()
+	defer inputLock.Unlock()
+	inputBuffer.WriteString(text)
+	return inputBuffer.Len()
+}
@@ -0,0 +1,144 @@
+// Copyright 2015 Rocky Bernstein.
+// Keeping the debugged program's output apart from ours
+
+package gub
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/rocky/ssa-interp/interp"
+)
+
+// What the program writes to standard output or standard error can
+// go:
+//
+//   terminal  to the terminal, as it normally would
+//   tag       to the terminal, each line starting with stdout> or
+//             stderr> so that it stands out from what we say
+//   buffer    to a buffer, shown with "info program-output"
+//   a file    to the named file
+//
+// When program output on the terminal leaves a line unfinished, we
+// end it before showing anything ourselves.
+
+// programDests says where standard output (1) and standard error (2)
+// of the program go; programFiles are the files open for them, by
+// name.
+var programDests = [3]string{"", "terminal", "terminal"}
+var programFiles = make(map[string]*os.File)
+
+// programLock guards programBuffer and the tag writers.
+var programLock sync.Mutex
+var programBuffer bytes.Buffer
+
+// tagWriter writes to the terminal, starting each line with tag.
+type tagWriter struct {
+	tag       string
+	w         io.Writer
+	lineStart bool
+}
+
+// tagWriters are the tag writers of standard output and error.
+var tagWriters = [3]*tagWriter{
+	nil,
+	{tag: "stdout> ", w: os.Stdout, lineStart: true},
+	{tag: "stderr> ", w: os.Stderr, lineStart: true},
+}
+
+func (t *tagWriter) Write(b []byte) (int, error) {
+	programLock.Lock()
+	defer programLock.Unlock()
+	var buf bytes.Buffer
+	for _, c := range b {
+		if t.lineStart {
+			buf.WriteString(t.tag)
+		}
+		buf.WriteByte(c)
+		t.lineStart = c == '\n'
+	}
+	if _, err := t.w.Write(buf.Bytes()); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// bufferWriter adds what is written to programBuffer.
+type bufferWriter struct{}
+
+func (bufferWriter) Write(b []byte) (int, error) {
+	programLock.Lock()
+	defer programLock.Unlock()
+	return programBuffer.Write(b)
+}
+
+// SetProgramOutput sends what the program writes to file descriptor
+// fd, 1 or 2, to dest: "terminal", "tag", "buffer" or the name of a
+// file, which is created afresh.
+func SetProgramOutput(fd int, dest string) error {
+	var w io.Writer
+	switch dest {
+	case "terminal":
+	case "tag":
+		w = tagWriters[fd]
+	case "buffer":
+		w = bufferWriter{}
+	default:
+		f, ok := programFiles[dest]
+		if !ok {
+			var err error
+			f, err = os.Create(dest)
+			if err != nil {
+				return err
+			}
+			programFiles[dest] = f
+		}
+		w = f
+	}
+	old := programDests[fd]
+	programDests[fd] = dest
+	interp.SetProgramOutput(fd, w)
+	if f, ok := programFiles[old]; ok && old != dest && programDests[3-fd] != old {
+		f.Close()
+		delete(programFiles, old)
+	}
+	return nil
+}
+
+// ProgramOutputDest says where what the program writes to file
+// descriptor fd, 1 or 2, goes.
+func ProgramOutputDest(fd int) string {
+	return programDests[fd]
+}
+
+// ProgramBuffer returns the program output kept in the buffer. If
+// clear is set, the buffer is emptied.
+func ProgramBuffer(clear bool) string {
+	programLock.Lock()
+	defer programLock.Unlock()
+	s := programBuffer.String()
+	if clear {
+		programBuffer.Reset()
+	}
+	return s
+}
+
+// endProgramLine ends the line program output on the terminal left
+// unfinished, if it did, before we write to the terminal.
+func endProgramLine() {
+	midLine := interp.EndProgramLine()
+	programLock.Lock()
+	for _, t := range tagWriters[1:] {
+		if !t.lineStart {
+			midLine = true
+			t.lineStart = true
+		}
+	}
+	programLock.Unlock()
+	if midLine && output == os.Stdout {
+		fmt.Fprintln(output)
+	}
+}
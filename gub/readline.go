@@ -0,0 +1,61 @@
+// Copyright 2015 Rocky Bernstein.
+// Completing debugger commands with TAB in GNU Readline
+
+package gub
+
+/*
+#cgo LDFLAGS: -lreadline
+#include <stdio.h>
+#include <stdlib.h>
+#include <readline/readline.h>
+
+extern char *gubCompletionEntry(char *text, int state);
+*/
+import "C"
+
+import (
+	"strings"
+	"unsafe"
+)
+
+// readlineMatches are the completions of the word being completed,
+// handed to Readline one at a time by gubCompletionEntry.
+var readlineMatches []string
+
+// readlineCompletionSetup makes Complete what Readline uses to
+// complete the command line when TAB is typed. Words are separated
+// by blanks only, as Complete separates them.
+func readlineCompletionSetup() {
+	C.rl_completer_word_break_characters = C.CString(" \t")
+	C.rl_completion_entry_function = (*C.rl_compentry_func_t)(unsafe.Pointer(C.gubCompletionEntry))
+}
+
+// gubCompletionEntry is Readline's completion entry function. It is
+// called for word text with state 0, 1, 2, ... and returns a new
+// completion of text each time until there are no more, when it
+// returns nil.
+//
+//export gubCompletionEntry
+func gubCompletionEntry(text *C.char, state C.int) *C.char {
+	if state == 0 {
+		readlineMatches = nil
+		line := C.GoStringN(C.rl_line_buffer, C.rl_point)
+		start := len(line) - len(C.GoString(text))
+		if start < 0 || (curFrame == nil && strings.Contains(line, " ")) {
+			// Only command names can be completed while
+			// there is no frame.
+			return nil
+		}
+		// Complete drops leading blanks, which Readline keeps.
+		blanks := line[:len(line)-len(strings.TrimLeft(line, " "))]
+		for _, c := range Complete(line) {
+			if c = blanks + c; strings.HasPrefix(c, line[:start]) {
+				readlineMatches = append(readlineMatches, c[start:])
+			}
+		}
+	}
+	if int(state) >= len(readlineMatches) {
+		return nil
+	}
+	return C.CString(readlineMatches[state])
+}
@@ -0,0 +1,353 @@
+// Copyright 2015 Rocky Bernstein.
+// Debugging over a TCP connection from another machine
+
+package gub
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"code.google.com/p/go-gnureadline"
+)
+
+// A remoteClient is a debugger client attached with "gub --attach".
+// The protocol is just the text the debugger would show on a
+// terminal, prompts included, and command lines sent back.
+type remoteClient struct {
+	conn net.Conn
+	out  chan []byte   // Output waiting to be sent
+	done chan struct{} // Closed when the client is dropped
+}
+
+// remoteOutMax is how many writes of output can wait to be sent to a
+// client. A client that falls further behind than that is dropped,
+// rather than holding up the debugger and the other clients.
+const remoteOutMax = 1024
+
+// newRemoteClient returns the client on conn and starts sending it
+// its output.
+func newRemoteClient(conn net.Conn) *remoteClient {
+	c := &remoteClient{
+		conn: conn,
+		out : make(chan []byte, remoteOutMax),
+		done: make(chan struct{}),
+	}
+	go func() {
+		for {
+			select {
+			case p := <-c.out:
+				if _, err := c.conn.Write(p); err != nil {
+					c.conn.Close()
+					return
+				}
+			case <-c.done:
+				return
+			}
+		}
+	}()
+	return c
+}
+
+// write queues p to be sent to client c. If c has too much queued
+// already, its connection is closed, which drops it.
+func (c *remoteClient) write(p []byte) {
+	buf := make([]byte, len(p))
+	copy(buf, p)
+	select {
+	case c.out <- buf:
+	case <-c.done:
+	default:
+		c.conn.Close()
+	}
+}
+
+// A remoteLine is a line of input sent by client, or the error
+// reading from it.
+type remoteLine struct {
+	client *remoteClient
+	line   string
+	err    error
+}
+
+// remoteClients are the debugger clients attached. There can be more
+// than one, e.g. an IDE and a terminal. remoteLines carries the lines
+// they send, and the end of input of each one that goes away; since
+// we read one line at a time from it, their commands are run one
+// after another. remoteCurrent is the client whose command or answer
+// we are working on, or nil when output should go to all clients, as
+// with the notice of where the program stopped and the prompt.
+// remotePending holds commands other clients sent while a question
+// was waiting for its answer, to be run next.
+var remoteClients []*remoteClient
+var remoteLines chan remoteLine
+var remoteCurrent *remoteClient
+var remotePending []remoteLine
+var remoteLock sync.Mutex
+
+// Remote is true if commands come from attached debugger clients
+// rather than the terminal.
+func Remote() bool { return remoteLines != nil }
+
+// remoteWriter sends debugger output to the client whose command is
+// being run or, if there is none, to all clients.
+type remoteWriter struct{}
+
+func (remoteWriter) Write(p []byte) (int, error) {
+	remoteLock.Lock()
+	defer remoteLock.Unlock()
+	for _, c := range remoteClients {
+		if remoteCurrent == nil || c == remoteCurrent {
+			c.write(p)
+		}
+	}
+	return len(p), nil
+}
+
+// remoteToken is what a client has to send as its first line before
+// it is attached and its commands are run.
+var remoteToken string
+
+// remoteAuthWait is how long a client has to send the token.
+const remoteAuthWait = 30 * time.Second
+
+// Listen waits for a debugger client to attach on TCP address addr,
+// e.g. ":2345" or "localhost:2345", and then takes debugger commands
+// from it. Other clients can attach later on while we run. The
+// debugged program's own input and output stay where they are.
+//
+// Without a host in addr, only clients on this machine can connect;
+// give one, such as 0.0.0.0, to take them from elsewhere. Either way a
+// client must first send token, which is made up and shown if it is
+// "".
+func Listen(addr string, token string) error {
+	if host, port, err := net.SplitHostPort(addr); err == nil && host == "" {
+		addr = net.JoinHostPort("localhost", port)
+	}
+	if token == "" {
+		b := make([]byte, 16)
+		if _, err := rand.Read(b); err != nil {
+			return err
+		}
+		token = hex.EncodeToString(b)
+		Msg("Clients attaching must give token %s", token)
+	}
+	remoteToken = token
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	Msg("Waiting for a debugger to attach on %s", ln.Addr())
+	var conn net.Conn
+	var r *bufio.Reader
+	for conn == nil {
+		c, err := ln.Accept()
+		if err != nil {
+			ln.Close()
+			return err
+		}
+		if r = remoteAuth(c); r != nil {
+			conn = c
+		}
+	}
+	Msg("Debugger attached from %s", conn.RemoteAddr())
+	remoteLines = make(chan remoteLine)
+	output = remoteWriter{}
+	remoteAdd(conn, r)
+	go func() {
+		defer ln.Close()
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				r := remoteAuth(conn)
+				if r == nil {
+					return
+				}
+				c, n := remoteAdd(conn, r)
+				c.write([]byte(fmt.Sprintf("Debugger attached; %d clients in all\n", n)))
+			}()
+		}
+	}()
+	return nil
+}
+
+// remoteAuth reads the token from client conn. If it is right, the
+// reader to read the client's commands with is returned. Otherwise
+// the client is told and disconnected, and nil is returned.
+func remoteAuth(conn net.Conn) *bufio.Reader {
+	r := bufio.NewReader(conn)
+	conn.SetReadDeadline(time.Now().Add(remoteAuthWait))
+	line, err := r.ReadString('\n')
+	conn.SetReadDeadline(time.Time{})
+	line = strings.TrimRight(line, "\r\n")
+	if err != nil ||
+		subtle.ConstantTimeCompare([]byte(line), []byte(remoteToken)) != 1 {
+		io.WriteString(conn, "Wrong token\n")
+		conn.Close()
+		return nil
+	}
+	return r
+}
+
+// remoteAdd starts passing the lines client conn sends, read with r,
+// on to remoteLines. It returns the client and the number of clients
+// attached.
+func remoteAdd(conn net.Conn, r *bufio.Reader) (*remoteClient, int) {
+	c := newRemoteClient(conn)
+	remoteLock.Lock()
+	remoteClients = append(remoteClients, c)
+	n := len(remoteClients)
+	remoteLock.Unlock()
+	go func() {
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				remoteRemove(c)
+				return
+			}
+			remoteLines <- remoteLine{c, line, nil}
+		}
+	}()
+	return c, n
+}
+
+// remoteRemove drops client c, which has gone away, and passes on its
+// end of input. When the last one goes, readLine sees the end of
+// input.
+func remoteRemove(c *remoteClient) {
+	remoteLock.Lock()
+	for i, rc := range remoteClients {
+		if rc == c {
+			remoteClients = append(remoteClients[:i], remoteClients[i+1:]...)
+			break
+		}
+	}
+	if remoteCurrent == c {
+		remoteCurrent = nil
+	}
+	remoteLock.Unlock()
+	close(c.done)
+	c.conn.Close()
+	remoteLines <- remoteLine{c, "", io.EOF}
+}
+
+// remoteReadLine shows prompt and reads a line from one of the
+// attached clients. Command prompts go to all clients, as any of them
+// can send the next command; questions go to the client whose command
+// asked them, and only its answer is taken. Commands other clients
+// send in the meantime are run after that one.
+func remoteReadLine(prompt string, command bool) (string, error) {
+	remoteLock.Lock()
+	asker := remoteCurrent
+	if command {
+		remoteCurrent = nil
+		asker = nil
+	}
+	remoteLock.Unlock()
+	if command && len(remotePending) > 0 {
+		rl := remotePending[0]
+		remotePending = remotePending[1:]
+		remoteLock.Lock()
+		remoteCurrent = rl.client
+		remoteLock.Unlock()
+		return rl.line, nil
+	}
+	if prompt != "" {
+		io.WriteString(output, prompt)
+	}
+	for {
+		rl := <-remoteLines
+		remoteLock.Lock()
+		left := len(remoteClients)
+		remoteLock.Unlock()
+		if rl.err != nil {
+			if rl.client == asker || left == 0 {
+				return "", rl.err
+			}
+			continue
+		}
+		if asker != nil && rl.client != asker {
+			remotePending = append(remotePending, rl)
+			rl.client.write([]byte("Another client is being asked a question; your command runs after that\n"))
+			continue
+		}
+		remoteLock.Lock()
+		remoteCurrent = rl.client
+		remoteLock.Unlock()
+		return rl.line, nil
+	}
+}
+
+// remoteBroadcast sends the output that follows to all attached
+// clients, as when the program stops.
+func remoteBroadcast() {
+	remoteLock.Lock()
+	remoteCurrent = nil
+	remoteLock.Unlock()
+}
+
+// Attach connects to a debugger started with -listen at TCP address
+// addr, giving it token, and passes our terminal's input to it and
+// its output to our terminal, until the debugger goes away. If token
+// is "", it is asked for.
+func Attach(addr string, token string) error {
+	stdin := bufio.NewReader(os.Stdin)
+	if token == "" {
+		fmt.Print("Token: ")
+		line, err := stdin.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		token = strings.TrimRight(line, "\r\n")
+	}
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if _, err := io.WriteString(conn, token+"\n"); err != nil {
+		return err
+	}
+	go func() {
+		io.Copy(conn, stdin)
+		if tcp, ok := conn.(*net.TCPConn); ok {
+			tcp.CloseWrite()
+		}
+	}()
+	_, err = io.Copy(os.Stdout, conn)
+	return err
+}
+
+// readLine shows prompt and reads a line of input from the attached
+// debugger client if there is one, and from the terminal otherwise.
+// If addHistory is set, the line is kept in the command history.
+func readLine(prompt string, addHistory bool) (string, error) {
+	if iface != nil {
+		return iface.ReadLine(prompt)
+	}
+	if MIMode() {
+		return miReadLine()
+	}
+	prompt = annotatePrompt(prompt)
+	if Annotate() >= 2 {
+		defer annotateWrite("post-prompt")
+	}
+	if Remote() {
+		return remoteReadLine(prompt, addHistory)
+	}
+	terminalLock.Lock()
+	defer terminalLock.Unlock()
+	endProgramLine()
+	return gnureadline.Readline(prompt, addHistory)
+}
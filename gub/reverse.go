@@ -0,0 +1,95 @@
+// Copyright 2015 Rocky Bernstein.
+// Looking back over the execution record
+
+package gub
+
+import (
+	"github.com/rocky/ssa-interp"
+	"github.com/rocky/ssa-interp/interp"
+)
+
+// histPos is the index in interp.Records() of the statement we have
+// moved back to with the reverse commands, or -1 if we haven't moved
+// back. Each stop in the program resets it.
+var histPos = -1
+
+// curHistory returns the position in records we are at along with
+// the goroutine and stack depth there. If we haven't moved back, the
+// position is that of the statement we are stopped at, which might
+// not have been recorded yet, in which case it is len(records).
+func curHistory(records []*interp.ExecRecord) (pos int, goNum int, depth int) {
+	if histPos >= 0 {
+		r := records[histPos]
+		return histPos, r.GoNum, r.Depth
+	}
+	pos = len(records)
+	if pos > 0 && Instr != nil {
+		if t, ok := (*Instr).(*ssa2.Trace); ok && records[pos-1].Trace == t {
+			pos--
+		}
+	}
+	for fr := topFrame; fr != nil; fr = fr.Caller(0) {
+		depth++
+	}
+	return pos, topFrame.GoNum(), depth
+}
+
+// HistoryBack moves back in the execution record of the current
+// goroutine to the most recent statement before where we are for
+// which match returns true. depth is the stack depth where we are.
+// If the statement was recorded by the frame we are stopped in, the
+// frame is put back the way it was then and we leave the command
+// loop to stop at the start of the statement again. Otherwise what
+// was recorded there is shown. It returns false if there is no such
+// statement.
+func HistoryBack(match func(r *interp.ExecRecord, depth int) bool) bool {
+	records := interp.Records()
+	pos, goNum, depth := curHistory(records)
+	for i := pos-1; i >= 0; i-- {
+		r := records[i]
+		if r.GoNum != goNum { continue }
+		if match(r, depth) {
+			if interp.RestoreRecord(topFrame, i) {
+				interp.SetStepIn(topFrame)
+				InCmdLoop = false
+				return true
+			}
+			histPos = i
+			PrintRecord(i, r)
+			return true
+		}
+	}
+	return false
+}
+
+// HistoryAtBreakpoint returns true if record r is at an enabled
+// breakpoint.
+func HistoryAtBreakpoint(r *interp.ExecRecord) bool {
+	for _, bpnum := range BreakpointFindByPos(r.Trace.Start) {
+		if Breakpoints[bpnum].Enabled {
+			return true
+		}
+	}
+	return false
+}
+
+// PrintRecord shows record r, which is number n in the execution
+// record: where it is and the values that were recorded.
+func PrintRecord(n int, r *interp.ExecRecord) {
+	fn := r.Fn
+	Msg("<<- %s() record %d of %d, goroutine %d", fn, n,
+		len(interp.Records()), r.GoNum)
+	Msg(ssa2.FmtRange(fn, r.Trace.Start, r.Trace.End))
+	if syntax := r.Trace.Syntax(); syntax != nil {
+		PrintSyntaxFirstLine(syntax, fn.Prog.Fset)
+	}
+	for i, p := range fn.Params {
+		Msg("\t%s %s = %s", p.Name(), p.Type(),
+			interp.ToInspect(r.Params[i], nil))
+	}
+	for i, l := range fn.Locals {
+		if i >= len(r.Locals) { break }
+		Msg("\t%s %s = %s", l.Name(), deref(l.Type()),
+			interp.ToInspect(r.Locals[i], nil))
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright 2015 Rocky Bernstein.
+// Embedding the debugger in another program
+
+package gub
+
+import (
+	"fmt"
+
+	"github.com/rocky/go-types"
+	"github.com/rocky/ssa-interp"
+	"github.com/rocky/ssa-interp/interp"
+)
+
+// An Interface is how the debugger talks to the front end driving
+// it. All of the debugger's input and output goes through it.
+type Interface interface {
+	// Write shows debugger output s, which is one or more lines
+	// each ending in a newline, or a prompt.
+	Write(s string)
+	// Error shows error message s.
+	Error(s string)
+	// ReadLine shows prompt and returns the next line of input,
+	// a debugger command or the answer to a question.
+	ReadLine(prompt string) (string, error)
+}
+
+// iface is the Interface of the embedding front end, or nil when we
+// talk to the terminal.
+var iface Interface
+
+// LocalTerminal returns true if debugger commands come from the
+// terminal we were started from, rather than from an attached remote
+// client, an MI front end or an embedding program.
+func LocalTerminal() bool {
+	return iface == nil && !Remote() && !MIMode()
+}
+
+// A Session is a handle on the debugger for a program embedding it.
+// The debugger's state isn't kept in the Session but in the package,
+// so only one session can be running at a time. See the package
+// comment for how to start one.
+type Session struct {
+	Program *ssa2.Program
+}
+
+// session is the session running, or nil.
+var session *Session
+
+// NewSession starts debugging program prog with front end ui. The
+// interpreter calls the debugger when prog is run. Package gub/cmd
+// must be imported for there to be any commands. It is an error to
+// start a session while another hasn't been ended.
+func NewSession(prog *ssa2.Program, ui Interface) (*Session, error) {
+	if session != nil {
+		return nil, fmt.Errorf("a debugger session is already running")
+	}
+	program = prog
+	iface = ui
+	*Highlight = false
+	interp.SetTraceHook(GubTraceHook)
+	interp.SetStepSkip(StepSkip)
+	session = &Session{Program: prog}
+	return session, nil
+}
+
+// End stops debugging. The breakpoints, watchpoints, catchpoints,
+// displays and event subscribers of the session are removed, and the
+// interpreter no longer calls the debugger, so another session can be
+// started. The command table, aliases and user-defined commands stay.
+func (s *Session) End() {
+	if s != session {
+		return
+	}
+	for i := range Breakpoints {
+		BreakpointDelete(i)
+	}
+	for i := range Displays {
+		DisplayDelete(i)
+	}
+	eventLock.Lock()
+	subscribers = nil
+	interp.SetGoroutineHook(nil)
+	eventLock.Unlock()
+	interp.SetTraceHook(interp.NullTraceHook)
+	interp.SetStepSkip(nil)
+	iface = nil
+	session = nil
+}
+
+// BreakFunction sets a breakpoint at the start of function fn and
+// returns its number.
+func (s *Session) BreakFunction(fn *ssa2.Function) int {
+	interp.SetFnBreakpoint(fn)
+	bp := &Breakpoint{
+		Id: BreakpointNext(),
+		Pos: fn.Pos(),
+		EndP: fn.EndP(),
+		Kind: "Function",
+		Enabled: true,
+	}
+	return BreakpointAdd(bp)
+}
+
+// RunCommand runs debugger command line as if it had been typed. It
+// returns false if the command reported an error or s has ended.
+func (s *Session) RunCommand(line string) bool {
+	if s != session {
+		return false
+	}
+	errors := ErrorCount
+	RunLine(line)
+	return ErrorCount == errors
+}
+
+// Stopped is true while the program is stopped in the debugger, and
+// so its state can be looked at.
+func (s *Session) Stopped() bool { return InCmdLoop }
+
+// Resumed is true if the last command run continues the program, so
+// the front end should stop asking for commands.
+func (s *Session) Resumed() bool { return !InCmdLoop }
+
+// Frame returns the selected frame.
+func (s *Session) Frame() *interp.Frame { return curFrame }
+
+// Stack returns the frames of the stack of the goroutine we are
+// stopped in, the most recent first.
+func (s *Session) Stack() []*interp.Frame {
+	var frames []*interp.Frame
+	for fr := topFrame; fr != nil; fr = fr.Caller(0) {
+		frames = append(frames, fr)
+	}
+	return frames
+}
+
+// Eval evaluates Go expression expr in the selected frame.
+func (s *Session) Eval(expr string) (interp.Value, types.Type, error) {
+	if curFrame == nil {
+		return nil, nil, fmt.Errorf("program is not stopped")
+	}
+	return EvalExpr(curFrame, curScope, expr)
+}
+
+// Breakpoints returns the breakpoints, watchpoints and catchpoints
+// that haven't been deleted.
+func (s *Session) Breakpoints() []*Breakpoint {
+	var bps []*Breakpoint
+	for _, bp := range Breakpoints {
+		if !bp.Deleted {
+			bps = append(bps, bp)
+		}
+	}
+	return bps
+}
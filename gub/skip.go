@@ -0,0 +1,89 @@
+// Copyright 2015 Rocky Bernstein.
+// Functions and files that stepping doesn't descend into
+
+package gub
+
+import (
+	"path/filepath"
+	"regexp"
+
+	"github.com/rocky/ssa-interp"
+)
+
+// A Skip is a pattern for functions or files that "step" steps over.
+type Skip struct {
+	Kind    string         // "function" or "file"
+	Pattern string         // Text of the regular expression or glob
+	re      *regexp.Regexp // Compiled Pattern for function skips
+}
+
+// Skips are the function and file patterns "step" doesn't descend
+// into.
+var Skips []*Skip
+
+// StepPackage is set when "step" shouldn't descend into calls to
+// functions of other packages.
+var StepPackage bool
+
+// SkipFunctionAdd arranges for step not to descend into functions
+// whose names, e.g. fmt.Printf or (*bytes.Buffer).Write, match
+// regular expression pattern.
+func SkipFunctionAdd(pattern string) error {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return err
+	}
+	Skips = append(Skips, &Skip{Kind: "function", Pattern: pattern, re: re})
+	return nil
+}
+
+// SkipFileAdd arranges for step not to descend into functions
+// defined in files matching glob pattern. The pattern is matched
+// against the file's full name and against its base name.
+func SkipFileAdd(pattern string) error {
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return err
+	}
+	Skips = append(Skips, &Skip{Kind: "file", Pattern: pattern})
+	return nil
+}
+
+// StepSkip returns true if stepping shouldn't descend into a call of
+// fn made by caller.
+func StepSkip(caller, fn *ssa2.Function) bool {
+	// Synthetic wrappers don't have a package; the function they
+	// call is checked when they call it.
+	if StepPackage && caller.Pkg != nil && fn.Pkg != nil &&
+		caller.Pkg != fn.Pkg {
+		return true
+	}
+	return IsSkipped(fn)
+}
+
+// IsSkipped returns true if stepping shouldn't descend into fn.
+func IsSkipped(fn *ssa2.Function) bool {
+	if len(Skips) == 0 {
+		return false
+	}
+	filename := ""
+	if fn.Prog != nil && fn.Pos().IsValid() {
+		filename = fn.Prog.Fset.Position(fn.Pos()).Filename
+	}
+	for _, skip := range Skips {
+		switch skip.Kind {
+		case "function":
+			if skip.re.MatchString(fn.String()) {
+				return true
+			}
+		case "file":
+			if filename == "" { continue }
+			if ok, _ := filepath.Match(skip.Pattern, filename); ok {
+				return true
+			}
+			if ok, _ := filepath.Match(skip.Pattern, filepath.Base(filename)); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
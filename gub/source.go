@@ -0,0 +1,180 @@
+// Copyright 2015 Rocky Bernstein.
+// Running debugger commands from a file
+
+package gub
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// InitFileName is the name of the files in the home directory and
+// in the current directory whose debugger commands are run when we
+// first stop.
+const InitFileName = ".gubrc"
+
+// A cmdSource is a file of debugger commands, or the body of a
+// user-defined command, being run.
+type cmdSource struct {
+	filename    string
+	lines       []string // Lines still to be run
+	stopOnError bool     // Give up on the rest of the file on an error
+	verbose     bool     // Show each command before running it
+}
+
+// sources are the files of commands being run. A file run by a
+// command in another file comes after it.
+var sources []*cmdSource
+
+// SourceFile runs the debugger commands in filename, one per line.
+// Blank lines and lines starting with # are skipped. If stopOnError
+// is set, the rest of the file is skipped after a command reports an
+// error. If verbose is set, each command is shown before it is run.
+//
+// When a command like "continue" resumes the program, the rest of the
+// file is run when we next stop.
+func SourceFile(filename string, stopOnError, verbose bool) error {
+	depth := len(sources)
+	if err := sourcePush(filename, stopOnError, verbose); err != nil {
+		return err
+	}
+	runSources(depth)
+	return nil
+}
+
+// sourcePush reads the commands in filename and puts it on top of
+// sources, to be run next.
+func sourcePush(filename string, stopOnError, verbose bool) error {
+	if len(sources) >= 10 {
+		Errmsg("source commands nested too deeply; skipping %s", filename)
+		return nil
+	}
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	sources = append(sources, &cmdSource{filename, lines, stopOnError, verbose})
+	return nil
+}
+
+// RunSourceLines runs the commands of the files being run until they
+// are done or one of them resumes the program.
+func RunSourceLines() {
+	runSources(0)
+}
+
+// runSources runs the commands of the files being run that come
+// after the first depth of them, until they are done or one of them
+// resumes the program. The rest of those before are left to whoever
+// is running them.
+func runSources(depth int) {
+	for len(sources) > depth && InCmdLoop {
+		src := sources[len(sources)-1]
+		if len(src.lines) == 0 {
+			sources = sources[:len(sources)-1]
+			continue
+		}
+		line := strings.TrimSpace(src.lines[0])
+		src.lines = src.lines[1:]
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		if src.verbose {
+			Msg("+%s", line)
+		}
+		errors := ErrorCount
+		RunLine(line)
+		if src.stopOnError && ErrorCount > errors {
+			Errmsg("Error in %s; skipping the rest of it", src.filename)
+			for i, s := range sources {
+				if s == src {
+					sources = sources[:i]
+					break
+				}
+			}
+		}
+	}
+}
+
+// ReadCommandLine reads the next line of debugger input, for commands
+// like "define" that take more than one line. While running a file of
+// commands, the line comes from that file.
+func ReadCommandLine(prompt string) (string, error) {
+	if len(sources) > 0 {
+		src := sources[len(sources)-1]
+		if len(src.lines) == 0 {
+			return "", io.EOF
+		}
+		line := src.lines[0]
+		src.lines = src.lines[1:]
+		return line, nil
+	}
+	if inputReader != nil {
+		return inputReader.ReadString('\n')
+	}
+	return readLine(prompt, true)
+}
+
+// RunInitFile runs the commands in the init file in the home
+// directory and then those in the init file in the current
+// directory, if there are any. This is where things like aliases,
+// settings and breakpoints can be set up for each session. Nothing is
+// run when gub is given -nx. If a command resumes the program, the
+// rest, including the file in the current directory, is run when we
+// next stop. The file in the current directory is run only if it is
+// ours and not everyone can write to it, as it may have come with the
+// program being debugged.
+func RunInitFile() {
+	if *noInit || os.Getenv("TESTING") != "" {
+		return
+	}
+	var filenames []string
+	if home := os.Getenv("HOME"); home != "" {
+		filenames = append(filenames, filepath.Join(home, InitFileName))
+	}
+	local, err := filepath.Abs(InitFileName)
+	if err == nil && (len(filenames) == 0 || local != filenames[0]) {
+		filenames = append(filenames, local)
+	}
+	// The last file pushed is run first.
+	for k := len(filenames) - 1; k >= 0; k-- {
+		filename := filenames[k]
+		fi, err := os.Stat(filename)
+		if err != nil {
+			continue
+		}
+		if filename == local && !initFileSafe(fi) {
+			Errmsg("Not running %s: it isn't yours or anyone can write to it", filename)
+			continue
+		}
+		if err := sourcePush(filename, false, false); err != nil {
+			Errmsg("Error reading %s: %s", filename, err)
+		}
+	}
+	RunSourceLines()
+}
+
+// initFileSafe returns true if the file described by fi is owned by
+// us and can't be written by everyone.
+func initFileSafe(fi os.FileInfo) bool {
+	if fi.Mode().Perm()&0002 != 0 {
+		return false
+	}
+	if st, ok := fi.Sys().(*syscall.Stat_t); ok && int(st.Uid) != os.Getuid() {
+		return false
+	}
+	return true
+}
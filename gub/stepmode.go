@@ -0,0 +1,49 @@
+// Copyright 2015 Rocky Bernstein.
+// What counts as one step
+
+package gub
+
+import (
+	"go/token"
+
+	"github.com/rocky/ssa-interp"
+	"github.com/rocky/ssa-interp/interp"
+)
+
+// StepModes are the values StepMode can take.
+var StepModes = []string{"statement", "line", "instruction"}
+
+// StepMode is what "step" counts as one step: "statement" stops at
+// each statement boundary the builder marks; "line" keeps going until
+// a different line is reached; "instruction" stops at each SSA
+// instruction.
+var StepMode = "statement"
+
+// When stepping by line, lineStepFn and lineStepFrom are the function
+// and position the step started from.
+var lineStepping bool
+var lineStepFn *ssa2.Function
+var lineStepFrom token.Position
+
+// LineStepStart notes that we are starting a line step in frame fr.
+func LineStepStart(fr *interp.Frame) {
+	lineStepping = true
+	lineStepFn = fr.Fn()
+	lineStepFrom = fr.Position()
+}
+
+// lineStepSkip returns true if event in frame fr is on the line a
+// line step started from, so we shouldn't stop for it yet.
+func lineStepSkip(fr *interp.Frame, event ssa2.TraceEvent) bool {
+	if !lineStepping {
+		return false
+	}
+	switch event {
+	case ssa2.CALL_ENTER, ssa2.CALL_RETURN, ssa2.PROGRAM_TERMINATION,
+		ssa2.TRACE_CALL, ssa2.DEADLOCK, ssa2.LIMIT:
+		return false
+	}
+	pos := fr.Position()
+	return fr.Fn() == lineStepFn && pos.Line == lineStepFrom.Line &&
+		pos.Filename == lineStepFrom.Filename
+}
@@ -0,0 +1,21 @@
+# Test of alias and unalias
+# Use with gcd.go
+set highlight off
+alias bg break gcd
+alias c2 c
+alias vv eval
+bg
+c2
+vv a + b
+aliases vv
+aliases bg
+# Redefining our own alias
+alias vv whatis
+vv a
+unalias vv
+vv a
+# Errors
+alias c step
+alias zz nosuchcommand
+unalias nosuchalias
+quit
@@ -0,0 +1,22 @@
+# Test of call
+# Use with call.go
+set highlight off
+break fact
+continue
+call divmod(17, 5)
+# The breakpoint in fact isn't hit inside the call
+call fact(4)
+call bump()
+print calls
+up
+call p.sum()
+down
+# Errors
+call divmod(1, 0)
+call nosuch(1)
+call fact("x")
+call divmod(n, true)
+call calls
+# Still stopped where we were
+bt
+quit
@@ -0,0 +1,23 @@
+package main
+
+type point struct{ x, y int }
+
+func (p point) sum() int { return p.x + p.y }
+
+func divmod(a, b int) (int, int) { return a / b, a % b }
+
+var calls int
+
+func bump() { calls++ }
+
+func fact(n int) int {
+	if n <= 1 {
+		return 1
+	}
+	return n * fact(n-1)
+}
+
+func main() {
+	p := point{3, 4}
+	println(p.sum(), fact(5))
+}
@@ -0,0 +1,27 @@
+# Test of define
+# Use with gcd.go
+set highlight off
+define pv
+eval $arg0
+whatis $arg0
+end
+define stepshow
+step
+eval a
+eval b
+end
+define hook-stop
+eval $argc
+end
+break gcd
+continue
+pv a
+pv a+b
+# Commands after one that resumes run at the next stop
+stepshow
+# Replacing a definition
+define pv
+eval $arg0 * 2
+end
+pv b
+quit
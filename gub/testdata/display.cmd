@@ -0,0 +1,20 @@
+# Test of display and undisplay
+# Use with gcd.go
+set highlight off
+break gcd
+continue
+step
+display a
+display/x b*16
+display b - a
+# The displays are shown each time we stop
+next
+info display
+undisplay 0
+next
+display
+# Errors
+undisplay 5
+display/q a
+display nosuch
+quit
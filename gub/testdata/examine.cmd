@@ -0,0 +1,14 @@
+# Test of examine
+# Use with gcd.go
+set highlight off
+break gcd
+continue
+step
+# A parameter
+examine a
+# The value of a loaded for a > b
+x t2
+next
+x t2
+x t99
+quit
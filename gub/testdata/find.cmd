@@ -0,0 +1,14 @@
+# Test of find
+# Use with find.go
+set highlight off
+break 11
+continue
+find "tail"
+find 42
+find head.next
+# Locals of the selected frame
+up
+find tail
+# Errors
+find nosuchvar
+quit
@@ -0,0 +1,24 @@
+package main
+
+type node struct {
+	name string
+	next *node
+}
+
+var head *node
+
+func walk(n *node, name string) int {
+	count := 0
+	for ; n != nil; n = n.next {
+		count++
+	}
+	return count
+}
+
+func main() {
+	tail := &node{name: "tail"}
+	head = &node{"head", tail}
+	names := []string{"head", "tail"}
+	ages := map[string]int{"tail": 42}
+	println(walk(head, "tail"), len(names), ages["tail"])
+}
@@ -0,0 +1,14 @@
+# Test of info line
+# Use with gcd.go
+set highlight off
+break gcd
+continue
+info line
+info line 11
+info line 17
+info line main.gcd
+# Errors
+info line 2
+info line 999
+info line x
+quit
@@ -0,0 +1,18 @@
+# Test of set output json
+# Use with gcd.go
+set highlight off
+set output json
+info breakpoint
+break gcd
+continue
+info breakpoint
+backtrace
+locals
+next
+locals
+show output
+# Errors
+set output xml
+set output text
+show output
+quit
@@ -0,0 +1,17 @@
+# Test of jump
+# Use with gcd.go
+set highlight off
+break gcd
+continue
+# No statement on line 9
+jump 9
+# Line 23 isn't in gcd()
+jump 23
+# xgcd.go isn't gcd.go
+jump xgcd.go:16
+# Skip the swap; a stays 5
+jump gcd.go:16
+info args
+# Back to the start of the if before
+jump 14
+quit
@@ -0,0 +1,14 @@
+1-break-insert gcd
+2-exec-continue
+3-stack-list-frames
+4-stack-list-locals 1
+5-data-evaluate-expression a+b
+6-break-list
+7-exec-next
+eval b
+-interpreter-exec console "eval a"
+8-break-delete 1
+-break-list
+9-data-evaluate-expression nosuchvar
+-no-such-command
+-gdb-exit
@@ -0,0 +1,36 @@
+# Test of print and eval
+# Use with gcd.go
+set highlight off
+break gcd
+continue
+print a
+step
+print a
+print a + b*2
+eval a > b && b != 0
+eval a > b == false
+p float64(a) / 2
+print -a
+eval int64(b) << 3
+eval 1 << b
+print a >> 1
+# Errors
+print nosuch
+print a +
+print a / 0
+print a << -1
+# Output formats stay in effect until changed
+print/x a*51
+print b
+print/ b
+print/q b
+print/c 65
+# Earlier values
+print/ $1 + $2
+# Evaluate in another frame
+eval -frame 1 a
+# Variables of the enclosing scope after a, b = b, a
+next
+next
+print a
+quit
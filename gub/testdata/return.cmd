@@ -0,0 +1,25 @@
+# Test of return
+# Use with return.go
+set highlight off
+break divmod
+continue
+next
+next
+# Named results keep the values they have
+return
+print q
+print r
+break fact
+continue
+continue
+# Errors
+return "x"
+return 1, 2
+up
+return 1
+down
+return n * 7
+print n
+continue
+print f
+quit
@@ -0,0 +1,20 @@
+package main
+
+func divmod(a, b int) (q, r int) {
+	q = a / b
+	r = a % b
+	return
+}
+
+func fact(n int) int {
+	if n <= 1 {
+		return 1
+	}
+	return n * fact(n-1)
+}
+
+func main() {
+	q, r := divmod(17, 5)
+	f := fact(3)
+	println(q, r, f)
+}
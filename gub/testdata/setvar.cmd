@@ -0,0 +1,30 @@
+# Test of set var
+# Use with setvar.go
+set highlight off
+break 17
+continue
+set var n = 5
+print n
+set var p.name = "bob"
+set var p.age = p.age + 1
+print p
+set var a[1] = a[0] * 10
+print a
+set var m["one"] = 11
+set var m["two"] = 2
+print m["two"]
+set var *ip = 7
+print n
+set var e = "text"
+print e
+set var debug = true
+set var main.debug = !debug
+print debug
+# Errors
+set var n = "x"
+set var n = 1 +
+set var a[5] = 1
+set var 5 = n
+set var nosuch = 1
+set var n
+quit
@@ -0,0 +1,18 @@
+package main
+
+type person struct {
+	name string
+	age  int
+}
+
+var debug bool
+
+func main() {
+	n := 1
+	p := person{"ann", 30}
+	a := []int{1, 2, 3}
+	m := map[string]int{"one": 1}
+	ip := &n
+	var e interface{} = n
+	println(debug, n, p.name, p.age, a[1], m["one"], m["two"], *ip, e)
+}
@@ -0,0 +1,13 @@
+# Test of source
+# Use with gcd.go
+set highlight off
+break gcd
+continue
+# The commands after next run when the program stops
+source testdata/gcd.gub
+step
+source -v -e testdata/gcd.gub
+# Errors
+source testdata/nosuchfile.gub
+source -x testdata/gcd.gub
+quit
@@ -0,0 +1,57 @@
+// Copyright 2015 Rocky Bernstein.
+// Colors of the parts of debugger output
+
+package gub
+
+import (
+	"os"
+	"sort"
+
+	"github.com/rocky/ssa-interp/terminal"
+)
+
+// Theme gives the terminal color or attribute, like "red" or "bold",
+// each kind of debugger output is shown in when highlighting is on.
+// "" leaves it as is. Change it with "set theme".
+var Theme = map[string]string{
+	"error":         "red",
+	"section":       "bold",
+	"current-frame": "bold",
+	"current-line":  "bold",
+	"breakpoint":    "red",
+}
+
+// ThemeNames returns the kinds of output in Theme, sorted.
+func ThemeNames() []string {
+	var names []string
+	for name := range Theme {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SetTheme shows output of kind name in color. It returns false if
+// there is no such kind of output or color.
+func SetTheme(name, color string) bool {
+	if _, ok := Theme[name]; !ok || !ansiterm.IsColor(color) {
+		return false
+	}
+	Theme[name] = color
+	return true
+}
+
+// Themed returns text in the color output of kind name is shown in
+// if highlighting is on, and as it is otherwise.
+func Themed(name string, text string) string {
+	if !*Highlight || Theme[name] == "" {
+		return text
+	}
+	return ansiterm.Colorize(Theme[name], text)
+}
+
+// isTerminal is true if f is a terminal rather than a file or pipe.
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
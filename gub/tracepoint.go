@@ -0,0 +1,54 @@
+// Copyright 2015 Rocky Bernstein.
+// Tracepoints which record hits but don't stop
+
+package gub
+
+import (
+	"github.com/rocky/ssa-interp"
+	"github.com/rocky/ssa-interp/interp"
+)
+
+// A TraceHit is what a tracepoint collected when it was hit.
+type TraceHit struct {
+	Bpnum  int      // Tracepoint number
+	Fn     string   // Name of the function the tracepoint was hit in
+	GoNum  int      // Goroutine that hit the tracepoint
+	Loc    string   // Position the tracepoint was hit at
+	Values []string // Values of the tracepoint's Collect expressions
+}
+
+// MaxTraceHits is the number of tracepoint hits we keep. Older hits
+// are dropped.
+const MaxTraceHits = 1000
+
+// TraceHits are the most recent tracepoint hits, oldest first.
+var TraceHits []TraceHit
+
+// TraceHitsDropped counts the tracepoint hits that have been dropped
+// from TraceHits to keep it under MaxTraceHits.
+var TraceHitsDropped = 0
+
+// Tracepoint records a hit of tracepoint bp in frame fr along with
+// the values of its Collect expressions.
+func Tracepoint(fr *interp.Frame, bp *Breakpoint) {
+	hit := TraceHit{
+		Bpnum: bp.Id,
+		Fn: fr.FnAndParamString(),
+		GoNum: fr.GoNum(),
+		Loc: ssa2.FmtPos(fr.Fset(), fr.StartP()),
+		Values: make([]string, len(bp.Collect)),
+	}
+	for i, expr := range bp.Collect {
+		v, _, err := EvalExpr(fr, fr.Scope(), expr)
+		if err != nil {
+			hit.Values[i] = "<" + err.Error() + ">"
+		} else {
+			hit.Values[i] = interp.ToInspect(v, nil)
+		}
+	}
+	if len(TraceHits) >= MaxTraceHits {
+		TraceHits = TraceHits[1:]
+		TraceHitsDropped ++
+	}
+	TraceHits = append(TraceHits, hit)
+}
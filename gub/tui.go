@@ -0,0 +1,149 @@
+// Copyright 2015 Rocky Bernstein.
+// A text user interface: source and disassembly panes above the
+// commands
+
+package gub
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/rocky/ssa-interp"
+)
+
+// TUI is set when the terminal is split into panes showing the source
+// around where we are stopped and, if TUIAsm is set, the SSA
+// instructions of the current block, above the commands and their
+// output, which scroll by themselves in the bottom half. The panes are
+// redrawn at each stop and after each command.
+//
+// We use ANSI terminal escapes rather than curses: a scrolling region
+// for the command pane, and saving and restoring the cursor while
+// drawing the panes.
+var TUI bool
+var TUIAsm bool
+
+// tuiRows are the rows the panes take up at the top of the terminal,
+// separator included.
+func tuiRows() int { return Height / 2 }
+
+// TUIEnable splits the terminal into panes, with the disassembly pane
+// shown if asm is set.
+func TUIEnable(asm bool) error {
+	if MIMode() || iface != nil || BatchMode() {
+		return fmt.Errorf("the TUI needs a terminal")
+	}
+	if Height < 10 {
+		return fmt.Errorf("the terminal needs at least 10 lines for the TUI; see \"set height\"")
+	}
+	TUI, TUIAsm = true, asm
+	top := tuiRows()
+	io.WriteString(output, "\x1b[2J")
+	io.WriteString(output, fmt.Sprintf("\x1b[%d;%dr", top+1, Height))
+	io.WriteString(output, fmt.Sprintf("\x1b[%d;1H", Height))
+	TUIRefresh()
+	return nil
+}
+
+// TUIDisable goes back to using the whole terminal for commands.
+func TUIDisable() {
+	if !TUI {
+		return
+	}
+	TUI = false
+	io.WriteString(output, "\x1b[r\x1b[2J")
+	io.WriteString(output, fmt.Sprintf("\x1b[%d;1H", Height))
+}
+
+// tuiLine draws text on row row of the terminal, cut to the width of
+// the terminal.
+func tuiLine(row int, text string) {
+	io.WriteString(output, fmt.Sprintf("\x1b[%d;1H\x1b[2K%s", row, text))
+}
+
+// tuiCut cuts plain text s to width columns, with tabs expanded.
+func tuiCut(s string, width int) string {
+	s = strings.Replace(s, "\t", "    ", -1)
+	if len(s) > width {
+		s = s[:width]
+	}
+	return s
+}
+
+// TUIRefresh redraws the panes for the selected frame.
+func TUIRefresh() {
+	if !TUI {
+		return
+	}
+	top := tuiRows()
+	srcRows, asmRows := top-1, 0
+	if TUIAsm {
+		srcRows = (top - 1) / 2
+		asmRows = top - 1 - srcRows
+	}
+	// Save the cursor, which is in the command pane.
+	io.WriteString(output, "\x1b7")
+	row := 1
+	title := "No frame"
+	if curFrame != nil {
+		pos := curFrame.Position()
+		title = ssa2.FmtPos(curFrame.Fset(), curFrame.StartP())
+		lines, _ := SourceLines(pos.Filename)
+		bpMarks := BreakpointLines(pos.Filename)
+		first := pos.Line - srcRows/2
+		if first < 1 {
+			first = 1
+		}
+		for i := 0; i < srcRows; i++ {
+			line := first + i
+			text := ""
+			if line <= len(lines) {
+				text = sourceLine(tuiCut(lines[line-1], Maxwidth-8), line,
+					line == pos.Line, bpMarks[line])
+			}
+			tuiLine(row, text)
+			row++
+		}
+		if asmRows > 0 {
+			tuiAsmPane(row, asmRows)
+			row += asmRows
+		}
+	} else {
+		for ; row < top; row++ {
+			tuiLine(row, "")
+		}
+	}
+	bar := tuiCut("--- "+title+" ", Maxwidth)
+	bar += strings.Repeat("-", Maxwidth-len(bar))
+	tuiLine(top, "\x1b[7m"+bar+"\x1b[0m")
+	io.WriteString(output, "\x1b8")
+}
+
+// tuiAsmPane draws the instructions of the current block around the
+// one we are stopped at in rows rows starting at row first.
+func tuiAsmPane(first int, rows int) {
+	b := curFrame.Block()
+	pc := curFrame.PC()
+	if curFrame != topFrame {
+		pc = -1
+	}
+	start := 0
+	if b != nil && pc > rows/2 {
+		start = pc - rows/2
+	}
+	for i := 0; i < rows; i++ {
+		text := ""
+		if i == 0 && b != nil {
+			text = fmt.Sprintf("# block %d of %s", b.Index, b.Parent())
+		} else if n := start + i - 1; b != nil && n < len(b.Instrs) {
+			prefix := "  "
+			if n == pc {
+				prefix = "=>"
+			}
+			text = fmt.Sprintf("%s%3d: %s", prefix, n,
+				strings.TrimSpace(ssa2.DisasmInst(b.Instrs[n], Maxwidth)))
+		}
+		tuiLine(first+i, tuiCut(text, Maxwidth))
+	}
+}
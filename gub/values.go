@@ -0,0 +1,183 @@
+// Copyright 2015 Rocky Bernstein.
+// Value history: $1, $2, ... refer to values shown by eval, and
+// convenience variables like $tmp set by the user
+
+package gub
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rocky/go-types"
+	"github.com/rocky/ssa-interp/interp"
+)
+
+// valueHistory holds the values shown by eval. $n is
+// valueHistory[n-1].
+var valueHistory []exprVal
+
+// histPrefix starts the identifier a history reference like $3 is
+// turned into so that the Go parser accepts it.
+const histPrefix = "gub۰history۰"
+
+// convVars holds the convenience variables, keyed by name without the
+// leading $. They belong to the debugger, not the program.
+var convVars = make(map[string]exprVal)
+
+// convPrefix starts the identifier a convenience variable like $tmp
+// is turned into.
+const convPrefix = "gub۰var۰"
+
+// recordValue adds v of type t to the value history and returns its
+// history number.
+func recordValue(v interp.Value, t types.Type) int {
+	valueHistory = append(valueHistory, exprVal{keepVal(v), t})
+	return len(valueHistory)
+}
+
+// keepVal returns a copy of v to keep, so that the program storing
+// into what v came from doesn't change it.
+func keepVal(v interp.Value) interp.Value {
+	if v == nil {
+		return nil
+	}
+	return interp.CopyVal(v)
+}
+
+// dollarExpr rewrites the value history references and convenience
+// variables in expr into identifiers evalIdent looks up: $n, $ for the
+// last value, and $name. Text inside of string and character literals
+// is left alone.
+func dollarExpr(expr string) string {
+	if !strings.Contains(expr, "$") {
+		return expr
+	}
+	var b []byte
+	var quote byte
+	for i := 0; i < len(expr); i++ {
+		c := expr[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote != '`' && i+1 < len(expr) {
+				b = append(b, c)
+				i++
+				c = expr[i]
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'' || c == '`':
+			quote = c
+		case c == '$':
+			j := i + 1
+			for j < len(expr) && expr[j] >= '0' && expr[j] <= '9' {
+				j++
+			}
+			if j == i+1 {
+				for j < len(expr) && isIdentChar(expr[j]) {
+					j++
+				}
+				if j > i+1 {
+					b = append(b, convPrefix+expr[i+1:j]...)
+					i = j - 1
+					continue
+				}
+			}
+			n := strconv.Itoa(len(valueHistory))
+			if j > i+1 {
+				n = expr[i+1 : j]
+			}
+			b = append(b, histPrefix+n...)
+			i = j - 1
+			continue
+		}
+		b = append(b, c)
+	}
+	return string(b)
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' ||
+		c >= '0' && c <= '9'
+}
+
+// evalHist returns the value history entry that identifier name, made
+// by histExpr, refers to.
+func evalHist(name string) (exprVal, error) {
+	n, err := strconv.Atoi(name[len(histPrefix):])
+	if err != nil || n < 1 || n > len(valueHistory) {
+		if len(valueHistory) == 0 {
+			return exprVal{}, fmt.Errorf("the value history is empty")
+		}
+		return exprVal{}, fmt.Errorf("history has values $1 to $%d, not $%s",
+			len(valueHistory), name[len(histPrefix):])
+	}
+	return valueHistory[n-1], nil
+}
+
+// PrintValueHistory shows the last count values of the value history.
+func PrintValueHistory(count int) {
+	if len(valueHistory) == 0 {
+		Msg("The value history is empty")
+		return
+	}
+	first := len(valueHistory) - count
+	if first < 0 {
+		first = 0
+	}
+	for i, x := range valueHistory[first:] {
+		Msg("$%d = (%s) %s", first+i+1, x.t, FormatValue(x.v, x.t))
+	}
+}
+
+// evalConvVar returns the convenience variable that identifier name,
+// made by dollarExpr, refers to.
+func evalConvVar(name string) (exprVal, error) {
+	x, ok := convVars[name[len(convPrefix):]]
+	if !ok {
+		return exprVal{}, fmt.Errorf("no convenience variable $%s",
+			name[len(convPrefix):])
+	}
+	return x, nil
+}
+
+// SetConvVar sets convenience variable name, given without the
+// leading $, to the value of expr evaluated in the current frame.
+func SetConvVar(name, expr string) error {
+	if name == "" || !isIdentChar(name[0]) || name[0] >= '0' && name[0] <= '9' {
+		return fmt.Errorf("bad convenience variable name $%s", name)
+	}
+	for i := 1; i < len(name); i++ {
+		if !isIdentChar(name[i]) {
+			return fmt.Errorf("bad convenience variable name $%s", name)
+		}
+	}
+	v, t, err := EvalExpr(curFrame, curScope, expr)
+	if err != nil {
+		return err
+	}
+	x := exprVal{keepVal(v), t}
+	if isUntyped(t) && t.(*types.Basic).Kind() != types.UntypedNil {
+		x = convertUntyped(x, defaultType(t))
+	}
+	convVars[name] = x
+	return nil
+}
+
+// PrintConvVars shows the convenience variables in name order.
+func PrintConvVars() {
+	if len(convVars) == 0 {
+		Msg("No convenience variables")
+		return
+	}
+	names := make([]string, 0, len(convVars))
+	for name := range convVars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		x := convVars[name]
+		Msg("$%s = (%s) %s", name, x.t, FormatValue(x.v, x.t))
+	}
+}
@@ -0,0 +1,180 @@
+// Copyright 2015 Rocky Bernstein.
+// Things dealing with data watchpoints
+
+package gub
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+
+	"github.com/rocky/go-types"
+	"github.com/rocky/ssa-interp"
+	"github.com/rocky/ssa-interp/interp"
+)
+
+// VarAddrLookup returns the interpreter address of the variable
+// name as seen from frame fr in scope, along with the SSA value that
+// declares it. name can be a local variable, a global variable in
+// the current package, or a package-qualified global like pkg.var.
+func VarAddrLookup(fr *interp.Frame, name string,
+	scope *ssa2.Scope) (*interp.Value, ssa2.Value, error) {
+	ids := strings.Split(name, ".")
+	pkg := fr.Fn().Pkg
+	switch len(ids) {
+	case 1:
+		nameVal, interpVal, _ := EnvLookup(fr, name, scope)
+		if alloc, ok := nameVal.(*ssa2.Alloc); ok {
+			if addr, ok := interpVal.(*interp.Value); ok && addr != nil {
+				return addr, alloc, nil
+			}
+			return nil, nil, fmt.Errorf("%s doesn't have an address yet", name)
+		} else if nameVal != nil {
+			if _, ok := nameVal.(*ssa2.Global); !ok {
+				return nil, nil, fmt.Errorf("%s is not an addressable variable", name)
+			}
+		}
+	case 2:
+		if try_pkg := PkgLookup(ids[0]); try_pkg != nil {
+			pkg = try_pkg
+		} else {
+			return nil, nil, fmt.Errorf("Can't find package %s", ids[0])
+		}
+		name = ids[1]
+	default:
+		return nil, nil, fmt.Errorf("%s should have at most one dot (.)", name)
+	}
+	if g := pkg.Var(name); g != nil {
+		if addr, ok := fr.I().Global(name, pkg); ok {
+			return addr, g, nil
+		}
+	}
+	return nil, nil, fmt.Errorf("Can't find variable %s", name)
+}
+
+// WatchExprLookup returns what to watch for expr as seen from frame
+// fr in scope. expr is either a variable name as in VarAddrLookup, or
+// an index expression like s[3] or m["key"]. For an element of a
+// slice or array its address is returned; for a map element, since
+// those don't have addresses, the map entry is returned.
+func WatchExprLookup(fr *interp.Frame, expr string,
+	scope *ssa2.Scope) (addr *interp.Value, entry *interp.MapEntry, err error) {
+	node, err := parser.ParseExpr(expr)
+	if err != nil {
+		return nil, nil, err
+	}
+	index, ok := node.(*ast.IndexExpr)
+	if !ok {
+		addr, _, err = VarAddrLookup(fr, expr, scope)
+		return addr, nil, err
+	}
+	defer func() {
+		if x := recover(); x != nil {
+			err = fmt.Errorf("evaluating %s: %v", expr, x)
+		}
+	}()
+	x, err := evalNode(fr, scope, index.X)
+	if err != nil {
+		return nil, nil, err
+	}
+	idx, err := evalNode(fr, scope, index.Index)
+	if err != nil {
+		return nil, nil, err
+	}
+	if p, ok := x.t.Underlying().(*types.Pointer); ok {
+		// Indexing a pointer to an array
+		ptr, _ := x.v.(*interp.Value)
+		if ptr == nil {
+			return nil, nil, fmt.Errorf("nil pointer dereference")
+		}
+		x = exprVal{*ptr, p.Elem()}
+	}
+	switch t := x.t.Underlying().(type) {
+	case *types.Map:
+		if x.v == nil {
+			return nil, nil, fmt.Errorf("can't watch an element of a nil map")
+		}
+		key := convertUntyped(idx, t.Key())
+		return nil, interp.WatchMapEntry(x.v, key.v, t.Key()), nil
+	case *types.Slice, *types.Array:
+		i, ok := asInt(convertUntyped(idx, types.Typ[types.Int]).v)
+		if !ok {
+			return nil, nil, fmt.Errorf("index must be an integer")
+		}
+		addr, err = interp.ElemAddr(x.v, i)
+		return addr, nil, err
+	}
+	return nil, nil, fmt.Errorf("can't watch an element of type %s", x.t)
+}
+
+// BreakpointFindByAddr returns the numbers of the undeleted
+// watchpoints on address addr.
+func BreakpointFindByAddr(addr *interp.Value) []int {
+	results := make([]int, 0)
+	for _, bp := range Breakpoints {
+		if addr != nil && bp.Addr == addr && !bp.Deleted {
+			results = append(results, bp.Id)
+		}
+	}
+	return results
+}
+
+// BreakpointFindByEntry returns the numbers of the undeleted
+// watchpoints on map element entry.
+func BreakpointFindByEntry(entry *interp.MapEntry) []int {
+	results := make([]int, 0)
+	for _, bp := range Breakpoints {
+		if entry != nil && bp.Entry == entry && !bp.Deleted {
+			results = append(results, bp.Id)
+		}
+	}
+	return results
+}
+
+// MapWatchpointAdd creates a watchpoint for writes to the map element
+// entry named by expr and returns its breakpoint number.
+func MapWatchpointAdd(expr string, entry *interp.MapEntry, pos token.Pos,
+	endP token.Pos) int {
+	bp := &Breakpoint {
+		Hits: 0,
+		Id: BreakpointNext(),
+		Pos: pos,
+		EndP: endP,
+		Ignore: 0,
+		Kind: "Watchpoint",
+		Temp: false,
+		Enabled: true,
+		Expr: expr,
+		Entry: entry,
+		Watch: interp.WATCH_WRITE,
+	}
+	Breakpoints = append(Breakpoints, bp)
+	miBreakpointCreated(bp)
+	return bp.Id
+}
+
+// WatchpointAdd creates a watchpoint on the variable named expr and
+// returns its breakpoint number. Watchpoints aren't tied to a
+// stopping location so they aren't added to BrkptLocs.
+func WatchpointAdd(expr string, addr *interp.Value, pos token.Pos,
+	endP token.Pos, kind interp.WatchType) int {
+	bp := &Breakpoint {
+		Hits: 0,
+		Id: BreakpointNext(),
+		Pos: pos,
+		EndP: endP,
+		Ignore: 0,
+		Kind: "Watchpoint",
+		Temp: false,
+		Enabled: true,
+		Expr: expr,
+		Addr: addr,
+		Watch: kind,
+	}
+	interp.SetWatch(addr, kind)
+	Breakpoints = append(Breakpoints, bp)
+	miBreakpointCreated(bp)
+	return bp.Id
+}
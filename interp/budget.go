@@ -0,0 +1,168 @@
+// Copyright 2015 Rocky Bernstein.
+// Limiting how long the interpreted program runs
+
+package interp
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rocky/ssa-interp"
+)
+
+// A program can be given a budget of instructions, counted over all
+// its goroutines, and of wall-clock time, so that one stuck in an
+// infinite loop still comes to an end, say under a test harness. When
+// it runs out, the program ends with exit status 2, after saying why
+// on standard error. With the BreakOnLimit mode, the trace hook is
+// called with a LIMIT event instead, so that in the debugger we can
+// see where the program got to; when the hook returns, the program
+// gets as much again.
+//
+// The time limit is noticed by the next instruction run, or failing
+// that, as when every goroutine is waiting on input, BudgetGrace
+// later, when the program is ended; with BreakOnLimit we wait for an
+// instruction to be run so as to stop in its goroutine. The clock is
+// stopped while a trace hook set by SetTraceHook runs, so that the
+// time spent stopped in the debugger doesn't count.
+
+// InstructionLimit is the number of instructions the program may run,
+// or 0 for no limit. TimeLimit is how long it may run, or 0 for no
+// limit.
+var InstructionLimit uint64
+var TimeLimit time.Duration
+
+// BudgetGrace is how long after the time limit is up we wait for a
+// goroutine to notice before we do it ourselves.
+var BudgetGrace = time.Second
+
+// budgetInsns counts the instructions run; budgetTimeUp is set when
+// the time limit is up. budgetDeadline is when it will be, or zero
+// while the program is stopped for having reached a limit.
+// budgetPaused counts the goroutines in the trace hook, and
+// budgetPausedAt is when the first of them went in.
+var budgetInsns uint64
+var budgetTimeUp int32
+var budgetLock sync.Mutex
+var budgetDeadline time.Time
+var budgetPaused int
+var budgetPausedAt time.Time
+
+// limitHit says which limit was reached last.
+var limitHit string
+
+// LimitHit says which limit the program reached, for the LIMIT trace
+// event.
+func LimitHit() string { return limitHit }
+
+// budgetReset gives the program its budget afresh.
+func budgetReset() {
+	atomic.StoreUint64(&budgetInsns, 0)
+	atomic.StoreInt32(&budgetTimeUp, 0)
+	budgetLock.Lock()
+	defer budgetLock.Unlock()
+	budgetDeadline = time.Time{}
+	if TimeLimit != 0 {
+		budgetDeadline = time.Now().Add(TimeLimit)
+	}
+	if budgetPaused != 0 {
+		budgetPausedAt = time.Now()
+	}
+}
+
+// budgetPause stops the clock of the time limit while a goroutine is
+// in the trace hook.
+func budgetPause() {
+	budgetLock.Lock()
+	defer budgetLock.Unlock()
+	if budgetPaused == 0 {
+		budgetPausedAt = time.Now()
+	}
+	budgetPaused++
+}
+
+// budgetResume starts the clock again once no goroutine is in the
+// trace hook, moving the deadline on by the time it was stopped.
+func budgetResume() {
+	budgetLock.Lock()
+	defer budgetLock.Unlock()
+	if budgetPaused--; budgetPaused == 0 && !budgetDeadline.IsZero() {
+		budgetDeadline = budgetDeadline.Add(time.Since(budgetPausedAt))
+	}
+}
+
+// budgetTick is called by frame fr before each instruction it runs
+// when there is a limit.
+func budgetTick(fr *Frame) {
+	if InstructionLimit != 0 && atomic.AddUint64(&budgetInsns, 1) == InstructionLimit {
+		limitReached(fr, fmt.Sprintf("instruction limit of %d reached", InstructionLimit))
+	}
+	if atomic.LoadInt32(&budgetTimeUp) != 0 && atomic.CompareAndSwapInt32(&budgetTimeUp, 1, 0) {
+		limitReached(fr, fmt.Sprintf("time limit of %s reached", TimeLimit))
+	}
+}
+
+// budgetWatch tells the goroutines of i when the time limit is up,
+// until done is closed.
+func budgetWatch(i *interpreter, done chan bool) {
+	for {
+		budgetLock.Lock()
+		deadline := budgetDeadline
+		if budgetPaused != 0 {
+			deadline = time.Time{}
+		}
+		budgetLock.Unlock()
+		wait := DeadlockPoll
+		if !deadline.IsZero() {
+			wait = deadline.Sub(time.Now())
+		}
+		if deadline.IsZero() || wait > 0 {
+			select {
+			case <-done:
+				return
+			case <-time.After(wait):
+			}
+			continue
+		}
+		atomic.StoreInt32(&budgetTimeUp, 1)
+		select {
+		case <-done:
+			return
+		case <-time.After(BudgetGrace):
+		}
+		if i.Mode&BreakOnLimit != 0 {
+			// Stopping is left to the goroutine that runs an
+			// instruction next, in its own frame.
+			continue
+		}
+		if atomic.CompareAndSwapInt32(&budgetTimeUp, 1, 0) {
+			// No goroutine is running instructions to notice,
+			// and we have no frame of our own to call the
+			// trace hook with.
+			fmt.Fprintf(os.Stderr, "interp: time limit of %s reached\n", TimeLimit)
+			setExit(2, "limit")
+			os.Exit(2)
+		}
+	}
+}
+
+// limitReached ends the program, or with BreakOnLimit stops in frame
+// fr, for the reason what.
+func limitReached(fr *Frame, what string) {
+	limitHit = what
+	budgetLock.Lock()
+	budgetDeadline = time.Time{}
+	budgetLock.Unlock()
+	if fr.i.Mode&BreakOnLimit != 0 {
+		TraceHook(fr, &fr.block.Instrs[fr.pc], ssa2.LIMIT)
+		budgetReset()
+		return
+	}
+	fmt.Fprintf(os.Stderr, "interp: %s\n", what)
+	setExit(2, "limit")
+	TraceHook(fr, nil, ssa2.PROGRAM_TERMINATION)
+	os.Exit(2)
+}
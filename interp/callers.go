@@ -0,0 +1,264 @@
+// Copyright 2015 Rocky Bernstein.
+// Program counters for runtime.Callers, CallersFrames and FuncForPC
+
+package interp
+
+import (
+	"fmt"
+	"go/token"
+	"strings"
+
+	"github.com/rocky/ssa-interp"
+	"github.com/rocky/go-types"
+)
+
+// The interpreted program has no machine code, so the program
+// counters runtime.Caller and runtime.Callers give it are synthetic:
+// each place in a function that a frame is at when asked gets a
+// number, which PCMapping maps back to the function, instruction and
+// source position. Functions asked about get a number for their
+// entry too. runtime.FuncForPC, the methods of runtime.Func and
+// runtime.CallersFrames all use that mapping, so a stack captured
+// one way can be looked at any other, as logging and error packages
+// do.
+//
+// Compiled code hands out return addresses, and code that knows this
+// looks up pc-1 rather than pc. Our program counters are therefore
+// spaced out, and anything near one stands for the same place.
+
+// pcShift is how far place numbers are shifted to make program
+// counters, and pcMid where in the gap between them the program
+// counter handed out lies.
+const pcShift = 4
+const pcMid = 1 << (pcShift - 1)
+
+// pcNumbers gives the number in PCMapping of each place handed out.
+var pcNumbers = make(map[PC]uintptr)
+
+// pcFor returns the program counter standing for place. The same
+// place always gets the same program counter, so that PCMapping
+// doesn't grow without bound in long-running programs.
+func pcFor(place PC) uintptr {
+	pcLock.Lock()
+	defer pcLock.Unlock()
+	n, ok := pcNumbers[place]
+	if !ok {
+		// Number 0 is the current place; see PCMapping.
+		n = uintptr(len(PCMapping))
+		PCMapping[n] = &place
+		pcNumbers[place] = n
+	}
+	return n<<pcShift | pcMid
+}
+
+// pcNumber returns the program counter standing for where frame fr
+// is, as runtime.Caller and the like give it.
+func pcNumber(fr *Frame) uintptr {
+	return pcFor(framePlace(fr))
+}
+
+// pcEntry returns the program counter standing for the start of fn.
+func pcEntry(fn *ssa2.Function) uintptr {
+	place := PC{fn: fn, pos: fn.Pos()}
+	if len(fn.Blocks) > 0 {
+		place.block = fn.Blocks[0]
+	}
+	return pcFor(place)
+}
+
+// pcLookup returns the place program counter pc stands for, or nil
+// if it isn't one we handed out.
+func pcLookup(pc uintptr) *PC {
+	n := pc >> pcShift
+	if n == 0 {
+		return nil
+	}
+	pcLock.Lock()
+	defer pcLock.Unlock()
+	return PCMapping[n]
+}
+
+// framePlace returns where frame fr is. The source position is that
+// of the instruction fr is at, or failing that, of the statement.
+func framePlace(fr *Frame) PC {
+	place := PC{fn: fr.fn, block: fr.block, instruction: fr.pc, pos: fr.startP}
+	if fr.block != nil && fr.pc < len(fr.block.Instrs) {
+		if p := fr.block.Instrs[fr.pc].Pos(); p != token.NoPos {
+			place.pos = p
+		}
+	}
+	return place
+}
+
+// goFuncName returns the name a compiled program gives fn in
+// tracebacks and runtime.Func.Name: "main.f", "main.(*T).M",
+// "main.f.func1" for the first function literal in f, and so on.
+func goFuncName(fn *ssa2.Function) string {
+	if parent := fn.Parent(); parent != nil {
+		sep := ".func"
+		if parent.Parent() != nil {
+			sep = "."
+		}
+		for i, anon := range parent.AnonFuncs {
+			if anon == fn {
+				return fmt.Sprintf("%s%s%d", goFuncName(parent), sep, i+1)
+			}
+		}
+		return fn.String()
+	}
+	if fn.Pkg == nil {
+		// Wrappers and the like.
+		return fn.String()
+	}
+	name := fn.Name()
+	if strings.HasSuffix(name, "$bound") {
+		name = strings.TrimSuffix(name, "$bound") + "-fm"
+	}
+	if recv := fn.Signature.Recv(); recv != nil {
+		recvType := recv.Type()
+		ptr := false
+		if p, ok := recvType.(*types.Pointer); ok {
+			recvType, ptr = p.Elem(), true
+		}
+		named, ok := recvType.(*types.Named)
+		if !ok {
+			return fn.String()
+		}
+		if ptr {
+			name = fmt.Sprintf("(*%s).%s", named.Obj().Name(), name)
+		} else {
+			name = fmt.Sprintf("%s.%s", named.Obj().Name(), name)
+		}
+	}
+	return fn.Pkg.Object.Path() + "." + name
+}
+
+// runtimeFunc returns the *runtime.Func standing for fn, or nil if
+// there is no fn.
+// Pretend: type runtime.Func struct { Fn *ssa2.Function }
+func runtimeFunc(fn *ssa2.Function) Value {
+	if fn == nil {
+		return (*Value)(nil)
+	}
+	var f Value = Structure{
+		fields    : []Value{fn},
+		fieldnames: []string{"Fn"},
+	}
+	return &f
+}
+
+// funcOf returns the function *runtime.Func value v stands for, or
+// nil.
+func funcOf(v Value) *ssa2.Function {
+	p, _ := v.(*Value)
+	if p == nil {
+		return nil
+	}
+	fn, _ := (*p).(Structure).fields[0].(*ssa2.Function)
+	return fn
+}
+
+func ext۰runtime۰FuncForPC(fr *Frame, args []Value) Value {
+	pc := args[0].(uintptr)
+	if pc == 0 {
+		return runtimeFunc(fr.fn)
+	}
+	if place := pcLookup(pc); place != nil {
+		return runtimeFunc(place.fn)
+	}
+	return runtimeFunc(nil)
+}
+
+func ext۰runtime۰Func۰FileLine(fr *Frame, args []Value) Value {
+	// func (*runtime.Func) FileLine(uintptr) (string, int)
+	f := funcOf(args[0])
+	if f == nil {
+		return tuple{"", 0}
+	}
+	pos := f.Pos()
+	if place := pcLookup(args[1].(uintptr)); place != nil && place.fn == f {
+		pos = place.pos
+	}
+	posn := f.Prog.Fset.Position(pos)
+	return tuple{posn.Filename, posn.Line}
+}
+
+func ext۰runtime۰Func۰Name(fr *Frame, args []Value) Value {
+	// func (*runtime.Func) Name() string
+	if f := funcOf(args[0]); f != nil {
+		return goFuncName(f)
+	}
+	return ""
+}
+
+func ext۰runtime۰Func۰Entry(fr *Frame, args []Value) Value {
+	// func (*runtime.Func) Entry() uintptr
+	if f := funcOf(args[0]); f != nil {
+		return pcEntry(f)
+	}
+	return uintptr(0)
+}
+
+// runtimeStruct returns the underlying struct of the named type of
+// package runtime in the program of fr, for filling in values of it
+// by field name.
+func runtimeStruct(fr *Frame, name string) *types.Struct {
+	t := fr.i.prog.ImportedPackage("runtime").Type(name).Object().Type()
+	return t.Underlying().(*types.Struct)
+}
+
+func ext۰runtime۰CallersFrames(fr *Frame, args []Value) Value {
+	// func CallersFrames(callers []uintptr) *Frames
+	st := runtimeStruct(fr, "Frames")
+	frames := zero(st).(Structure)
+	for f := 0; f < st.NumFields(); f++ {
+		if st.Field(f).Name() == "callers" {
+			frames.fields[f] = args[0]
+		}
+	}
+	var v Value = frames
+	return &v
+}
+
+func ext۰runtime۰Frames۰Next(fr *Frame, args []Value) Value {
+	// func (ci *Frames) Next() (frame Frame, more bool)
+	st := runtimeStruct(fr, "Frames")
+	frames := (*args[0].(*Value)).(Structure)
+	callersField := -1
+	for f := 0; f < st.NumFields(); f++ {
+		if st.Field(f).Name() == "callers" {
+			callersField = f
+		}
+	}
+	callers, _ := frames.fields[callersField].([]Value)
+	fst := runtimeStruct(fr, "Frame")
+	frame := zero(fst).(Structure)
+	if len(callers) == 0 {
+		return tuple{frame, false}
+	}
+	pc := callers[0].(uintptr)
+	frames.fields[callersField] = callers[1:]
+	place := pcLookup(pc)
+	for f := 0; f < fst.NumFields(); f++ {
+		switch fst.Field(f).Name() {
+		case "PC":
+			frame.fields[f] = pc
+		}
+		if place == nil {
+			continue
+		}
+		switch fst.Field(f).Name() {
+		case "Func":
+			frame.fields[f] = runtimeFunc(place.fn)
+		case "Function":
+			frame.fields[f] = goFuncName(place.fn)
+		case "File":
+			frame.fields[f] = place.fn.Prog.Fset.Position(place.pos).Filename
+		case "Line":
+			frame.fields[f] = place.fn.Prog.Fset.Position(place.pos).Line
+		case "Entry":
+			frame.fields[f] = pcEntry(place.fn)
+		}
+	}
+	return tuple{frame, len(callers) > 1}
+}
@@ -0,0 +1,127 @@
+// Copyright 2015 Rocky Bernstein.
+
+// Channel catchpoints. The debugger registers a channel, and the
+// interpreter calls the trace hook with a CHAN_OP event whenever a
+// value is sent on, received from, or the channel is closed.
+//
+// External call catchpoints. The debugger flags an entry of the
+// externals table, and the interpreter calls the trace hook with an
+// EXTERNAL_CALL event just before that function is called.
+
+package interp
+
+import (
+	"sync/atomic"
+
+	"github.com/rocky/ssa-interp"
+)
+
+// ChanOp is the kind of channel operation that triggered a channel
+// catchpoint.
+type ChanOp uint8
+
+const (
+	CHAN_SEND ChanOp = iota
+	CHAN_RECV
+	CHAN_CLOSE
+)
+
+var ChanOp2Name = map[ChanOp]string{
+	CHAN_SEND : "send",
+	CHAN_RECV : "receive",
+	CHAN_CLOSE: "close",
+}
+
+// caughtChans is the set of channels we stop on.
+var caughtChans map[chan Value]bool = make(map[chan Value]bool)
+
+// nCaughtChans is the number of channels we stop on, kept so that
+// goroutines can check cheaply whether there are any.
+var nCaughtChans int32
+
+// anyChanCatches returns true if we stop on some channel.
+func anyChanCatches() bool { return atomic.LoadInt32(&nCaughtChans) > 0 }
+
+// CatchChan arranges for operations on ch to trigger a CHAN_OP trace
+// event.
+func CatchChan(ch chan Value) {
+	breakLock.Lock()
+	defer breakLock.Unlock()
+	caughtChans[ch] = true
+	atomic.StoreInt32(&nCaughtChans, int32(len(caughtChans)))
+}
+
+// UncatchChan stops operations on ch from triggering trace events.
+func UncatchChan(ch chan Value) {
+	breakLock.Lock()
+	defer breakLock.Unlock()
+	delete(caughtChans, ch)
+	atomic.StoreInt32(&nCaughtChans, int32(len(caughtChans)))
+}
+
+// ChanHit returns the channel, the operation, and for sends and
+// receives the value, of the channel catchpoint frame fr is stopped
+// at.
+func ChanHit(fr *Frame) (chan Value, ChanOp, Value) {
+	hit := fr.Hit()
+	return hit.Chan, hit.ChanOp, hit.ChanVal
+}
+
+// checkChanCatch is called before a send or close, and after a
+// receive, of v on channel ch by instr. For a select, it is called
+// after the chosen case's operation.
+func checkChanCatch(fr *Frame, instr *ssa2.Instruction, ch chan Value,
+	op ChanOp, v Value) {
+	breakLock.RLock()
+	caught := caughtChans[ch]
+	breakLock.RUnlock()
+	if !caught {
+		return
+	}
+	fr.traceHit(instr, ssa2.CHAN_OP, &Hit{Chan: ch, ChanOp: op, ChanVal: v})
+}
+
+// externalBreak flags the names of the functions in externals that we
+// stop before calling.
+var externalBreak map[string]bool = make(map[string]bool)
+
+// SetExternalBreakpoint arranges for calls to the external function
+// name to trigger an EXTERNAL_CALL trace event. It returns false if
+// name isn't in the externals table.
+func SetExternalBreakpoint(name string) bool {
+	if externals[name] == nil {
+		return false
+	}
+	breakLock.Lock()
+	defer breakLock.Unlock()
+	externalBreak[name] = true
+	return true
+}
+
+// ClearExternalBreakpoint stops calls to the external function name
+// from triggering trace events.
+func ClearExternalBreakpoint(name string) {
+	breakLock.Lock()
+	defer breakLock.Unlock()
+	delete(externalBreak, name)
+}
+
+// ExternalHit returns the name and arguments of the external
+// function call frame fr is stopped at.
+func ExternalHit(fr *Frame) (string, []Value) {
+	hit := fr.Hit()
+	return hit.ExternalName, hit.ExternalArgs
+}
+
+// checkExternalCatch is called by caller just before it calls the
+// external function name with args.
+func checkExternalCatch(caller *Frame, name string, args []Value) {
+	breakLock.RLock()
+	caught := externalBreak[name]
+	breakLock.RUnlock()
+	if !caught || caller == nil {
+		return
+	}
+	caller.traceHit(&caller.block.Instrs[caller.pc], ssa2.EXTERNAL_CALL,
+		&Hit{ExternalName: name, ExternalArgs: args})
+}
@@ -0,0 +1,95 @@
+// Copyright 2015 Rocky Bernstein.
+// Stubbing out the C functions that cgo packages call
+
+package interp
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/rocky/ssa-interp"
+)
+
+// We can't run C code, but a program that imports a package using
+// cgo still builds: cgo turns each C function the package calls into
+// a Go function, _Cfunc_f for C.f, that we intercept. If a stub for
+// the C function has been registered with RegisterCgo, the stub is
+// called instead; otherwise the program panics with a runtime error
+// naming the C function, which it won't do until the function is
+// actually called. So programs that use C only here and there can go
+// a long way.
+//
+// A stub is either compiled Go code taking and returning the values
+// C functions of simple types do, with the same restrictions as
+// native functions, or an emulation taking the interpreted arguments,
+// func(fr *Frame, args []Value) Value. C.malloc is stubbed as
+// "malloc". Calls that get errno back, as in n, err := C.f(), get a
+// nil error.
+
+// cgoStubs are the registered stubs, by package path and C function
+// name, as in "net.getaddrinfo".
+var cgoStubs = make(map[string]externalFn)
+
+// RegisterCgo makes calls of C function name by cgo package path,
+// e.g. "net" and "getaddrinfo", go to stub.
+func RegisterCgo(path, name string, stub interface{}) error {
+	key := path + "." + name
+	if fn, ok := stub.(func(*Frame, []Value) Value); ok {
+		cgoStubs[key] = fn
+		return nil
+	}
+	v := reflect.ValueOf(stub)
+	t := v.Type()
+	if t.Kind() != reflect.Func || t.IsVariadic() {
+		return fmt.Errorf("C.%s of %s: stub must be a function taking a fixed number of arguments", name, path)
+	}
+	for i := 0; i < t.NumIn(); i++ {
+		if err := nativeType(t.In(i), false); err != nil {
+			return fmt.Errorf("C.%s of %s: %s", name, path, err)
+		}
+	}
+	for i := 0; i < t.NumOut(); i++ {
+		if err := nativeType(t.Out(i), true); err != nil {
+			return fmt.Errorf("C.%s of %s: %s", name, path, err)
+		}
+	}
+	cgoStubs[key] = func(fr *Frame, args []Value) Value {
+		return callNative(v, args)
+	}
+	return nil
+}
+
+// cgoFunction returns the name of the C function fn calls for a cgo
+// package, and whether it returns errno as an error too. name is ""
+// if fn isn't such a function.
+func cgoFunction(fn *ssa2.Function) (name string, errno bool) {
+	switch n := fn.Name(); {
+	case strings.HasPrefix(n, "_Cfunc_"):
+		name = strings.TrimPrefix(n, "_Cfunc_")
+	case strings.HasPrefix(n, "_C2func_"):
+		name, errno = strings.TrimPrefix(n, "_C2func_"), true
+	default:
+		return "", false
+	}
+	if name == "_CMalloc" {
+		name = "malloc"
+	}
+	return name, errno
+}
+
+// callCgo calls the stub of C function name for the package of fn,
+// which frame caller calls with args.
+func callCgo(caller *Frame, fn *ssa2.Function, name string, errno bool, args []Value) Value {
+	path := fn.Pkg.Object.Path()
+	stub := cgoStubs[path+"."+name]
+	if stub == nil {
+		caller.sourcePanic(fmt.Sprintf("call of C.%s in package %s, which has no stub; see interp.RegisterCgo", name, path))
+	}
+	checkExternalCatch(caller, fn.String(), args)
+	result := stub(caller, args)
+	if errno {
+		return tuple{result, iface{}}
+	}
+	return result
+}
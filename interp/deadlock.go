@@ -0,0 +1,161 @@
+// Copyright 2015 Rocky Bernstein.
+// Noticing when all goroutines of the interpreted program are stuck
+
+package interp
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/rocky/ssa-interp"
+)
+
+// While the program runs, a watchdog checks every DeadlockPoll
+// whether every goroutine that hasn't finished is blocked on a
+// channel, in a select, or on a semaphore of package sync, as a
+// locked sync.Mutex or WaitGroup.Wait would be. If they still are at
+// the next check, and no goroutine has changed state in between, the
+// program can never go on. As Go does, we say so and show each
+// goroutine's stack and what it is waiting on, on standard error.
+// Then the trace hook is called with a DEADLOCK event, so that in the
+// debugger the goroutines can be looked at. If they are still stuck
+// once the hook returns, the program ends with exit status 2.
+//
+// Goroutines that are sleeping, waiting for a process to finish, or
+// reading or writing a file or the network, as in reading standard
+// input, aren't blocked for good, and neither are those in other
+// calls of compiled code.
+
+// DeadlockPoll is how often the watchdog checks for a deadlock.
+var DeadlockPoll = 100 * time.Millisecond
+
+// deadlocked returns true if all goroutines of i that haven't
+// finished are blocked, along with the count of goroutine state
+// changes so far.
+func deadlocked(i *interpreter) (changes int, dead bool) {
+	gocall.Lock()
+	defer gocall.Unlock()
+	blocked := 0
+	for _, goTop := range i.goTops {
+		switch goTop.state {
+		case GoFinished:
+		case GoChanSend, GoChanRecv, GoSelect, GoSemacquire:
+			blocked++
+		default:
+			return goStateChanges, false
+		}
+	}
+	return goStateChanges, blocked > 0
+}
+
+// deadlockWatch checks for a deadlock in the run of the program by i
+// until done is closed.
+func deadlockWatch(i *interpreter, done chan bool) {
+	seen := -1
+	for {
+		select {
+		case <-done:
+			return
+		case <-time.After(DeadlockPoll):
+		}
+		changes, dead := deadlocked(i)
+		switch {
+		case !dead:
+			seen = -1
+		case changes != seen:
+			// A goroutine may just have been let go without
+			// having said so yet; wait and see.
+			seen = changes
+		default:
+			deadlock(i)
+			seen = -1
+		}
+	}
+}
+
+// deadlock reports that the goroutines of i are stuck and gives the
+// debugger a look. If they are still stuck after that, the program
+// ends.
+func deadlock(i *interpreter) {
+	fmt.Fprintln(programStderr, "fatal error: all goroutines are asleep - deadlock!")
+	printDeadlock(i)
+	fr := reportFrame(i)
+	if fr == nil {
+		return
+	}
+	changes, _ := deadlocked(i)
+	TraceHook(fr, &fr.block.Instrs[fr.pc], ssa2.DEADLOCK)
+	time.Sleep(DeadlockPoll)
+	if now, dead := deadlocked(i); !dead || now != changes {
+		// The debugger got the program going again.
+		return
+	}
+	setExit(2, "deadlock")
+	TraceHook(fr, nil, ssa2.PROGRAM_TERMINATION)
+	os.Exit(2)
+}
+
+// reportFrame returns the frame to report a deadlock or the like in:
+// the top frame of the main goroutine, or failing that of the first
+// goroutine that hasn't finished.
+func reportFrame(i *interpreter) *Frame {
+	gocall.Lock()
+	defer gocall.Unlock()
+	for _, goTop := range i.goTops {
+		if goTop.Fr != nil && goTop.state != GoFinished {
+			return goTop.Fr
+		}
+	}
+	return nil
+}
+
+// printDeadlock shows the stack of each goroutine of i that hasn't
+// finished and what it is blocked on, in the manner of a Go
+// traceback.
+func printDeadlock(i *interpreter) {
+	gocall.Lock()
+	defer gocall.Unlock()
+	for goNum, goTop := range i.goTops {
+		fr := goTop.Fr
+		if fr == nil || goTop.state == GoFinished {
+			continue
+		}
+		fmt.Fprintf(programStderr, "\n%s", goroutineHeader(goNum, goTop.state))
+		if what := waitingOn(fr); what != "" {
+			fmt.Fprintf(programStderr, "  waiting on %s\n", what)
+		}
+		fmt.Fprint(programStderr, goroutineStack(fr))
+	}
+	fmt.Fprintln(programStderr)
+}
+
+// waitingOn describes what the goroutine whose top frame is fr is
+// blocked on, using source names for the channels where it can.
+func waitingOn(fr *Frame) string {
+	if fr.block == nil {
+		return ""
+	}
+	name := func(v ssa2.Value) string {
+		if varName, ok := fr.Reg2Var[v.Name()]; ok {
+			return varName
+		}
+		return v.Name()
+	}
+	switch instr := fr.block.Instrs[fr.pc].(type) {
+	case *ssa2.UnOp:
+		return "receive from " + name(instr.X)
+	case *ssa2.Send:
+		return "send on " + name(instr.Chan)
+	case *ssa2.Select:
+		chans := make([]string, len(instr.States))
+		for j, st := range instr.States {
+			chans[j] = name(st.Chan)
+		}
+		return "select on " + strings.Join(chans, ", ")
+	case *ssa2.Call:
+		return "semaphore in " + fr.fn.String()
+	}
+	return ""
+}
@@ -0,0 +1,177 @@
+// Copyright 2015 Rocky Bernstein.
+// The interpreted program's own environment variables
+
+package interp
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// The program gets an environment of its own, which starts out as a
+// copy of ours, or as an empty one, with variables set or removed by
+// the debugger or tortoise's -env and -envfile. The program's
+// os.Getenv, os.Setenv, os.Unsetenv and os.Environ work on the copy
+// it gets at the start of each run, so that nothing it does is seen
+// by us, and what we do in between runs is seen by its next run.
+// GOSSAINTERP=1 and GOARCH are added, as they always have been.
+
+// envLock guards envVars, the environment the program starts with,
+// or nil if that is a copy of ours.
+var envLock sync.Mutex
+var envVars map[string]string
+
+// SetProgramEnv sets variable name in the environment the program
+// starts with.
+func SetProgramEnv(name, value string) {
+	envLock.Lock()
+	defer envLock.Unlock()
+	envCopy()
+	envVars[name] = value
+}
+
+// UnsetProgramEnv removes variable name from the environment the
+// program starts with.
+func UnsetProgramEnv(name string) {
+	envLock.Lock()
+	defer envLock.Unlock()
+	envCopy()
+	delete(envVars, name)
+}
+
+// ClearProgramEnv has the program start with no environment
+// variables other than those we add.
+func ClearProgramEnv() {
+	envLock.Lock()
+	defer envLock.Unlock()
+	envVars = make(map[string]string)
+}
+
+// InheritProgramEnv has the program start with a copy of our
+// environment again, forgetting what was set or removed.
+func InheritProgramEnv() {
+	envLock.Lock()
+	defer envLock.Unlock()
+	envVars = nil
+}
+
+// ProgramEnvInherited returns true if the program starts with a copy
+// of our environment, unchanged.
+func ProgramEnvInherited() bool {
+	envLock.Lock()
+	defer envLock.Unlock()
+	return envVars == nil
+}
+
+// ProgramEnv returns the environment the program starts with, as
+// name=value strings sorted by name, without what we add.
+func ProgramEnv() []string {
+	envLock.Lock()
+	defer envLock.Unlock()
+	if envVars == nil {
+		return os.Environ()
+	}
+	env := make([]string, 0, len(envVars))
+	for name, value := range envVars {
+		env = append(env, name+"="+value)
+	}
+	sort.Strings(env)
+	return env
+}
+
+// ProgramGetenv returns the value of variable name in the environment
+// the program starts with, and whether it is there.
+func ProgramGetenv(name string) (string, bool) {
+	envLock.Lock()
+	defer envLock.Unlock()
+	if envVars == nil {
+		prefix := name + "="
+		for _, s := range os.Environ() {
+			if strings.HasPrefix(s, prefix) {
+				return s[len(prefix):], true
+			}
+		}
+		return "", false
+	}
+	value, ok := envVars[name]
+	return value, ok
+}
+
+// ReadProgramEnv sets the variables given in file filename, one
+// name=value per line, in the environment the program starts with.
+// Blank lines and lines starting with # are skipped.
+func ReadProgramEnv(filename string) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for lineno := 1; scanner.Scan(); lineno++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		eq := strings.Index(line, "=")
+		if eq <= 0 {
+			return fmt.Errorf("%s:%d: expecting name=value; got %s", filename, lineno, line)
+		}
+		SetProgramEnv(line[:eq], line[eq+1:])
+	}
+	return scanner.Err()
+}
+
+// envCopy makes envVars a copy of our environment if it is nil.
+// envLock must be held.
+func envCopy() {
+	if envVars != nil {
+		return
+	}
+	envVars = make(map[string]string)
+	for _, s := range os.Environ() {
+		if eq := strings.Index(s, "="); eq > 0 {
+			envVars[s[:eq]] = s[eq+1:]
+		}
+	}
+}
+
+// environ is the environment of the current run of the program.
+var environ []Value
+
+// environReset sets the environment of a new run of the program.
+func environReset() {
+	environ = nil
+	for _, s := range ProgramEnv() {
+		environ = append(environ, s)
+	}
+	environ = append(environ, "GOSSAINTERP=1")
+	environ = append(environ, "GOARCH="+runtime.GOARCH)
+}
+
+// programGetenv returns the value of variable name in the
+// environment the current run of the program started with.
+func programGetenv(name string) string {
+	prefix := name + "="
+	for _, v := range environ {
+		if s := v.(string); strings.HasPrefix(s, prefix) {
+			return s[len(prefix):]
+		}
+	}
+	return ""
+}
+
+func ext۰syscall۰setenv_c(fr *Frame, args []Value) Value {
+	// func setenv_c(k, v string)
+	// The program's syscall package keeps its environment itself.
+	return nil
+}
+
+func ext۰syscall۰unsetenv_c(fr *Frame, args []Value) Value {
+	// func unsetenv_c(k string)
+	return nil
+}
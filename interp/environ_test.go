@@ -0,0 +1,72 @@
+// Copyright 2015 Rocky Bernstein.
+// Tests of the interpreted program's environment
+
+package interp
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestProgramEnv(t *testing.T) {
+	defer InheritProgramEnv()
+	os.Setenv("SSA_INTERP_TEST_OURS", "1")
+	defer os.Unsetenv("SSA_INTERP_TEST_OURS")
+
+	InheritProgramEnv()
+	if v, ok := ProgramGetenv("SSA_INTERP_TEST_OURS"); !ok || v != "1" {
+		t.Errorf("inherited variable: got %q, %v", v, ok)
+	}
+	SetProgramEnv("SSA_INTERP_TEST_SET", "x")
+	if ProgramEnvInherited() {
+		t.Errorf("environment still inherited after a set")
+	}
+	if _, ok := os.LookupEnv("SSA_INTERP_TEST_SET"); ok {
+		t.Errorf("setting the program's variable set ours")
+	}
+	UnsetProgramEnv("SSA_INTERP_TEST_OURS")
+	if _, ok := ProgramGetenv("SSA_INTERP_TEST_OURS"); ok {
+		t.Errorf("unset variable is still there")
+	}
+	if os.Getenv("SSA_INTERP_TEST_OURS") != "1" {
+		t.Errorf("unsetting the program's variable unset ours")
+	}
+
+	ClearProgramEnv()
+	if env := ProgramEnv(); len(env) != 0 {
+		t.Errorf("cleared environment: got %v", env)
+	}
+	environReset()
+	if programGetenv("GOSSAINTERP") != "1" {
+		t.Errorf("GOSSAINTERP isn't added to a cleared environment")
+	}
+	if programGetenv("SSA_INTERP_TEST_OURS") != "" {
+		t.Errorf("our variable is in a cleared environment")
+	}
+}
+
+func TestReadProgramEnv(t *testing.T) {
+	defer InheritProgramEnv()
+	f, err := ioutil.TempFile("", "envfile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.WriteString("# comment\n\nA=1\nB=two=2\n")
+	f.Close()
+
+	ClearProgramEnv()
+	if err := ReadProgramEnv(f.Name()); err != nil {
+		t.Fatalf("ReadProgramEnv: %v", err)
+	}
+	env := ProgramEnv()
+	if len(env) != 2 || env[0] != "A=1" || env[1] != "B=two=2" {
+		t.Errorf("got %v, want [A=1 B=two=2]", env)
+	}
+
+	ioutil.WriteFile(f.Name(), []byte("no equals sign\n"), 0600)
+	if err := ReadProgramEnv(f.Name()); err == nil {
+		t.Errorf("line without =: want an error")
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2015 Rocky Bernstein.
+// Stopping at os.Exit before the program goes away
+
+package interp
+
+import (
+	"sync"
+
+	"github.com/rocky/ssa-interp"
+)
+
+// A call of os.Exit ends our process along with the program, so once
+// made, there is nothing left to look at. With the exit trap set, the
+// default, the exit status is recorded and the program stops at the
+// call with a PROGRAM_TERMINATION trace event while its goroutines
+// and frames are all still there. ExitTrapped says that this is where
+// it is stopped, and a trace hook should stop for it even if it has
+// turned the event off. Once the debugger lets it go on, it exits as
+// before.
+
+var exitTrapLock sync.Mutex
+var exitTrap = true
+
+// exitTrapped is set while the program is stopped in a call of
+// os.Exit.
+var exitTrapped bool
+
+// SetExitTrap sets whether the program stops when it calls os.Exit.
+func SetExitTrap(on bool) {
+	exitTrapLock.Lock()
+	defer exitTrapLock.Unlock()
+	exitTrap = on
+}
+
+// ExitTrap returns true if the program stops when it calls os.Exit.
+func ExitTrap() bool {
+	exitTrapLock.Lock()
+	defer exitTrapLock.Unlock()
+	return exitTrap
+}
+
+// ExitTrapped returns true if the program is stopped in a call of
+// os.Exit. Its exit status is given by ExitStatus.
+func ExitTrapped() bool {
+	exitTrapLock.Lock()
+	defer exitTrapLock.Unlock()
+	return exitTrapped
+}
+
+// trapExit stops the program in frame fr, which is calling os.Exit,
+// if the exit trap is set. The exit status must have been recorded.
+func trapExit(fr *Frame) {
+	if !ExitTrap() {
+		TraceHook(fr, nil, ssa2.PROGRAM_TERMINATION)
+		return
+	}
+	exitTrapLock.Lock()
+	exitTrapped = true
+	exitTrapLock.Unlock()
+	defer func() {
+		exitTrapLock.Lock()
+		exitTrapped = false
+		exitTrapLock.Unlock()
+	}()
+	TraceHook(fr, &fr.block.Instrs[fr.pc], ssa2.PROGRAM_TERMINATION)
+}
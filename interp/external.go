@@ -8,11 +8,10 @@ package interp
 // external or because they use "unsafe" or "reflect" operations.
 
 import (
-	"os"
 	"runtime"
+	"sync"
 	"syscall"
 	"time"
-	"unsafe"
 
 	"github.com/rocky/ssa-interp"
 	"github.com/rocky/go-types"
@@ -94,6 +93,7 @@ func init() {
 		"runtime.Breakpoint":               ext۰runtime۰Breakpoint,
 		"runtime.Caller":                   ext۰runtime۰Caller,
 		"runtime.Callers":                  ext۰runtime۰Callers,
+		"runtime.CallersFrames":            ext۰runtime۰CallersFrames,
 		"runtime.FuncForPC":                ext۰runtime۰FuncForPC,
 		"runtime.GC":                       ext۰runtime۰GC,
 		"runtime.GOMAXPROCS":               ext۰runtime۰GOMAXPROCS,
@@ -106,6 +106,7 @@ func init() {
 		"(*runtime.Func).Entry":            ext۰runtime۰Func۰Entry,
 		"(*runtime.Func).FileLine":         ext۰runtime۰Func۰FileLine,
 		"(*runtime.Func).Name":             ext۰runtime۰Func۰Name,
+		"(*runtime.Frames).Next":           ext۰runtime۰Frames۰Next,
 		"runtime.environ":                  ext۰runtime۰environ,
 		"runtime.getgoroot":                ext۰runtime۰getgoroot,
 		"strings.IndexByte":                ext۰strings۰IndexByte,
@@ -131,12 +132,17 @@ func init() {
 		"syscall.Lstat":                    ext۰syscall۰Lstat,
 		"syscall.Open":                     ext۰syscall۰Open,
 		"syscall.ParseDirent":              ext۰syscall۰ParseDirent,
+		"syscall.Pipe":                     ext۰syscall۰Pipe,
 		"syscall.RawSyscall":               ext۰syscall۰RawSyscall,
 		"syscall.Read":                     ext۰syscall۰Read,
 		"syscall.ReadDirent":               ext۰syscall۰ReadDirent,
+		"syscall.StartProcess":             ext۰syscall۰StartProcess,
 		"syscall.Stat":                     ext۰syscall۰Stat,
+		"syscall.Wait4":                    ext۰syscall۰Wait4,
 		"syscall.Write":                    ext۰syscall۰Write,
 		"syscall.runtime_envs":             ext۰runtime۰environ,
+		"syscall.setenv_c":                 ext۰syscall۰setenv_c,
+		"syscall.unsetenv_c":               ext۰syscall۰unsetenv_c,
 		"time.Sleep":                       ext۰time۰Sleep,
 		"time.now":                         ext۰time۰now,
 		"github.com/rocky/ssa-interp/trepan.Debug":  ext۰trepan۰Debug,
@@ -217,7 +223,7 @@ func ext۰runtime۰environ(fr *Frame, args []Value) Value {
 }
 
 func ext۰runtime۰getgoroot(fr *Frame, args []Value) Value {
-	return os.Getenv("GOROOT")
+	return programGetenv("GOROOT")
 }
 
 func ext۰strings۰IndexByte(fr *Frame, args []Value) Value {
@@ -241,19 +247,53 @@ func ext۰sync۰runtime_registerPoolCleanup(fr *Frame, args []Value) Value {
 	return nil
 }
 
+// semLock guards the semaphores of package sync, which sync.Mutex,
+// sync.WaitGroup and so on block on; semCond is signalled when one
+// is released.
+var semLock sync.Mutex
+var semCond = sync.NewCond(&semLock)
+
 func ext۰sync۰runtime_Semacquire(fr *Frame, args []Value) Value {
-	// TODO(adonovan): fix: implement.
+	addr := args[0].(*Value)
+	semLock.Lock()
+	blocked := false
+	for (*addr).(uint32) == 0 {
+		if !blocked {
+			// setGoState can wait for our turn to run, so
+			// don't hold semLock over it.
+			semLock.Unlock()
+			setGoState(fr, GoSemacquire)
+			semLock.Lock()
+			blocked = true
+			continue
+		}
+		semCond.Wait()
+	}
+	*addr = (*addr).(uint32) - 1
+	semLock.Unlock()
+	if blocked {
+		setGoState(fr, GoRunning)
+	}
+	raceAtomic(fr, addr)
 	return nil
 }
 
 func ext۰sync۰runtime_Semrelease(fr *Frame, args []Value) Value {
-	// TODO(adonovan): fix: implement.
+	addr := args[0].(*Value)
+	raceAtomic(fr, addr)
+	semLock.Lock()
+	*addr = (*addr).(uint32) + 1
+	semCond.Broadcast()
+	semLock.Unlock()
 	return nil
 }
 
 func ext۰runtime۰GOMAXPROCS(fr *Frame, args []Value) Value {
-	// Ignore args[0]; don't let the interpreted program
-	// set the interpreter's GOMAXPROCS!
+	// Interpreted goroutines are ours, so the program's setting
+	// is ours too, unless we are running serially.
+	if n := args[0].(int); n > 0 && fr.i.Mode&SerialExecution == 0 {
+		return runtime.GOMAXPROCS(n)
+	}
 	return runtime.GOMAXPROCS(0)
 }
 
@@ -269,6 +309,7 @@ func ext۰runtime۰GC(fr *Frame, args []Value) Value {
 }
 
 func ext۰runtime۰Gosched(fr *Frame, args []Value) Value {
+	schedYield(fr.goNum)
 	runtime.Gosched()
 	return nil
 }
@@ -282,34 +323,45 @@ func ext۰runtime۰NumCPU(fr *Frame, args []Value) Value {
 }
 
 func ext۰runtime۰ReadMemStats(fr *Frame, args []Value) Value {
-	// TODO(adonovan): populate args[0].(Struct)
+	SetHeapAccounting(true)
+	heapMemStats(fr.i, (*args[0].(*Value)).(Structure))
 	return nil
 }
 
 func ext۰atomic۰LoadUint32(fr *Frame, args []Value) Value {
-	// TODO(adonovan): fix: not atomic!
+	atomicLock.Lock()
+	defer atomicLock.Unlock()
+	raceAtomic(fr, args[0].(*Value))
 	return (*args[0].(*Value)).(uint32)
 }
 
 func ext۰atomic۰StoreUint32(fr *Frame, args []Value) Value {
-	// TODO(adonovan): fix: not atomic!
+	atomicLock.Lock()
+	defer atomicLock.Unlock()
+	raceAtomic(fr, args[0].(*Value))
 	*args[0].(*Value) = args[1].(uint32)
 	return nil
 }
 
 func ext۰atomic۰LoadInt32(fr *Frame, args []Value) Value {
-	// TODO(adonovan): fix: not atomic!
+	atomicLock.Lock()
+	defer atomicLock.Unlock()
+	raceAtomic(fr, args[0].(*Value))
 	return (*args[0].(*Value)).(int32)
 }
 
 func ext۰atomic۰StoreInt32(fr *Frame, args []Value) Value {
-	// TODO(adonovan): fix: not atomic!
+	atomicLock.Lock()
+	defer atomicLock.Unlock()
+	raceAtomic(fr, args[0].(*Value))
 	*args[0].(*Value) = args[1].(int32)
 	return nil
 }
 
 func ext۰atomic۰CompareAndSwapInt32(fr *Frame, args []Value) Value {
-	// TODO(adonovan): fix: not atomic!
+	atomicLock.Lock()
+	defer atomicLock.Unlock()
+	raceAtomic(fr, args[0].(*Value))
 	p := args[0].(*Value)
 	if (*p).(int32) == args[1].(int32) {
 		*p = args[2].(int32)
@@ -319,7 +371,9 @@ func ext۰atomic۰CompareAndSwapInt32(fr *Frame, args []Value) Value {
 }
 
 func ext۰atomic۰AddInt32(fr *Frame, args []Value) Value {
-	// TODO(adonovan): fix: not atomic!
+	atomicLock.Lock()
+	defer atomicLock.Unlock()
+	raceAtomic(fr, args[0].(*Value))
 	p := args[0].(*Value)
 	newv := (*p).(int32) + args[1].(int32)
 	*p = newv
@@ -327,7 +381,9 @@ func ext۰atomic۰AddInt32(fr *Frame, args []Value) Value {
 }
 
 func ext۰atomic۰AddUint32(fr *Frame, args []Value) Value {
-	// TODO(adonovan): fix: not atomic!
+	atomicLock.Lock()
+	defer atomicLock.Unlock()
+	raceAtomic(fr, args[0].(*Value))
 	p := args[0].(*Value)
 	newv := (*p).(uint32) + args[1].(uint32)
 	*p = newv
@@ -335,54 +391,24 @@ func ext۰atomic۰AddUint32(fr *Frame, args []Value) Value {
 }
 
 func ext۰atomic۰AddUint64(fr *Frame, args []Value) Value {
-	// TODO(adonovan): fix: not atomic!
+	atomicLock.Lock()
+	defer atomicLock.Unlock()
+	raceAtomic(fr, args[0].(*Value))
 	p := args[0].(*Value)
 	newv := (*p).(uint64) + args[1].(uint64)
 	*p = newv
 	return newv
 }
 
-func ext۰runtime۰SetFinalizer(fr *Frame, args []Value) Value {
-	return nil // ignore
-}
-
-// Pretend: type runtime.Func struct { entry *ssa2.Function }
-
-func ext۰runtime۰Func۰FileLine(fr *Frame, args []Value) Value {
-	// func (*runtime.Func) FileLine(uintptr) (string, int)
-	f, _ := (*args[0].(*Value)).(Structure).fields[0].(*ssa2.Function)
-	pc := args[1].(uintptr)
-	_ = pc
-	if f != nil {
-		// TODO(adonovan): use position of current instruction, not fn.
-		posn := f.Prog.Fset.Position(f.Pos())
-		return tuple{posn.Filename, posn.Line}
-	}
-	return tuple{"", 0}
-}
-
-func ext۰runtime۰Func۰Name(fr *Frame, args []Value) Value {
-	// func (*runtime.Func) Name() string
-	f, _ := (*args[0].(*Value)).(Structure).fields[0].(*ssa2.Function)
-	if f != nil {
-		return f.String()
-	}
-	return ""
-}
-
-func ext۰runtime۰Func۰Entry(fr *Frame, args []Value) Value {
-	// func (*runtime.Func) Entry() uintptr
-	f, _ := (*args[0].(*Value)).(Structure).fields[0].(*ssa2.Function)
-	return uintptr(unsafe.Pointer(f))
-}
-
 func ext۰time۰now(fr *Frame, args []Value) Value {
 	nano := time.Now().UnixNano()
 	return tuple{int64(nano / 1e9), int32(nano % 1e9)}
 }
 
 func ext۰time۰Sleep(fr *Frame, args []Value) Value {
+	setGoState(fr, GoSleep)
 	time.Sleep(time.Duration(args[0].(int64)))
+	setGoState(fr, GoRunning)
 	return nil
 }
 
@@ -14,6 +14,32 @@ func Externals() map[string]externalFn {
 	return externals
 }
 
+// RegisterExternal makes interpreted calls of the function named name,
+// as given by Function.String(), e.g. "os.Getpid" or
+// "(*bytes.Buffer).Len", go to fn, replacing any implementation we
+// have. fn gets the calling frame and the arguments, the receiver
+// first, and returns the result: nil if there is none, or Results of
+// them if there are several. Values are held as the interpreter holds
+// them: numbers, strings and booleans as themselves, slices as
+// []Value, pointers as *Value, and errors as ErrorValue makes them.
+// Register functions before the program is run.
+func RegisterExternal(name string, fn func(fr *Frame, args []Value) Value) {
+	externals[name] = fn
+	delete(natives, name)
+}
+
+// Results returns the result of an external function returning
+// values vs.
+func Results(vs ...Value) Value {
+	return tuple(vs)
+}
+
+// ErrorValue returns err as an interpreted error value, holding its
+// message, or nil if err is nil.
+func ErrorValue(err error) Value {
+	return wrapError(err)
+}
+
 
 func fn2Num(fn *ssa2.Function) uint {
 	if fn2NumMap[fn] != 0 {
@@ -45,6 +71,9 @@ func ext۰os۰Exit(fr *Frame, args []Value) Value {
 	msg := fmt.Sprintf("exit status %d", args[0].(int))
 	io.WriteString(os.Stderr, msg)
 	io.WriteString(os.Stderr, "\n")
+	// Let the debugger see how the program finished before we go.
+	setExit(args[0].(int), "exit")
+	trapExit(fr)
 	// os.Exit works even if it doesn't allow cleanup as I suppose
 	// exitPanic might.
 	os.Exit(args[0].(int))
@@ -86,7 +115,8 @@ func runtime۰Caller(fr *Frame, skip int) (pc uintptr, file string, line int, ok
 	}
 
 	fset := fr.fn.Prog.Fset
-	startP := fset.Position(final_fr.startP)
+	place := framePlace(final_fr)
+	startP := fset.Position(place.pos)
 
 	var filename string
 	if startP.IsValid() {
@@ -95,15 +125,7 @@ func runtime۰Caller(fr *Frame, skip int) (pc uintptr, file string, line int, ok
 		filename = "??"
 	}
 
-	n := uintptr(len(PCMapping))
-	pcMap := &PC{
-		fn: final_fr.fn,
-		block: final_fr.block,
-		instruction: final_fr.pc,
-	}
-	PCMapping[n] = pcMap
-	pc = uintptr(n)
-	// pc = uintptr(EncodePC(final_fr))
+	pc = pcFor(place)
 	line = startP.Line
 	return pc, filename, line, true
 }
@@ -124,16 +146,8 @@ func ext۰runtime۰Callers(fr *Frame, args []Value) Value {
 		}
 	}
 	i := 0
-	n := uintptr(len(PCMapping))
-	for fr != nil {
-		n++
-		pcMap := &PC{
-			fn: fr.fn,
-			block: fr.block,
-			instruction: fr.pc,
-		}
-		PCMapping[n] = pcMap
-		pc[i] = uintptr(n)
+	for fr != nil && i < len(pc) {
+		pc[i] = pcNumber(fr)
 		i++
 		fr = fr.caller
 	}
@@ -146,21 +160,3 @@ func ext۰trepan۰Debug(fr *Frame, args []Value) Value {
 	TraceHook(fr, &fr.block.Instrs[0], ssa2.TRACE_CALL)
 	return nil
 }
-
-// Not sure how to replace value *runtime.Func with our
-// own opaque type.
-func ext۰runtime۰FuncForPC(fr *Frame, args []Value) Value {
-	pc := args[0].(uintptr)
-	fn := fr.fn
-	if pc != 0 {
-		fn = PCMapping[pc].fn
-	}
-	fields    := []Value{fn}
-	fieldnames:= []string{"Fn"}
-	var retFn = Structure{
-		fields    : fields,
-		fieldnames: fieldnames,
-	}
-	retFn2 := Value(retFn)
-	return &retFn2
-}
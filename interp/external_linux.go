@@ -0,0 +1,22 @@
+// Copyright 2015 Rocky Bernstein.
+
+// +build linux
+
+package interp
+
+import "syscall"
+
+func init() {
+	externals["syscall.Pipe2"] = ext۰syscall۰Pipe2
+}
+
+func ext۰syscall۰Pipe2(fr *Frame, args []Value) Value {
+	// func Pipe2(p []int, flags int) (err error)
+	p := args[0].([]Value)
+	fds := make([]int, len(p))
+	err := syscall.Pipe2(fds, args[1].(int))
+	for i := range p {
+		p[i] = fds[i]
+	}
+	return wrapError(err)
+}
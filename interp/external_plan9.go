@@ -42,6 +42,16 @@ func ext۰syscall۰RawSyscall(fr *Frame, args []value) value {
 	return tuple{^uintptr(0), uintptr(0), uintptr(0)}
 }
 
+func ext۰syscall۰Pipe(fr *Frame, args []value) value {
+	panic("syscall.Pipe not yet implemented")
+}
+func ext۰syscall۰StartProcess(fr *Frame, args []value) value {
+	panic("syscall.StartProcess not yet implemented")
+}
+func ext۰syscall۰Wait4(fr *Frame, args []value) value {
+	panic("syscall.Wait4 not yet implemented")
+}
+
 func syswrite(fd int, b []byte) (int, error) {
 	return syscall.Write(fd, b)
 }
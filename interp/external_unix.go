@@ -94,12 +94,80 @@ func ext۰syscall۰ParseDirent(fr *Frame, args []Value) Value {
 	return tuple{consumed, count, inewnames}
 }
 
+func ext۰syscall۰Pipe(fr *Frame, args []Value) Value {
+	// func Pipe(p []int) (err error)
+	p := args[0].([]Value)
+	fds := make([]int, len(p))
+	err := syscall.Pipe(fds)
+	for i := range p {
+		p[i] = fds[i]
+	}
+	return wrapError(err)
+}
+
+func ext۰syscall۰StartProcess(fr *Frame, args []Value) Value {
+	// func StartProcess(argv0 string, argv []string, attr *ProcAttr) (pid int, handle uintptr, err error)
+	var argv []string
+	for _, arg := range args[1].([]Value) {
+		argv = append(argv, arg.(string))
+	}
+	attr := &syscall.ProcAttr{}
+	if p := args[2].(*Value); p != nil {
+		// ProcAttr's Sys field, which holds things like the
+		// process group, isn't passed on.
+		fields := (*p).(Structure).fields
+		attr.Dir = fields[0].(string)
+		if env, ok := fields[1].([]Value); ok {
+			for _, e := range env {
+				attr.Env = append(attr.Env, e.(string))
+			}
+		}
+		if files, ok := fields[2].([]Value); ok {
+			for _, f := range files {
+				attr.Files = append(attr.Files, f.(uintptr))
+			}
+		}
+	}
+	done, err := programChildFiles(attr.Files)
+	if err != nil {
+		return tuple{0, uintptr(0), wrapError(err)}
+	}
+	pid, handle, err := syscall.StartProcess(args[0].(string), argv, attr)
+	done()
+	if err == nil {
+		processStarted(pid, argv, attr.Dir)
+	}
+	return tuple{pid, handle, wrapError(err)}
+}
+
+func ext۰syscall۰Wait4(fr *Frame, args []Value) Value {
+	// func Wait4(pid int, wstatus *WaitStatus, options int, rusage *Rusage) (wpid int, err error)
+	var status syscall.WaitStatus
+	setGoState(fr, GoProcWait)
+	wpid, err := syscall.Wait4(args[0].(int), &status, args[2].(int), nil)
+	setGoState(fr, GoRunning)
+	if p := args[1].(*Value); p != nil {
+		*p = uint32(status)
+	}
+	if err == nil && wpid > 0 && (status.Exited() || status.Signaled()) {
+		processExited(wpid, status.ExitStatus())
+	}
+	return tuple{wpid, wrapError(err)}
+}
+
 func ext۰syscall۰Read(fr *Frame, args []Value) Value {
 	// func Read(fd int, p []byte) (n int, err error)
 	fd := args[0].(int)
 	p := args[1].([]Value)
 	b := make([]byte, len(p))
-	n, err := syscall.Read(fd, b)
+	// Reading can block, as on a terminal or a pipe, so give
+	// other goroutines their turn meanwhile.
+	setGoState(fr, GoIOWait)
+	n, err, handled := programRead(fd, b)
+	if !handled {
+		n, err = syscall.Read(fd, b)
+	}
+	setGoState(fr, GoRunning)
 	for i := 0; i < n; i++ {
 		p[i] = b[i]
 	}
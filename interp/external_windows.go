@@ -39,6 +39,16 @@ func ext۰syscall۰Write(fr *Frame, args []value) value {
 func ext۰syscall۰RawSyscall(fr *Frame, args []value) value {
 	return tuple{uintptr(0), uintptr(0), uintptr(syscall.ENOSYS)}
 }
+func ext۰syscall۰Pipe(fr *Frame, args []value) value {
+	panic("syscall.Pipe not yet implemented")
+}
+func ext۰syscall۰StartProcess(fr *Frame, args []value) value {
+	panic("syscall.StartProcess not yet implemented")
+}
+func ext۰syscall۰Wait4(fr *Frame, args []value) value {
+	panic("syscall.Wait4 not yet implemented")
+}
+
 func syswrite(fd int, b []byte) (int, error) {
 	panic("syswrite not yet implemented")
 }
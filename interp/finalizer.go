@@ -0,0 +1,93 @@
+// Copyright 2015 Rocky Bernstein.
+// runtime.SetFinalizer for the interpreted program
+
+package interp
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/rocky/go-types"
+)
+
+// The objects of the interpreted program live in our own heap, so
+// the Go garbage collector reclaims them as it does ours. A finalizer
+// set with runtime.SetFinalizer is run from the real finalizer of the
+// object, which heap accounting may have set already: when the
+// collector finds the object unreachable, the interpreted finalizer
+// is called, in a goroutine of its own, with the object. As in Go,
+// finalizers run one at a time.
+//
+// When main returns, garbage is collected and the finalizers of
+// objects no longer reachable are run before the program ends.
+// Finalizers of objects still reachable then don't run, as in Go.
+// Watchpoints and race detection keep what they look at reachable.
+
+// finalizersUsed is set once the program has set a finalizer;
+// finalizersRunning counts the finalizers running.
+var finalizersUsed bool
+var finalizersRunning sync.WaitGroup
+
+// FinalizerWait is how long to wait after collecting garbage for the
+// finalizers of what was reclaimed to start, when main returns.
+var FinalizerWait = 10 * time.Millisecond
+
+func ext۰runtime۰SetFinalizer(fr *Frame, args []Value) Value {
+	obj, fn := args[0].(iface), args[1].(iface)
+	p, ok := obj.v.(*Value)
+	if !ok {
+		panic(fmt.Sprintf("runtime.SetFinalizer: first argument is %s, not pointer", obj.t))
+	}
+	if fn.v == nil {
+		if !heapSetFinalizer(p, nil) {
+			runtime.SetFinalizer(p, nil)
+		}
+		return nil
+	}
+	// The finalizer takes the pointer itself or an interface
+	// holding it. Either way we mustn't hold on to the object here,
+	// or it would never be reclaimed.
+	sig := fn.t.Underlying().(*types.Signature)
+	_, wantIface := sig.Params().At(0).Type().Underlying().(*types.Interface)
+	objType := obj.t
+	finalizersUsed = true
+	it, goNum := fr.i, fr.goNum
+	fin := func(p *Value) {
+		if it != i {
+			// Left over from an earlier run of the program.
+			return
+		}
+		arg := Value(p)
+		if wantIface {
+			arg = iface{objType, p}
+		}
+		finalizersRunning.Add(1)
+		defer finalizersRunning.Done()
+		finGoNum := newGoroutine(it)
+		if it.Mode&RaceDetect != 0 {
+			raceGo(goNum, finGoNum)
+		}
+		goCall(it, finGoNum, fn.v, []Value{arg})
+	}
+	// We may be tracking p already for heap accounting.
+	if !heapSetFinalizer(p, fin) {
+		runtime.SetFinalizer(p, fin)
+	}
+	return nil
+}
+
+// finalizeAtExit runs the finalizers of what is garbage when main
+// returns.
+func finalizeAtExit() {
+	if !finalizersUsed {
+		return
+	}
+	// Finalizers can free more objects with finalizers.
+	for n := 0; n < 2; n++ {
+		runtime.GC()
+		time.Sleep(FinalizerWait)
+		finalizersRunning.Wait()
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2015 Rocky Bernstein.
+// Searching the values reachable from the program's variables
+
+package interp
+
+import (
+	"strconv"
+
+	"github.com/rocky/go-types"
+)
+
+// A FindRoot is a variable to start a search from: its value, type,
+// and how to show where it is.
+type FindRoot struct {
+	Path string
+	V    Value
+	T    types.Type
+}
+
+type finder struct {
+	target Value
+	t      types.Type
+	seen   map[*Value]bool
+	found  []string
+	max    int
+}
+
+// FindValue looks for target, a value of type t, among the values
+// reachable from roots, by following pointers and looking inside
+// structs, arrays, slices, maps, interface values and closures. It
+// returns the paths of the places holding the value, at most max of
+// them.
+func FindValue(target Value, t types.Type, roots []FindRoot, max int) []string {
+	f := &finder{
+		target: target,
+		t:      t,
+		seen:   make(map[*Value]bool),
+		max:    max,
+	}
+	for _, root := range roots {
+		f.walk(root.V, root.T, root.Path)
+	}
+	return f.found
+}
+
+// matches reports whether v of type t is the value we are looking
+// for.
+func (f *finder) matches(v Value, t types.Type) (ok bool) {
+	if !types.Identical(t, f.t) {
+		return false
+	}
+	// equals panics on values that aren't comparable.
+	defer func() {
+		if recover() != nil {
+			ok = false
+		}
+	}()
+	return equals(t, v, f.target)
+}
+
+func (f *finder) walk(v Value, t types.Type, path string) {
+	if v == nil || len(f.found) >= f.max {
+		return
+	}
+	if f.matches(v, t) {
+		f.found = append(f.found, path)
+	}
+	switch ut := t.Underlying().(type) {
+	case *types.Pointer:
+		p, ok := v.(*Value)
+		if !ok || p == nil || f.seen[p] {
+			return
+		}
+		f.seen[p] = true
+		f.walk(*p, ut.Elem(), "(*"+path+")")
+	case *types.Struct:
+		s, ok := v.(Structure)
+		if !ok {
+			return
+		}
+		for i := 0; i < ut.NumFields() && i < len(s.fields); i++ {
+			f.walk(s.fields[i], ut.Field(i).Type(), path+"."+ut.Field(i).Name())
+		}
+	case *types.Array:
+		a, _ := v.(array)
+		f.walkElems(a, ut.Elem(), path)
+	case *types.Slice:
+		s, _ := v.([]Value)
+		f.walkElems(s, ut.Elem(), path)
+	case *types.Map:
+		switch m := v.(type) {
+		case map[Value]Value:
+			for k, e := range m {
+				f.walk(e, ut.Elem(), path+"["+ToInspectType(k, ut.Key())+"]")
+			}
+		case *hashmap:
+			for _, e := range m.table {
+				for ; e != nil; e = e.next {
+					f.walk(e.Value, ut.Elem(),
+						path+"["+ToInspectType(e.key, ut.Key())+"]")
+				}
+			}
+		}
+	case *types.Interface:
+		if x, ok := v.(iface); ok && x.t != nil {
+			f.walk(x.v, x.t, path)
+		}
+	case *types.Signature:
+		if c, ok := v.(*closure); ok {
+			for i, fv := range c.Fn.FreeVars {
+				if i < len(c.Env) {
+					f.walk(c.Env[i], fv.Type(), path+"."+fv.Name())
+				}
+			}
+		}
+	}
+}
+
+func (f *finder) walkElems(elems []Value, t types.Type, path string) {
+	for i, e := range elems {
+		f.walk(e, t, path+"["+strconv.Itoa(i)+"]")
+	}
+}
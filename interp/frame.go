@@ -28,12 +28,18 @@ type Frame struct {
 	env              map[ssa2.Value]Value // dynamic Values of SSA variables
 	locals           []Value
 	defers           []func()
+	deferFns         []Value     // function values of defers, in parallel
 	result           Value
 	panicking        bool
 	panic            interface{}
 
 	status           RunStatusType
 	tracing		     TraceType
+	stepCalls        int         // Calls to step over before stepping in
+	inDefers         bool        // Set while running deferred calls
+	returnNow        bool        // Set by the debugger's "return"
+	debugCall        bool        // Set while running a call made by the debugger
+	hit              *Hit        // What triggered the trace event being issued
 	goNum            int         // Goroutine number
 	Var2Reg          map[string] string // Turns an SSA
 										// register/variable into its
@@ -52,8 +58,11 @@ type PC struct{
 	fn *ssa2.Function
 	block *ssa2.BasicBlock
 	instruction int
+	pos token.Pos   // Source position of the instruction, or the statement
 }
 
+// PCMapping gives the place each synthetic program counter
+// number stands for; see pcFor.
 var PCMapping map[uintptr] *PC
 
 func init() {
@@ -118,10 +127,13 @@ func (fr *Frame) runDefers() {
 			fmt.Fprintln(os.Stderr, "Invoking deferred function", i)
 		}
 		fn := fr.defers[len(fr.defers)-1-i]
-		TraceHook(fr, nil, ssa2.DEFER_ENTER)
+		fr.traceHit(nil, ssa2.DEFER_ENTER, &Hit{DeferFn: fr.deferFns[len(fr.deferFns)-1-i]})
+		fr.inDefers = true
 		fn()
+		fr.inDefers = false
 	}
 	fr.defers = nil
+	fr.deferFns = nil
 	if fr.panicking {
 		panic(fr.panic) // new panic, or still panicking
 	}
@@ -138,6 +150,9 @@ func (fr *Frame) PositionRange() string {
 	fset   := fr.fn.Prog.Fset
 	startP := fset.Position(fr.startP)
 	endP   := fset.Position(fr.endP)
+	if ssa2.LocationFunction {
+		return fr.fn.Name() + " at " + ssa2.PositionRange(startP, endP)
+	}
 	return ssa2.PositionRange(startP, endP)
 }
 
@@ -165,5 +180,13 @@ func (fr *Frame) PC() int { return fr.pc }
 func (fr *Frame) PrevBlock() *ssa2.BasicBlock { return fr.prevBlock }
 func (fr *Frame) Result() Value { return fr.result }
 func (fr *Frame) SetPC(newpc int) { fr.pc = newpc }
+
+// SetBlock makes b the block fr is running. Set the PC afterwards.
+// Since we arrive at b from the block we were in, that is used as
+// the predecessor for its phi nodes.
+func (fr *Frame) SetBlock(b *ssa2.BasicBlock) {
+	fr.prevBlock = fr.block
+	fr.block = b
+}
 func (fr *Frame) StartP() token.Pos { return fr.startP }
 func (fr *Frame) Status() RunStatusType { return fr.status }
@@ -0,0 +1,87 @@
+// Copyright 2015 Rocky Bernstein.
+// Tracking what each goroutine of the interpreted program is doing
+
+package interp
+
+// GoState is what a goroutine is doing.
+type GoState int
+
+const (
+	GoRunning  GoState = iota // running, or able to run
+	GoChanSend                // blocked sending on a channel
+	GoChanRecv                // blocked receiving from a channel
+	GoSelect                  // blocked in a select statement
+	GoSemacquire              // blocked locking a sync.Mutex, in WaitGroup.Wait, etc.
+	GoSleep                   // in time.Sleep
+	GoProcWait                // waiting for a process to finish
+	GoIOWait                  // reading or writing a file or the network
+	GoFinished                // returned from its function
+)
+
+var goStateNames = map[GoState]string{
+	GoRunning:  "running",
+	GoChanSend: "chan send",
+	GoChanRecv: "chan receive",
+	GoSelect:   "select",
+	GoSemacquire: "semacquire",
+	GoSleep:    "sleeping",
+	GoProcWait: "process wait",
+	GoIOWait:   "io wait",
+	GoFinished: "finished",
+}
+
+func (s GoState) String() string { return goStateNames[s] }
+
+// goStateChanges counts the changes of goroutine state, so that it
+// can be told whether the goroutines have stayed blocked. gocall
+// guards it.
+var goStateChanges int
+
+// State returns what goroutine g is doing.
+func (g *GoreState) State() GoState { return g.state }
+
+// newGoroutine registers a goroutine about to be started by a go
+// statement and returns its number.
+func newGoroutine(i *interpreter) int {
+	gocall.Lock()
+	defer gocall.Unlock()
+	i.goTops = append(i.goTops, &GoreState{Fr: nil, state: GoRunning})
+	i.nGoroutines = len(i.goTops)
+	goStateChanges++
+	return i.nGoroutines - 1
+}
+
+// setGoTop records that fr is the top frame of goroutine goNum of i.
+func setGoTop(i *interpreter, goNum int, fr *Frame) {
+	gocall.Lock()
+	defer gocall.Unlock()
+	i.goTops[goNum].Fr = fr
+}
+
+// goTopFrame returns the top frame of goroutine goNum of i, or nil if
+// it has none.
+func goTopFrame(i *interpreter, goNum int) *Frame {
+	gocall.Lock()
+	defer gocall.Unlock()
+	if goNum < len(i.goTops) {
+		return i.goTops[goNum].Fr
+	}
+	return nil
+}
+
+// setGoState records that the goroutine running frame fr is now in
+// state s. With a Scheduler, a goroutine gives up its turn when it
+// blocks and waits for another when it can go on.
+func setGoState(fr *Frame, s GoState) {
+	if s != GoRunning {
+		// Let another goroutine run while we are blocked.
+		schedRelease(fr.goNum)
+	}
+	gocall.Lock()
+	fr.i.goTops[fr.goNum].state = s
+	goStateChanges++
+	gocall.Unlock()
+	if s == GoRunning {
+		schedAcquire(fr.goNum)
+	}
+}
@@ -0,0 +1,346 @@
+// Copyright 2015 Rocky Bernstein.
+// Accounting for the memory the interpreted program allocates
+
+package interp
+
+import (
+	"fmt"
+	"go/token"
+	"math"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unsafe"
+
+	"github.com/rocky/ssa-interp"
+	"github.com/rocky/go-types"
+)
+
+// What the program allocates, variables that escape to the heap,
+// new(T), slices made with make or grown by append, maps and
+// channels, is counted with the size a compiled program would give
+// it. The Go collector reclaims these objects once they are
+// unreachable, as it does our own memory. A finalizer on each
+// variable and slice backing array tells us when, so that we know how
+// much is still in use. Maps and channels can't have finalizers, so
+// they count toward what was allocated but not toward what is live.
+// The entries added to a map, though, count as in use until they are
+// deleted, so that filling a map runs into the heap limit; a map let
+// go of with entries in it is taken to hold on to them. Strings made
+// by concatenation count toward what was allocated, and one that
+// would go over the limit by itself isn't made.
+//
+// Setting a finalizer on every object and counting under a lock slow
+// the program down and make its goroutines wait on one another, so
+// none of this is done unless heap accounting is on. Setting a heap
+// limit turns it on, as do tortoise's -heapstats, the first "info
+// memory" and the program's first runtime.ReadMemStats. What was
+// allocated before that isn't counted.
+
+// heapLimit is the most memory, in bytes, the program may have in
+// use, or 0 if there is no limit. An allocation that would go over it
+// panics with a runtime error saying where it was made, which the
+// program can recover from. What's in use is what hasn't been
+// reclaimed yet, so garbage is collected before giving up, unless
+// too little has been allocated since the last time for that to be
+// worth the wait.
+var heapLimit uint64
+
+// SetHeapLimit sets the most memory, in bytes, the program may have
+// in use; 0 means there is no limit. A limit turns heap accounting on.
+func SetHeapLimit(limit uint64) {
+	atomic.StoreUint64(&heapLimit, limit)
+	if limit != 0 {
+		SetHeapAccounting(true)
+	}
+}
+
+// HeapLimit returns the most memory, in bytes, the program may have
+// in use, or 0 if there is no limit.
+func HeapLimit() uint64 { return atomic.LoadUint64(&heapLimit) }
+
+// HeapStats describes the memory the program has allocated, in the
+// manner of runtime.MemStats.
+type HeapStats struct {
+	Mallocs     uint64 // objects allocated
+	Frees       uint64 // objects reclaimed
+	TotalAlloc  uint64 // bytes allocated
+	HeapAlloc   uint64 // bytes of objects allocated and not reclaimed
+	HeapObjects uint64 // objects allocated and not reclaimed
+	NumGC       uint32 // garbage collections so far
+}
+
+// heapSizes holds the size of each object whose reclaiming we will
+// see and that hasn't been reclaimed yet, by address. Addresses are
+// kept as numbers so as not to keep the objects alive.
+// heapFinalizers are the finalizers the program set on such objects.
+var heapLock sync.Mutex
+var heapStats HeapStats
+var heapSizes = make(map[uintptr]uint64)
+var heapFinalizers = make(map[uintptr]func(*Value))
+
+// heapGCLock is held by the goroutine collecting garbage to make room
+// under the heap limit. heapGCAlloc is heapStats.TotalAlloc when it
+// last did.
+var heapGCLock sync.Mutex
+var heapGCAlloc uint64
+
+// heapAccounting is 1 when heap accounting is on.
+var heapAccounting int32
+
+// SetHeapAccounting turns the accounting of the program's memory on
+// or off.
+func SetHeapAccounting(on bool) {
+	if on {
+		atomic.StoreInt32(&heapAccounting, 1)
+	} else {
+		atomic.StoreInt32(&heapAccounting, 0)
+	}
+}
+
+// HeapAccounting returns true if the program's memory is being
+// accounted for.
+func HeapAccounting() bool { return heapOn() }
+
+func heapOn() bool { return atomic.LoadInt32(&heapAccounting) != 0 }
+
+// ReadHeapStats returns what the program has allocated so far.
+func ReadHeapStats() HeapStats {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	heapLock.Lock()
+	defer heapLock.Unlock()
+	s := heapStats
+	s.NumGC = m.NumGC
+	return s
+}
+
+// heapReset starts the accounting afresh for a new run of the
+// program.
+func heapReset() {
+	heapLock.Lock()
+	defer heapLock.Unlock()
+	heapStats = HeapStats{}
+	heapGCAlloc = 0
+	heapSizes = make(map[uintptr]uint64)
+	heapFinalizers = make(map[uintptr]func(*Value))
+}
+
+// heapSize returns the size in bytes of n values of type t in the
+// program.
+func heapSize(i *interpreter, t types.Type, n int) uint64 {
+	return uint64(i.sizes.Sizeof(t)) * uint64(n)
+}
+
+// heapGrowCap returns about the capacity append gives a slice of
+// capacity old that must hold need elements.
+func heapGrowCap(old, need int) int {
+	if newcap := 2 * old; newcap > need {
+		return newcap
+	}
+	return need
+}
+
+// heapMapEntrySize returns the size in bytes of an entry of a map of
+// type t.
+func heapMapEntrySize(i *interpreter, t *types.Map) uint64 {
+	return heapSize(i, t.Key(), 1) + heapSize(i, t.Elem(), 1)
+}
+
+// heapMapAlloc counts a map entry of size bytes as in use.
+func heapMapAlloc(size uint64) {
+	heapLock.Lock()
+	defer heapLock.Unlock()
+	heapStats.TotalAlloc += size
+	heapStats.HeapAlloc += size
+}
+
+// heapMapFree counts a deleted map entry of size bytes as no longer
+// in use.
+func heapMapFree(size uint64) {
+	heapLock.Lock()
+	defer heapLock.Unlock()
+	if size > heapStats.HeapAlloc {
+		// Added before heap accounting was on.
+		size = heapStats.HeapAlloc
+	}
+	heapStats.HeapAlloc -= size
+}
+
+// heapCount counts an object of size bytes whose reclaiming we won't
+// see.
+func heapCount(size uint64) {
+	if !heapOn() {
+		return
+	}
+	heapLock.Lock()
+	defer heapLock.Unlock()
+	heapStats.Mallocs++
+	heapStats.TotalAlloc += size
+}
+
+// heapTrack counts the object at p, of size bytes, until it is
+// reclaimed.
+func heapTrack(p *Value, size uint64) {
+	if !heapOn() {
+		return
+	}
+	heapLock.Lock()
+	heapSizes[uintptr(unsafe.Pointer(p))] = size
+	heapStats.Mallocs++
+	heapStats.TotalAlloc += size
+	heapStats.HeapAlloc += size
+	heapStats.HeapObjects++
+	heapLock.Unlock()
+	runtime.SetFinalizer(p, heapFree)
+}
+
+// heapTrackSlice counts the backing array of slice s, whose elements
+// are of type t, until it is reclaimed.
+func heapTrackSlice(i *interpreter, s []Value, t types.Type) {
+	if !heapOn() {
+		return
+	}
+	if cap(s) == 0 {
+		heapCount(0)
+		return
+	}
+	heapTrack(&s[:1][0], heapSize(i, t, cap(s)))
+}
+
+// heapFree is the finalizer of the objects we track. It runs the
+// finalizer the program set on the object, if any.
+func heapFree(p *Value) {
+	addr := uintptr(unsafe.Pointer(p))
+	heapLock.Lock()
+	if size, ok := heapSizes[addr]; ok {
+		delete(heapSizes, addr)
+		heapStats.Frees++
+		heapStats.HeapAlloc -= size
+		heapStats.HeapObjects--
+	}
+	fin := heapFinalizers[addr]
+	delete(heapFinalizers, addr)
+	heapLock.Unlock()
+	if fin != nil {
+		fin(p)
+	}
+}
+
+// heapSetFinalizer makes fin, or nothing if fin is nil, the
+// finalizer the program has set on p. It returns false if we don't
+// track p, in which case the finalizer must be set on p directly.
+func heapSetFinalizer(p *Value, fin func(*Value)) bool {
+	addr := uintptr(unsafe.Pointer(p))
+	heapLock.Lock()
+	defer heapLock.Unlock()
+	if _, ok := heapSizes[addr]; !ok {
+		return false
+	}
+	if fin == nil {
+		delete(heapFinalizers, addr)
+	} else {
+		heapFinalizers[addr] = fin
+	}
+	return true
+}
+
+// heapMemStats fills in runtime.MemStats value m from what we know
+// of the program's memory.
+func heapMemStats(i *interpreter, m Structure) {
+	s := ReadHeapStats()
+	st := i.prog.ImportedPackage("runtime").Type("MemStats").Object().Type().Underlying().(*types.Struct)
+	for f := 0; f < st.NumFields() && f < len(m.fields); f++ {
+		switch st.Field(f).Name() {
+		case "Alloc", "HeapAlloc":
+			m.fields[f] = s.HeapAlloc
+		case "TotalAlloc":
+			m.fields[f] = s.TotalAlloc
+		case "Mallocs":
+			m.fields[f] = s.Mallocs
+		case "Frees":
+			m.fields[f] = s.Frees
+		case "HeapObjects":
+			m.fields[f] = s.HeapObjects
+		case "NumGC":
+			m.fields[f] = s.NumGC
+		}
+	}
+}
+
+// heapCheck panics in frame fr if allocating size bytes at pos would
+// take the program over HeapLimit.
+func heapCheck(fr *Frame, pos token.Pos, size uint64) {
+	if limit, ok := heapRoom(size); !ok {
+		fr.sourcePanic(fmt.Sprintf("out of memory: allocating %d bytes at %s would go over the heap limit of %d bytes",
+			size, ssa2.FmtPos(fr.i.prog.Fset, pos), limit))
+	}
+}
+
+// heapRoom returns the heap limit and whether there is room under it
+// for size more bytes, collecting garbage first if need be.
+func heapRoom(size uint64) (uint64, bool) {
+	limit := HeapLimit()
+	if limit == 0 || heapInUse()+size <= limit {
+		return limit, true
+	}
+	heapCollect(limit)
+	return limit, heapInUse()+size <= limit
+}
+
+// heapCollect collects garbage to make room under the heap limit,
+// unless less than a quarter of limit has been allocated since the
+// last collection. One goroutine collects at a time; the others wait
+// for it and see what it reclaimed.
+func heapCollect(limit uint64) {
+	heapGCLock.Lock()
+	defer heapGCLock.Unlock()
+	heapLock.Lock()
+	since := heapStats.TotalAlloc - heapGCAlloc
+	heapLock.Unlock()
+	if since < limit/4 {
+		return
+	}
+	// Our finalizers tell us what was reclaimed once the
+	// collection is over.
+	runtime.GC()
+	time.Sleep(FinalizerWait)
+	heapLock.Lock()
+	heapGCAlloc = heapStats.TotalAlloc
+	heapLock.Unlock()
+}
+
+func heapInUse() uint64 {
+	heapLock.Lock()
+	defer heapLock.Unlock()
+	return heapStats.HeapAlloc
+}
+
+// ParseSize parses a size in bytes, such as a heap limit, given as a
+// number optionally followed by K, M or G for kilobytes, megabytes or
+// gigabytes.
+func ParseSize(s string) (uint64, error) {
+	digits, mult := s, uint64(1)
+	switch {
+	case strings.HasSuffix(s, "K"):
+		mult = 1 << 10
+	case strings.HasSuffix(s, "M"):
+		mult = 1 << 20
+	case strings.HasSuffix(s, "G"):
+		mult = 1 << 30
+	}
+	if mult != 1 {
+		digits = s[:len(s)-1]
+	}
+	n, err := strconv.ParseUint(digits, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("expecting a number of bytes, optionally followed by K, M or G; got %s", s)
+	}
+	if n > math.MaxUint64/mult {
+		return 0, fmt.Errorf("size %s is too big", s)
+	}
+	return n * mult, nil
+}
@@ -0,0 +1,30 @@
+// Copyright 2015 Rocky Bernstein.
+// Tests of reading sizes like the heap limit
+
+package interp
+
+import "testing"
+
+func TestParseSize(t *testing.T) {
+	for _, tc := range []struct {
+		s    string
+		want uint64
+		ok   bool
+	}{
+		{"100", 100, true},
+		{"4K", 4 << 10, true},
+		{"64M", 64 << 20, true},
+		{"2G", 2 << 30, true},
+		{"16777215G", 16777215 << 30, true},
+		{"17179869184G", 0, false},
+		{"", 0, false},
+		{"M", 0, false},
+		{"-1K", 0, false},
+		{"10T", 0, false},
+	} {
+		got, err := ParseSize(tc.s)
+		if (err == nil) != tc.ok || got != tc.want {
+			t.Errorf("ParseSize(%q) = %d, %v; want %d, ok %v", tc.s, got, err, tc.want, tc.ok)
+		}
+	}
+}
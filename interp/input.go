@@ -0,0 +1,45 @@
+// Copyright 2015 Rocky Bernstein.
+// Where the interpreted program's standard input comes from
+
+package interp
+
+import (
+	"io"
+)
+
+// The program normally reads our standard input. A debugger reading
+// its commands from the same terminal can give the program its input
+// some other way instead, say from a file or from text it was given.
+
+// programInput is what standard input of the program reads from, or
+// nil for ours. outputLock guards it.
+var programInput io.Reader
+
+// SetProgramInput has the program's standard input read from r. A nil
+// r has it read from ours again.
+func SetProgramInput(r io.Reader) {
+	outputLock.Lock()
+	defer outputLock.Unlock()
+	programInput = r
+}
+
+// programRead reads into b from file descriptor fd of the program, if
+// that is standard input coming from elsewhere. handled is false if
+// fd is ours to read. As with read(2), the end of input gives n = 0
+// and no error.
+func programRead(fd int, b []byte) (n int, err error, handled bool) {
+	if fd != 0 {
+		return 0, nil, false
+	}
+	outputLock.Lock()
+	r := programInput
+	outputLock.Unlock()
+	if r == nil {
+		return 0, nil, false
+	}
+	n, err = r.Read(b)
+	if err == io.EOF {
+		err = nil
+	}
+	return n, err, true
+}
@@ -70,6 +70,24 @@ type Mode uint
 const (
 	// Disable recover() in target programs; show interpreter crash instead.
 	DisableRecover Mode = 1 << iota
+
+	// Run one goroutine at a time, taking turns in order. See Scheduler.
+	RoundRobinSchedule
+
+	// Run one goroutine at a time, picking the next one at random
+	// with seed SchedSeed. See Scheduler.
+	RandomSchedule
+
+	// Look for data races and report them on standard error.
+	RaceDetect
+
+	// Run goroutines on one OS thread rather than in parallel,
+	// whatever GOMAXPROCS says.
+	SerialExecution
+
+	// When InstructionLimit or TimeLimit is reached, stop in the
+	// debugger rather than ending the program.
+	BreakOnLimit
 )
 
 type methodSet map[string]*ssa2.Function
@@ -89,7 +107,7 @@ type interpreter struct {
 	sizes              types.Sizes           // the effective type-sizing function
 
 	TraceMode      TraceMode                 // interpreter trace options
-	TraceEventMask ssa2.TraceEventMask
+	traceEventMask ssa2.TraceEventMask       // events for the trace hook; see TraceEventOn
 	nGoroutines    int                       // number of goroutines
 	goTops         []*GoreState
 }
@@ -109,6 +127,9 @@ func (fr *Frame) runDefer(d *deferred) {
 			// Deferred call created a new state of panic.
 			fr.panicking = true
 			fr.panic = recover()
+			if _, ok := fr.panic.(restartPanic); ok {
+				panic(fr.panic)
+			}
 		}
 	}()
 	call(fr.i, fr.goNum, fr, d.fn, d.args)
@@ -124,6 +145,9 @@ func lookupMethod(i *interpreter, typ types.Type, meth *types.Func) *ssa2.Functi
 	case errorType:
 		return i.errorMethods[meth.Id()]
 	}
+	if f := lookupNetMethod(typ, meth); f != nil {
+		return f
+	}
 	return i.prog.LookupMethod(typ, meth.Pkg(), meth.Name())
 }
 
@@ -147,10 +171,38 @@ func visitInstr(fr *Frame, genericInstr ssa2.Instruction) continuation {
 			}
 		}
 	case *ssa2.UnOp:
-		fr.env[instr] = unop(instr, fr.get(instr.X))
+		x := fr.get(instr.X)
+		if instr.Op == token.ARROW {
+			setGoState(fr, GoChanRecv)
+			fr.env[instr] = unop(instr, x)
+			setGoState(fr, GoRunning)
+		} else {
+			if instr.Op == token.MUL && raceOn(fr) {
+				raceRead(fr, instr, x.(*Value))
+			}
+			fr.env[instr] = unop(instr, x)
+		}
+		if instr.Op == token.ARROW && raceOn(fr) {
+			raceAcquire(fr.goNum, x)
+		}
+		if instr.Op == token.MUL && anyWatches() {
+			checkReadWatch(fr, &genericInstr, x.(*Value))
+		} else if instr.Op == token.ARROW && anyChanCatches() {
+			checkChanCatch(fr, &genericInstr, x.(chan Value), CHAN_RECV,
+				fr.env[instr])
+		}
 
 	case *ssa2.BinOp:
-		fr.env[instr] = binop(instr.Op, instr.X.Type(), fr.get(instr.X), fr.get(instr.Y))
+		x, y := fr.get(instr.X), fr.get(instr.Y)
+		if instr.Op == token.ADD && heapOn() {
+			if xs, ok := x.(string); ok {
+				// A new string is made.
+				bytes := uint64(len(xs) + len(y.(string)))
+				heapCheck(fr, instr.Pos(), bytes)
+				heapCount(bytes)
+			}
+		}
+		fr.env[instr] = binop(instr.Op, instr.X.Type(), x, y)
 
 	case *ssa2.Call:
 		fn, args := prepareCall(fr, &instr.Call)
@@ -193,13 +245,35 @@ func visitInstr(fr *Frame, genericInstr ssa2.Instruction) continuation {
 		fr.runDefers()
 
 	case *ssa2.Panic:
-		fr.sourcePanic(ToInspect(fr.get(instr.X), nil))
+		v := fr.get(instr.X)
+		fr.sourcePanicValue(v, ToInspect(v, nil))
 
 	case *ssa2.Send:
-		fr.get(instr.Chan).(chan Value) <- copyVal(fr.get(instr.X))
+		ch := fr.get(instr.Chan).(chan Value)
+		v  := copyVal(fr.get(instr.X))
+		if anyChanCatches() {
+			checkChanCatch(fr, &genericInstr, ch, CHAN_SEND, v)
+		}
+		if raceOn(fr) {
+			raceRelease(fr.goNum, ch)
+		}
+		setGoState(fr, GoChanSend)
+		ch <- v
+		setGoState(fr, GoRunning)
 
 	case *ssa2.Store:
-		*fr.get(instr.Addr).(*Value) = copyVal(fr.get(instr.Val))
+		addr := fr.get(instr.Addr).(*Value)
+		if raceOn(fr) {
+			raceWrite(fr, instr, addr)
+		}
+		old := *addr
+		*addr = copyVal(fr.get(instr.Val))
+		if raceOn(fr) {
+			raceStored(fr, instr, addr)
+		}
+		if anyWatches() {
+			checkWriteWatch(fr, &genericInstr, addr, old)
+		}
 
 	case *ssa2.If:
 		succ := 1
@@ -216,6 +290,7 @@ func visitInstr(fr *Frame, genericInstr ssa2.Instruction) continuation {
 	case *ssa2.Defer:
 		fn, args := prepareCall(fr, &instr.Call)
 		fr.defers = append(fr.defers, func() { call(fr.i, fr.goNum, fr, fn, args) })
+		fr.deferFns = append(fr.deferFns, fn)
 		// fr.defers = &deferred{
 		// 	fn:    fn,
 		// 	args:  args,
@@ -225,15 +300,33 @@ func visitInstr(fr *Frame, genericInstr ssa2.Instruction) continuation {
 
 	case *ssa2.Go:
 		fn, args := prepareCall(fr, &instr.Call)
-		go call(fr.i, i.nGoroutines, nil, fn, args)
+		goNum := newGoroutine(fr.i)
+		if raceOn(fr) {
+			raceGo(fr.goNum, goNum)
+		}
+		if goroutineHook != nil {
+			goroutineHook(fr, goNum)
+		}
+		schedSpawn(goNum)
+		go goCall(fr.i, goNum, fn, args)
 
 	case *ssa2.MakeChan:
-		fr.env[instr] = make(chan Value, asInt(fr.get(instr.Size)))
+		size := asInt(fr.get(instr.Size))
+		var bytes uint64
+		if heapOn() {
+			bytes = heapSize(fr.i, instr.Type().Underlying().(*types.Chan).Elem(), size)
+			heapCheck(fr, instr.Pos(), bytes)
+		}
+		fr.env[instr] = make(chan Value, size)
+		heapCount(bytes)
 
 	case *ssa2.Alloc:
 		var addr *Value
 		if instr.Heap {
 			// new
+			if heapOn() {
+				heapCheck(fr, instr.Pos(), heapSize(fr.i, deref(instr.Type()), 1))
+			}
 			addr = new(Value)
 			fr.env[instr] = addr
 		} else {
@@ -241,13 +334,28 @@ func visitInstr(fr *Frame, genericInstr ssa2.Instruction) continuation {
 			addr = fr.env[instr].(*Value)
 		}
 		*addr = zero(deref(instr.Type()))
+		if instr.Heap && heapOn() {
+			heapTrack(addr, heapSize(fr.i, deref(instr.Type()), 1))
+		}
+		if raceOn(fr) {
+			raceNew(addr)
+		}
 
 	case *ssa2.MakeSlice:
-		slice := make([]Value, asInt(fr.get(instr.Cap)))
 		tElt := instr.Type().Underlying().(*types.Slice).Elem()
+		if heapOn() {
+			heapCheck(fr, instr.Pos(), heapSize(fr.i, tElt, asInt(fr.get(instr.Cap))))
+		}
+		slice := make([]Value, asInt(fr.get(instr.Cap)))
 		for i := range slice {
 			slice[i] = zero(tElt)
 		}
+		heapTrackSlice(fr.i, slice, tElt)
+		if raceOn(fr) {
+			for i := range slice {
+				raceNew(&slice[i])
+			}
+		}
 		fr.env[instr] = slice[:asInt(fr.get(instr.Len))]
 
 	case *ssa2.MakeMap:
@@ -255,7 +363,14 @@ func visitInstr(fr *Frame, genericInstr ssa2.Instruction) continuation {
 		if instr.Reserve != nil {
 			reserve = asInt(fr.get(instr.Reserve))
 		}
-		fr.env[instr] = makeMap(instr.Type().Underlying().(*types.Map).Key(), reserve)
+		tMap := instr.Type().Underlying().(*types.Map)
+		var bytes uint64
+		if heapOn() {
+			bytes = heapSize(fr.i, tMap.Key(), reserve) + heapSize(fr.i, tMap.Elem(), reserve)
+			heapCheck(fr, instr.Pos(), bytes)
+		}
+		fr.env[instr] = makeMap(tMap.Key(), reserve)
+		heapCount(bytes)
 
 	case *ssa2.Range:
 		fr.env[instr] = rangeIter(fr.get(instr.X), instr.X.Type())
@@ -301,6 +416,18 @@ func visitInstr(fr *Frame, genericInstr ssa2.Instruction) continuation {
 		m := fr.get(instr.Map)
 		key := fr.get(instr.Key)
 		v := fr.get(instr.Value)
+		var old Value
+		if anyMapWatches() {
+			old, _ = MapLookup(m, key)
+		}
+		var entry uint64
+		if heapOn() {
+			if _, ok := MapLookup(m, key); !ok {
+				// A new entry is made.
+				entry = heapMapEntrySize(fr.i, instr.Map.Type().Underlying().(*types.Map))
+				heapCheck(fr, instr.Pos(), entry)
+			}
+		}
 		switch m := m.(type) {
 		case map[Value]Value:
 			m[key] = v
@@ -309,6 +436,12 @@ func visitInstr(fr *Frame, genericInstr ssa2.Instruction) continuation {
 		default:
 			panic(fmt.Sprintf("illegal map type: %T", m))
 		}
+		if entry != 0 {
+			heapMapAlloc(entry)
+		}
+		if anyMapWatches() {
+			checkMapWatch(fr, &genericInstr, m, key, old)
+		}
 
 	case *ssa2.TypeAssert:
 		fr.env[instr] = typeAssert(fr.i, instr, fr.get(instr.X).(iface))
@@ -316,6 +449,9 @@ func visitInstr(fr *Frame, genericInstr ssa2.Instruction) continuation {
 	case *ssa2.Trace:
 		fr.startP = instr.Start
 		fr.endP   = instr.End
+		if recording && !fr.debugCall {
+			record(fr, instr)
+		}
 		if (fr.tracing == TRACE_STEP_IN) ||
 			instr.Breakpoint ||
 			(fr.tracing == TRACE_STEP_OVER) && GlobalStmtTracing() {
@@ -361,10 +497,27 @@ func visitInstr(fr *Frame, genericInstr ssa2.Instruction) continuation {
 				Send: send,
 			})
 		}
-		chosen, recv, recvOk := reflect.Select(cases)
+		if raceOn(fr) {
+			for _, st := range instr.States {
+				if st.Dir != types.RecvOnly {
+					raceRelease(fr.goNum, fr.get(st.Chan))
+				}
+			}
+		}
+		chosen, recv, recvOk, ready := selectReady(cases)
+		if !ready {
+			if instr.Blocking {
+				setGoState(fr, GoSelect)
+			}
+			chosen, recv, recvOk = reflect.Select(cases)
+			setGoState(fr, GoRunning)
+		}
 		if !instr.Blocking {
 			chosen-- // default case should have index -1.
 		}
+		if chosen >= 0 && raceOn(fr) && instr.States[chosen].Dir == types.RecvOnly {
+			raceAcquire(fr.goNum, fr.get(instr.States[chosen].Chan))
+		}
 		r := tuple{chosen, recvOk}
 		for i, st := range instr.States {
 			if st.Dir == types.RecvOnly {
@@ -379,6 +532,19 @@ func visitInstr(fr *Frame, genericInstr ssa2.Instruction) continuation {
 			}
 		}
 		fr.env[instr] = r
+		if chosen >= 0 && anyChanCatches() {
+			st := instr.States[chosen]
+			ch := fr.get(st.Chan).(chan Value)
+			if st.Dir == types.RecvOnly {
+				var v Value
+				if recvOk {
+					v = recv.Interface().(Value)
+				}
+				checkChanCatch(fr, &genericInstr, ch, CHAN_RECV, v)
+			} else {
+				checkChanCatch(fr, &genericInstr, ch, CHAN_SEND, fr.get(st.Send))
+			}
+		}
 
 	default:
 		panic(fmt.Sprintf("unexpected instruction: %T", instr))
@@ -430,15 +596,50 @@ func call(i *interpreter, goNum int, caller *Frame, fn Value, args []Value) Valu
 		if fn == nil {
 			panic("call of nil function") // nil of func type
 		}
-		return callSSA(i, goNum, caller, fn, args, nil)
+		return callSSA(i, goNum, caller, fn, args, nil, false)
 	case *closure:
-		return callSSA(i, goNum, caller, fn.Fn, args, fn.Env)
+		return callSSA(i, goNum, caller, fn.Fn, args, fn.Env, false)
 	case *ssa2.Builtin:
 		return callBuiltin(caller, fn, args)
 	}
 	panic(fmt.Sprintf("cannot call %T", fn))
 }
 
+// goCall runs the call of fn with arguments args made by a go
+// statement as goroutine goNum.
+func goCall(i *interpreter, goNum int, fn Value, args []Value) {
+	schedAcquire(goNum)
+	defer func() {
+		gocall.Lock()
+		i.goTops[goNum].state = GoFinished
+		goStateChanges++
+		gocall.Unlock()
+		schedRelease(goNum)
+	}()
+	defer func() {
+		p := recover()
+		switch p.(type) {
+		case nil:
+			return
+		case exitPanic, restartPanic:
+			panic(p)
+		}
+		if i.Mode&DisableRecover != 0 {
+			panic(p)
+		}
+		// A panic in any goroutine ends the program.
+		reportGoroutinePanic(i, goNum, p)
+	}()
+	switch fn := fn.(type) {
+	case *ssa2.Function:
+		callSSA(i, goNum, nil, fn, args, nil, true)
+	case *closure:
+		callSSA(i, goNum, nil, fn.Fn, args, fn.Env, true)
+	default:
+		call(i, goNum, nil, fn, args)
+	}
+}
+
 func loc(fset *token.FileSet, pos token.Pos) string {
 	if pos == token.NoPos {
 		return ""
@@ -450,7 +651,24 @@ func loc(fset *token.FileSet, pos token.Pos) string {
 // and lexical environment env, returning its result.
 // callpos is the position of the callsite.
 //
-func callSSA(i *interpreter, goNum int, caller *Frame, fn *ssa2.Function, args []Value, env []Value) Value {
+//
+// goStart is true when fn is the start of a goroutine run by a go
+// statement.
+func callSSA(i *interpreter, goNum int, caller *Frame, fn *ssa2.Function, args []Value, env []Value, goStart bool) Value {
+	// When stepping into the nth call of a statement, the calls
+	// before it are stepped over. Count external calls too, since
+	// they are calls the user sees.
+	stepIn := caller != nil && caller.tracing == TRACE_STEP_IN
+	if stepIn && caller.stepCalls > 0 {
+		caller.stepCalls--
+		stepIn = false
+	}
+	// Deferred functions run when stepping over a return are
+	// stepped into if we've been asked to.
+	if caller != nil && caller.inDefers && caller.tracing == TRACE_STEP_OVER &&
+		stepDefers {
+		stepIn = true
+	}
 	if InstTracing() {
 		loc := "-"
 		if fn.Prog == nil {
@@ -474,12 +692,17 @@ func callSSA(i *interpreter, goNum int, caller *Frame, fn *ssa2.Function, args [
 		defer fmt.Fprintf(os.Stderr, "Leaving %s%s.\n", fn, suffix)
 	}
 
+	args = randSeedArgs(fn, args)
 	if fn.Parent() == nil {
+		if cname, errno := cgoFunction(fn); cname != "" && fn.Pkg != nil {
+			return callCgo(caller, fn, cname, errno, args)
+		}
 		name := fn.String()
 		if ext := externals[name]; ext != nil {
 			if InstTracing() {
 				fmt.Fprintln(os.Stderr, "\t(external)")
 			}
+			checkExternalCatch(caller, name, args)
 			return ext(caller, args)
 		}
 		if fn.Blocks == nil {
@@ -495,10 +718,11 @@ func callSSA(i *interpreter, goNum int, caller *Frame, fn *ssa2.Function, args [
 		locals  : make([]Value, len(fn.Locals)),
 		tracing : TRACE_STEP_NONE,
 		goNum   : goNum,
+		debugCall : caller != nil && caller.debugCall,
 		Var2Reg : make(map[string]string),
 		Reg2Var : make(map[string]string),
 	}
-	i.goTops[goNum].Fr = fr
+	setGoTop(i, goNum, fr)
 
 	fr.env = make(map[ssa2.Value]Value)
 	fr.block = fn.Blocks[0]
@@ -515,16 +739,30 @@ func callSSA(i *interpreter, goNum int, caller *Frame, fn *ssa2.Function, args [
 	}
 
 	if caller == nil {
-		if GlobalStmtTracing() {
+		// While stepping, goroutines started by a go statement
+		// are stopped in only if we've been asked to.
+		if GlobalStmtTracing() && (!goStart || stepGoroutines) {
+			fr.tracing = TRACE_STEP_IN
+		}
+	} else if stepIn {
+		// Step into fn unless we've been told to skip it, in
+		// which case we stop back in the caller.
+		if stepSkip == nil || !stepSkip(caller.fn, fn) {
 			fr.tracing = TRACE_STEP_IN
 		}
-	} else if caller.tracing == TRACE_STEP_IN {
-		fr.tracing = TRACE_STEP_IN
 	}
 
 	for fr.block != nil {
 		runFrame(fr)
 	}
+	if caller != nil {
+		// Back to running in the caller.
+		setGoTop(i, goNum, caller)
+	}
+	if fn == randInitFn {
+		// Seed math/rand before the packages using it start.
+		randSeedStart(i, goNum, caller)
+	}
 	// Destroy the locals to avoid accidental use after return.
 	for i := range fn.Locals {
 		fr.locals[i] = bad{}
@@ -560,6 +798,11 @@ func runFrame(fr *Frame) {
 		}
 		fr.panicking = true
 		fr.panic = recover()
+		if _, ok := fr.panic.(restartPanic); ok {
+			// Abandon the run without running deferred calls.
+			panic(fr.panic)
+		}
+		fr.reportRuntimePanic(fr.panic)
 		if InstTracing() || GlobalStmtTracing() {
 			fmt.Fprintf(os.Stderr, "Panicking (error type %T): %v.\n", fr.panic, fr.panic)
 			debug.PrintStack()
@@ -589,6 +832,10 @@ func runFrame(fr *Frame) {
 	block:
 		// rocky: changed to allow for debugger "jump" command
 		for fr.pc = 0; fr.pc < len(fr.block.Instrs); fr.pc++ {
+			if fr.returnNow {
+				forcedReturn(fr)
+				return
+			}
 			instr = fr.block.Instrs[fr.pc]
 			if InstTracing() {
 				fmt.Fprint(os.Stderr, fr.pc, "\t")
@@ -598,8 +845,19 @@ func runFrame(fr *Frame) {
 					fmt.Fprintln(os.Stderr, "\t", instr)
 				}
 			}
+			if !fr.debugCall {
+				schedTick(fr.goNum)
+				if InstructionLimit != 0 || TimeLimit != 0 {
+					budgetTick(fr)
+				}
+			}
 			if fr.tracing == TRACE_STEP_INSTRUCTION {
 				TraceHook(fr, &instr, ssa2.STEP_INSTRUCTION)
+				if fr.returnNow {
+					// Don't run instr.
+					forcedReturn(fr)
+					return
+				}
 			}
 			switch visitInstr(fr, instr) {
 			case kReturn:
@@ -621,9 +879,15 @@ func runFrame(fr *Frame) {
 				}
 
 				fr.status = StComplete
-				if (fr.tracing != TRACE_STEP_NONE) && GlobalStmtTracing() {
+				if fr.tracing == TRACE_STEP_OUT && fr.caller != nil &&
+					GlobalStmtTracing() {
+					setFinishHit(fr)
+				} else if (fr.tracing != TRACE_STEP_NONE) && GlobalStmtTracing() {
 					TraceHook(fr, &instr, ssa2.CALL_RETURN)
 				}
+				// A "return" at the CALL_RETURN stop has already
+				// replaced fr.result.
+				fr.returnNow = false
 				return
 			case kNext:
 				// no-op
@@ -634,6 +898,18 @@ func runFrame(fr *Frame) {
 	}
 }
 
+// forcedReturn returns from frame fr, whose result the debugger's
+// "return" set, without running any more of it.
+func forcedReturn(fr *Frame) {
+	fr.returnNow = false
+	fr.block = nil
+	fr.status = StComplete
+	if fr.tracing == TRACE_STEP_OUT && fr.caller != nil &&
+		GlobalStmtTracing() {
+		setFinishHit(fr)
+	}
+}
+
 // doRecover implements the recover() built-in.
 func doRecover(caller *Frame) Value {
 	// recover() must be exactly one level beneath the deferred
@@ -646,6 +922,7 @@ func doRecover(caller *Frame) Value {
 		caller.caller.panicking = false
 		p := caller.caller.panic
 		caller.caller.panic = nil
+		panicRecovered(caller.goNum)
 		switch p := p.(type) {
 		case targetPanic:
 			// The target program explicitly called panic().
@@ -672,16 +949,6 @@ func setGlobal(i *interpreter, pkg *ssa2.Package, name string, v Value) {
 	panic("no global variable: " + pkg.Object.Path() + "." + name)
 }
 
-var environ []Value
-
-func init() {
-	for _, s := range os.Environ() {
-		environ = append(environ, s)
-	}
-	environ = append(environ, "GOSSAINTERP=1")
-	environ = append(environ, "GOARCH="+runtime.GOARCH)
-}
-
 // deleteBodies delete the bodies of all standalone functions except the
 // specified ones.  A missing intrinsic leads to a clear runtime error.
 func deleteBodies(pkg *ssa2.Package, except ...string) {
@@ -706,13 +973,46 @@ func deleteBodies(pkg *ssa2.Package, except ...string) {
 //
 // The SSA program must include the "runtime" package.
 //
+// If the debugger asks for the program to be restarted, it is run
+// again from the beginning with freshly initialized globals.
+//
 func Interpret(mainpkg *ssa2.Package, mode Mode, traceMode TraceMode, sizes types.Sizes, filename string, args []string) (exitCode int) {
+	for {
+		var restart bool
+		exitCode, restart = interpretOnce(mainpkg, mode, traceMode, sizes,
+			filename, args)
+		if !restart {
+			return exitCode
+		}
+	}
+}
+
+// interpretOnce runs the program once, returning its exit code. If
+// the run was abandoned because a restart was requested, restart is
+// true.
+func interpretOnce(mainpkg *ssa2.Package, mode Mode, traceMode TraceMode, sizes types.Sizes, filename string, args []string) (exitCode int, restart bool) {
+	defer func() {
+		if x := recover(); x != nil {
+			if _, ok := x.(restartPanic); !ok {
+				panic(x)
+			}
+			restart = true
+		}
+	}()
+	return interpret(mainpkg, mode, traceMode, sizes, filename, args), false
+}
+
+func interpret(mainpkg *ssa2.Package, mode Mode, traceMode TraceMode, sizes types.Sizes, filename string, args []string) (exitCode int) {
+	var prevDefer bool
+	if i != nil {
+		prevDefer = i.TraceEventOn(ssa2.DEFER_ENTER)
+	}
 	i = &interpreter{
 		prog:    mainpkg.Prog,
 		globals: make(map[ssa2.Value]*Value),
 		Mode:    mode,
 		TraceMode: traceMode,
-		TraceEventMask: make(ssa2.TraceEventMask, ssa2.TRACE_EVENT_LAST),
+		traceEventMask: make(ssa2.TraceEventMask, ssa2.TRACE_EVENT_LAST),
 		sizes:   sizes,
 	}
 	runtimePkg := i.prog.ImportedPackage("runtime")
@@ -722,18 +1022,33 @@ func Interpret(mainpkg *ssa2.Package, mode Mode, traceMode TraceMode, sizes type
 	i.runtimeErrorString = runtimePkg.Type("errorString").Object().Type()
 
 	for event := ssa2.TRACE_EVENT_FIRST; event <= ssa2.TRACE_EVENT_LAST; event++ {
-		i.TraceEventMask[event] = true
+		i.traceEventMask[event] = true
 	}
-	i.TraceEventMask[ssa2.TRACE_CALL] = false
-	i.TraceEventMask[ssa2.DEFER_ENTER] = false
+	i.traceEventMask[ssa2.TRACE_CALL] = false
+	// On a restart, keep events the debugger asked for.
+	i.traceEventMask[ssa2.DEFER_ENTER] = prevDefer
 	if i.TraceMode & EnableInitTracing == 0 {
 		// clear tracing bits in init() functions that occur before
 		// main.main()
 		i.TraceMode &= ^(EnableStmtTracing|EnableTracing)
 	}
+	gocall.Lock()
 	i.goTops = append(i.goTops, &GoreState{Fr: nil, state: 0})
+	gocall.Unlock()
+	parallelReset(mode)
+	heapReset()
+	panicReset()
+	environReset()
+	randReset(i)
+	setExit(0, "")
+	schedReset(mode)
+	selectReset()
+	if mode&RaceDetect != 0 {
+		raceReset()
+	}
 
 	initReflect(i)
+	netReset(i)
 
 	i.osArgs = append(i.osArgs, filename)
 	for _, arg := range args {
@@ -753,12 +1068,6 @@ func Interpret(mainpkg *ssa2.Package, mode Mode, traceMode TraceMode, sizes type
 		// Ad-hoc initialization for magic system variables.
 		switch pkg.Object.Path() {
 		case "syscall":
-			var envs []Value
-			for _, s := range os.Environ() {
-				envs = append(envs, s)
-			}
-			envs = append(envs, "GOSSAINTERP=1")
-			envs = append(envs, "GOARCH="+runtime.GOARCH)
 			setGlobal(i, pkg, "envs", environ)
 
 		case "reflect":
@@ -775,45 +1084,63 @@ func Interpret(mainpkg *ssa2.Package, mode Mode, traceMode TraceMode, sizes type
 	exitCode = 2
 	defer func() {
 		if exitCode != 2 || i.Mode&DisableRecover != 0 {
-			TraceHook(i.goTops[0].Fr, nil, ssa2.PROGRAM_TERMINATION)
+			switch {
+			case exitCode == 2:
+				setExit(exitCode, "panic")
+			case mainpkg.Func("main") == nil:
+				setExit(exitCode, "no-main")
+			default:
+				setExit(exitCode, "normal")
+			}
+			TraceHook(goTopFrame(i, 0), nil, ssa2.PROGRAM_TERMINATION)
 			return
 		}
 		switch p := recover().(type) {
 		case exitPanic:
 			exitCode = int(p)
+			setExit(exitCode, "exit")
 			return
+		case restartPanic:
+			panic(p)
 		case targetPanic:
-			fmt.Fprintln(os.Stderr, "panic:", toString(p.v))
+			printPanic(programStderr, i, 0, toString(p.v))
 		case runtime.Error:
-			fmt.Fprintln(os.Stderr, "panic:", p.Error())
+			printPanic(programStderr, i, 0, p.Error())
 		case string:
-			fmt.Fprintln(os.Stderr, "panic:", p)
+			printPanic(programStderr, i, 0, p)
 		default:
-			fmt.Fprintf(os.Stderr, "panic: unexpected type: %T: %v\n", p, p)
+			printPanic(programStderr, i, 0, fmt.Sprintf("unexpected type: %T: %v", p, p))
 		}
-		TraceHook(i.goTops[0].Fr, nil, ssa2.PROGRAM_TERMINATION)
-
-		// TODO(adonovan): dump panicking interpreter goroutine?
-		// buf := make([]byte, 0x10000)
-		// runtime.Stack(buf, false)
-		// fmt.Fprintln(os.Stderr, string(buf))
-		// (Or dump panicking target goroutine?)
+		setExit(exitCode, "panic")
+		TraceHook(goTopFrame(i, 0), nil, ssa2.PROGRAM_TERMINATION)
 	}()
 
+	// Watch for the goroutines all getting stuck.
+	done := make(chan bool)
+	defer close(done)
+	go deadlockWatch(i, done)
+	budgetReset()
+	if TimeLimit != 0 {
+		go budgetWatch(i, done)
+	}
+
 	// Run!
 	call(i, 0, nil, mainpkg.Func("init"), nil)
 	if mainFn := mainpkg.Func("main"); mainFn != nil {
+		randSeedStart(i, 0, nil)
+
 		// If we didn't set tracing before because EnableInitTracing
 		// was off, we'll set it now.
 		i.TraceMode = traceMode
 
 		// And allow runtime.Breakpoint() take effect now.
-		i.TraceEventMask[ssa2.TRACE_CALL] = true
+		SetTraceEvent(ssa2.TRACE_CALL)
 
 		// Allow defer tracing now that we've hit main
 		// On second thought. We catch defer enter with a call enter.
-		// i.TraceEventMask[ssa2.DEFER_ENTER] = true
+		// SetTraceEvent(ssa2.DEFER_ENTER)
 		call(i, 0, nil, mainFn, nil)
+		finalizeAtExit()
 		exitCode = 0
 	} else {
 		fmt.Fprintln(os.Stderr, "No main function.")
@@ -9,7 +9,7 @@ alike.
 package interp
 import (
 	"fmt"
-	"os"
+	"runtime"
 	"github.com/rocky/ssa-interp"
 )
 
@@ -26,6 +26,58 @@ func init() {
 	TraceHook = NullTraceHook
 }
 
+// Restart abandons the current run of the program, without running
+// deferred calls, so that Interpret runs it again from the beginning.
+// It must be called from the trace hook of the main goroutine, as
+// the panic is only caught there.
+func Restart() {
+	records = nil
+	finishFn = nil
+	panic(restartPanic{})
+}
+
+// CallFunction calls function value fn with arguments args on behalf
+// of the debugger, which is stopped in frame fr, and returns the
+// results. While fn runs, the trace hook isn't called for its frames,
+// so neither breakpoints nor stepping stop in it, nothing is recorded,
+// and it neither takes turns with other goroutines nor uses up the
+// program's limits. Other goroutines go on as before. A panic in fn
+// is returned as an error.
+func CallFunction(fr *Frame, fn Value, args []Value) (results []Value, err error) {
+	tracing, stepCalls, debugCall := fr.tracing, fr.stepCalls, fr.debugCall
+	panicked := panicSave(fr.goNum)
+	top := goTopFrame(fr.i, fr.goNum)
+	fr.tracing = TRACE_STEP_NONE
+	fr.debugCall = true
+	defer func() {
+		if x := recover(); x != nil {
+			err = fmt.Errorf("panic: %v", x)
+		}
+		fr.tracing, fr.stepCalls, fr.debugCall = tracing, stepCalls, debugCall
+		panicRestore(fr.goNum, panicked)
+		setGoTop(fr.i, fr.goNum, top)
+	}()
+	v := call(fr.i, fr.goNum, fr, fn, args)
+	if t, ok := v.(tuple); ok {
+		return []Value(t), nil
+	}
+	return []Value{v}, nil
+}
+
+// ForceReturn makes fr return results as soon as it is resumed,
+// without running the rest of its instructions or its deferred calls.
+func ForceReturn(fr *Frame, results []Value) {
+	switch len(results) {
+	case 0:
+		fr.result = nil
+	case 1:
+		fr.result = results[0]
+	default:
+		fr.result = tuple(results)
+	}
+	fr.returnNow = true
+}
+
 func GetInterpreter() *interpreter {
 	return i
 }
@@ -37,25 +89,42 @@ func SetGlobal(i *interpreter, pkg *ssa2.Package, name string, v Value) {
 	setGlobal(i, pkg, name, v)
 }
 
+// exitStatus is the exit status of the program once it has finished,
+// and exitReason says how it finished: "normal" if main returned,
+// "exit" if os.Exit was called, "panic" if it panicked, "deadlock" if
+// its goroutines all got stuck, "limit" if it ran out of instructions
+// or time, or "no-main" if there was no main function to run. Both
+// are set before the PROGRAM_TERMINATION trace event is issued, and
+// are 0 and "" while a run is still going.
+var exitStatus int
+var exitReason string
+
+// ExitStatus returns the exit status of the finished program and how
+// it finished.
+func ExitStatus() (int, string) { return exitStatus, exitReason }
+
+// setExit records that the program finished with status for reason.
+func setExit(status int, reason string) {
+	exitStatus, exitReason = status, reason
+}
+
 // sourcePanic is a panic in the source code rather than a normal panic
 // which would be in the interpreter code
 func (fr *Frame) sourcePanic(mess string) {
-	fmt.Fprintf(os.Stderr, "panic: %s\n", mess)
-	gotraceback := os.Getenv("GOTRACEBACK")
-	switch gotraceback {
-	case "0":
-		//do nothing
-	case "1":
-		runtime۰Gotraceback(fr)
-	case "2", "crash":
-		runtime۰Gotraceback(fr)
-		for _, goTop := range fr.i.goTops {
-			otherFr := goTop.Fr
-			if otherFr != fr {
-				runtime۰Gotraceback(otherFr)
-			}
-		}
-	}
+	fr.raisePanic(mess, mess, runtimeErrorMessage(mess))
+}
+
+// sourcePanicValue is sourcePanic where the panic value v is
+// something other than the message mess, e.g. the argument of a
+// panic() call.
+func (fr *Frame) sourcePanicValue(v Value, mess string) {
+	fr.raisePanic(v, mess, panicString(fr, v))
+}
+
+// raisePanic panics with value v and message mess. report is what to
+// say about it if it isn't recovered from.
+func (fr *Frame) raisePanic(v Value, mess string, report string) {
+	panicBegin(fr, v, report)
 
 	TraceHook(fr, &fr.block.Instrs[fr.pc], ssa2.PANIC)
 	// Don't know if setting fr.status really does anything, but
@@ -73,4 +142,47 @@ func (fr *Frame) sourcePanic(mess string) {
 
 func (i *interpreter) Program() *ssa2.Program { return i.prog }
 func (i  *interpreter) Globals() map[ssa2.Value]*Value { return i.globals }
-func (i  *interpreter) GoTops() []*GoreState { return i.goTops }
+// GoTops returns a copy of the state of each goroutine, indexed by
+// goroutine number.
+func (i  *interpreter) GoTops() []*GoreState {
+	gocall.Lock()
+	defer gocall.Unlock()
+	goTops := make([]*GoreState, len(i.goTops))
+	for goNum, goTop := range i.goTops {
+		g := *goTop
+		goTops[goNum] = &g
+	}
+	return goTops
+}
+
+// reportRuntimePanic issues a PANIC trace event for a panic p, caught
+// in frame fr, that the interpreter raised without going through
+// sourcePanic, e.g. a nil map assignment or an integer divide by
+// zero. Since the panic hasn't been reported yet, fr is the frame it
+// originated in, and deferred functions have not been run yet.
+func (fr *Frame) reportRuntimePanic(p interface{}) {
+	if panicIsReported(fr.goNum) {
+		return
+	}
+	var v Value
+	var report string
+	switch p := p.(type) {
+	case exitPanic:
+		return
+	case targetPanic:
+		v = p.v
+		report = panicString(fr, p.v)
+	case runtime.Error:
+		v = p.Error()
+		report = runtimeErrorMessage(p.Error())
+	case string:
+		v = p
+		report = runtimeErrorMessage(p)
+	default:
+		report = fmt.Sprintf("%v", p)
+		v = report
+	}
+	panicBegin(fr, v, report)
+	TraceHook(fr, &fr.block.Instrs[fr.pc], ssa2.PANIC)
+	fr.status = StPanic
+}
@@ -11,8 +11,11 @@ import (
 	"fmt"
 	"go/build"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"syscall"
 	"testing"
 	"time"
 
@@ -174,9 +177,39 @@ var gorootSrcTests = []string{
 	// "flag",
 }
 
+// These are programs in testdata/ that fail on purpose, or that the
+// interpreter finds fault with. Each is run in mode in a child process
+// of the test, as some end it with os.Exit, with setup, if given,
+// called there first. It should exit with exitCode having written
+// each of want and not BUG.
+var exitTests = []struct {
+	input    string
+	mode     interp.Mode
+	setup    func()
+	exitCode int
+	want     []string
+}{
+	{"race.go", interp.RaceDetect, nil, 0, []string{
+		"WARNING: DATA RACE", "Write by goroutine", "Previous write by goroutine"}},
+	{"deadlock.go", 0, nil, 2, []string{
+		"fatal error: all goroutines are asleep - deadlock!", "main.main()"}},
+	{"heaplimit.go", 0, func() { interp.SetHeapLimit(1 << 20) }, 2, []string{
+		"out of memory", "over the heap limit of 1048576 bytes"}},
+	{"loop.go", 0, func() { interp.InstructionLimit = 10000 }, 2, []string{
+		"interp: instruction limit of 10000 reached"}},
+	{"goroutinepanic.go", 0, nil, 2, []string{
+		"panic: boom", "goroutine 2 [running]:", "main.f(0x5)"}},
+	{"exit.go", 0, nil, 3, []string{"exiting"}},
+}
+
 type successPredicate func(exitcode int, output string) error
 
 func run(t *testing.T, dir, input string, success successPredicate) bool {
+	return runMode(t, dir, input, 0, success)
+}
+
+// runMode is run with the interpreter in mode.
+func runMode(t *testing.T, dir, input string, mode interp.Mode, success successPredicate) bool {
 	fmt.Printf("Input: %s\n", input)
 
 	start := time.Now()
@@ -250,7 +283,7 @@ func run(t *testing.T, dir, input string, success successPredicate) bool {
 	interp.CapturedOutput = &out
 
 	hint = fmt.Sprintf("To trace execution, run:\n%% go build golang.org/x/tools/cmd/ssadump && ./ssadump -build=C -run --interp=T %s\n", input)
-	exitCode := interp.Interpret(mainPkg, 0, 0, &types.StdSizes{8, 8}, inputs[0], []string{})
+	exitCode := interp.Interpret(mainPkg, mode, 0, &types.StdSizes{8, 8}, inputs[0], []string{})
 
 	// The definition of success varies with each file.
 	if err := success(exitCode, out.String()); err != nil {
@@ -299,6 +332,57 @@ func TestTestdataFiles(t *testing.T) {
 	printFailures(failures)
 }
 
+// TestExitTests runs the interpreter on exitTests, each in a child
+// process: this test run again with $INTERP_EXIT_TEST set to which.
+func TestExitTests(t *testing.T) {
+	if which := os.Getenv("INTERP_EXIT_TEST"); which != "" {
+		n, _ := strconv.Atoi(which)
+		test := exitTests[n]
+		if test.setup != nil {
+			test.setup()
+		}
+		exitCode := -1
+		runMode(t, "testdata"+slash, test.input, test.mode, func(code int, output string) error {
+			exitCode = code
+			return nil
+		})
+		os.Exit(exitCode)
+	}
+
+	var failures []string
+	for n, test := range exitTests {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestExitTests$")
+		cmd.Env = append(os.Environ(), fmt.Sprintf("INTERP_EXIT_TEST=%d", n))
+		out, err := cmd.CombinedOutput()
+		exitCode := 0
+		if e, ok := err.(*exec.ExitError); ok {
+			exitCode = e.Sys().(syscall.WaitStatus).ExitStatus()
+		} else if err != nil {
+			t.Errorf("%s: %s", test.input, err)
+			failures = append(failures, test.input)
+			continue
+		}
+		if exitCode != test.exitCode {
+			t.Errorf("%s: exit code was %d, want %d:\n%s", test.input, exitCode, test.exitCode, out)
+			failures = append(failures, test.input)
+			continue
+		}
+		if strings.Contains(string(out), "BUG") {
+			t.Errorf("%s: output contained 'BUG':\n%s", test.input, out)
+			failures = append(failures, test.input)
+			continue
+		}
+		for _, want := range test.want {
+			if !strings.Contains(string(out), want) {
+				t.Errorf("%s: output doesn't contain %q:\n%s", test.input, want, out)
+				failures = append(failures, test.input)
+				break
+			}
+		}
+	}
+	printFailures(failures)
+}
+
 // TestGorootTest runs the interpreter on $GOROOT/test/*.go.
 func TestGorootTest(t *testing.T) {
 	if testing.Short() {
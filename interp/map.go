@@ -111,3 +111,14 @@ func (m *hashmap) len() int {
 	}
 	return 0
 }
+
+// mapLen returns the number of key/value associations in map m.
+func mapLen(m Value) int {
+	switch m := m.(type) {
+	case map[Value]Value:
+		return len(m)
+	case *hashmap:
+		return m.len()
+	}
+	return 0
+}
@@ -0,0 +1,247 @@
+// Copyright 2015 Rocky Bernstein.
+// Calling natively-compiled Go functions from interpreted code
+
+package interp
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// A native function is compiled Go code that interpreted calls of a
+// function go to instead of its SSA code. Arguments are converted to
+// real Go values, the compiled function is called, and its results
+// are converted back. This is meant for speeding up hot spots among
+// small functions of the standard library, such as those listed in
+// NativePackages.
+//
+// Only parameters and results of basic types (numbers, strings,
+// booleans), slices and arrays of these, and, for results, error can
+// be converted. So functions taking or returning pointers, maps,
+// structs, channels or functions, which includes every method with a
+// pointer receiver, can't be native. Changes a native function makes
+// to the elements of a slice argument are copied back. Errors come
+// back as plain errors holding the message, so the interpreted
+// program can't type-assert them to, say, *strconv.NumError.
+
+// NativePackages are functions of the standard library that can be
+// made native with RegisterNativePackage, by package path.
+var NativePackages = map[string]map[string]interface{}{
+	"math": {
+		"Sqrt": math.Sqrt, "Pow": math.Pow, "Sin": math.Sin,
+		"Cos": math.Cos, "Tan": math.Tan, "Atan": math.Atan,
+		"Atan2": math.Atan2, "Floor": math.Floor, "Ceil": math.Ceil,
+		"Mod": math.Mod, "Hypot": math.Hypot, "Log10": math.Log10,
+		"Exp2": math.Exp2, "Trunc": math.Trunc,
+	},
+	"strconv": {
+		"Itoa": strconv.Itoa, "Atoi": strconv.Atoi,
+		"FormatInt": strconv.FormatInt, "ParseInt": strconv.ParseInt,
+		"FormatUint": strconv.FormatUint, "ParseUint": strconv.ParseUint,
+		"FormatFloat": strconv.FormatFloat, "ParseFloat": strconv.ParseFloat,
+		"ParseBool": strconv.ParseBool, "Quote": strconv.Quote,
+		"Unquote": strconv.Unquote,
+	},
+	"strings": {
+		"Contains": strings.Contains, "Count": strings.Count,
+		"EqualFold": strings.EqualFold, "Fields": strings.Fields,
+		"HasPrefix": strings.HasPrefix, "HasSuffix": strings.HasSuffix,
+		"Index": strings.Index, "Join": strings.Join,
+		"LastIndex": strings.LastIndex, "Repeat": strings.Repeat,
+		"Replace": strings.Replace, "Split": strings.Split,
+		"SplitN": strings.SplitN, "Title": strings.Title,
+		"ToLower": strings.ToLower, "ToUpper": strings.ToUpper,
+		"Trim": strings.Trim, "TrimLeft": strings.TrimLeft,
+		"TrimRight": strings.TrimRight, "TrimSpace": strings.TrimSpace,
+	},
+	"unicode": {
+		"IsDigit": unicode.IsDigit, "IsLetter": unicode.IsLetter,
+		"IsLower": unicode.IsLower, "IsSpace": unicode.IsSpace,
+		"IsUpper": unicode.IsUpper, "ToLower": unicode.ToLower,
+		"ToUpper": unicode.ToUpper,
+	},
+	"unicode/utf8": {
+		"RuneCountInString": utf8.RuneCountInString,
+		"RuneLen": utf8.RuneLen, "ValidString": utf8.ValidString,
+		"EncodeRune": utf8.EncodeRune,
+		"DecodeRuneInString": utf8.DecodeRuneInString,
+	},
+}
+
+// natives are the names of the functions made native.
+var natives = make(map[string]bool)
+
+// IsNative is true if calls of the function named name, as given by
+// Function.String(), go to compiled code.
+func IsNative(name string) bool { return natives[name] }
+
+// goErrorType is the Go type error, which native functions can return.
+var goErrorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// nativeType checks that values of type t can be passed to and from
+// native functions.
+func nativeType(t reflect.Type, result bool) error {
+	switch t.Kind() {
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16,
+		reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8,
+		reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Complex64,
+		reflect.Complex128, reflect.String:
+		return nil
+	case reflect.Slice, reflect.Array:
+		return nativeType(t.Elem(), result)
+	case reflect.Interface:
+		if result && t == goErrorType {
+			return nil
+		}
+	}
+	return fmt.Errorf("can't pass values of type %s to native code", t)
+}
+
+// RegisterNative makes interpreted calls of the function named name,
+// e.g. "strings.Index" or "(time.Month).String", go to compiled Go
+// function fn. A method's receiver is fn's first parameter.
+func RegisterNative(name string, fn interface{}) error {
+	v := reflect.ValueOf(fn)
+	t := v.Type()
+	if t.Kind() != reflect.Func || t.IsVariadic() {
+		return fmt.Errorf("%s: native code must be a function taking a fixed number of arguments", name)
+	}
+	for i := 0; i < t.NumIn(); i++ {
+		if err := nativeType(t.In(i), false); err != nil {
+			return fmt.Errorf("%s: %s", name, err)
+		}
+	}
+	for i := 0; i < t.NumOut(); i++ {
+		if err := nativeType(t.Out(i), true); err != nil {
+			return fmt.Errorf("%s: %s", name, err)
+		}
+	}
+	externals[name] = func(fr *Frame, args []Value) Value {
+		return callNative(v, args)
+	}
+	natives[name] = true
+	return nil
+}
+
+// RegisterNativePackage makes the functions of package path listed in
+// NativePackages native.
+func RegisterNativePackage(path string) error {
+	fns, ok := NativePackages[path]
+	if !ok {
+		return fmt.Errorf("no native code for package %s", path)
+	}
+	for name, fn := range fns {
+		if err := RegisterNative(path+"."+name, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// callNative calls compiled function fn with interpreted arguments
+// args and returns its results as interpreted values.
+func callNative(fn reflect.Value, args []Value) Value {
+	t := fn.Type()
+	in := make([]reflect.Value, len(args))
+	for i, arg := range args {
+		in[i] = toNative(arg, t.In(i))
+	}
+	out := fn.Call(in)
+	// Copy back what was done to slices passed in.
+	for i, arg := range args {
+		if s, ok := arg.([]Value); ok {
+			for j := range s {
+				s[j] = fromNative(in[i].Index(j))
+			}
+		}
+	}
+	switch len(out) {
+	case 0:
+		return nil
+	case 1:
+		return fromNative(out[0])
+	}
+	results := make(tuple, len(out))
+	for i, v := range out {
+		results[i] = fromNative(v)
+	}
+	return results
+}
+
+// toNative converts interpreted value v to a Go value of type t.
+func toNative(v Value, t reflect.Type) reflect.Value {
+	switch t.Kind() {
+	case reflect.Slice:
+		vs, _ := v.([]Value)
+		if vs == nil {
+			return reflect.Zero(t)
+		}
+		s := reflect.MakeSlice(t, len(vs), len(vs))
+		for i, e := range vs {
+			s.Index(i).Set(toNative(e, t.Elem()))
+		}
+		return s
+	case reflect.Array:
+		a := reflect.New(t).Elem()
+		for i, e := range v.(array) {
+			a.Index(i).Set(toNative(e, t.Elem()))
+		}
+		return a
+	}
+	return reflect.ValueOf(v).Convert(t)
+}
+
+// basicTypes are the types interpreted values of each basic kind have.
+var basicTypes = map[reflect.Kind]reflect.Type{
+	reflect.Bool: reflect.TypeOf(false),
+	reflect.Int: reflect.TypeOf(int(0)),
+	reflect.Int8: reflect.TypeOf(int8(0)),
+	reflect.Int16: reflect.TypeOf(int16(0)),
+	reflect.Int32: reflect.TypeOf(int32(0)),
+	reflect.Int64: reflect.TypeOf(int64(0)),
+	reflect.Uint: reflect.TypeOf(uint(0)),
+	reflect.Uint8: reflect.TypeOf(uint8(0)),
+	reflect.Uint16: reflect.TypeOf(uint16(0)),
+	reflect.Uint32: reflect.TypeOf(uint32(0)),
+	reflect.Uint64: reflect.TypeOf(uint64(0)),
+	reflect.Uintptr: reflect.TypeOf(uintptr(0)),
+	reflect.Float32: reflect.TypeOf(float32(0)),
+	reflect.Float64: reflect.TypeOf(float64(0)),
+	reflect.Complex64: reflect.TypeOf(complex64(0)),
+	reflect.Complex128: reflect.TypeOf(complex128(0)),
+	reflect.String: reflect.TypeOf(""),
+}
+
+// fromNative converts Go value v to an interpreted value.
+func fromNative(v reflect.Value) Value {
+	switch v.Kind() {
+	case reflect.Slice:
+		if v.IsNil() {
+			return []Value(nil)
+		}
+		s := make([]Value, v.Len())
+		for i := range s {
+			s[i] = fromNative(v.Index(i))
+		}
+		return s
+	case reflect.Array:
+		a := make(array, v.Len())
+		for i := range a {
+			a[i] = fromNative(v.Index(i))
+		}
+		return a
+	case reflect.Interface:
+		// Only error results get here.
+		err, _ := v.Interface().(error)
+		return wrapError(err)
+	}
+	// Values of named types like time.Duration are held as their
+	// underlying type.
+	return v.Convert(basicTypes[v.Kind()]).Interface()
+}
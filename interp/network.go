@@ -0,0 +1,599 @@
+// Copyright 2015 Rocky Bernstein.
+// Standing between the interpreted program and the network
+
+package interp
+
+import (
+	"fmt"
+	"go/token"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rocky/go-types"
+	"github.com/rocky/ssa-interp"
+)
+
+// The program's net.Dial, net.DialTimeout, Dialer.Dial,
+// Dialer.DialContext and net.Listen don't reach the network
+// themselves; they go through a NetTransport, which decides what the
+// program may connect to. There are three of our own:
+//
+//   NetDeny      refuses every connection and listener; the default
+//   NetLoopback  allows connections to and listeners on loopback
+//                addresses only, over the real network
+//   a NetMock    connects the program to listeners it makes itself,
+//                and to handlers registered with Handle, in memory
+//
+// A debugger or test harness can supply its own. The connections,
+// listeners and addresses the program gets back are fake types of
+// our "reflect" package that implement net.Conn, net.Listener and
+// net.Addr; their methods call those of what the transport returned.
+// Other ways into the network, such as the lookup functions or
+// packet connections, are not intercepted and fail as the system
+// calls beneath them do.
+
+// A NetTransport makes the network connections and listeners of the
+// interpreted program.
+type NetTransport interface {
+	Dial(network, address string) (net.Conn, error)
+	Listen(network, address string) (net.Listener, error)
+}
+
+var netLock sync.Mutex
+var netTransport NetTransport = NetDeny
+
+// SetNetTransport has the program's connections and listeners made
+// by t.
+func SetNetTransport(t NetTransport) {
+	netLock.Lock()
+	defer netLock.Unlock()
+	netTransport = t
+}
+
+// CurrentNetTransport returns the transport the program's
+// connections and listeners are made by.
+func CurrentNetTransport() NetTransport {
+	netLock.Lock()
+	defer netLock.Unlock()
+	return netTransport
+}
+
+// NetDeny refuses every connection and listener.
+var NetDeny NetTransport = denyTransport{}
+
+type denyTransport struct{}
+
+func (denyTransport) Dial(network, address string) (net.Conn, error) {
+	return nil, fmt.Errorf("dial %s %s: network access denied", network, address)
+}
+
+func (denyTransport) Listen(network, address string) (net.Listener, error) {
+	return nil, fmt.Errorf("listen %s %s: network access denied", network, address)
+}
+
+// NetLoopback allows connections to, and listeners on, loopback
+// addresses only. A listener on an address without a host listens on
+// 127.0.0.1.
+var NetLoopback NetTransport = loopbackTransport{}
+
+type loopbackTransport struct{}
+
+func (loopbackTransport) Dial(network, address string) (net.Conn, error) {
+	if !isLoopback(address, false) {
+		return nil, fmt.Errorf("dial %s %s: only loopback addresses are allowed", network, address)
+	}
+	return net.Dial(network, address)
+}
+
+func (loopbackTransport) Listen(network, address string) (net.Listener, error) {
+	if host, port, err := net.SplitHostPort(address); err == nil && host == "" {
+		address = net.JoinHostPort("127.0.0.1", port)
+	}
+	if !isLoopback(address, true) {
+		return nil, fmt.Errorf("listen %s %s: only loopback addresses are allowed", network, address)
+	}
+	return net.Listen(network, address)
+}
+
+// isLoopback returns true if the host of address is localhost or a
+// loopback IP address, or, if any is set, no host at all.
+func isLoopback(address string, any bool) bool {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" || (any && host == "") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// A NetMock is an in-memory network. A connection is made to a
+// listener the program has on the address dialed, or else to the
+// handler registered for it with Handle. Hosts localhost, 127.0.0.1,
+// ::1 and no host at all are the same, and a listener on port 0 gets
+// ports numbered from 49152 up. Nothing leaves the process, so a
+// program sees the same network each run.
+type NetMock struct {
+	mu        sync.Mutex
+	listeners map[string]*mockListener
+	handlers  map[string]func(net.Conn)
+	nextPort  int
+}
+
+const mockFirstPort = 49152
+
+// NewNetMock returns an in-memory network with no listeners or
+// handlers.
+func NewNetMock() *NetMock {
+	return &NetMock{
+		listeners: make(map[string]*mockListener),
+		handlers:  make(map[string]func(net.Conn)),
+		nextPort:  mockFirstPort,
+	}
+}
+
+// Handle has connections to address served by serve, each on a
+// goroutine of its own, when the program has no listener there.
+func (m *NetMock) Handle(address string, serve func(net.Conn)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.handlers[mockAddress(address)] = serve
+}
+
+// Reset closes the program's listeners, for a new run of it. Handlers
+// stay.
+func (m *NetMock) Reset() {
+	m.mu.Lock()
+	listeners := m.listeners
+	m.listeners = make(map[string]*mockListener)
+	m.nextPort = mockFirstPort
+	m.mu.Unlock()
+	for _, l := range listeners {
+		l.Close()
+	}
+}
+
+func (m *NetMock) Dial(network, address string) (net.Conn, error) {
+	key := mockAddress(address)
+	m.mu.Lock()
+	l := m.listeners[key]
+	serve := m.handlers[key]
+	local := mockAddr{network, net.JoinHostPort("127.0.0.1", strconv.Itoa(m.nextPort))}
+	m.nextPort++
+	m.mu.Unlock()
+	remote := mockAddr{network, key}
+	client, server := net.Pipe()
+	switch {
+	case l != nil:
+		select {
+		case l.conns <- &mockConn{server, remote, local}:
+		case <-l.done:
+			return nil, fmt.Errorf("dial %s %s: connection refused", network, address)
+		}
+	case serve != nil:
+		go serve(&mockConn{server, remote, local})
+	default:
+		return nil, fmt.Errorf("dial %s %s: connection refused", network, address)
+	}
+	return &mockConn{client, local, remote}, nil
+}
+
+func (m *NetMock) Listen(network, address string) (net.Listener, error) {
+	key := mockAddress(address)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if host, port, _ := net.SplitHostPort(key); port == "0" {
+		key = net.JoinHostPort(host, strconv.Itoa(m.nextPort))
+		m.nextPort++
+	}
+	if m.listeners[key] != nil {
+		return nil, fmt.Errorf("listen %s %s: address already in use", network, address)
+	}
+	l := &mockListener{
+		mock:  m,
+		key:   key,
+		addr:  mockAddr{network, key},
+		conns: make(chan net.Conn),
+		done:  make(chan struct{}),
+	}
+	m.listeners[key] = l
+	return l, nil
+}
+
+// mockAddress returns address with its host put the one way NetMock
+// knows it by.
+func mockAddress(address string) string {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return address
+	}
+	switch host {
+	case "", "localhost", "127.0.0.1", "::1", "0.0.0.0", "::":
+		host = "127.0.0.1"
+	}
+	return net.JoinHostPort(host, port)
+}
+
+type mockAddr struct {
+	network, address string
+}
+
+func (a mockAddr) Network() string { return a.network }
+func (a mockAddr) String() string  { return a.address }
+
+// mockConn is one end of an in-memory connection.
+type mockConn struct {
+	net.Conn
+	local, remote net.Addr
+}
+
+func (c *mockConn) LocalAddr() net.Addr  { return c.local }
+func (c *mockConn) RemoteAddr() net.Addr { return c.remote }
+
+type mockListener struct {
+	mock  *NetMock
+	key   string
+	addr  net.Addr
+	conns chan net.Conn
+	done  chan struct{}
+	once  sync.Once
+}
+
+func (l *mockListener) Accept() (net.Conn, error) {
+	select {
+	case c := <-l.conns:
+		return c, nil
+	case <-l.done:
+		return nil, fmt.Errorf("accept %s %s: use of closed network connection", l.addr.Network(), l.key)
+	}
+}
+
+func (l *mockListener) Close() error {
+	l.once.Do(func() {
+		close(l.done)
+		l.mock.mu.Lock()
+		if l.mock.listeners[l.key] == l {
+			delete(l.mock.listeners, l.key)
+		}
+		l.mock.mu.Unlock()
+	})
+	return nil
+}
+
+func (l *mockListener) Addr() net.Addr { return l.addr }
+
+// The fake types of the program's connections, listeners and
+// addresses, and their method sets, for the run of a program that
+// imports net; netMethods is nil otherwise.
+var netConnType, netListenerType, netAddrType *types.Named
+var netMethods map[types.Type]methodSet
+
+// netReset makes the fake net types for the program of i, for a new
+// run of it, and readies the transport for it.
+func netReset(i *interpreter) {
+	if r, ok := CurrentNetTransport().(interface {
+		Reset()
+	}); ok {
+		r.Reset()
+	}
+	netMethods = nil
+	pkg := i.prog.ImportedPackage("net")
+	if pkg == nil {
+		return
+	}
+	netMethods = make(map[types.Type]methodSet)
+	netConnType = makeNetType(i, "netConn", pkg.Type("Conn").Type())
+	netListenerType = makeNetType(i, "netListener", pkg.Type("Listener").Type())
+	netAddrType = makeNetType(i, "netAddr", pkg.Type("Addr").Type())
+}
+
+// makeNetType makes fake type name, with the methods of interface
+// itype, and its method set.
+func makeNetType(i *interpreter, name string, itype types.Type) *types.Named {
+	t := makeNamedType(name, types.NewStruct(nil, nil))
+	recv := types.NewVar(token.NoPos, reflectTypesPackage, "", t)
+	ms := make(methodSet)
+	iface := itype.Underlying().(*types.Interface)
+	for j := 0; j < iface.NumMethods(); j++ {
+		m := iface.Method(j)
+		sig := m.Type().(*types.Signature)
+		sig = types.NewSignature(nil, recv, sig.Params(), sig.Results(), sig.Variadic())
+		t.AddMethod(types.NewFunc(token.NoPos, reflectTypesPackage, m.Name(), sig))
+		ms[m.Id()] = newMethod(i.reflectPackage, t, m.Name())
+	}
+	netMethods[t] = ms
+	return t
+}
+
+func init() {
+	for name, fn := range map[string]externalFn{
+		"net.Dial":                           ext۰net۰Dial,
+		"net.DialTimeout":                    ext۰net۰DialTimeout,
+		"(*net.Dialer).Dial":                 ext۰net۰Dialer۰Dial,
+		"(*net.Dialer).DialContext":          ext۰net۰Dialer۰DialContext,
+		"net.Listen":                         ext۰net۰Listen,
+		"(reflect.netConn).Read":             ext۰netConn۰Read,
+		"(reflect.netConn).Write":            ext۰netConn۰Write,
+		"(reflect.netConn).Close":            ext۰netConn۰Close,
+		"(reflect.netConn).LocalAddr":        ext۰netConn۰LocalAddr,
+		"(reflect.netConn).RemoteAddr":       ext۰netConn۰RemoteAddr,
+		"(reflect.netConn).SetDeadline":      ext۰netConn۰SetDeadline,
+		"(reflect.netConn).SetReadDeadline":  ext۰netConn۰SetReadDeadline,
+		"(reflect.netConn).SetWriteDeadline": ext۰netConn۰SetWriteDeadline,
+		"(reflect.netListener).Accept":       ext۰netListener۰Accept,
+		"(reflect.netListener).Close":        ext۰netListener۰Close,
+		"(reflect.netListener).Addr":         ext۰netListener۰Addr,
+		"(reflect.netAddr).Network":          ext۰netAddr۰Network,
+		"(reflect.netAddr).String":           ext۰netAddr۰String,
+	} {
+		externals[name] = fn
+	}
+}
+
+// netError returns err as an error of the program; io.EOF is the
+// program's own, so that it compares equal.
+func netError(fr *Frame, err error) Value {
+	if err == io.EOF {
+		if pkg := fr.i.prog.ImportedPackage("io"); pkg != nil {
+			if g, ok := fr.i.globals[pkg.Var("EOF")]; ok {
+				return *g
+			}
+		}
+	}
+	return wrapError(err)
+}
+
+// netDial has the transport dial address. It gives up after timeout,
+// if that isn't 0, and when the program's context.Context ctx, if it
+// isn't nil, is done; the error is then a timeout or the context's.
+func netDial(fr *Frame, network, address string, timeout time.Duration, ctx Value) Value {
+	var done chan Value
+	if ctx, ok := ctx.(iface); ok && ctx.t != nil {
+		done, _ = ctxCall(fr, ctx, "Done").(chan Value)
+	}
+	// Dialing can take a while; other goroutines run meanwhile.
+	setGoState(fr, GoIOWait)
+	if timeout <= 0 && done == nil {
+		c, err := CurrentNetTransport().Dial(network, address)
+		setGoState(fr, GoRunning)
+		if err != nil {
+			return tuple{iface{}, netError(fr, err)}
+		}
+		return tuple{iface{netConnType, c}, iface{}}
+	}
+	type dialResult struct {
+		c   net.Conn
+		err error
+	}
+	res := make(chan dialResult, 1)
+	go func() {
+		c, err := CurrentNetTransport().Dial(network, address)
+		res <- dialResult{c, err}
+	}()
+	// A connection made after we have given up is closed.
+	abandon := func() {
+		go func() {
+			if r := <-res; r.c != nil {
+				r.c.Close()
+			}
+		}()
+	}
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+	select {
+	case r := <-res:
+		setGoState(fr, GoRunning)
+		if r.err != nil {
+			return tuple{iface{}, netError(fr, r.err)}
+		}
+		return tuple{iface{netConnType, r.c}, iface{}}
+	case <-expired:
+		abandon()
+		setGoState(fr, GoRunning)
+		return tuple{iface{}, netError(fr,
+			fmt.Errorf("dial %s %s: i/o timeout", network, address))}
+	case <-done:
+		abandon()
+		setGoState(fr, GoRunning)
+		return tuple{iface{}, ctxCall(fr, ctx.(iface), "Err")}
+	}
+}
+
+// ctxCall calls method name, which has no arguments, of the
+// program's context.Context ctx.
+func ctxCall(fr *Frame, ctx iface, name string) Value {
+	pkg := fr.i.prog.ImportedPackage("context")
+	if pkg == nil {
+		return nil
+	}
+	itype := pkg.Type("Context").Type().Underlying().(*types.Interface)
+	for j := 0; j < itype.NumMethods(); j++ {
+		if m := itype.Method(j); m.Name() == name {
+			return call(fr.i, fr.goNum, fr, lookupMethod(fr.i, ctx.t, m), []Value{ctx.v})
+		}
+	}
+	return nil
+}
+
+// dialerTimeout returns how long the program's net.Dialer d gives a
+// dial, from its Timeout and Deadline, or 0 for no limit.
+func dialerTimeout(fr *Frame, d Value) time.Duration {
+	p, ok := d.(*Value)
+	if !ok || p == nil {
+		return 0
+	}
+	s, ok := (*p).(Structure)
+	if !ok {
+		return 0
+	}
+	st := fr.i.prog.ImportedPackage("net").Type("Dialer").Type().Underlying().(*types.Struct)
+	var timeout time.Duration
+	for j := 0; j < st.NumFields() && j < len(s.fields); j++ {
+		switch st.Field(j).Name() {
+		case "Timeout":
+			if t, ok := s.fields[j].(int64); ok && t > 0 &&
+				(timeout == 0 || time.Duration(t) < timeout) {
+				timeout = time.Duration(t)
+			}
+		case "Deadline":
+			if deadline := goTime(fr, s.fields[j]); !deadline.IsZero() {
+				left := deadline.Sub(time.Now())
+				if left <= 0 {
+					left = time.Nanosecond
+				}
+				if timeout == 0 || left < timeout {
+					timeout = left
+				}
+			}
+		}
+	}
+	return timeout
+}
+
+func ext۰net۰Dial(fr *Frame, args []Value) Value {
+	// func Dial(network, address string) (Conn, error)
+	return netDial(fr, args[0].(string), args[1].(string), 0, nil)
+}
+
+func ext۰net۰DialTimeout(fr *Frame, args []Value) Value {
+	// func DialTimeout(network, address string, timeout time.Duration) (Conn, error)
+	return netDial(fr, args[0].(string), args[1].(string),
+		time.Duration(args[2].(int64)), nil)
+}
+
+func ext۰net۰Dialer۰Dial(fr *Frame, args []Value) Value {
+	// func (d *Dialer) Dial(network, address string) (Conn, error)
+	return netDial(fr, args[1].(string), args[2].(string),
+		dialerTimeout(fr, args[0]), nil)
+}
+
+func ext۰net۰Dialer۰DialContext(fr *Frame, args []Value) Value {
+	// func (d *Dialer) DialContext(ctx context.Context, network, address string) (Conn, error)
+	return netDial(fr, args[2].(string), args[3].(string),
+		dialerTimeout(fr, args[0]), args[1])
+}
+
+func ext۰net۰Listen(fr *Frame, args []Value) Value {
+	// func Listen(network, address string) (Listener, error)
+	l, err := CurrentNetTransport().Listen(args[0].(string), args[1].(string))
+	if err != nil {
+		return tuple{iface{}, netError(fr, err)}
+	}
+	return tuple{iface{netListenerType, l}, iface{}}
+}
+
+func ext۰netConn۰Read(fr *Frame, args []Value) Value {
+	// func (c Conn) Read(b []byte) (n int, err error)
+	p := args[1].([]Value)
+	b := make([]byte, len(p))
+	setGoState(fr, GoIOWait)
+	n, err := args[0].(net.Conn).Read(b)
+	setGoState(fr, GoRunning)
+	for i := 0; i < n; i++ {
+		p[i] = b[i]
+	}
+	return tuple{n, netError(fr, err)}
+}
+
+func ext۰netConn۰Write(fr *Frame, args []Value) Value {
+	// func (c Conn) Write(b []byte) (n int, err error)
+	p := args[1].([]Value)
+	b := make([]byte, len(p))
+	for i := range b {
+		b[i] = p[i].(byte)
+	}
+	setGoState(fr, GoIOWait)
+	n, err := args[0].(net.Conn).Write(b)
+	setGoState(fr, GoRunning)
+	return tuple{n, netError(fr, err)}
+}
+
+func ext۰netConn۰Close(fr *Frame, args []Value) Value {
+	// func (c Conn) Close() error
+	return netError(fr, args[0].(net.Conn).Close())
+}
+
+func ext۰netConn۰LocalAddr(fr *Frame, args []Value) Value {
+	// func (c Conn) LocalAddr() Addr
+	return iface{netAddrType, args[0].(net.Conn).LocalAddr()}
+}
+
+func ext۰netConn۰RemoteAddr(fr *Frame, args []Value) Value {
+	// func (c Conn) RemoteAddr() Addr
+	return iface{netAddrType, args[0].(net.Conn).RemoteAddr()}
+}
+
+// goTime returns time.Time t of the program as a time.Time of ours.
+func goTime(fr *Frame, t Value) time.Time {
+	pkg := fr.i.prog.ImportedPackage("time")
+	timeType := pkg.Type("Time").Type()
+	if call(fr.i, fr.goNum, fr, fr.i.prog.LookupMethod(timeType, pkg.Object, "IsZero"), []Value{t}).(bool) {
+		return time.Time{}
+	}
+	ns := call(fr.i, fr.goNum, fr, fr.i.prog.LookupMethod(timeType, pkg.Object, "UnixNano"), []Value{t}).(int64)
+	return time.Unix(0, ns)
+}
+
+func ext۰netConn۰SetDeadline(fr *Frame, args []Value) Value {
+	// func (c Conn) SetDeadline(t time.Time) error
+	return netError(fr, args[0].(net.Conn).SetDeadline(goTime(fr, args[1])))
+}
+
+func ext۰netConn۰SetReadDeadline(fr *Frame, args []Value) Value {
+	// func (c Conn) SetReadDeadline(t time.Time) error
+	return netError(fr, args[0].(net.Conn).SetReadDeadline(goTime(fr, args[1])))
+}
+
+func ext۰netConn۰SetWriteDeadline(fr *Frame, args []Value) Value {
+	// func (c Conn) SetWriteDeadline(t time.Time) error
+	return netError(fr, args[0].(net.Conn).SetWriteDeadline(goTime(fr, args[1])))
+}
+
+func ext۰netListener۰Accept(fr *Frame, args []Value) Value {
+	// func (l Listener) Accept() (Conn, error)
+	setGoState(fr, GoIOWait)
+	c, err := args[0].(net.Listener).Accept()
+	setGoState(fr, GoRunning)
+	if err != nil {
+		return tuple{iface{}, netError(fr, err)}
+	}
+	return tuple{iface{netConnType, c}, iface{}}
+}
+
+func ext۰netListener۰Close(fr *Frame, args []Value) Value {
+	// func (l Listener) Close() error
+	return netError(fr, args[0].(net.Listener).Close())
+}
+
+func ext۰netListener۰Addr(fr *Frame, args []Value) Value {
+	// func (l Listener) Addr() Addr
+	return iface{netAddrType, args[0].(net.Listener).Addr()}
+}
+
+func ext۰netAddr۰Network(fr *Frame, args []Value) Value {
+	// func (a Addr) Network() string
+	return args[0].(net.Addr).Network()
+}
+
+func ext۰netAddr۰String(fr *Frame, args []Value) Value {
+	// func (a Addr) String() string
+	return args[0].(net.Addr).String()
+}
+
+// lookupNetMethod returns the method of fake net type typ for meth,
+// or nil if typ isn't one.
+func lookupNetMethod(typ types.Type, meth *types.Func) *ssa2.Function {
+	if ms, ok := netMethods[typ]; ok {
+		return ms[meth.Id()]
+	}
+	return nil
+}
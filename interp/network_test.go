@@ -0,0 +1,108 @@
+// Copyright 2015 Rocky Bernstein.
+// Tests of the network interposition layer
+
+package interp
+
+import (
+	"io"
+	"io/ioutil"
+	"net"
+	"testing"
+)
+
+func TestNetMockListener(t *testing.T) {
+	m := NewNetMock()
+	l, err := m.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer l.Close()
+	if got, want := l.Addr().String(), "127.0.0.1:49152"; got != want {
+		t.Errorf("listener address: got %s, want %s", got, want)
+	}
+	if _, err := m.Listen("tcp", "localhost:49152"); err == nil {
+		t.Errorf("second listener on the same address: want an error")
+	}
+	go func() {
+		c, err := l.Accept()
+		if err != nil {
+			return
+		}
+		io.Copy(c, c)
+		c.Close()
+	}()
+	c, err := m.Dial("tcp", "localhost:49152")
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer c.Close()
+	if _, err := c.Write([]byte("hello")); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	buf := make([]byte, 5)
+	if _, err := io.ReadFull(c, buf); err != nil || string(buf) != "hello" {
+		t.Errorf("echo: got %q, %v; want \"hello\"", buf, err)
+	}
+}
+
+func TestNetMockHandle(t *testing.T) {
+	m := NewNetMock()
+	m.Handle("example.com:80", func(c net.Conn) {
+		c.Write([]byte("served"))
+		c.Close()
+	})
+	c, err := m.Dial("tcp", "example.com:80")
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	b, _ := ioutil.ReadAll(c)
+	if string(b) != "served" {
+		t.Errorf("handler: got %q, want \"served\"", b)
+	}
+	if _, err := m.Dial("tcp", "example.com:81"); err == nil {
+		t.Errorf("dial without a listener or handler: want an error")
+	}
+
+	// Reset closes the program's listeners but keeps handlers.
+	l, _ := m.Listen("tcp", ":8080")
+	m.Reset()
+	if _, err := l.Accept(); err == nil {
+		t.Errorf("accept after Reset: want an error")
+	}
+	if _, err := m.Dial("tcp", "example.com:80"); err != nil {
+		t.Errorf("dial handler after Reset: %v", err)
+	}
+}
+
+func TestNetDenyAndLoopback(t *testing.T) {
+	if _, err := NetDeny.Dial("tcp", "localhost:80"); err == nil {
+		t.Errorf("NetDeny dial: want an error")
+	}
+	if _, err := NetDeny.Listen("tcp", ":0"); err == nil {
+		t.Errorf("NetDeny listen: want an error")
+	}
+	if _, err := NetLoopback.Dial("tcp", "example.com:80"); err == nil {
+		t.Errorf("NetLoopback dial of a remote host: want an error")
+	}
+	if _, err := NetLoopback.Listen("tcp", "0.0.0.0:0"); err == nil {
+		t.Errorf("NetLoopback listen on all addresses: want an error")
+	}
+}
+
+func TestNetValueIdentity(t *testing.T) {
+	m := NewNetMock()
+	l1, _ := m.Listen("tcp", ":0")
+	l2, _ := m.Listen("tcp", ":0")
+	defer l1.Close()
+	defer l2.Close()
+	if !equals(nil, l1, l1) || equals(nil, l1, l2) {
+		t.Errorf("listeners should be equal only to themselves")
+	}
+	if hash(nil, l1) != hash(nil, l1) {
+		t.Errorf("hash of a listener changed")
+	}
+	a, b := l1.Addr(), mockAddr{"tcp", "127.0.0.1:49152"}
+	if !equals(nil, a, b) || hash(nil, a) != hash(nil, b) {
+		t.Errorf("equal addresses should be equal and hash the same")
+	}
+}
@@ -29,6 +29,10 @@ func (p targetPanic) String() string {
 // If the target program calls exit, the interpreter panics with this type.
 type exitPanic int
 
+// When the debugger restarts the program, the interpreter panics with
+// this type to abandon the current run.
+type restartPanic struct{}
+
 // constValue returns the value of the constant with the
 // dynamic type tag appropriate for c.Type().
 func constValue(c *ssa2.Const) Value {
@@ -935,6 +939,9 @@ func write(fd int, b []byte) (int, error) {
 		CapturedOutput.Write(b) // ignore errors
 		capturedOutputMu.Unlock()
 	}
+	if n, err, handled := programWrite(fd, b); handled {
+		return n, err
+	}
 	return syswrite(fd, b)
 }
 
@@ -947,16 +954,48 @@ func callBuiltin(caller *Frame, fn *ssa2.Builtin, args []Value) Value {
 		if len(args) == 1 {
 			return args[0]
 		}
-		if s, ok := args[1].(string); ok {
+		arg0 := args[0].([]Value)
+		s, isString := args[1].(string)
+		n := len(s)
+		if !isString {
+			n = len(args[1].([]Value))
+		}
+		// If a new backing array is needed, see that it fits before
+		// making it.
+		grow := len(arg0)+n > cap(arg0) && heapOn()
+		it := i
+		var tElt types.Type
+		if grow {
+			if caller != nil {
+				it = caller.i
+			}
+			tSlice := fn.Type().(*types.Signature).Params().At(0).Type()
+			tElt = tSlice.Underlying().(*types.Slice).Elem()
+			if caller != nil {
+				heapCheck(caller, caller.block.Instrs[caller.pc].Pos(),
+					heapSize(it, tElt, heapGrowCap(cap(arg0), len(arg0)+n)))
+			}
+		}
+		var result []Value
+		if isString {
 			// append([]byte, ...string) []byte
-			arg0 := args[0].([]Value)
+			result = arg0
 			for i := 0; i < len(s); i++ {
-				arg0 = append(arg0, s[i])
+				result = append(result, s[i])
+			}
+		} else {
+			// append([]T, ...[]T) []T
+			result = append(arg0, args[1].([]Value)...)
+		}
+		if grow && cap(result) != cap(arg0) {
+			heapTrackSlice(it, result, tElt)
+		}
+		if cap(result) != cap(arg0) && caller != nil && raceOn(caller) {
+			for i := range result {
+				raceNew(&result[i])
 			}
-			return arg0
 		}
-		// append([]T, ...[]T) []T
-		return append(args[0].([]Value), args[1].([]Value)...)
+		return result
 
 	case "copy": // copy([]T, []T) int or copy([]byte, string) int
 		src := args[1]
@@ -967,10 +1006,19 @@ func callBuiltin(caller *Frame, fn *ssa2.Builtin, args []Value) Value {
 		return copy(args[0].([]Value), src.([]Value))
 
 	case "close": // close(chan T)
-		close(args[0].(chan Value))
+		ch := args[0].(chan Value)
+		if anyChanCatches() && caller != nil {
+			checkChanCatch(caller, &caller.block.Instrs[caller.pc], ch,
+				CHAN_CLOSE, nil)
+		}
+		if caller != nil && raceOn(caller) {
+			raceRelease(caller.goNum, ch)
+		}
+		close(ch)
 		return nil
 
 	case "delete": // delete(map[K]Value, K)
+		n := mapLen(args[0])
 		switch m := args[0].(type) {
 		case map[Value]Value:
 			delete(m, args[1])
@@ -979,6 +1027,14 @@ func callBuiltin(caller *Frame, fn *ssa2.Builtin, args []Value) Value {
 		default:
 			panic(fmt.Sprintf("illegal map type: %T", m))
 		}
+		if mapLen(args[0]) < n && heapOn() {
+			it := i
+			if caller != nil {
+				it = caller.i
+			}
+			tMap := fn.Type().(*types.Signature).Params().At(0).Type()
+			heapMapFree(heapMapEntrySize(it, tMap.Underlying().(*types.Map)))
+		}
 		return nil
 
 	case "print", "println": // print(any, ...)
@@ -1063,7 +1119,7 @@ func callBuiltin(caller *Frame, fn *ssa2.Builtin, args []Value) Value {
 	case "panic":
 		// ssa2.Panic handles most cases; this is only for "go
 		// panic" or "defer panic".
-		caller.sourcePanic(toString(args[0]))
+		caller.sourcePanicValue(args[0], toString(args[0]))
 
 	case "recover":
 		return doRecover(caller)
@@ -1,6 +1,7 @@
 package interp
 
 import (
+	"fmt"
 	"go/token"
 	"github.com/rocky/go-types"
 	"github.com/rocky/ssa-interp"
@@ -14,3 +15,141 @@ func Binop(op token.Token, t types.Type, x, y Value) Value {
 func Unop(instr *ssa2.UnOp, x Value) Value {
 	return unop(instr, x)
 }
+func ConstValue(c *ssa2.Const) Value {
+	return constValue(c)
+}
+func Zero(t types.Type) Value {
+	return zero(t)
+}
+
+// MapLookup returns m[key] for an interpreter map value m, and
+// whether key was found.
+func MapLookup(m Value, key Value) (Value, bool) {
+	switch m := m.(type) {
+	case map[Value]Value:
+		v, ok := m[key]
+		return v, ok
+	case *hashmap:
+		if k, ok := key.(hashable); ok {
+			v := m.lookup(k)
+			return v, v != nil
+		}
+	}
+	return nil, false
+}
+func Conv(t_dst, t_src types.Type, x Value) Value {
+	return conv(t_dst, t_src, x)
+}
+
+// SeqIndex returns element i of an interpreter array, slice, or
+// string value x.
+func SeqIndex(x Value, i int) (Value, error) {
+	n := -1
+	switch x := x.(type) {
+	case array:
+		if n = len(x); i >= 0 && i < n {
+			return x[i], nil
+		}
+	case []Value:
+		if n = len(x); i >= 0 && i < n {
+			return x[i], nil
+		}
+	case string:
+		if n = len(x); i >= 0 && i < n {
+			return x[i], nil
+		}
+	default:
+		return nil, fmt.Errorf("can't index %T", x)
+	}
+	return nil, fmt.Errorf("index %d out of range [0:%d]", i, n)
+}
+
+// Len returns len(x) for interpreter value x. ok is false if x is
+// not something that has a length.
+func Len(x Value) (n int, ok bool) {
+	switch x := x.(type) {
+	case string:
+		return len(x), true
+	case array:
+		return len(x), true
+	case []Value:
+		return len(x), true
+	case map[Value]Value:
+		return len(x), true
+	case *hashmap:
+		return x.len(), true
+	case chan Value:
+		return len(x), true
+	}
+	return 0, false
+}
+
+// Cap returns cap(x) for interpreter value x. ok is false if x is
+// not something that has a capacity.
+func Cap(x Value) (n int, ok bool) {
+	switch x := x.(type) {
+	case array:
+		return cap(x), true
+	case []Value:
+		return cap(x), true
+	case chan Value:
+		return cap(x), true
+	}
+	return 0, false
+}
+
+// IfaceValue returns the dynamic type and value of interface value
+// x. ok is false if x is not a non-nil interface value.
+func IfaceValue(x Value) (t types.Type, v Value, ok bool) {
+	if x, ok := x.(iface); ok && x.t != nil {
+		return x.t, x.v, true
+	}
+	return nil, nil, false
+}
+
+// MakeInterface returns value v of concrete type t as an interface
+// value.
+func MakeInterface(t types.Type, v Value) Value {
+	return iface{t, v}
+}
+
+// CopyVal returns a copy of v, the way a store of v into a variable
+// copies it.
+func CopyVal(v Value) Value {
+	return copyVal(v)
+}
+
+// FieldAddr returns the address of field i of struct value s.
+func FieldAddr(s Value, i int) (*Value, error) {
+	st, ok := s.(Structure)
+	if !ok {
+		return nil, fmt.Errorf("%T is not a struct", s)
+	}
+	if i < 0 || i >= len(st.fields) {
+		return nil, fmt.Errorf("field %d out of range [0:%d]", i, len(st.fields))
+	}
+	return &st.fields[i], nil
+}
+
+// MapUpdate sets m[key] to v for an interpreter map value m.
+func MapUpdate(m Value, key Value, v Value) error {
+	switch m := m.(type) {
+	case map[Value]Value:
+		if m == nil {
+			return fmt.Errorf("assignment to entry in nil map")
+		}
+		m[key] = v
+		return nil
+	case *hashmap:
+		if m == nil {
+			return fmt.Errorf("assignment to entry in nil map")
+		}
+		k, ok := key.(hashable)
+		if !ok {
+			return fmt.Errorf("%T is not a hashable map key", key)
+		}
+		m.insert(k, v)
+		return nil
+	}
+	return fmt.Errorf("%T is not a map", m)
+}
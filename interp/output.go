@@ -0,0 +1,130 @@
+// Copyright 2015 Rocky Bernstein.
+// Where the interpreted program's standard output and error go
+
+package interp
+
+import (
+	"io"
+	"os"
+	"sync"
+)
+
+// What the program writes to standard output and standard error, with
+// print and println or through package os, normally goes to ours. A
+// debugger can send either elsewhere, say to a file or a buffer, so
+// that it isn't mixed up with its own messages. When it does go to
+// ours, we note whether a line was left unfinished, so that the
+// debugger can end it before saying anything itself. What we write on
+// the program's behalf, like the traceback of a panic that ends it,
+// and what the processes it starts write go there too.
+
+// programOutputs are the writers standard output (1) and standard
+// error (2) of the program go to, or nil for ours. outputLock guards
+// them.
+var programOutputs [3]io.Writer
+var outputLock sync.Mutex
+
+// outputMidLine is set when the program's last write to our standard
+// output or error didn't end a line.
+var outputMidLine bool
+
+// SetProgramOutput sends what the program writes to file descriptor
+// fd, 1 for standard output or 2 for standard error, to w. A nil w
+// sends it to ours again.
+func SetProgramOutput(fd int, w io.Writer) {
+	if fd != 1 && fd != 2 {
+		return
+	}
+	outputLock.Lock()
+	defer outputLock.Unlock()
+	programOutputs[fd] = w
+}
+
+// ProgramOutput returns the writer what the program writes to file
+// descriptor fd goes to, or nil if it goes to ours.
+func ProgramOutput(fd int) io.Writer {
+	if fd != 1 && fd != 2 {
+		return nil
+	}
+	outputLock.Lock()
+	defer outputLock.Unlock()
+	return programOutputs[fd]
+}
+
+// EndProgramLine returns true if the program's last output to our
+// standard output or error left a line unfinished, and forgets that
+// it did.
+func EndProgramLine() bool {
+	outputLock.Lock()
+	defer outputLock.Unlock()
+	midLine := outputMidLine
+	outputMidLine = false
+	return midLine
+}
+
+// programWrite writes b, which the program writes to file descriptor
+// fd, wherever that is to go. handled is false if fd isn't standard
+// output or error sent elsewhere, and so is ours to write to.
+func programWrite(fd int, b []byte) (n int, err error, handled bool) {
+	if w := ProgramOutput(fd); w != nil {
+		n, err = w.Write(b)
+		return n, err, true
+	}
+	if (fd == 1 || fd == 2) && len(b) > 0 {
+		outputLock.Lock()
+		outputMidLine = b[len(b)-1] != '\n'
+		outputLock.Unlock()
+	}
+	return 0, nil, false
+}
+
+// programFile is standard output (1) or error (2) of the program, for
+// what is written to it on the program's behalf. It goes where the
+// program's own writes do, CapturedOutput included.
+type programFile int
+
+func (fd programFile) Write(b []byte) (int, error) {
+	return write(int(fd), b)
+}
+
+// programStderr is the program's standard error.
+var programStderr io.Writer = programFile(2)
+
+// programChildFiles has a process the program starts with files, the
+// file descriptors it is to have, write its standard output and error
+// where the program's go. What goes to a writer other than a file
+// goes through a pipe copied to it. done closes our end of the pipes
+// once the process is started.
+func programChildFiles(files []uintptr) (done func(), err error) {
+	var ours []*os.File
+	done = func() {
+		for _, f := range ours {
+			f.Close()
+		}
+	}
+	for k, fd := range files {
+		if fd != 1 && fd != 2 {
+			continue
+		}
+		w := ProgramOutput(int(fd))
+		if w == nil {
+			continue
+		}
+		if f, ok := w.(*os.File); ok {
+			files[k] = f.Fd()
+			continue
+		}
+		r, pw, err := os.Pipe()
+		if err != nil {
+			done()
+			return nil, err
+		}
+		files[k] = pw.Fd()
+		ours = append(ours, pw)
+		go func() {
+			io.Copy(w, r)
+			r.Close()
+		}()
+	}
+	return done, nil
+}
@@ -0,0 +1,64 @@
+// Copyright 2015 Rocky Bernstein.
+// Tests of sending the program's output elsewhere
+
+package interp
+
+import (
+	"bytes"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// lockedBuffer is a bytes.Buffer safe to write from more than one
+// goroutine.
+type lockedBuffer struct {
+	sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.Lock()
+	defer b.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.Lock()
+	defer b.Unlock()
+	return b.buf.String()
+}
+
+func TestProgramStderr(t *testing.T) {
+	defer SetProgramOutput(2, nil)
+	var buf lockedBuffer
+	SetProgramOutput(2, &buf)
+	programStderr.Write([]byte("panic: boom\n"))
+	if got := buf.String(); got != "panic: boom\n" {
+		t.Errorf("got %q, want \"panic: boom\\n\"", got)
+	}
+}
+
+func TestProgramChildFiles(t *testing.T) {
+	defer SetProgramOutput(1, nil)
+	var buf lockedBuffer
+	SetProgramOutput(1, &buf)
+	files := []uintptr{0, 1, 2}
+	done, err := programChildFiles(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if files[0] != 0 || files[1] == 1 || files[2] != 2 {
+		t.Fatalf("only standard output should be replaced: got %v", files)
+	}
+	// Write as the child would, then let the pipe's end go.
+	syscall.Write(int(files[1]), []byte("hello\n"))
+	done()
+	for k := 0; k < 100 && buf.String() == ""; k++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := buf.String(); got != "hello\n" {
+		t.Errorf("got %q, want \"hello\\n\"", got)
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright 2015 Rocky Bernstein.
+// Running the goroutines of the interpreted program in parallel
+
+package interp
+
+import (
+	"os"
+	"runtime"
+	"sync"
+)
+
+// Goroutines of the interpreted program are Go goroutines, so they
+// run in parallel on as many OS threads as GOMAXPROCS allows: by
+// default one per CPU, or what the GOMAXPROCS environment variable
+// says, or what the program itself asks for with runtime.GOMAXPROCS.
+// CPU-bound concurrent programs then behave, and scale, much as they
+// would compiled.
+//
+// Interpreter state that goroutines share is locked: the locks here,
+// and schedLock, raceLock, recordLock and so on next to what they
+// guard.
+//
+// With the SerialExecution mode, goroutines run on a single OS
+// thread. They still interleave, but never run at the same moment,
+// which can make a misbehaving program easier to follow in the
+// debugger. For goroutines that take turns in a set order, see
+// Scheduler.
+
+// atomicLock makes the operations of sync/atomic atomic.
+var atomicLock sync.Mutex
+
+// pcLock guards PCMapping and pcNumbers, which runtime.Caller,
+// runtime.Callers and runtime.Func.Entry add to.
+var pcLock sync.Mutex
+
+// breakLock guards what the debugger sets up to stop on while
+// goroutines run: watched, mapWatches, caughtChans and
+// externalBreak.
+var breakLock sync.RWMutex
+
+// startProcs is GOMAXPROCS as it was when we started.
+var startProcs = runtime.GOMAXPROCS(0)
+
+// parallelReset sets how many OS threads goroutines can run on for a
+// new run of the program in mode, undoing what an earlier run set.
+func parallelReset(mode Mode) {
+	n := startProcs
+	switch {
+	case mode&SerialExecution != 0:
+		n = 1
+	case os.Getenv("GOMAXPROCS") == "":
+		n = runtime.NumCPU()
+	}
+	runtime.GOMAXPROCS(n)
+}
@@ -0,0 +1,44 @@
+// Copyright 2015 Rocky Bernstein.
+// Custom printers for values of named types
+
+package interp
+
+import (
+	"sort"
+)
+
+// A PrinterFunc returns how value v of the type it was registered for
+// should be shown.
+type PrinterFunc func(v Value) string
+
+// printers are the custom printers, keyed by the name of the type,
+// such as "time.Time".
+var printers = make(map[string]PrinterFunc)
+
+// RegisterPrinter arranges for values of the named type typeName,
+// e.g. "main.ID", to be shown using printer instead of the generic
+// formatting. A printer already registered for the type is replaced.
+func RegisterPrinter(typeName string, printer PrinterFunc) {
+	printers[typeName] = printer
+}
+
+// UnregisterPrinter removes the printer for type typeName. It
+// returns false if there wasn't one.
+func UnregisterPrinter(typeName string) bool {
+	if printers[typeName] == nil {
+		return false
+	}
+	delete(printers, typeName)
+	return true
+}
+
+// Printers returns the names of the types that have printers
+// registered, sorted.
+func Printers() []string {
+	names := make([]string, 0, len(printers))
+	for name := range printers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,46 @@
+// Copyright 2015 Rocky Bernstein.
+// Keeping track of the processes the interpreted program starts
+
+package interp
+
+import "sync"
+
+// A Process is a process the interpreted program has started, say
+// with os/exec.
+type Process struct {
+	Pid    int
+	Args   []string
+	Dir    string
+	Exited bool
+	Status int // exit status, once Exited is set
+}
+
+var processLock sync.Mutex
+var processes []*Process
+
+// Processes returns the processes the program has started, in the
+// order they were started.
+func Processes() []*Process {
+	processLock.Lock()
+	defer processLock.Unlock()
+	return append([]*Process(nil), processes...)
+}
+
+// processStarted records that the program started process pid
+// running args in directory dir.
+func processStarted(pid int, args []string, dir string) {
+	processLock.Lock()
+	defer processLock.Unlock()
+	processes = append(processes, &Process{Pid: pid, Args: args, Dir: dir})
+}
+
+// processExited records that process pid has finished with status.
+func processExited(pid int, status int) {
+	processLock.Lock()
+	defer processLock.Unlock()
+	for _, p := range processes {
+		if p.Pid == pid && !p.Exited {
+			p.Exited, p.Status = true, status
+		}
+	}
+}
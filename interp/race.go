@@ -0,0 +1,286 @@
+// Copyright 2015 Rocky Bernstein.
+// Finding data races in the interpreted program
+
+package interp
+
+import (
+	"fmt"
+	"go/token"
+	"sync"
+	"unsafe"
+
+	"github.com/rocky/ssa-interp"
+)
+
+// With the RaceDetect mode, each load and store of a variable, struct
+// field or array or slice element is checked against the last write
+// and the reads since by other goroutines. If no happens-before
+// relation orders the two accesses, that is a data race, which is
+// reported on standard error with the stacks of both accesses.
+//
+// Happens-before is tracked with vector clocks. A go statement orders
+// what its goroutine did before it with what the new goroutine does;
+// a channel send or close orders what came before it with what comes
+// after the receive; and sync/atomic operations, which sync.Mutex,
+// sync.WaitGroup and so on are built from, order what comes before
+// one with what comes after a later one on the same address. Accesses
+// made through maps aren't checked.
+//
+// A load or store of a whole struct or array is an access to each of
+// its fields or elements, so that it is checked against accesses to
+// them alone. The accesses to a variable are kept by its address as a
+// number, which doesn't keep it from being collected. When a variable
+// is made, by new, make or append, or by storing a struct or array
+// into another variable, what was kept for whatever had its address
+// before is dropped.
+
+// A vclock is a vector clock: the clock of each goroutine, by
+// goroutine number, as last known.
+type vclock []uint64
+
+// join sets c to the later of each of the clocks in c and d.
+func (c vclock) join(d vclock) vclock {
+	for len(c) < len(d) {
+		c = append(c, 0)
+	}
+	for g, t := range d {
+		if t > c[g] {
+			c[g] = t
+		}
+	}
+	return c
+}
+
+func (c vclock) get(g int) uint64 {
+	if g < len(c) {
+		return c[g]
+	}
+	return 0
+}
+
+// A raceFrame is a function and position in the stack of an access.
+type raceFrame struct {
+	fn  *ssa2.Function
+	pos token.Pos
+}
+
+// raceStackDepth is the number of frames kept for each access.
+const raceStackDepth = 8
+
+// A raceAccess is a load or store: the goroutine making it, the
+// goroutine's clock at the time, and where it was made.
+type raceAccess struct {
+	goNum int
+	clock uint64
+	stack []raceFrame
+}
+
+// A raceShadow holds the last write to a variable and the reads of
+// it since, by goroutine.
+type raceShadow struct {
+	write *raceAccess
+	reads map[int]*raceAccess
+}
+
+// raceClocks are the vector clocks of the goroutines; raceSyncs those
+// of channels and, by number, the addresses of atomic operations;
+// raceVars the accesses to each variable by its address as a number. raceReported holds the pairs of
+// positions of the races reported, so each is reported once.
+var raceLock sync.Mutex
+var raceClocks []vclock
+var raceSyncs map[interface{}]vclock
+var raceVars map[uintptr]*raceShadow
+var raceReported map[[2]token.Pos]bool
+var raceCount int
+
+// RaceCount returns the number of data races reported.
+func RaceCount() int { return raceCount }
+
+// raceOn is true if data races are being looked for.
+func raceOn(fr *Frame) bool { return fr.i.Mode&RaceDetect != 0 }
+
+// raceReset starts afresh for a new run of the program.
+func raceReset() {
+	raceLock.Lock()
+	defer raceLock.Unlock()
+	raceClocks = []vclock{{1}}
+	raceSyncs = make(map[interface{}]vclock)
+	raceVars = make(map[uintptr]*raceShadow)
+	raceReported = make(map[[2]token.Pos]bool)
+	raceCount = 0
+}
+
+// raceClock returns the vector clock of goroutine g. raceLock is held.
+func raceClock(g int) vclock {
+	for len(raceClocks) <= g {
+		raceClocks = append(raceClocks, nil)
+	}
+	if raceClocks[g] == nil {
+		c := make(vclock, g+1)
+		c[g] = 1
+		raceClocks[g] = c
+	}
+	return raceClocks[g]
+}
+
+// raceTick advances the clock of goroutine g. raceLock is held.
+func raceTick(g int) {
+	raceClock(g)[g]++
+}
+
+// raceGo records that goroutine parent started goroutine child.
+func raceGo(parent int, child int) {
+	raceLock.Lock()
+	defer raceLock.Unlock()
+	c := raceClock(child)
+	raceClocks[child] = c.join(raceClock(parent))
+	raceTick(parent)
+}
+
+// raceRelease records that what goroutine g has done so far happens
+// before whatever acquires sync object obj next.
+func raceRelease(g int, obj interface{}) {
+	raceLock.Lock()
+	defer raceLock.Unlock()
+	raceSyncs[obj] = raceSyncs[obj].join(raceClock(g))
+	raceTick(g)
+}
+
+// raceAcquire records that what was released with sync object obj
+// happens before what goroutine g does from now on.
+func raceAcquire(g int, obj interface{}) {
+	raceLock.Lock()
+	defer raceLock.Unlock()
+	raceClocks[g] = raceClock(g).join(raceSyncs[obj])
+}
+
+// raceAtomic records an atomic operation by goroutine g on addr, which
+// both acquires and releases.
+func raceAtomic(fr *Frame, addr *Value) {
+	if fr != nil && raceOn(fr) {
+		key := uintptr(unsafe.Pointer(addr))
+		raceAcquire(fr.goNum, key)
+		raceRelease(fr.goNum, key)
+	}
+}
+
+// raceStack returns where the access made by instr in frame fr is.
+func raceStack(fr *Frame, instr ssa2.Instruction) []raceFrame {
+	pos := instr.Pos()
+	if pos == token.NoPos {
+		pos = fr.startP
+	}
+	stack := []raceFrame{{fr.fn, pos}}
+	for f := fr.caller; f != nil && len(stack) < raceStackDepth; f = f.caller {
+		stack = append(stack, raceFrame{f.fn, f.startP})
+	}
+	return stack
+}
+
+// raceParts calls f with addr and the address of each field or
+// element, however deep, of the struct or array at addr.
+func raceParts(addr *Value, f func(uintptr)) {
+	f(uintptr(unsafe.Pointer(addr)))
+	switch v := (*addr).(type) {
+	case Structure:
+		for i := range v.fields {
+			raceParts(&v.fields[i], f)
+		}
+	case array:
+		for i := range v {
+			raceParts(&v[i], f)
+		}
+	}
+}
+
+// raceRead checks a load from addr by instr in frame fr.
+func raceRead(fr *Frame, instr ssa2.Instruction, addr *Value) {
+	raceLock.Lock()
+	defer raceLock.Unlock()
+	g := fr.goNum
+	c := raceClock(g)
+	a := &raceAccess{g, c[g], raceStack(fr, instr)}
+	raceParts(addr, func(key uintptr) {
+		sh := raceVars[key]
+		if sh == nil {
+			sh = &raceShadow{reads: make(map[int]*raceAccess)}
+			raceVars[key] = sh
+		}
+		if w := sh.write; w != nil && w.goNum != g && w.clock > c.get(w.goNum) {
+			raceReport(fr, "Read", a, "write", w)
+		}
+		sh.reads[g] = a
+	})
+}
+
+// raceWrite checks a store to addr by instr in frame fr.
+func raceWrite(fr *Frame, instr ssa2.Instruction, addr *Value) {
+	raceLock.Lock()
+	defer raceLock.Unlock()
+	g := fr.goNum
+	c := raceClock(g)
+	a := &raceAccess{g, c[g], raceStack(fr, instr)}
+	raceParts(addr, func(key uintptr) {
+		sh := raceVars[key]
+		if sh == nil {
+			sh = &raceShadow{}
+			raceVars[key] = sh
+		}
+		if w := sh.write; w != nil && w.goNum != g && w.clock > c.get(w.goNum) {
+			raceReport(fr, "Write", a, "write", w)
+		}
+		for _, r := range sh.reads {
+			if r.goNum != g && r.clock > c.get(r.goNum) {
+				raceReport(fr, "Write", a, "read", r)
+			}
+		}
+		sh.write = a
+		sh.reads = make(map[int]*raceAccess)
+	})
+}
+
+// raceStored records the store to addr by instr in frame fr, which
+// raceWrite has checked, in the fields and elements of the struct or
+// array stored, which are new.
+func raceStored(fr *Frame, instr ssa2.Instruction, addr *Value) {
+	raceLock.Lock()
+	defer raceLock.Unlock()
+	g := fr.goNum
+	a := &raceAccess{g, raceClock(g)[g], raceStack(fr, instr)}
+	raceParts(addr, func(key uintptr) {
+		raceVars[key] = &raceShadow{write: a, reads: make(map[int]*raceAccess)}
+	})
+}
+
+// raceNew drops what was kept for the variable that had address
+// addr, and its parts, before the one just made there.
+func raceNew(addr *Value) {
+	raceLock.Lock()
+	defer raceLock.Unlock()
+	raceParts(addr, func(key uintptr) { delete(raceVars, key) })
+}
+
+// raceReport reports the race between access a, of kind what, and the
+// earlier access prev of kind prevWhat. raceLock is held.
+func raceReport(fr *Frame, what string, a *raceAccess, prevWhat string, prev *raceAccess) {
+	key := [2]token.Pos{a.stack[0].pos, prev.stack[0].pos}
+	if raceReported[key] {
+		return
+	}
+	raceReported[key] = true
+	raceCount++
+	fset := fr.fn.Prog.Fset
+	fmt.Fprintln(programStderr, "==================")
+	fmt.Fprintln(programStderr, "WARNING: DATA RACE")
+	fmt.Fprintf(programStderr, "%s by goroutine %d:\n", what, a.goNum)
+	printRaceStack(fset, a.stack)
+	fmt.Fprintf(programStderr, "\nPrevious %s by goroutine %d:\n", prevWhat, prev.goNum)
+	printRaceStack(fset, prev.stack)
+	fmt.Fprintln(programStderr, "==================")
+}
+
+func printRaceStack(fset *token.FileSet, stack []raceFrame) {
+	for _, f := range stack {
+		fmt.Fprintf(programStderr, "  %s\n      %s\n", f.fn, ssa2.FmtPos(fset, f.pos))
+	}
+}
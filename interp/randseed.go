@@ -0,0 +1,104 @@
+// Copyright 2015 Rocky Bernstein.
+// Seeding math/rand's default source the same way every run
+
+package interp
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/rocky/ssa-interp"
+)
+
+// A program using the top-level functions of math/rand can behave
+// differently each run, because it is seeded from the clock or
+// because Go seeds the default source at random. With a fixed seed,
+// the default source is seeded with it as soon as math/rand has been
+// initialized, before the packages using it are, and the program's
+// own calls of rand.Seed get it too, so that replaying a
+// program, as when the debugger restarts it, gives the same numbers.
+// Sources the program makes with rand.NewSource are left alone. When
+// the seed is applied, we say so on standard error, so that a run
+// can be reproduced.
+
+var randLock sync.Mutex
+var randFixed bool
+var randSeed int64
+
+// randSeedFn is math/rand.Seed in the program being run, or nil if
+// it doesn't use math/rand, and randInitFn is math/rand's package
+// initializer. randSeeded is set once the seed has been applied in
+// this run.
+var randSeedFn *ssa2.Function
+var randInitFn *ssa2.Function
+var randSeeded bool
+
+// SetRandSeed has math/rand's default source be seeded with seed.
+func SetRandSeed(seed int64) {
+	randLock.Lock()
+	defer randLock.Unlock()
+	randFixed, randSeed = true, seed
+}
+
+// SetRandGo has math/rand's default source be seeded as Go seeds it.
+func SetRandGo() {
+	randLock.Lock()
+	defer randLock.Unlock()
+	randFixed = false
+}
+
+// RandSeedChoice describes how math/rand's default source is seeded:
+// "go" or the seed.
+func RandSeedChoice() string {
+	randLock.Lock()
+	defer randLock.Unlock()
+	if randFixed {
+		return strconv.FormatInt(randSeed, 10)
+	}
+	return "go"
+}
+
+// fixedRandSeed returns the seed to use, if it is fixed.
+func fixedRandSeed() (seed int64, fixed bool) {
+	randLock.Lock()
+	defer randLock.Unlock()
+	return randSeed, randFixed
+}
+
+// randReset finds math/rand.Seed in the program of i, if it is
+// there, for a new run of it.
+func randReset(i *interpreter) {
+	randSeedFn, randInitFn, randSeeded = nil, nil, false
+	if pkg := i.prog.ImportedPackage("math/rand"); pkg != nil {
+		randSeedFn = pkg.Func("Seed")
+		randInitFn = pkg.Func("init")
+	}
+}
+
+// randSeedStart seeds math/rand's default source in the program of i,
+// if the seed is fixed and it hasn't been seeded yet in this run. It
+// is called in goroutine goNum, from frame caller, once math/rand's
+// initializer has returned, and again before main runs.
+func randSeedStart(i *interpreter, goNum int, caller *Frame) {
+	seed, fixed := fixedRandSeed()
+	if !fixed || randSeedFn == nil || randSeeded {
+		return
+	}
+	randSeeded = true
+	fmt.Fprintf(os.Stderr, "interp: math/rand seeded with %d\n", seed)
+	call(i, goNum, caller, randSeedFn, []Value{seed})
+}
+
+// randSeedArgs returns the arguments for a call of fn with args: the
+// fixed seed rather than the program's for math/rand.Seed.
+func randSeedArgs(fn *ssa2.Function, args []Value) []Value {
+	if fn != randSeedFn || fn == nil {
+		return args
+	}
+	if seed, fixed := fixedRandSeed(); fixed {
+		return []Value{seed}
+	}
+	return args
+}
@@ -0,0 +1,164 @@
+// Copyright 2015 Rocky Bernstein.
+
+// Execution recording. When recording is on, each statement boundary
+// reached is saved in a bounded history along with a copy of the
+// frame's parameters and local variables, so the debugger can look
+// back at where the program has been.
+
+package interp
+
+import (
+	"sync"
+
+	"github.com/rocky/ssa-interp"
+)
+
+// An ExecRecord is what we save at a statement boundary.
+type ExecRecord struct {
+	Fn     *ssa2.Function
+	Trace  *ssa2.Trace // Statement boundary reached
+	GoNum  int         // Goroutine that reached it
+	Depth  int         // Number of frames on the goroutine's stack
+	Params []Value     // Values of Fn.Params
+	Locals []Value     // Values of the variables of Fn.Locals
+
+	frame *Frame                // Frame that reached Trace
+	env   map[ssa2.Value]Value  // Its registers then
+}
+
+// RecordMax is the number of statements we keep. Older records are
+// dropped.
+var RecordMax = 1000
+
+var recording bool
+var records []*ExecRecord
+
+// recordLock serializes goroutines adding to records.
+var recordLock sync.Mutex
+
+// SetRecording turns execution recording on or off. Turning it on
+// clears any previous history.
+func SetRecording(on bool) {
+	recording = on
+	if on {
+		records = nil
+	}
+}
+
+// Recording returns true if execution recording is on.
+func Recording() bool {
+	return recording
+}
+
+// Records returns the recorded history, oldest first.
+func Records() []*ExecRecord {
+	return records
+}
+
+// record saves frame fr reaching statement boundary instr.
+func record(fr *Frame, instr *ssa2.Trace) {
+	r := &ExecRecord{
+		Fn    : fr.fn,
+		Trace : instr,
+		GoNum : fr.goNum,
+		Params: make([]Value, len(fr.fn.Params)),
+		Locals: make([]Value, len(fr.locals)),
+		frame : fr,
+		env   : make(map[ssa2.Value]Value, len(fr.env)),
+	}
+	for f := fr; f != nil; f = f.caller {
+		r.Depth++
+	}
+	// Copy values rather than addresses so that later stores
+	// don't change what was recorded.
+	for i, p := range fr.fn.Params {
+		r.Params[i] = recordVal(fr.env[p])
+	}
+	for i, v := range fr.locals {
+		r.Locals[i] = recordVal(v)
+	}
+	// Registers aren't stored into, only set again, so the values
+	// themselves can be shared.
+	for k, v := range fr.env {
+		r.env[k] = v
+	}
+	recordLock.Lock()
+	defer recordLock.Unlock()
+	if len(records) >= RecordMax {
+		records = records[1:]
+	}
+	records = append(records, r)
+}
+
+// recordVal returns a copy of v that later stores into v can't
+// change. Structs and arrays inside v are copied too; what is reached
+// through pointers, slices, maps and channels is shared.
+func recordVal(v Value) Value {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case Structure:
+		a := Structure{
+			fields    : make([]Value, len(v.fields)),
+			fieldnames: v.fieldnames,
+		}
+		for i, f := range v.fields {
+			a.fields[i] = recordVal(f)
+		}
+		return a
+	case array:
+		a := make(array, len(v))
+		for i, e := range v {
+			a[i] = recordVal(e)
+		}
+		return a
+	}
+	return copyVal(v)
+}
+
+// RecordInFrame returns true if record n was made by frame fr, so
+// that RestoreRecord can put fr back the way it was then.
+func RecordInFrame(fr *Frame, n int) bool {
+	recordLock.Lock()
+	defer recordLock.Unlock()
+	return n >= 0 && n < len(records) && records[n].frame == fr
+}
+
+// RestoreRecord puts frame fr, which made record n and is stopped in
+// the trace hook, back the way it was at record n: its parameters,
+// local variables and registers get the values they had then, and it
+// resumes at the start of the recorded statement. Records from n on
+// are dropped, since running on makes them again. Globals, and what
+// is reached through pointers, slices, maps and channels, are not put
+// back. It returns false if fr didn't make record n.
+func RestoreRecord(fr *Frame, n int) bool {
+	recordLock.Lock()
+	defer recordLock.Unlock()
+	if n < 0 || n >= len(records) || records[n].frame != fr {
+		return false
+	}
+	r := records[n]
+	records = records[:n]
+	for k, v := range r.env {
+		fr.env[k] = v
+	}
+	for i, p := range fr.fn.Params {
+		fr.env[p] = recordVal(r.Params[i])
+	}
+	for i, v := range r.Locals {
+		fr.locals[i] = recordVal(v)
+	}
+	b := r.Trace.Block()
+	for i, instr := range b.Instrs {
+		if instr == ssa2.Instruction(r.Trace) {
+			if b != fr.block {
+				fr.SetBlock(b)
+			}
+			// The interpreter loop does pc++ before running
+			// the next instruction.
+			fr.pc = i-1
+			break
+		}
+	}
+	return true
+}
@@ -23,10 +23,9 @@ func debug۰source(lines [][]byte, n int) []byte {
 
 
 func debug۰Function(fr *Frame, pc uintptr) []byte {
-	fnIndex := pc >> 16
-	fn := num2fnMap[fnIndex - 1]
-	if fn == nil {
+	place := pcLookup(pc)
+	if place == nil {
 		return []byte("??Unknown fn")
 	}
-	return []byte(fn.Name())
+	return []byte(place.fn.Name())
 }
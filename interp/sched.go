@@ -0,0 +1,245 @@
+// Copyright 2015 Rocky Bernstein.
+// Choosing which goroutine of the interpreted program runs
+
+package interp
+
+import (
+	"math/rand"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// A Scheduler decides which goroutine of the interpreted program runs
+// next. Normally goroutines are real Go goroutines and run whenever
+// the Go runtime lets them. With a Scheduler, only one runs at a
+// time: it runs until it blocks, say on a channel, finishes, or has
+// run SchedQuantum instructions, and then the Scheduler picks the
+// next one from those waiting to run. With the same input, a program
+// then runs the same way each time, which makes bugs that depend on
+// how goroutines interleave reproducible.
+//
+// The Go runtime still decides when a goroutine blocked on a channel
+// or sleeping can go on, so programs that depend on timing may not be
+// entirely reproducible.
+type Scheduler interface {
+	// Next picks the goroutine to run, by number, from runnable,
+	// which is sorted and not empty. cur is the goroutine that has
+	// just stopped running.
+	Next(cur int, runnable []int) int
+	// String describes the scheduler, in the form that "set
+	// scheduler" in the debugger accepts.
+	String() string
+}
+
+// SchedQuantum is the number of instructions a goroutine runs before
+// another one gets its turn.
+var SchedQuantum = 100
+
+// roundRobin is a Scheduler running goroutines in turn in order of
+// their numbers.
+type roundRobin struct{}
+
+// NewRoundRobin returns a Scheduler that runs goroutines in turn.
+func NewRoundRobin() Scheduler { return roundRobin{} }
+
+func (roundRobin) Next(cur int, runnable []int) int {
+	for _, g := range runnable {
+		if g > cur {
+			return g
+		}
+	}
+	return runnable[0]
+}
+
+func (roundRobin) String() string { return "round-robin" }
+
+// randomSched is a Scheduler picking the next goroutine at random,
+// for shaking out races.
+type randomSched struct {
+	seed int64
+	r    *rand.Rand
+}
+
+// NewRandomScheduler returns a Scheduler that picks the goroutine to
+// run at random. With the same seed the same choices are made.
+func NewRandomScheduler(seed int64) Scheduler {
+	return &randomSched{seed, rand.New(rand.NewSource(seed))}
+}
+
+func (s *randomSched) Next(cur int, runnable []int) int {
+	return runnable[s.r.Intn(len(runnable))]
+}
+
+func (s *randomSched) String() string {
+	return "random " + strconv.FormatInt(s.seed, 10)
+}
+
+// scheduler is the Scheduler in use, or nil if goroutines run as the
+// Go runtime sees fit. schedRunning is the goroutine allowed to run,
+// or -1 if none is; schedWaiting are the goroutines waiting for their
+// turn. schedTicks counts the instructions run by schedRunning in its
+// turn. schedLock guards them all. schedOn is 1 when there is a
+// scheduler, so that schedTick can tell there isn't one without
+// taking the lock.
+var scheduler Scheduler
+var schedOn int32
+var schedLock sync.Mutex
+var schedCond = sync.NewCond(&schedLock)
+var schedRunning = -1
+var schedWaiting = make(map[int]bool)
+var schedTicks int
+
+// SetScheduler starts scheduling goroutines with s, or stops
+// scheduling them if s is nil. It can be called while the program
+// runs.
+func SetScheduler(s Scheduler) {
+	schedLock.Lock()
+	defer schedLock.Unlock()
+	scheduler = s
+	if s == nil {
+		schedRunning = -1
+		atomic.StoreInt32(&schedOn, 0)
+	} else {
+		atomic.StoreInt32(&schedOn, 1)
+	}
+	schedCond.Broadcast()
+}
+
+// GetScheduler returns the Scheduler in use, or nil if there is none.
+func GetScheduler() Scheduler {
+	schedLock.Lock()
+	defer schedLock.Unlock()
+	return scheduler
+}
+
+// schedReset sets up scheduling for a new run of the program in mode,
+// with goroutine 0, main, running.
+func schedReset(mode Mode) {
+	switch {
+	case mode&RoundRobinSchedule != 0:
+		SetScheduler(NewRoundRobin())
+	case mode&RandomSchedule != 0:
+		SetScheduler(NewRandomScheduler(SchedSeed))
+	}
+	schedLock.Lock()
+	defer schedLock.Unlock()
+	schedWaiting = make(map[int]bool)
+	schedTicks = 0
+	schedRunning = -1
+	if scheduler != nil {
+		schedRunning = 0
+	}
+}
+
+// SchedSeed is the seed of the random scheduler used with
+// RandomSchedule.
+var SchedSeed int64 = 1
+
+// schedPick gives the turn to the goroutine the scheduler picks from
+// those waiting; cur has just had its turn. schedLock is held.
+func schedPick(cur int) {
+	schedRunning = -1
+	schedTicks = 0
+	if len(schedWaiting) > 0 {
+		runnable := make([]int, 0, len(schedWaiting))
+		for g := range schedWaiting {
+			runnable = append(runnable, g)
+		}
+		sort.Ints(runnable)
+		schedRunning = scheduler.Next(cur, runnable)
+	}
+	schedCond.Broadcast()
+}
+
+// schedWait waits for goroutine goNum's turn. schedLock is held.
+func schedWait(goNum int) {
+	schedWaiting[goNum] = true
+	if schedRunning < 0 {
+		schedPick(goNum)
+	}
+	for scheduler != nil && schedRunning != goNum {
+		schedCond.Wait()
+	}
+	delete(schedWaiting, goNum)
+}
+
+// schedSpawn registers goroutine goNum, which a go statement is
+// about to start, as waiting for its turn. This is done before the
+// goroutine is started, so that whether the scheduler can pick it
+// next doesn't depend on how soon the Go runtime gets it going.
+func schedSpawn(goNum int) {
+	schedLock.Lock()
+	defer schedLock.Unlock()
+	if scheduler != nil {
+		schedWaiting[goNum] = true
+	}
+}
+
+// schedAcquire waits until goroutine goNum, which is about to start or
+// has stopped being blocked, can run.
+func schedAcquire(goNum int) {
+	schedLock.Lock()
+	defer schedLock.Unlock()
+	if scheduler != nil && schedRunning != goNum {
+		schedWait(goNum)
+	}
+}
+
+// schedRelease ends the turn of goroutine goNum, which is about to
+// block or has finished.
+func schedRelease(goNum int) {
+	schedLock.Lock()
+	defer schedLock.Unlock()
+	if scheduler != nil && schedRunning == goNum {
+		schedPick(goNum)
+	}
+}
+
+// schedTick is called by goroutine goNum before each instruction it
+// runs. When its turn is up, another goroutine gets to run.
+func schedTick(goNum int) {
+	if atomic.LoadInt32(&schedOn) == 0 {
+		return
+	}
+	schedLock.Lock()
+	defer schedLock.Unlock()
+	if scheduler == nil {
+		return
+	}
+	if schedRunning != goNum {
+		// Scheduling was turned on while we were running.
+		schedWait(goNum)
+		return
+	}
+	schedTicks++
+	if schedTicks >= SchedQuantum {
+		schedYieldLocked(goNum)
+	}
+}
+
+// schedYield ends goroutine goNum's turn early, as runtime.Gosched
+// does.
+func schedYield(goNum int) {
+	if atomic.LoadInt32(&schedOn) == 0 {
+		return
+	}
+	schedLock.Lock()
+	defer schedLock.Unlock()
+	if scheduler != nil && schedRunning == goNum {
+		schedYieldLocked(goNum)
+	}
+}
+
+// schedYieldLocked lets the scheduler pick which goroutine runs next,
+// goNum included. schedLock is held.
+func schedYieldLocked(goNum int) {
+	if len(schedWaiting) == 0 {
+		schedTicks = 0
+		return
+	}
+	schedWaiting[goNum] = true
+	schedPick(goNum)
+	schedWait(goNum)
+}
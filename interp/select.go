@@ -0,0 +1,106 @@
+// Copyright 2015 Rocky Bernstein.
+// Choosing among the ready cases of a select statement
+
+package interp
+
+import (
+	"math/rand"
+	"reflect"
+	"strconv"
+	"sync"
+)
+
+// When more than one case of a select statement is ready, Go picks
+// one at random, so a program can take a different branch each time
+// it is run. Here the choice can instead be made in source order, or
+// pseudo-randomly from a seed, so that rerunning a program, say in
+// the debugger, takes the same branches every time.
+//
+// When no case is ready and the select blocks, the case that becomes
+// ready first is taken, as usual.
+
+// selectOrdered is set when the first ready case in source order is
+// taken. Otherwise, if selectRand is not nil, it picks the case.
+var selectOrdered bool
+var selectRand *rand.Rand
+var selectSeed int64
+var selectLock sync.Mutex
+
+// SetSelectRandom has the ready case a select takes be picked as Go
+// picks it.
+func SetSelectRandom() {
+	selectLock.Lock()
+	defer selectLock.Unlock()
+	selectOrdered, selectRand = false, nil
+}
+
+// SetSelectOrdered has a select take the first of its ready cases in
+// source order.
+func SetSelectOrdered() {
+	selectLock.Lock()
+	defer selectLock.Unlock()
+	selectOrdered, selectRand = true, nil
+}
+
+// SetSelectSeed has the ready case a select takes be picked
+// pseudo-randomly from seed, so that the same choices are made each
+// time the program is run with the same seed.
+func SetSelectSeed(seed int64) {
+	selectLock.Lock()
+	defer selectLock.Unlock()
+	selectOrdered, selectRand, selectSeed = false, rand.New(rand.NewSource(seed)), seed
+}
+
+// selectReset starts the pseudo-random choices over for a new run of
+// the program, so that it takes the same branches as the last.
+func selectReset() {
+	selectLock.Lock()
+	defer selectLock.Unlock()
+	if selectRand != nil {
+		selectRand = rand.New(rand.NewSource(selectSeed))
+	}
+}
+
+// SelectChoice describes how a select picks among its ready cases:
+// "random", "ordered" or the seed.
+func SelectChoice() string {
+	switch {
+	case selectOrdered:
+		return "ordered"
+	case selectRand != nil:
+		return strconv.FormatInt(selectSeed, 10)
+	}
+	return "random"
+}
+
+// selectReady takes a ready case of cases, in the order set with
+// SetSelectOrdered or SetSelectSeed, without blocking. ready is false
+// if no case is ready or Go's own choice is to be used.
+func selectReady(cases []reflect.SelectCase) (chosen int, recv reflect.Value, recvOk bool, ready bool) {
+	selectLock.Lock()
+	if !selectOrdered && selectRand == nil {
+		selectLock.Unlock()
+		return 0, recv, false, false
+	}
+	var order []int
+	for k, c := range cases {
+		if c.Dir != reflect.SelectDefault {
+			order = append(order, k)
+		}
+	}
+	if selectRand != nil {
+		for k := len(order) - 1; k > 0; k-- {
+			j := selectRand.Intn(k + 1)
+			order[k], order[j] = order[j], order[k]
+		}
+	}
+	selectLock.Unlock()
+	poll := []reflect.SelectCase{{}, {Dir: reflect.SelectDefault}}
+	for _, k := range order {
+		poll[0] = cases[k]
+		if i, recv, recvOk := reflect.Select(poll); i == 0 {
+			return k, recv, recvOk, true
+		}
+	}
+	return 0, recv, false, false
+}
@@ -0,0 +1,10 @@
+package main
+
+// A deadlock: main waits on a channel no one sends on. The
+// interpreter should notice and end the program as the runtime does.
+
+func main() {
+	ch := make(chan int)
+	<-ch
+	println("BUG: received from a channel no one sends on")
+}
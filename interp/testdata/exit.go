@@ -0,0 +1,12 @@
+package main
+
+// os.Exit, which should end the program with its status even though
+// it stops there first.
+
+import "os"
+
+func main() {
+	println("exiting")
+	os.Exit(3)
+	println("BUG: still running after os.Exit")
+}
@@ -0,0 +1,18 @@
+package main
+
+// A panic in a goroutine that isn't recovered from, which should end
+// the program with a traceback of the goroutine.
+
+func f(n int) {
+	panic("boom")
+}
+
+func main() {
+	done := make(chan bool)
+	go func() {
+		f(5)
+		done <- true
+	}()
+	<-done
+	println("BUG: still running after the panic")
+}
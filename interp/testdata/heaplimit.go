@@ -0,0 +1,13 @@
+package main
+
+// Running out of memory: with a heap limit of a megabyte, keeping
+// four megabytes of slices should stop the program.
+
+var keep [][]byte
+
+func main() {
+	for i := 0; i < 1000; i++ {
+		keep = append(keep, make([]byte, 4096))
+	}
+	println("BUG: went over the heap limit")
+}
@@ -0,0 +1,10 @@
+package main
+
+// A loop that never ends, which an instruction limit should end.
+
+func main() {
+	n := 0
+	for {
+		n++
+	}
+}
@@ -0,0 +1,18 @@
+package main
+
+// A data race, which the interpreter should report when run with
+// RaceDetect: main and the goroutine it starts both write x with
+// nothing to order the two writes.
+
+var x int
+
+func main() {
+	done := make(chan bool)
+	go func() {
+		x = 1
+		done <- true
+	}()
+	x = 2
+	<-done
+	println(x)
+}
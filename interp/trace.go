@@ -17,11 +17,12 @@ const (
 	TRACE_STEP_OVER
 )
 
+// gocall guards the interpreter's goTops and what is in them.
 var gocall sync.Mutex
 
 type GoreState struct {
 	Fr     *Frame
-	state  int  // running, finished, etc. Fill this in later
+	state  GoState  // running, finished, etc.
 }
 
 // TraceMode is a bitmask of options influencing the tracing.
@@ -54,7 +55,7 @@ var TraceHook TraceHookFunc
 // This gets called for special trace events if tracing is on
 // FIXME: Move elsewhere
 func DefaultTraceHook(fr *Frame, instr *ssa2.Instruction, event ssa2.TraceEvent) {
-	if ! fr.i.TraceEventMask[event] { return }
+	if !fr.i.TraceEventOn(event) { return }
 	fset := fr.Fn().Prog.Fset
 	startP := fset.Position(fr.StartP())
 	endP   := fset.Position(fr.EndP())
@@ -71,15 +72,83 @@ func NullTraceHook(fr *Frame, instr *ssa2.Instruction, event ssa2.TraceEvent) {
 	return
 }
 
+// stepDefers is set when stepping over a return should stop in the
+// deferred functions it runs. stepGoroutines is set when stepping
+// should stop in goroutines started by a go statement.
+var stepDefers bool
+var stepGoroutines bool
+
+// SetStepDefers sets whether "next" stops inside deferred calls.
+func SetStepDefers(on bool) { stepDefers = on }
+
+// StepDefers returns whether "next" stops inside deferred calls.
+func StepDefers() bool { return stepDefers }
+
+// SetStepGoroutines sets whether stepping stops in goroutines that
+// are started by a go statement.
+func SetStepGoroutines(on bool) { stepGoroutines = on }
+
+// StepGoroutines returns whether stepping stops in goroutines that
+// are started by a go statement.
+func StepGoroutines() bool { return stepGoroutines }
+
+// StepSkipFunc returns true if stepping shouldn't descend into a call
+// of fn made by caller.
+type StepSkipFunc func(caller, fn *ssa2.Function) bool
+
+// stepSkip, if not nil, is consulted when stepping into a call.
+var stepSkip StepSkipFunc
+
+// SetStepSkip sets the function used to decide whether a "step"
+// descends into a function or steps over it.
+func SetStepSkip(skip StepSkipFunc) {
+	stepSkip = skip
+}
+
+// GoroutineHookFunc is called by the goroutine running frame fr
+// when its go statement starts goroutine goNum.
+type GoroutineHookFunc func(fr *Frame, goNum int)
+
+// goroutineHook, if not nil, is told of each goroutine started.
+var goroutineHook GoroutineHookFunc
+
+// SetGoroutineHook sets the function called when a go statement
+// starts a goroutine.
+func SetGoroutineHook(hook GoroutineHookFunc) {
+	goroutineHook = hook
+}
+
+// SetTraceHook makes hook the trace hook. The program's time limit
+// doesn't run down while hook runs, and hook isn't called for frames
+// running a call the debugger made with CallFunction.
 // FIXME: should be able to chain trace hooks
 func SetTraceHook(hook TraceHookFunc) {
 	// FIXME turn this into an append
-	TraceHook = hook
+	TraceHook = func(fr *Frame, instr *ssa2.Instruction, event ssa2.TraceEvent) {
+		if fr != nil && fr.debugCall {
+			return
+		}
+		if TimeLimit == 0 {
+			hook(fr, instr, event)
+			return
+		}
+		budgetPause()
+		defer budgetResume()
+		hook(fr, instr, event)
+	}
 }
 
 func SetStepIn(fr *Frame) {
 	i.TraceMode |= EnableStmtTracing
 	fr.tracing = TRACE_STEP_IN
+	fr.stepCalls = 0
+}
+
+// SetStepInto is like SetStepIn, but the first n-1 calls fr makes
+// are stepped over and we step into the nth one.
+func SetStepInto(fr *Frame, n int) {
+	SetStepIn(fr)
+	fr.stepCalls = n-1
 }
 
 func SetStepInstruction(fr *Frame) {
@@ -95,6 +164,7 @@ func SetStepOver(fr *Frame) {
 func SetStepOut(fr *Frame) {
 	i.TraceMode |= EnableStmtTracing
 	fr.tracing = TRACE_STEP_OUT
+	finishFn = nil
 }
 
 func SetStepOff(fr *Frame) {
@@ -118,6 +188,113 @@ func GlobalStmtTracing() bool {
 	return 0 != i.TraceMode & EnableStmtTracing
 }
 
+// traceMaskLock guards the trace event mask of the interpreter,
+// which the debugger changes while goroutines of the program read it.
+var traceMaskLock sync.RWMutex
+
+// TraceEventOn returns true if event is passed to the trace hook.
+func (i *interpreter) TraceEventOn(event ssa2.TraceEvent) bool {
+	traceMaskLock.RLock()
+	defer traceMaskLock.RUnlock()
+	return i.traceEventMask[event]
+}
+
+// SetTraceEvent arranges for event to be passed to the trace hook.
+func SetTraceEvent(event ssa2.TraceEvent) {
+	traceMaskLock.Lock()
+	defer traceMaskLock.Unlock()
+	i.traceEventMask[event] = true
+}
+
+// ClearTraceEvent stops event from being passed to the trace hook.
+func ClearTraceEvent(event ssa2.TraceEvent) {
+	traceMaskLock.Lock()
+	defer traceMaskLock.Unlock()
+	i.traceEventMask[event] = false
+}
+
+// A Hit tells what triggered a trace event that has more to say than
+// its frame and instruction. It goes with the frame the event is
+// issued for, rather than in globals, since goroutines running at
+// once can issue events at once.
+type Hit struct {
+	// WATCHPOINT: the address, or map element, accessed, the value
+	// it had before a write or the value read, and the kind of
+	// access.
+	WatchAddr  *Value
+	WatchEntry *MapEntry
+	WatchOld   Value
+	WatchType  WatchType
+
+	// CHAN_OP: the channel, the operation and the value sent or
+	// received.
+	Chan    chan Value
+	ChanOp  ChanOp
+	ChanVal Value
+
+	// EXTERNAL_CALL: the name of the function and its arguments.
+	ExternalName string
+	ExternalArgs []Value
+
+	// DEFER_ENTER: the function value of the deferred call.
+	DeferFn Value
+}
+
+// traceHit issues trace event event for instr in frame fr, which
+// hit triggered.
+func (fr *Frame) traceHit(instr *ssa2.Instruction, event ssa2.TraceEvent, hit *Hit) {
+	fr.hit = hit
+	defer func() { fr.hit = nil }()
+	TraceHook(fr, instr, event)
+}
+
+// Hit returns what triggered the trace event fr is stopped at, or an
+// empty Hit if there is nothing more to say about it.
+func (fr *Frame) Hit() Hit {
+	if fr.hit == nil {
+		return Hit{}
+	}
+	return *fr.hit
+}
+
+// DeferHit returns the function value of the deferred call about to
+// be run at a DEFER_ENTER event in frame fr.
+func DeferHit(fr *Frame) Value {
+	return fr.Hit().DeferFn
+}
+
+// finishFn is the function that was stepped out of and finishResults
+// the values it returned. finishFn is nil if there's nothing to report.
+var finishFn *ssa2.Function
+var finishResults []Value
+
+// setFinishHit records the results of frame fr which we are stepping
+// out of. Rather than stop at its return, we stop at the next
+// statement of its caller.
+func setFinishHit(fr *Frame) {
+	finishFn = fr.fn
+	switch r := fr.result.(type) {
+	case tuple:
+		finishResults = []Value(r)
+	default:
+		if fr.fn.Signature.Results().Len() == 0 {
+			finishResults = nil
+		} else {
+			finishResults = []Value{r}
+		}
+	}
+	fr.caller.tracing = TRACE_STEP_OVER
+}
+
+// FinishHit returns the function that was stepped out of by a finish
+// and the values it returned. The function is nil if the stop isn't
+// the result of a finish. The information is reported only once.
+func FinishHit() (*ssa2.Function, []Value) {
+	fn := finishFn
+	finishFn = nil
+	return fn, finishResults
+}
+
 func SetFnBreakpoint(fn *ssa2.Function) {
 	fn.Breakpoint = true
 }
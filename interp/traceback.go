@@ -0,0 +1,552 @@
+// Copyright 2015 Rocky Bernstein.
+// Reporting uncaught panics the way the Go runtime does
+
+package interp
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"unsafe"
+
+	"github.com/rocky/ssa-interp"
+	"github.com/rocky/go-types"
+)
+
+// When a panic isn't recovered, we print what a compiled program
+// would on standard error: the panic value, formatted as the runtime
+// formats it, and the stack of the goroutine at the point of the
+// panic, each frame giving the function with its arguments as machine
+// words and then its file and line, as in
+//
+//	panic: boom
+//
+//	goroutine 1 [running]:
+//	main.f(0x1, {0xc42000e1e0, 0x3})
+//		/home/me/f.go:12 +0x5
+//	main.main()
+//		/home/me/f.go:20 +0x2
+//
+// The offset after the line is that of the instruction in the
+// function. Goroutines are numbered as the Go runtime numbers them,
+// one more than the debugger does, so main's is 1. Panics raised
+// while panicking are listed in turn.
+//
+// GOTRACEBACK says how much to show, as it does for compiled
+// programs: "none" or 0 for just the panic, "single" or 1, the
+// default, for the goroutine that panicked too, and "all", "system",
+// "crash" or 2 for all goroutines.
+
+// tracebackWords and tracebackDepth are the most machine words of
+// arguments and the most levels of structs and arrays shown for a
+// frame; tracebackFrames is the most frames shown for a goroutine.
+const tracebackWords = 10
+const tracebackDepth = 5
+const tracebackFrames = 100
+
+// panicReport is what we say about the unrecovered panics of a
+// goroutine. A goroutine has one from the time its panic has been
+// reported with a PANIC trace event until it recovers, so frames the
+// panic unwinds through don't report it again.
+type panicReport struct {
+	value Value    // The value of the last panic
+	msgs  []string // The panic values, formatted
+	stack string   // The stack at the last panic
+}
+
+// panicReports holds the reports of the goroutines that are
+// panicking, by goroutine number.
+var panicLock sync.Mutex
+var panicReports = make(map[int]*panicReport)
+
+// panicReset forgets the panics of an earlier run of the program.
+func panicReset() {
+	panicLock.Lock()
+	defer panicLock.Unlock()
+	panicReports = make(map[int]*panicReport)
+}
+
+// panicBegin notes that the goroutine of frame fr, whose top frame
+// fr is, has started panicking with value v and message msg.
+func panicBegin(fr *Frame, v Value, msg string) {
+	stack := goroutineHeader(fr.goNum, GoRunning) + goroutineStack(fr)
+	panicLock.Lock()
+	defer panicLock.Unlock()
+	report := panicReports[fr.goNum]
+	if report == nil {
+		report = &panicReport{}
+		panicReports[fr.goNum] = report
+	}
+	report.value = v
+	report.msgs = append(report.msgs, msg)
+	report.stack = stack
+}
+
+// panicIsReported returns true if the panic of goroutine goNum has
+// been reported and not recovered from.
+func panicIsReported(goNum int) bool {
+	panicLock.Lock()
+	defer panicLock.Unlock()
+	return panicReports[goNum] != nil
+}
+
+// panicSave returns the panic state of goroutine goNum, for
+// panicRestore to put back.
+func panicSave(goNum int) *panicReport {
+	panicLock.Lock()
+	defer panicLock.Unlock()
+	if report := panicReports[goNum]; report != nil {
+		saved := *report
+		saved.msgs = append([]string(nil), report.msgs...)
+		return &saved
+	}
+	return nil
+}
+
+// panicRestore puts back the panic state of goroutine goNum that
+// panicSave returned.
+func panicRestore(goNum int, saved *panicReport) {
+	panicLock.Lock()
+	defer panicLock.Unlock()
+	if saved == nil {
+		delete(panicReports, goNum)
+	} else {
+		panicReports[goNum] = saved
+	}
+}
+
+// PanicValue returns the value of the panic the goroutine of frame fr
+// is in, or nil if it isn't panicking. For runtime errors this is the
+// error message.
+func PanicValue(fr *Frame) Value {
+	panicLock.Lock()
+	defer panicLock.Unlock()
+	if report := panicReports[fr.goNum]; report != nil {
+		return report.value
+	}
+	return nil
+}
+
+// panicRecovered notes that goroutine goNum has recovered from its
+// panic.
+func panicRecovered(goNum int) {
+	panicLock.Lock()
+	defer panicLock.Unlock()
+	delete(panicReports, goNum)
+}
+
+// tracebackLevel returns how much GOTRACEBACK in the program's
+// environment says to show: 0 for the panic alone, 1 for the
+// panicking goroutine too, 2 for all goroutines.
+func tracebackLevel() int {
+	switch programGetenv("GOTRACEBACK") {
+	case "none", "0":
+		return 0
+	case "all", "system", "crash", "2":
+		return 2
+	}
+	return 1
+}
+
+// printPanic writes to w the report of the panic goroutine goNum of i
+// didn't recover from, with tracebacks as GOTRACEBACK says. If
+// nothing is known about the panic, fallback is given as its value.
+func printPanic(w io.Writer, i *interpreter, goNum int, fallback string) {
+	panicLock.Lock()
+	report := panicReports[goNum]
+	panicLock.Unlock()
+	if report == nil {
+		report = &panicReport{msgs: []string{fallback}}
+	}
+	for n, msg := range report.msgs {
+		if n > 0 {
+			fmt.Fprint(w, "\t")
+		}
+		fmt.Fprintf(w, "panic: %s\n", msg)
+	}
+	level := tracebackLevel()
+	if level == 0 {
+		return
+	}
+	if report.stack != "" {
+		fmt.Fprintf(w, "\n%s", report.stack)
+	}
+	if level < 2 {
+		return
+	}
+	gocall.Lock()
+	defer gocall.Unlock()
+	for otherNum, goTop := range i.goTops {
+		if otherNum == goNum || goTop.Fr == nil || goTop.state == GoFinished {
+			continue
+		}
+		fmt.Fprintf(w, "\n%s%s", goroutineHeader(otherNum, goTop.state), goroutineStack(goTop.Fr))
+	}
+}
+
+// goroutineHeader returns the line that starts the traceback of
+// goroutine goNum, which is in state. The runtime numbers main's
+// goroutine 1, not 0.
+func goroutineHeader(goNum int, state GoState) string {
+	return fmt.Sprintf("goroutine %d [%s]:\n", goNum+1, state)
+}
+
+// goroutineStack returns the traceback of the frames from fr down.
+func goroutineStack(fr *Frame) string {
+	var buf bytes.Buffer
+	for n := 0; fr != nil; n, fr = n+1, fr.caller {
+		if n == tracebackFrames {
+			buf.WriteString("...additional frames elided...\n")
+			break
+		}
+		place := framePlace(fr)
+		posn := fr.fn.Prog.Fset.Position(place.pos)
+		file := posn.Filename
+		if file == "" {
+			file = "?"
+		}
+		fmt.Fprintf(&buf, "%s(%s)\n\t%s:%d +0x%x\n", goFuncName(fr.fn),
+			tracebackArgs(fr), file, posn.Line, instrOffset(place))
+	}
+	return buf.String()
+}
+
+// instrOffset returns the index among all the instructions of its
+// function of the instruction at place.
+func instrOffset(place PC) int {
+	if place.block == nil {
+		return 0
+	}
+	offset := place.instruction
+	for _, b := range place.fn.Blocks {
+		if b == place.block {
+			break
+		}
+		offset += len(b.Instrs)
+	}
+	return offset
+}
+
+// argWriter writes the arguments of a frame as machine words, as a
+// traceback shows them: {} around those of a struct, array, string,
+// slice, interface or complex number, and ... once there are too
+// many.
+type argWriter struct {
+	buf   bytes.Buffer
+	words int
+	open  int  // {s not yet closed
+	sep   bool // A word or } was written last
+	full  bool // ... has been written
+}
+
+func (a *argWriter) word(w uint64) {
+	if a.full {
+		return
+	}
+	if a.sep {
+		a.buf.WriteString(", ")
+	}
+	if a.words == tracebackWords {
+		a.buf.WriteString("...")
+		a.full = true
+		return
+	}
+	a.words++
+	fmt.Fprintf(&a.buf, "0x%x", w)
+	a.sep = true
+}
+
+func (a *argWriter) begin() {
+	if a.full {
+		return
+	}
+	if a.sep {
+		a.buf.WriteString(", ")
+	}
+	a.buf.WriteString("{")
+	a.open++
+	a.sep = false
+}
+
+func (a *argWriter) end() {
+	if a.full {
+		return
+	}
+	a.buf.WriteString("}")
+	a.open--
+	a.sep = true
+}
+
+// String closes what's still open.
+func (a *argWriter) String() string {
+	return a.buf.String() + strings.Repeat("}", a.open)
+}
+
+// tracebackArgs returns the arguments of frame fr as a traceback
+// shows them.
+func tracebackArgs(fr *Frame) string {
+	var a argWriter
+	for _, p := range fr.fn.Params {
+		a.value(fr.env[p], p.Type(), 0)
+	}
+	return a.String()
+}
+
+// value writes the words of v, of type t, nested depth levels deep.
+func (a *argWriter) value(v Value, t types.Type, depth int) {
+	switch t := t.Underlying().(type) {
+	case *types.Struct:
+		s, _ := v.(Structure)
+		a.aggregate(depth, func() {
+			for f := 0; f < t.NumFields() && f < len(s.fields); f++ {
+				a.value(s.fields[f], t.Field(f).Type(), depth+1)
+			}
+		})
+	case *types.Array:
+		arr, _ := v.(array)
+		a.aggregate(depth, func() {
+			for _, elem := range arr {
+				a.value(elem, t.Elem(), depth+1)
+			}
+		})
+	case *types.Slice:
+		s, _ := v.([]Value)
+		a.begin()
+		a.word(sliceWord(s))
+		a.word(uint64(len(s)))
+		a.word(uint64(cap(s)))
+		a.end()
+	case *types.Interface:
+		it, _ := v.(iface)
+		a.begin()
+		a.word(pointerWord(it.t))
+		a.word(pointerWord(it.v))
+		a.end()
+	case *types.Basic:
+		switch v := v.(type) {
+		case string:
+			a.begin()
+			a.word(stringWord(v))
+			a.word(uint64(len(v)))
+			a.end()
+		case complex64:
+			a.begin()
+			a.word(uint64(math.Float32bits(real(v))))
+			a.word(uint64(math.Float32bits(imag(v))))
+			a.end()
+		case complex128:
+			a.begin()
+			a.word(math.Float64bits(real(v)))
+			a.word(math.Float64bits(imag(v)))
+			a.end()
+		default:
+			a.word(scalarWord(v))
+		}
+	default:
+		a.word(pointerWord(v))
+	}
+}
+
+// aggregate writes the words body writes within {}, or {...} if
+// that is too deep.
+func (a *argWriter) aggregate(depth int, body func()) {
+	if depth >= tracebackDepth {
+		if !a.full {
+			if a.sep {
+				a.buf.WriteString(", ")
+			}
+			a.buf.WriteString("{...}")
+			a.sep = true
+		}
+		return
+	}
+	a.begin()
+	body()
+	a.end()
+}
+
+// scalarWord returns the machine word holding v, a boolean or number.
+func scalarWord(v Value) uint64 {
+	switch v := v.(type) {
+	case bool:
+		if v {
+			return 1
+		}
+		return 0
+	case int:
+		return uint64(v)
+	case int8:
+		return uint64(v)
+	case int16:
+		return uint64(v)
+	case int32:
+		return uint64(v)
+	case int64:
+		return uint64(v)
+	case uint:
+		return uint64(v)
+	case uint8:
+		return uint64(v)
+	case uint16:
+		return uint64(v)
+	case uint32:
+		return uint64(v)
+	case uint64:
+		return v
+	case uintptr:
+		return uint64(v)
+	case float32:
+		return uint64(math.Float32bits(v))
+	case float64:
+		return math.Float64bits(v)
+	}
+	return pointerWord(v)
+}
+
+// pointerWord returns the address v refers to, if it is a pointer,
+// map, channel or function, or else 0.
+func pointerWord(v Value) uint64 {
+	if v == nil {
+		return 0
+	}
+	switch rv := reflect.ValueOf(v); rv.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		return uint64(rv.Pointer())
+	}
+	return 0
+}
+
+// sliceWord returns the address of the backing array of s.
+func sliceWord(s []Value) uint64 {
+	if cap(s) == 0 {
+		return 0
+	}
+	return uint64(uintptr(unsafe.Pointer(&s[:1][0])))
+}
+
+// stringWord returns the address of the bytes of s.
+func stringWord(s string) uint64 {
+	if len(s) == 0 {
+		return 0
+	}
+	return uint64((*[2]uintptr)(unsafe.Pointer(&s))[0])
+}
+
+// panicString formats panic value v, the argument of panic() in frame
+// fr, as the runtime does: the result of its Error or String method
+// if it has one, a basic value as print would show it, and any other
+// value with its type.
+func panicString(fr *Frame, v Value) string {
+	it, ok := v.(iface)
+	if !ok {
+		return toString(v)
+	}
+	if it.t == nil {
+		return "panic called with nil argument"
+	}
+	for _, name := range []string{"Error", "String"} {
+		if s, ok := callStringMethod(fr, it, name); ok {
+			return s
+		}
+	}
+	basic, ok := it.t.Underlying().(*types.Basic)
+	if !ok {
+		return fmt.Sprintf("(%s) %s", it.t, toString(it.v))
+	}
+	s := printString(it.v)
+	if basic.Info()&types.IsString != 0 && it.t != basic {
+		s = strconv.Quote(s)
+	}
+	if it.t != basic {
+		// A value of a named type, as in main.T(5).
+		s = fmt.Sprintf("%s(%s)", it.t, s)
+	}
+	return s
+}
+
+// callStringMethod calls the method name, which must return a string,
+// of the value in it, if it has one.
+func callStringMethod(fr *Frame, it iface, name string) (s string, ok bool) {
+	if it.t == errorType {
+		if name != "Error" {
+			return "", false
+		}
+		s, ok = it.v.(string)
+		return
+	}
+	prog := fr.i.prog
+	sel := prog.MethodSets.MethodSet(it.t).Lookup(nil, name)
+	if sel == nil {
+		return "", false
+	}
+	sig, _ := sel.Type().(*types.Signature)
+	if sig == nil || sig.Params().Len() != 0 || sig.Results().Len() != 1 ||
+		!types.Identical(sig.Results().At(0).Type(), types.Typ[types.String]) {
+		return "", false
+	}
+	defer func() {
+		if recover() != nil {
+			s, ok = "", false
+		}
+	}()
+	s, ok = call(fr.i, fr.goNum, fr, prog.Method(sel), []Value{copyVal(it.v)}).(string)
+	return
+}
+
+// printString formats basic value v as the print built-in does.
+func printString(v Value) string {
+	switch v := v.(type) {
+	case float32:
+		return printFloat(float64(v))
+	case float64:
+		return printFloat(v)
+	case complex64:
+		return "(" + printFloat(float64(real(v))) + printFloat(float64(imag(v))) + "i)"
+	case complex128:
+		return "(" + printFloat(real(v)) + printFloat(imag(v)) + "i)"
+	}
+	return toString(v)
+}
+
+// printFloat formats f as the print built-in does, as in
+// +1.500000e+000.
+func printFloat(f float64) string {
+	s := fmt.Sprintf("%+e", f)
+	e := strings.LastIndexAny(s, "+-")
+	if e <= 0 || s[e-1] != 'e' {
+		// NaN or infinity
+		return s
+	}
+	return s[:e+1] + strings.Repeat("0", 3-len(s[e+1:])) + s[e+1:]
+}
+
+// runtimeErrorMessage is the message for a runtime error with mess.
+func runtimeErrorMessage(mess string) string {
+	if strings.HasPrefix(mess, "runtime error: ") {
+		return mess
+	}
+	return "runtime error: " + mess
+}
+
+// reportGoroutinePanic reports the panic p that goroutine goNum of i
+// didn't recover from and ends the program, as an unrecovered panic
+// in any goroutine does.
+func reportGoroutinePanic(i *interpreter, goNum int, p interface{}) {
+	printPanic(programStderr, i, goNum, fmt.Sprint(p))
+	setExit(2, "panic")
+	fr := goTopFrame(i, goNum)
+	if fr == nil {
+		// The goroutine's frames are gone; the hook wants one.
+		fr = reportFrame(i)
+	}
+	if fr != nil {
+		TraceHook(fr, nil, ssa2.PROGRAM_TERMINATION)
+	}
+	os.Exit(2)
+}
@@ -0,0 +1,51 @@
+// Copyright 2015 Rocky Bernstein.
+// Tests of the format of panic reports and tracebacks
+
+package interp
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rocky/go-types"
+)
+
+func TestGoroutineHeader(t *testing.T) {
+	if got, want := goroutineHeader(0, GoRunning), "goroutine 1 [running]:\n"; got != want {
+		t.Errorf("main's goroutine: got %q, want %q", got, want)
+	}
+	if got, want := goroutineHeader(2, GoRunning), "goroutine 3 [running]:\n"; got != want {
+		t.Errorf("goroutine 2: got %q, want %q", got, want)
+	}
+}
+
+func TestTracebackArgs(t *testing.T) {
+	var a argWriter
+	a.value(5, types.Typ[types.Int], 0)
+	a.value(true, types.Typ[types.Bool], 0)
+	a.value("", types.Typ[types.String], 0)
+	a.value(complex128(0), types.Typ[types.Complex128], 0)
+	if got, want := a.String(), "0x5, 0x1, {0x0, 0x0}, {0x0, 0x0}"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	var b argWriter
+	for n := 0; n < tracebackWords+2; n++ {
+		b.value(n, types.Typ[types.Int], 0)
+	}
+	if got := b.String(); !strings.HasSuffix(got, "0x9, ...") {
+		t.Errorf("too many words: got %q, want it to end in \"0x9, ...\"", got)
+	}
+}
+
+func TestPanicFormats(t *testing.T) {
+	if got, want := printFloat(1.5), "+1.500000e+000"; got != want {
+		t.Errorf("printFloat(1.5) = %q, want %q", got, want)
+	}
+	if got, want := runtimeErrorMessage("index out of range"), "runtime error: index out of range"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got, want := runtimeErrorMessage("runtime error: x"), "runtime error: x"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -37,6 +37,7 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"net"
 	"reflect"
 	"strings"
 	"sync"
@@ -250,6 +251,11 @@ func equals(t types.Type, x, y Value) bool {
 		return x.eq(t, y)
 	case rtype:
 		return x.eq(t, y)
+	case net.Conn, net.Listener, net.Addr:
+		// Connections, listeners and addresses of the network
+		// interposition layer are the same if they are the same
+		// one.
+		return x == y
 	}
 
 	switch reflect.TypeOf(x).Kind().String() {
@@ -347,6 +353,11 @@ func hash(t types.Type, x Value) int {
 		return x.hash(t)
 	case rtype:
 		return x.hash(t)
+	case net.Conn, net.Listener, net.Addr:
+		if v := reflect.ValueOf(x); v.Kind() == reflect.Ptr {
+			return int(v.Pointer())
+		}
+		return hashString(fmt.Sprint(x))
 	}
 	panic(fmt.Sprintf("%T is unhashable", x))
 }
@@ -390,6 +401,8 @@ func copyVal(v Value) Value {
 		break
 	case rtype:
 		return v
+	case net.Conn, net.Listener, net.Addr:
+		return v // connections of the network interposition layer
 	}
 	switch reflect.TypeOf(v).Kind().String() {
 	case "bool", "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64", "uintptr", "float32", "float64", "complex64", "complex128", "string", "unsafe.Pointer":
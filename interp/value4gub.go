@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"reflect"
 	"strconv"
 	"github.com/rocky/go-types"
 	"github.com/rocky/ssa-interp"
@@ -13,6 +14,89 @@ import (
 // Shadows array type
 type Array []Value
 
+// printDepth is how deeply nested composite values are shown before
+// they are elided; printElements is how many elements of an array,
+// slice, map, or struct, or characters of a string, are shown. 0
+// means no limit.
+var printDepth = 0
+var printElements = 200
+
+// SetPrintDepth sets how deeply nested values are shown. 0 means no
+// limit.
+func SetPrintDepth(n int) { printDepth = n }
+
+// PrintDepth returns how deeply nested values are shown.
+func PrintDepth() int { return printDepth }
+
+// SetPrintElements sets how many elements of a value are shown. 0
+// means no limit.
+func SetPrintElements(n int) { printElements = n }
+
+// PrintElements returns how many elements of a value are shown.
+func PrintElements() int { return printElements }
+
+// printDeref is how many levels of pointers are followed to show what
+// they point to rather than the pointer's address.
+var printDeref = 0
+
+// SetPrintDeref sets how many levels of pointers are followed when
+// showing a value.
+func SetPrintDeref(n int) { printDeref = n }
+
+// PrintDeref returns how many levels of pointers are followed when
+// showing a value.
+func PrintDeref() int { return printDeref }
+
+// An inspector holds the state of showing a value with toInspect.
+type inspector struct {
+	w     io.Writer
+	depth int                  // how deeply nested we are
+	derefs int                 // how many pointers we have followed
+	seen  map[interface{}]bool // reference values we are inside of
+}
+
+// enter is called before showing the parts of composite value v,
+// which is identified by ref if it is a reference value and nil
+// otherwise. If v is too deeply nested or we are already inside of
+// it, an elision marker is shown and false is returned. Otherwise
+// leave must be called when we are done with v.
+func (in *inspector) enter(ref interface{}, elided string) bool {
+	if ref != nil && in.seen[ref] {
+		io.WriteString(in.w, "<cycle>")
+		return false
+	}
+	if printDepth > 0 && in.depth >= printDepth {
+		io.WriteString(in.w, elided)
+		return false
+	}
+	in.depth++
+	if ref != nil {
+		in.seen[ref] = true
+	}
+	return true
+}
+
+func (in *inspector) leave(ref interface{}) {
+	in.depth--
+	if ref != nil {
+		delete(in.seen, ref)
+	}
+}
+
+// more writes sep before element i of a composite value and returns
+// true if element i should be shown. Once printElements have been
+// shown, it writes an elision marker and returns false.
+func (in *inspector) more(i int, sep string) bool {
+	if i > 0 {
+		io.WriteString(in.w, sep)
+	}
+	if printElements > 0 && i >= printElements {
+		io.WriteString(in.w, "...")
+		return false
+	}
+	return true
+}
+
 // Prints in the style of built-in println.
 // (More or less; in gc println is actually a compiler intrinsic and
 // can distinguish println(1) from println(interface{}(1)).)
@@ -20,7 +104,38 @@ type Array []Value
 //   * strings are quoted
 //   * separators lists maps are ", " (rather than " ")
 //   * nil is "nil" rather than "<nil>"
+//   * large or deeply nested values are elided, see printDepth and
+//     printElements
 func toInspect(w io.Writer, v Value, name *ssa2.Value) {
+	var t types.Type
+	if name != nil {
+		t = deref((*name).Type())
+	}
+	toInspectType(w, v, t)
+}
+
+// toInspectType is toInspect where the type of v, if known, is t
+// rather than the type of an SSA value. t is nil when it isn't known.
+func toInspectType(w io.Writer, v Value, t types.Type) {
+	in := &inspector{w: w, seen: make(map[interface{}]bool)}
+	in.inspect(v, t)
+}
+
+// inspect shows v, whose type is t if t isn't nil. Types of the parts
+// of v are worked out from t so that struct fields can be named and
+// registered printers found.
+func (in *inspector) inspect(v Value, t types.Type) {
+	w := in.w
+	if named, ok := t.(*types.Named); ok {
+		if printer := printers[named.String()]; printer != nil {
+			io.WriteString(w, printer(v))
+			return
+		}
+	}
+	var ut types.Type
+	if t != nil {
+		ut = t.Underlying()
+	}
 	switch v := v.(type) {
 
 	case nil:
@@ -30,32 +145,50 @@ func toInspect(w io.Writer, v Value, name *ssa2.Value) {
 		fmt.Fprintf(w, "%v", v)
 
 	case string:
-		fmt.Fprintf(w, "%s", strconv.QuoteToASCII(v))
+		if printElements > 0 && len(v) > printElements {
+			fmt.Fprintf(w, "%s...", strconv.QuoteToASCII(v[:printElements]))
+		} else {
+			fmt.Fprintf(w, "%s", strconv.QuoteToASCII(v))
+		}
 
 
 	case map[Value]Value:
+		ref := reflect.ValueOf(v).Pointer()
+		if !in.enter(ref, "map[...]") { return }
+		defer in.leave(ref)
+		kt, et := mapTypes(ut)
 		io.WriteString(w, "map[")
 		sep := " "
+		i := 0
 		for k, e := range v {
 			io.WriteString(w, sep)
 			sep = ", "
-			toInspect(w, k, name)
+			if !in.more(i, "") { break }
+			in.inspect(k, kt)
 			io.WriteString(w, ":")
-			toInspect(w, e, name)
+			in.inspect(e, et)
+			i++
 		}
 		io.WriteString(w, "]")
 
 	case *hashmap:
+		if !in.enter(v, "map[...]") { return }
+		defer in.leave(v)
+		kt, et := mapTypes(ut)
 		io.WriteString(w, "map[")
 		sep := " "
+		i := 0
+	table:
 		for _, e := range v.table {
 			for e != nil {
 				io.WriteString(w, sep)
 				sep = ", "
-				toInspect(w, e.key, name)
+				if !in.more(i, "") { break table }
+				in.inspect(e.key, kt)
 				io.WriteString(w, ":")
-				toInspect(w, e.Value, name)
+				in.inspect(e.Value, et)
 				e = e.next
+				i++
 			}
 		}
 		io.WriteString(w, "]")
@@ -66,22 +199,37 @@ func toInspect(w io.Writer, v Value, name *ssa2.Value) {
 	case *Value:
 		if v == nil {
 			io.WriteString(w, "nil")
+		} else if in.derefs < printDeref {
+			if in.seen[v] {
+				io.WriteString(w, "<cycle>")
+				return
+			}
+			var et types.Type
+			if p, ok := ut.(*types.Pointer); ok {
+				et = p.Elem()
+			}
+			in.derefs++
+			in.seen[v] = true
+			io.WriteString(w, "&")
+			in.inspect(*v, et)
+			delete(in.seen, v)
+			in.derefs--
 		} else {
 			fmt.Fprintf(w, "%p", v)
 		}
 
 	case iface:
-		toInspect(w, v.v, name)
+		// Show the dynamic type along with the value.
+		if v.t != nil {
+			fmt.Fprintf(w, "(%s) ", v.t)
+		}
+		in.inspect(v.v, v.t)
 
 	case Structure:
+		if !in.enter(nil, "{...}") { return }
+		defer in.leave(nil)
 		io.WriteString(w, "{")
-		var ok bool = false
-		var typ types.Type
-		var t *types.Struct
-		if name != nil {
-			typ = deref((*name).Type()).Underlying()
-			t, ok = typ.(*types.Struct)
-		}
+		t, ok := ut.(*types.Struct)
 		// The vNum, tNum values below are to
 		// guard and mask against what is probably a bug
 		// (or bugs) elsewhere.
@@ -91,9 +239,7 @@ func toInspect(w io.Writer, v Value, name *ssa2.Value) {
 			tNum = t.NumFields()
 		}
 		for i, e := range v.fields {
-			if i > 0 {
-				io.WriteString(w, " ")
-			}
+			if !in.more(i, " ") { break }
 			if ok && i < tNum {
 				fmt.Fprintf(w, "%s: ", t.Field(i).Name())
 			} else if i < vNum && v.fieldnames[i] != "" {
@@ -101,28 +247,38 @@ func toInspect(w io.Writer, v Value, name *ssa2.Value) {
 			} else {
 				fmt.Fprintf(w, "?? ")
 			}
-			toInspect(w, e, name)
+			var ft types.Type
+			if ok && i < tNum {
+				ft = t.Field(i).Type()
+			}
+			in.inspect(e, ft)
 			io.WriteString(w, ",")
 		}
 		io.WriteString(w, "}")
 
 	case array:
+		if !in.enter(nil, "{...}") { return }
+		defer in.leave(nil)
+		et := elemType(ut)
 		io.WriteString(w, "{")
 		for i, e := range v {
-			if i > 0 {
-				io.WriteString(w, ", ")
-			}
-			toInspect(w, e, name)
+			if !in.more(i, ", ") { break }
+			in.inspect(e, et)
 		}
 		io.WriteString(w, "}")
 
 	case []Value:
+		var ref interface{}
+		if len(v) > 0 {
+			ref = &v[0]
+		}
+		if !in.enter(ref, "{...}") { return }
+		defer in.leave(ref)
+		et := elemType(ut)
 		io.WriteString(w, "{")
 		for i, e := range v {
-			if i > 0 {
-				io.WriteString(w, ", ")
-			}
-			toInspect(w, e, name)
+			if !in.more(i, ", ") { break }
+			in.inspect(e, et)
 		}
 		io.WriteString(w, "}")
 
@@ -139,7 +295,7 @@ func toInspect(w io.Writer, v Value, name *ssa2.Value) {
 			if i > 0 {
 				io.WriteString(w, ", ")
 			}
-			toInspect(w, e, name)
+			in.inspect(e, nil)
 		}
 		io.WriteString(w, ")")
 
@@ -148,6 +304,27 @@ func toInspect(w io.Writer, v Value, name *ssa2.Value) {
 	}
 }
 
+// mapTypes returns the key and element types of map type t, or nil if
+// t isn't a map type.
+func mapTypes(t types.Type) (types.Type, types.Type) {
+	if m, ok := t.(*types.Map); ok {
+		return m.Key(), m.Elem()
+	}
+	return nil, nil
+}
+
+// elemType returns the element type of array or slice type t, or nil
+// if t is neither.
+func elemType(t types.Type) types.Type {
+	switch t := t.(type) {
+	case *types.Array:
+		return t.Elem()
+	case *types.Slice:
+		return t.Elem()
+	}
+	return nil
+}
+
 // Similar to ToString but using toInspect
 // Note: we can't use a method because the receiver is an interface type.
 func ToInspect(v Value, name *ssa2.Value) string {
@@ -156,6 +333,13 @@ func ToInspect(v Value, name *ssa2.Value) string {
 	return b.String()
 }
 
+// ToInspectType is ToInspect for a value v whose type is t.
+func ToInspectType(v Value, t types.Type) string {
+	var b bytes.Buffer
+	toInspectType(&b, v, t)
+	return b.String()
+}
+
 // Returns a string representation of the types of interp.Value
 // Note: we can't use a method becasue the receiver is an interface type.
 func Type(v Value) string {
@@ -0,0 +1,203 @@
+// Copyright 2015 Rocky Bernstein.
+
+// Data watchpoints. The debugger registers the address of a
+// variable, and the interpreter calls the trace hook with a
+// WATCHPOINT event whenever that address is stored to or, for read
+// watchpoints, loaded from. Since map elements don't have addresses,
+// writes to a map element are watched by map and key instead.
+
+package interp
+
+import (
+	"fmt"
+	"reflect"
+	"sync/atomic"
+
+	"github.com/rocky/go-types"
+	"github.com/rocky/ssa-interp"
+)
+
+// WatchType is a bitmask of the kinds of access that trigger a
+// watchpoint.
+type WatchType uint8
+
+const (
+	WATCH_WRITE WatchType = 1 << iota
+	WATCH_READ
+)
+
+// watched maps the address of a variable to the kinds of access we
+// stop on.
+var watched map[*Value]WatchType = make(map[*Value]WatchType)
+
+// nWatched and nMapWatches are the number of addresses and map
+// elements watched, kept so that goroutines can check cheaply, without
+// breakLock, whether there are any.
+var nWatched, nMapWatches int32
+
+// anyWatches returns true if some address is watched.
+func anyWatches() bool { return atomic.LoadInt32(&nWatched) > 0 }
+
+// anyMapWatches returns true if some map element is watched.
+func anyMapWatches() bool { return atomic.LoadInt32(&nMapWatches) > 0 }
+
+// SetWatch arranges for accesses of kind to addr to trigger a
+// WATCHPOINT trace event.
+func SetWatch(addr *Value, kind WatchType) {
+	breakLock.Lock()
+	defer breakLock.Unlock()
+	watched[addr] |= kind
+	atomic.StoreInt32(&nWatched, int32(len(watched)))
+}
+
+// ClearWatch removes kind from the accesses we stop on for addr.
+func ClearWatch(addr *Value, kind WatchType) {
+	breakLock.Lock()
+	defer breakLock.Unlock()
+	if w := watched[addr] &^ kind; w == 0 {
+		delete(watched, addr)
+	} else {
+		watched[addr] = w
+	}
+	atomic.StoreInt32(&nWatched, int32(len(watched)))
+}
+
+// IsWatched returns true if accesses of kind to addr are watched.
+func IsWatched(addr *Value, kind WatchType) bool {
+	breakLock.RLock()
+	defer breakLock.RUnlock()
+	return watched[addr]&kind != 0
+}
+
+// WatchHit returns the address and the value it had before it was
+// changed for the watchpoint frame fr is stopped at. For a read
+// watchpoint the value is the value loaded. For a map element
+// watchpoint the address is nil; see WatchHitEntry.
+func WatchHit(fr *Frame) (*Value, Value) {
+	hit := fr.Hit()
+	return hit.WatchAddr, hit.WatchOld
+}
+
+// WatchHitType returns the kind of access, WATCH_WRITE or WATCH_READ,
+// that triggered the watchpoint frame fr is stopped at.
+func WatchHitType(fr *Frame) WatchType {
+	return fr.Hit().WatchType
+}
+
+// checkWriteWatch is called after instr has stored into addr. old is
+// the value addr had before the store.
+func checkWriteWatch(fr *Frame, instr *ssa2.Instruction, addr *Value, old Value) {
+	if !IsWatched(addr, WATCH_WRITE) {
+		return
+	}
+	fr.traceHit(instr, ssa2.WATCHPOINT, &Hit{WatchAddr: addr, WatchOld: old, WatchType: WATCH_WRITE})
+}
+
+// checkReadWatch is called after instr has loaded from addr.
+func checkReadWatch(fr *Frame, instr *ssa2.Instruction, addr *Value) {
+	if !IsWatched(addr, WATCH_READ) {
+		return
+	}
+	fr.traceHit(instr, ssa2.WATCHPOINT, &Hit{WatchAddr: addr, WatchOld: *addr, WatchType: WATCH_READ})
+}
+
+// A MapEntry is the element for Key in map Map, watched for writes.
+type MapEntry struct {
+	Map     Value
+	Key     Value
+	keyType types.Type
+}
+
+// mapWatches are the map elements we stop on writes to.
+var mapWatches []*MapEntry
+
+// sameMap returns true if x and y are the same interpreter map.
+func sameMap(x, y Value) bool {
+	switch x := x.(type) {
+	case *hashmap:
+		y, ok := y.(*hashmap)
+		return ok && x == y
+	case map[Value]Value:
+		y, ok := y.(map[Value]Value)
+		return ok && reflect.ValueOf(x).Pointer() == reflect.ValueOf(y).Pointer()
+	}
+	return false
+}
+
+// WatchMapEntry arranges for writes to element key of map m, whose
+// keys have type keyType, to trigger a WATCHPOINT trace event. The
+// MapEntry for it is returned.
+func WatchMapEntry(m Value, key Value, keyType types.Type) *MapEntry {
+	breakLock.Lock()
+	defer breakLock.Unlock()
+	for _, e := range mapWatches {
+		if sameMap(e.Map, m) && equals(keyType, e.Key, key) {
+			return e
+		}
+	}
+	e := &MapEntry{Map: m, Key: key, keyType: keyType}
+	mapWatches = append(mapWatches, e)
+	atomic.StoreInt32(&nMapWatches, int32(len(mapWatches)))
+	return e
+}
+
+// UnwatchMapEntry stops watching writes to map element e.
+func UnwatchMapEntry(e *MapEntry) {
+	breakLock.Lock()
+	defer breakLock.Unlock()
+	for i, other := range mapWatches {
+		if other == e {
+			mapWatches = append(mapWatches[:i], mapWatches[i+1:]...)
+			atomic.StoreInt32(&nMapWatches, int32(len(mapWatches)))
+			return
+		}
+	}
+}
+
+// Value returns the current value of map element e and whether it
+// is present in the map.
+func (e *MapEntry) Value() (Value, bool) {
+	return MapLookup(e.Map, e.Key)
+}
+
+// WatchHitEntry returns the map element of the watchpoint frame fr
+// is stopped at, or nil if it is on an address.
+func WatchHitEntry(fr *Frame) *MapEntry {
+	return fr.Hit().WatchEntry
+}
+
+// checkMapWatch is called after instr has set element key of map m.
+// old is the element's value before that, or nil if it was absent.
+func checkMapWatch(fr *Frame, instr *ssa2.Instruction, m Value, key Value, old Value) {
+	var hit *MapEntry
+	breakLock.RLock()
+	for _, e := range mapWatches {
+		if sameMap(e.Map, m) && equals(e.keyType, e.Key, key) {
+			hit = e
+			break
+		}
+	}
+	breakLock.RUnlock()
+	if hit == nil {
+		return
+	}
+	fr.traceHit(instr, ssa2.WATCHPOINT, &Hit{WatchEntry: hit, WatchOld: old, WatchType: WATCH_WRITE})
+}
+
+// ElemAddr returns the address of element i of an interpreter array
+// or slice value x.
+func ElemAddr(x Value, i int) (*Value, error) {
+	var elems []Value
+	switch x := x.(type) {
+	case array:
+		elems = x
+	case []Value:
+		elems = x
+	default:
+		return nil, fmt.Errorf("can't take the address of an element of %T", x)
+	}
+	if i < 0 || i >= len(elems) {
+		return nil, fmt.Errorf("index %d out of range [0:%d]", i, len(elems))
+	}
+	return &elems[i], nil
+}
@@ -271,6 +271,7 @@ func lift(fn *Function) {
 	}
 
 	// Remove any fn.Locals that were lifted.
+	old := append([]*Alloc(nil), fn.Locals...)
 	j := 0
 	for _, l := range fn.Locals {
 		if l.index < 0 {
@@ -283,6 +284,7 @@ func lift(fn *Function) {
 		fn.Locals[i] = nil
 	}
 	fn.Locals = fn.Locals[:j]
+	fn.renumberLocals(old)
 }
 
 func phiIsLive(phi *Phi) bool {
@@ -13,6 +13,7 @@ alike.
 import (
 	"go/ast"
 	"go/token"
+	"strings"
 	"github.com/rocky/go-types"
 	"github.com/rocky/go-loader"
 )
@@ -63,7 +64,67 @@ func (v *Register)  setEnd(pos token.Pos)       { v.endP = pos }
 
 func (v *LocInst)   Pos() token.Pos             { return v.pos }
 func (p *Package)   Locs() []LocInst { return p.locs }
+func (p *Package)   InitFunc() *Function { return p.init }
 func (p *Package)   Info() *loader.PackageInfo { return p.info }
 
 func (s *Scope) ScopeId() ScopeId   { return s.scopeId }
 func (s *Scope) Node()    *ast.Node { return s.node }
+
+// FileLineLocs returns the stopping locations, in any package of
+// prog, which are on line line of a file named filename. filename
+// matches if it is the full file name or a trailing part of it that
+// starts after a slash, for example "fmt/print.go". If column is not
+// -1, only locations starting in that column are returned.
+func (prog *Program) FileLineLocs(filename string, line, column int) []*LocInst {
+	results := make([]*LocInst, 0)
+	for _, pkg := range prog.AllPackages() {
+		for i := range pkg.locs {
+			l := &pkg.locs[i]
+			try := prog.Fset.Position(l.pos)
+			if try.Line != line || (column != -1 && column != try.Column) {
+				continue
+			}
+			if try.Filename == filename ||
+				strings.HasSuffix(try.Filename, "/"+filename) {
+				results = append(results, l)
+			}
+		}
+	}
+	return results
+}
+
+// InterfaceMethodImpls returns the concrete Functions that a dynamic
+// call of method (pkg, name) of interface iface can end up in. Only
+// types that have a method set at run time are considered. Wrappers,
+// such as those for promoted methods or for value methods called
+// through a pointer, are resolved to the declared method they wrap,
+// so each method appears once.
+func (prog *Program) InterfaceMethodImpls(iface *types.Interface,
+	pkg *types.Package, name string) []*Function {
+	results := make([]*Function, 0)
+	seen := make(map[*Function]bool)
+	for _, T := range prog.TypesWithMethodSets() {
+		if isInterface(T) {
+			continue
+		}
+		if meth, _ := types.MissingMethod(T, iface, true); meth != nil {
+			continue
+		}
+		fn := prog.LookupMethod(T, pkg, name)
+		if fn == nil {
+			continue
+		}
+		if fn.Synthetic != "" {
+			if obj, ok := fn.object.(*types.Func); ok {
+				if declared := prog.FuncValue(obj); declared != nil {
+					fn = declared
+				}
+			}
+		}
+		if !seen[fn] {
+			seen[fn] = true
+			results = append(results, fn)
+		}
+	}
+	return results
+}
@@ -48,6 +48,13 @@ func ResetColor() string {
 }
 
 
+// IsColor is true if color_key is a color or attribute Colorize
+// knows, like "red" or "bold".
+func IsColor(color_key string) bool {
+    _, ok := codes[color_key]
+    return ok
+}
+
 func Colorize(color_key string, text string) string {
     return codes[color_key] + text + codes["reset"]
 }
@@ -5,6 +5,9 @@ import (
 	"fmt"
 	"go/token"
 	"go/ast"
+	"os"
+	"path/filepath"
+	"strings"
 )
 
 //-------------------------------
@@ -33,10 +36,15 @@ const (
 	STMT_IN_LIST
 	SWITCH_COND
 	TRACE_CALL
+	WATCHPOINT
+	CHAN_OP
+	EXTERNAL_CALL
+	DEADLOCK
+	LIMIT
 )
 
 const TRACE_EVENT_FIRST = OTHER
-const TRACE_EVENT_LAST  = TRACE_CALL
+const TRACE_EVENT_LAST  = LIMIT
 
 type TraceEventMask map[TraceEvent]bool
 
@@ -64,6 +72,11 @@ func init() {
 		STMT_IN_LIST    : "STATEMENT in list",
 		SWITCH_COND     : "SWITCH condition",
 		PROGRAM_TERMINATION : "Program Terminated",
+		WATCHPOINT      : "Watchpoint",
+		CHAN_OP         : "Channel operation",
+		EXTERNAL_CALL   : "External function call",
+		DEADLOCK        : "Deadlock",
+		LIMIT           : "Limit reached",
 	}
 }
 
@@ -90,17 +103,65 @@ type Trace struct {
 	syntax  ast.Node
 }
 
+// LocationPaths are the ways file names in positions can be shown:
+// as they are, which is usually an absolute path, relative to the
+// current directory, or just the last component.
+var LocationPaths = []string{"absolute", "relative", "basename"}
+
+// LocationPath is how PositionRange and the functions that use it
+// show file names; it is one of LocationPaths.
+var LocationPath = "absolute"
+
+// LocationColumn is set if positions include column numbers as well
+// as line numbers.
+var LocationColumn = true
+
+// LocationFunction is set if positions of stack frames start with
+// the name of the frame's function.
+var LocationFunction = false
+
+// LocationFilename gives filename the way LocationPath says
+// positions should show it.
+func LocationFilename(filename string) string {
+	switch LocationPath {
+	case "basename":
+		return filepath.Base(filename)
+	case "relative":
+		if !filepath.IsAbs(filename) {
+			return filename
+		}
+		cwd, err := os.Getwd()
+		if err != nil {
+			return filename
+		}
+		rel, err := filepath.Rel(cwd, filename)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			return filename
+		}
+		return rel
+	}
+	return filename
+}
+
+// lineCol gives line, and column if LocationColumn is set.
+func lineCol(line int, column int) string {
+	if LocationColumn {
+		return fmt.Sprintf("%d:%d", line, column)
+	}
+	return fmt.Sprintf("%d", line)
+}
+
 // FIXME: arrange to put in ast
 func PositionRange(start token.Position, end token.Position) string {
 	s := ""
 	if start.IsValid() {
-		s = start.Filename + ":" + PositionRangeSansFile(start, end)
+		s = LocationFilename(start.Filename) + ":" + PositionRangeSansFile(start, end)
 	} else if end.IsValid() {
 		s = "-"
 		if end.Filename != "" {
-			s += end.Filename + ":"
+			s += LocationFilename(end.Filename) + ":"
 		}
-		s += fmt.Sprintf("%d:%d", end.Line, end.Column)
+		s += lineCol(end.Line, end.Column)
 	}
 	if s == "" {
 		s = "-"
@@ -111,21 +172,21 @@ func PositionRange(start token.Position, end token.Position) string {
 func PositionRangeSansFile(start token.Position, end token.Position) string {
 	s := ""
 	if start.IsValid() {
-		s += fmt.Sprintf("%d:%d", start.Line, start.Column)
+		s += lineCol(start.Line, start.Column)
 		if start.Filename == end.Filename && end.IsValid() {
 			// this is what we expect
 			if start.Line == end.Line {
-				if start.Column != end.Column {
+				if start.Column != end.Column && LocationColumn {
 					s += fmt.Sprintf("-%d", end.Column)
 				}
 			} else {
-				s += fmt.Sprintf("-%d:%d", end.Line, end.Column)
+				s += "-" + lineCol(end.Line, end.Column)
 			}
 		}
 
 	} else if end.IsValid() {
 		s = "-"
-		s += fmt.Sprintf("%d:%d", end.Line, end.Column)
+		s += lineCol(end.Line, end.Column)
 	}
 	if s == "" {
 		s = "-"